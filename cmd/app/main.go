@@ -1,18 +1,41 @@
 package main
 
 import (
+	"context"
 	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"truck-analytics-platform/internal/alerts"
 	"truck-analytics-platform/internal/db"
 	"truck-analytics-platform/internal/handlers"
+	"truck-analytics-platform/internal/logging"
+	"truck-analytics-platform/internal/quality"
 )
 
 func main() {
-	_, err := db.Connect()
-	if err != nil {
+	logging.Setup()
+
+	// Контекст отменяется по SIGINT/SIGTERM, после чего серверы завершают активные запросы
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if _, err := db.Init(ctx); err != nil {
 		slog.Error(err.Error())
+	} else {
+		go db.MigrateUntilDone(ctx)
 	}
-	slog.Info("Connected to DB")
+	defer db.Close()
+
+	// Проверки качества данных при старте и после каждой перезагрузки таблиц
+	go quality.Watch(ctx, quality.WatchInterval)
+	// Алерты по последнему загруженному месяцу после каждой загрузки
+	go alerts.Watch(ctx, alerts.WatchInterval)
 
-	handlers.InitRouter()
 	slog.Info("Server started")
+	if err := handlers.InitRouter(ctx); err != nil {
+		slog.Error("Server stopped with error", "error", err)
+		os.Exit(1)
+	}
+	slog.Info("Server stopped")
 }
@@ -0,0 +1,297 @@
+// Package alerts ищет значимые изменения после каждой месячной загрузки:
+// падение доли отслеживаемого бренда в регионе, первые продажи конкурента
+// в регионе и отклонение продаж округа от сезонного ожидания. Алерты
+// хранятся в таблице alerts и могут рассылаться по почте
+package alerts
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+	"truck-analytics-platform/internal/db"
+	"truck-analytics-platform/internal/reports"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// WatchInterval — как часто Watch проверяет, не загружен ли новый месяц
+const WatchInterval = 10 * time.Minute
+
+// MaxListed — сколько алертов List отдаёт за раз
+const MaxListed = 500
+
+// Alert — найденное изменение. Value и Baseline зависят от вида: доля
+// и прежняя доля, %; продажи нового бренда; продажи и ожидание
+type Alert struct {
+	ID        int64     `json:"id"`
+	Kind      string    `json:"kind"`
+	Segment   string    `json:"segment"`
+	Year      int       `json:"year"`
+	Month     int       `json:"month"`
+	District  string    `json:"district"`
+	Region    string    `json:"region,omitempty"`
+	Brand     string    `json:"brand,omitempty"`
+	Value     float64   `json:"value"`
+	Baseline  float64   `json:"baseline"`
+	Message   string    `json:"message"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Config — пороги правил
+type Config struct {
+	// Brand — бренд, за долей которого следим
+	Brand string
+	// ShareDrop — падение доли, п.п.
+	ShareDrop float64
+	// SeasonalDeviation — отклонение от сезонного ожидания, %
+	SeasonalDeviation float64
+	// MinVolume — продажи месяца, меньше которых территория не проверяется
+	MinVolume int
+}
+
+// DefaultConfig — пороги без переменных окружения
+var DefaultConfig = Config{Brand: "FOTON", ShareDrop: 5, SeasonalDeviation: 30, MinVolume: 10}
+
+// ConfigFromEnv читает пороги из ALERT_BRAND, ALERT_SHARE_DROP_PP,
+// ALERT_SEASONAL_DEVIATION_PCT и ALERT_MIN_VOLUME. Неверное значение
+// заменяется значением по умолчанию
+func ConfigFromEnv() Config {
+	cfg := DefaultConfig
+	if brand := os.Getenv("ALERT_BRAND"); brand != "" {
+		cfg.Brand = brand
+	}
+	for _, v := range []struct {
+		name  string
+		value *float64
+	}{
+		{"ALERT_SHARE_DROP_PP", &cfg.ShareDrop},
+		{"ALERT_SEASONAL_DEVIATION_PCT", &cfg.SeasonalDeviation},
+	} {
+		if raw := os.Getenv(v.name); raw != "" {
+			parsed, err := strconv.ParseFloat(raw, 64)
+			if err != nil || parsed < 0 {
+				slog.Warn("Invalid alert threshold, using default", "variable", v.name, "value", raw)
+				continue
+			}
+			*v.value = parsed
+		}
+	}
+	if raw := os.Getenv("ALERT_MIN_VOLUME"); raw != "" {
+		if parsed, err := strconv.Atoi(raw); err == nil && parsed >= 0 {
+			cfg.MinVolume = parsed
+		} else {
+			slog.Warn("Invalid alert threshold, using default", "variable", "ALERT_MIN_VOLUME", "value", raw)
+		}
+	}
+	return cfg
+}
+
+var (
+	mu sync.Mutex
+	// loaded — отпечатки продаж рынков при последней проверке
+	loaded = map[string]fingerprint{}
+)
+
+// fingerprint отличает одну загрузку рынка от другой
+type fingerprint struct {
+	rows, quantity int
+}
+
+// Run проверяет последний загруженный месяц каждого рынка, сохраняет
+// алерты и рассылает новые. Повторный запуск по тем же данным новых
+// алертов не даёт. force проверяет и рынки, продажи которых не менялись
+func Run(ctx context.Context, force bool) ([]Alert, error) {
+	cfg := ConfigFromEnv()
+
+	var found []Alert
+	checked := map[string]fingerprint{}
+	for _, segment := range reports.MarketKeys() {
+		market := reports.Markets[segment]
+		year := latestYear(market)
+		years := []int{year}
+		if _, ok := market.Tables[year-1]; ok {
+			years = append(years, year-1)
+		}
+
+		sales, err := market.MonthlySales(ctx, years)
+		if err != nil {
+			return nil, err
+		}
+		current := fingerprintOf(sales)
+		if !force && !changed(segment, current) {
+			continue
+		}
+		checked[segment] = current
+		found = append(found, detect(segment, newHistory(sales, year, len(years) > 1), cfg)...)
+	}
+
+	created, err := save(ctx, found)
+	if err != nil {
+		return nil, err
+	}
+	// Отпечатки запоминаются после записи, чтобы сбой записи повторился на следующей проверке
+	mu.Lock()
+	for segment, f := range checked {
+		loaded[segment] = f
+	}
+	mu.Unlock()
+
+	if len(created) > 0 {
+		if err := notify(created); err != nil {
+			slog.ErrorContext(ctx, "Alert email failed", "alerts", len(created), "error", err)
+		}
+	}
+	return created, nil
+}
+
+// Watch проверяет рынки при старте и затем каждые interval — после
+// перезагрузки таблиц. Блокируется до отмены ctx
+func Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		created, err := Run(ctx, false)
+		if err != nil {
+			slog.Warn("Alert detection skipped", "error", err)
+		} else if len(created) > 0 {
+			slog.InfoContext(ctx, "Alerts created", "alerts", len(created))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func latestYear(m reports.Market) int {
+	years := make([]int, 0, len(m.Tables))
+	for year := range m.Tables {
+		years = append(years, year)
+	}
+	sort.Ints(years)
+	return years[len(years)-1]
+}
+
+func fingerprintOf(sales []reports.MonthlySale) fingerprint {
+	f := fingerprint{rows: len(sales)}
+	for _, s := range sales {
+		f.quantity += s.Quantity
+	}
+	return f
+}
+
+// changed сообщает, изменились ли продажи рынка с последней проверки
+func changed(segment string, current fingerprint) bool {
+	mu.Lock()
+	defer mu.Unlock()
+	previous, checked := loaded[segment]
+	return !checked || previous != current
+}
+
+// save записывает алерты и возвращает только новые: алерт того же вида
+// за тот же месяц по той же территории и бренду повторно не создаётся
+func save(ctx context.Context, alerts []Alert) ([]Alert, error) {
+	if len(alerts) == 0 {
+		return []Alert{}, nil
+	}
+	conn, err := db.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	columns := struct {
+		kinds, segments, districts, regions, brands, messages []string
+		years, months                                         []int
+		values, baselines                                     []float64
+	}{}
+	for _, a := range alerts {
+		columns.kinds = append(columns.kinds, a.Kind)
+		columns.segments = append(columns.segments, a.Segment)
+		columns.districts = append(columns.districts, a.District)
+		columns.regions = append(columns.regions, a.Region)
+		columns.brands = append(columns.brands, a.Brand)
+		columns.messages = append(columns.messages, a.Message)
+		columns.years = append(columns.years, a.Year)
+		columns.months = append(columns.months, a.Month)
+		columns.values = append(columns.values, a.Value)
+		columns.baselines = append(columns.baselines, a.Baseline)
+	}
+
+	rows, err := conn.Query(ctx, `
+		INSERT INTO alerts (kind, segment, district, region, brand, message, year, month, value, baseline)
+		SELECT * FROM unnest($1::text[], $2::text[], $3::text[], $4::text[], $5::text[], $6::text[],
+			$7::int[], $8::int[], $9::float8[], $10::float8[])
+		ON CONFLICT (kind, segment, year, month, district, region, brand) DO NOTHING
+		RETURNING `+alertColumns,
+		columns.kinds, columns.segments, columns.districts, columns.regions, columns.brands, columns.messages,
+		columns.years, columns.months, columns.values, columns.baselines)
+	if err != nil {
+		return nil, err
+	}
+	return scanAlerts(rows)
+}
+
+// Filter — отбор алертов для List; пустые поля не фильтруют
+type Filter struct {
+	Segment  string
+	Kind     string
+	District string
+	Brand    string
+	Year     int
+	Month    int
+	Limit    int
+}
+
+// List возвращает алерты от новых к старым
+func List(ctx context.Context, f Filter) ([]Alert, error) {
+	conn, err := db.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := f.Limit
+	if limit <= 0 || limit > MaxListed {
+		limit = MaxListed
+	}
+
+	rows, err := conn.Query(ctx, `
+		SELECT `+alertColumns+`
+		FROM alerts
+		WHERE ($1 = '' OR segment = $1)
+			AND ($2 = '' OR kind = $2)
+			AND ($3 = '' OR district = $3)
+			AND ($4 = '' OR brand = $4)
+			AND ($5 = 0 OR year = $5)
+			AND ($6 = 0 OR month = $6)
+		ORDER BY created_at DESC, id DESC
+		LIMIT $7
+	`, f.Segment, f.Kind, f.District, f.Brand, f.Year, f.Month, limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanAlerts(rows)
+}
+
+const alertColumns = `kind, segment, district, region, brand, message, created_at, id, year, month, value, baseline`
+
+func scanAlerts(rows pgx.Rows) ([]Alert, error) {
+	defer rows.Close()
+
+	result := []Alert{}
+	for rows.Next() {
+		var a Alert
+		if err := rows.Scan(&a.Kind, &a.Segment, &a.District, &a.Region, &a.Brand, &a.Message, &a.CreatedAt,
+			&a.ID, &a.Year, &a.Month, &a.Value, &a.Baseline); err != nil {
+			return nil, err
+		}
+		result = append(result, a)
+	}
+	return result, rows.Err()
+}
@@ -0,0 +1,145 @@
+package alerts
+
+import (
+	"net/smtp"
+	"strings"
+	"testing"
+
+	"truck-analytics-platform/internal/reports"
+)
+
+// sale — продажи в регионе; год 2024 — текущий
+func sale(year, month int, region, brand string, quantity int) reports.MonthlySale {
+	return reports.MonthlySale{Year: year, Month: month, District: "Central", Region: region, Brand: brand, Quantity: quantity}
+}
+
+// steadyMarket — FOTON и SITRAK в Москве по 10 машин в месяц оба года
+func steadyMarket(months int) []reports.MonthlySale {
+	var sales []reports.MonthlySale
+	for _, year := range []int{2023, 2024} {
+		for month := 1; month <= months; month++ {
+			sales = append(sales, sale(year, month, "Moscow", "FOTON", 10), sale(year, month, "Moscow", "SITRAK", 10))
+		}
+	}
+	return sales
+}
+
+func kinds(alerts []Alert) []string {
+	var result []string
+	for _, a := range alerts {
+		result = append(result, a.Kind)
+	}
+	return result
+}
+
+func TestSteadyMarketHasNoAlerts(t *testing.T) {
+	found := detect("ldt", newHistory(steadyMarket(10), 2024, true), DefaultConfig)
+	if len(found) != 0 {
+		t.Errorf("alerts = %+v", found)
+	}
+}
+
+func TestShareDrop(t *testing.T) {
+	sales := steadyMarket(9)
+	sales = append(sales, sale(2024, 10, "Moscow", "FOTON", 4), sale(2024, 10, "Moscow", "SITRAK", 16))
+
+	found := detect("ldt", newHistory(sales, 2024, true), DefaultConfig)
+	if len(found) != 1 || found[0].Kind != KindShareDrop {
+		t.Fatalf("alerts = %v", kinds(found))
+	}
+	a := found[0]
+	if a.Month != 10 || a.Region != "Moscow" || a.Brand != "FOTON" || a.Value != 20 || a.Baseline != 50 {
+		t.Errorf("alert = %+v", a)
+	}
+	if !strings.Contains(a.Message, "fell from 50.0% to 20.0%") {
+		t.Errorf("message = %q", a.Message)
+	}
+
+	// Ниже порога продаж регион не проверяется
+	cfg := DefaultConfig
+	cfg.MinVolume = 25
+	if found := detect("ldt", newHistory(sales, 2024, true), cfg); len(found) != 0 {
+		t.Errorf("alerts below min volume = %v", kinds(found))
+	}
+}
+
+func TestNewEntrant(t *testing.T) {
+	sales := append(steadyMarket(10), sale(2024, 10, "Moscow", "SHACMAN", 2))
+	// Бренд, продававший в регионе в прошлом году, новичком не считается
+	sales = append(sales, sale(2023, 5, "Moscow", "KAMAZ", 1), sale(2024, 10, "Moscow", "KAMAZ", 1))
+
+	found := newEntrants("ldt", newHistory(sales, 2024, true), DefaultConfig)
+	if len(found) != 1 || found[0].Brand != "SHACMAN" || found[0].Value != 2 {
+		t.Fatalf("alerts = %+v", found)
+	}
+
+	// Регион, которого раньше не было в выгрузке, не проверяется
+	sales = append(steadyMarket(10), sale(2024, 10, "Tula Region", "SHACMAN", 5))
+	if found := newEntrants("ldt", newHistory(sales, 2024, true), DefaultConfig); len(found) != 0 {
+		t.Errorf("alerts for a new region = %+v", found)
+	}
+}
+
+func TestSeasonalDeviation(t *testing.T) {
+	var sales []reports.MonthlySale
+	for month := 1; month <= 10; month++ {
+		prior, current := 10, 20
+		if month == 10 {
+			prior, current = 20, 80
+		}
+		sales = append(sales, sale(2023, month, "Moscow", "FOTON", prior), sale(2024, month, "Moscow", "FOTON", current))
+	}
+
+	found := seasonalDeviations("ldt", newHistory(sales, 2024, true), DefaultConfig)
+	// Ожидание: 20 за октябрь прошлого года × 2 (рост с начала года) = 40
+	if len(found) != 1 || found[0].District != "Central" || found[0].Value != 80 || found[0].Baseline != 40 {
+		t.Fatalf("alerts = %+v", found)
+	}
+	if !strings.Contains(found[0].Message, "+100.0%") {
+		t.Errorf("message = %q", found[0].Message)
+	}
+
+	if found := seasonalDeviations("ldt", newHistory(sales, 2024, false), DefaultConfig); len(found) != 0 {
+		t.Errorf("alerts without the previous year = %+v", found)
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("ALERT_BRAND", "SITRAK")
+	t.Setenv("ALERT_SHARE_DROP_PP", "2.5")
+	t.Setenv("ALERT_MIN_VOLUME", "many")
+
+	cfg := ConfigFromEnv()
+	want := Config{Brand: "SITRAK", ShareDrop: 2.5, SeasonalDeviation: DefaultConfig.SeasonalDeviation, MinVolume: DefaultConfig.MinVolume}
+	if cfg != want {
+		t.Errorf("config = %+v, want %+v", cfg, want)
+	}
+}
+
+func TestNotifySendsOneEmail(t *testing.T) {
+	t.Setenv("SMTP_HOST", "smtp.example.com")
+	t.Setenv("SMTP_USERNAME", "alerts@example.com")
+	t.Setenv("ALERT_EMAIL_TO", "sales@example.com, ceo@example.com")
+
+	var addr string
+	var to []string
+	var body string
+	sendMail = func(a string, _ smtp.Auth, _ string, recipients []string, msg []byte) error {
+		addr, to, body = a, recipients, string(msg)
+		return nil
+	}
+	defer func() { sendMail = smtp.SendMail }()
+
+	err := notify([]Alert{{Kind: KindNewEntrant, Segment: "ldt", Message: "SHACMAN registered 2 trucks in Moscow (Central) for the first time in 10/2024"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if addr != "smtp.example.com:587" || len(to) != 2 {
+		t.Errorf("addr = %q, to = %v", addr, to)
+	}
+	for _, want := range []string{"From: alerts@example.com", "Subject: Sales alerts: 1 new", "[ldt] new_entrant: SHACMAN registered"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("message has no %q:\n%s", want, body)
+		}
+	}
+}
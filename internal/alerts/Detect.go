@@ -0,0 +1,259 @@
+package alerts
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"truck-analytics-platform/internal/reports"
+)
+
+// Виды алертов
+const (
+	// KindShareDrop — доля отслеживаемого бренда в регионе за последний месяц
+	// упала больше чем на Config.ShareDrop п.п. относительно предыдущих месяцев года
+	KindShareDrop = "share_drop"
+	// KindNewEntrant — конкурент впервые зарегистрировал машины в регионе
+	KindNewEntrant = "new_entrant"
+	// KindSeasonalDeviation — продажи округа за месяц отклонились от сезонного
+	// ожидания больше чем на Config.SeasonalDeviation %
+	KindSeasonalDeviation = "seasonal_deviation"
+)
+
+// Kinds — все виды алертов
+var Kinds = []string{KindShareDrop, KindNewEntrant, KindSeasonalDeviation}
+
+type place struct {
+	district, region string
+}
+
+type brandPlace struct {
+	place
+	brand string
+}
+
+// monthly — продажи по месяцам года, индекс — номер месяца
+type monthly [13]int
+
+func (m *monthly) sum(from, to int) int {
+	total := 0
+	for month := from; month <= to; month++ {
+		total += m[month]
+	}
+	return total
+}
+
+// history — продажи рынка за текущий и прошлый год в разрезах, нужных правилам
+type history struct {
+	year, month int
+	hasPrev     bool
+
+	regions    map[place]*monthly
+	brands     map[brandPlace]*monthly
+	districts  map[string]*monthly
+	prevRegion map[place]int
+	prevBrand  map[brandPlace]int
+	prevDist   map[string]*monthly
+}
+
+// newHistory раскладывает продажи года year и прошлого года. Последний
+// месяц — последний месяц года, за который есть продажи
+func newHistory(sales []reports.MonthlySale, year int, hasPrev bool) history {
+	h := history{
+		year:       year,
+		hasPrev:    hasPrev,
+		regions:    map[place]*monthly{},
+		brands:     map[brandPlace]*monthly{},
+		districts:  map[string]*monthly{},
+		prevRegion: map[place]int{},
+		prevBrand:  map[brandPlace]int{},
+		prevDist:   map[string]*monthly{},
+	}
+
+	for _, s := range sales {
+		p := place{s.District, s.Region}
+		bp := brandPlace{p, s.Brand}
+
+		if s.Year != year {
+			h.prevRegion[p] += s.Quantity
+			h.prevBrand[bp] += s.Quantity
+			series(h.prevDist, s.District)[s.Month] += s.Quantity
+			continue
+		}
+
+		series(h.regions, p)[s.Month] += s.Quantity
+		series(h.brands, bp)[s.Month] += s.Quantity
+		series(h.districts, s.District)[s.Month] += s.Quantity
+		if s.Quantity > 0 && s.Month > h.month {
+			h.month = s.Month
+		}
+	}
+	return h
+}
+
+func series[K comparable](m map[K]*monthly, key K) *monthly {
+	if _, ok := m[key]; !ok {
+		m[key] = &monthly{}
+	}
+	return m[key]
+}
+
+// detect применяет все правила к последнему загруженному месяцу рынка
+func detect(segment string, h history, cfg Config) []Alert {
+	if h.month == 0 {
+		return nil
+	}
+
+	var result []Alert
+	result = append(result, shareDrops(segment, h, cfg)...)
+	result = append(result, newEntrants(segment, h, cfg)...)
+	result = append(result, seasonalDeviations(segment, h, cfg)...)
+	return result
+}
+
+// shareDrops сравнивает долю бренда в регионе за последний месяц с долей
+// за предыдущие месяцы года, а в январе — с долей за прошлый год
+func shareDrops(segment string, h history, cfg Config) []Alert {
+	var result []Alert
+	for _, p := range sortedPlaces(h.regions) {
+		volume := h.regions[p][h.month]
+		if volume < cfg.MinVolume {
+			continue
+		}
+
+		var brand monthly
+		if b, ok := h.brands[brandPlace{p, cfg.Brand}]; ok {
+			brand = *b
+		}
+		baseBrand, baseVolume := brand.sum(1, h.month-1), h.regions[p].sum(1, h.month-1)
+		if h.month == 1 {
+			baseBrand, baseVolume = h.prevBrand[brandPlace{p, cfg.Brand}], h.prevRegion[p]
+		}
+		if baseVolume == 0 {
+			continue
+		}
+
+		share, baseShare := percent(brand[h.month], volume), percent(baseBrand, baseVolume)
+		if change := round1(share - baseShare); -change > cfg.ShareDrop {
+			result = append(result, Alert{
+				Kind: KindShareDrop, Segment: segment, Year: h.year, Month: h.month,
+				District: p.district, Region: p.region, Brand: cfg.Brand,
+				Value: share, Baseline: baseShare,
+				Message: fmt.Sprintf("%s share in %s (%s) fell from %.1f%% to %.1f%% in %02d/%d (%.1f pp)",
+					cfg.Brand, p.region, p.district, baseShare, share, h.month, h.year, change),
+			})
+		}
+	}
+	return result
+}
+
+// newEntrants ищет бренды, у которых в регионе не было продаж ни в прошлые
+// месяцы года, ни в прошлом году. Регион без прошлых продаж не проверяется:
+// это скорее новый регион в выгрузке, чем новый конкурент
+func newEntrants(segment string, h history, cfg Config) []Alert {
+	if h.month == 1 && !h.hasPrev {
+		return nil
+	}
+
+	var keys []brandPlace
+	for bp := range h.brands {
+		keys = append(keys, bp)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].place != keys[j].place {
+			return less(keys[i].place, keys[j].place)
+		}
+		return keys[i].brand < keys[j].brand
+	})
+
+	var result []Alert
+	for _, bp := range keys {
+		if bp.brand == cfg.Brand {
+			continue
+		}
+		sales := h.brands[bp]
+		quantity := sales[h.month]
+		if quantity <= 0 || sales.sum(1, h.month-1) > 0 || h.prevBrand[bp] > 0 {
+			continue
+		}
+		if h.regions[bp.place].sum(1, h.month-1)+h.prevRegion[bp.place] == 0 {
+			continue
+		}
+
+		result = append(result, Alert{
+			Kind: KindNewEntrant, Segment: segment, Year: h.year, Month: h.month,
+			District: bp.district, Region: bp.region, Brand: bp.brand,
+			Value: float64(quantity),
+			Message: fmt.Sprintf("%s registered %d trucks in %s (%s) for the first time in %02d/%d",
+				bp.brand, quantity, bp.region, bp.district, h.month, h.year),
+		})
+	}
+	return result
+}
+
+// seasonalDeviations сравнивает продажи округа за месяц с ожиданием: тот же
+// месяц прошлого года, умноженный на отношение продаж с начала года к тем же
+// месяцам прошлого года
+func seasonalDeviations(segment string, h history, cfg Config) []Alert {
+	if !h.hasPrev {
+		return nil
+	}
+
+	var result []Alert
+	for _, district := range reports.DistrictOrder() {
+		cur, ok := h.districts[district]
+		prev, hasPrev := h.prevDist[district]
+		if !ok || !hasPrev || prev[h.month] == 0 {
+			continue
+		}
+
+		ratio := 1.0
+		if ytd := prev.sum(1, h.month-1); ytd > 0 {
+			ratio = float64(cur.sum(1, h.month-1)) / float64(ytd)
+		}
+		expected := float64(prev[h.month]) * ratio
+		actual := float64(cur[h.month])
+		if math.Max(actual, expected) < float64(cfg.MinVolume) || expected == 0 {
+			continue
+		}
+
+		deviation := round1((actual - expected) / expected * 100)
+		if math.Abs(deviation) <= cfg.SeasonalDeviation {
+			continue
+		}
+		result = append(result, Alert{
+			Kind: KindSeasonalDeviation, Segment: segment, Year: h.year, Month: h.month,
+			District: district,
+			Value:    actual, Baseline: round1(expected),
+			Message: fmt.Sprintf("%s volume in %02d/%d is %.0f against %.0f expected from the seasonal profile (%+.1f%%)",
+				district, h.month, h.year, actual, expected, deviation),
+		})
+	}
+	return result
+}
+
+func sortedPlaces(m map[place]*monthly) []place {
+	keys := make([]place, 0, len(m))
+	for p := range m {
+		keys = append(keys, p)
+	}
+	sort.Slice(keys, func(i, j int) bool { return less(keys[i], keys[j]) })
+	return keys
+}
+
+func less(a, b place) bool {
+	if a.district != b.district {
+		return a.district < b.district
+	}
+	return a.region < b.region
+}
+
+func percent(part, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return round1(float64(part) / float64(total) * 100)
+}
+
+func round1(v float64) float64 {
+	return math.Round(v*10) / 10
+}
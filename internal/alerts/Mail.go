@@ -0,0 +1,75 @@
+package alerts
+
+import (
+	"fmt"
+	"net"
+	"net/smtp"
+	"os"
+	"strings"
+)
+
+// SMTPConfig — почтовый сервер и получатели рассылки алертов
+type SMTPConfig struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+	To       []string
+}
+
+// SMTPFromEnv читает настройки рассылки из SMTP_HOST, SMTP_PORT (по умолчанию 587),
+// SMTP_USERNAME, SMTP_PASSWORD, ALERT_EMAIL_FROM и ALERT_EMAIL_TO — адресов
+// через запятую. false — рассылка не настроена: нет сервера или получателей
+func SMTPFromEnv() (SMTPConfig, bool) {
+	cfg := SMTPConfig{
+		Host:     os.Getenv("SMTP_HOST"),
+		Port:     os.Getenv("SMTP_PORT"),
+		Username: os.Getenv("SMTP_USERNAME"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     os.Getenv("ALERT_EMAIL_FROM"),
+	}
+	for _, to := range strings.Split(os.Getenv("ALERT_EMAIL_TO"), ",") {
+		if to = strings.TrimSpace(to); to != "" {
+			cfg.To = append(cfg.To, to)
+		}
+	}
+	if cfg.Port == "" {
+		cfg.Port = "587"
+	}
+	if cfg.From == "" {
+		cfg.From = cfg.Username
+	}
+	return cfg, cfg.Host != "" && cfg.From != "" && len(cfg.To) > 0
+}
+
+// sendMail отправляет письмо; в тестах подменяется
+var sendMail = smtp.SendMail
+
+// notify рассылает алерты одним письмом, если рассылка настроена
+func notify(alerts []Alert) error {
+	cfg, ok := SMTPFromEnv()
+	if !ok {
+		return nil
+	}
+
+	var auth smtp.Auth
+	if cfg.Username != "" {
+		auth = smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)
+	}
+	return sendMail(net.JoinHostPort(cfg.Host, cfg.Port), auth, cfg.From, cfg.To, message(cfg, alerts))
+}
+
+// message собирает текстовое письмо: по строке на алерт
+func message(cfg SMTPConfig, alerts []Alert) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", cfg.From)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(cfg.To, ", "))
+	fmt.Fprintf(&b, "Subject: Sales alerts: %d new\r\n", len(alerts))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	for _, a := range alerts {
+		fmt.Fprintf(&b, "[%s] %s: %s\r\n", a.Segment, a.Kind, a.Message)
+	}
+	return []byte(b.String())
+}
@@ -0,0 +1,189 @@
+// Package annotations — заметки аналитиков к точкам данных: сегмент, год
+// и месяц, округ или регион и бренд, например «провал FOTON в Сибири из-за
+// закрытия дилера в Омске, 08/2024». Заметки отдаются вместе со сводными
+// таблицами и попадают в их выгрузку
+package annotations
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"truck-analytics-platform/internal/db"
+	"truck-analytics-platform/internal/reports"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// MaxListed — сколько заметок отдаётся за раз
+const MaxListed = 500
+
+// MaxText — длина заметки в символах
+const MaxText = 2000
+
+// Annotation — заметка. Пустые Segment, District, Region, Brand и Month = 0
+// означают, что заметка относится ко всем значениям ключа
+type Annotation struct {
+	ID        int64     `json:"id"`
+	Segment   string    `json:"segment"`
+	Year      int       `json:"year"`
+	Month     int       `json:"month"`
+	District  string    `json:"district"`
+	Region    string    `json:"region"`
+	Brand     string    `json:"brand"`
+	Text      string    `json:"text"`
+	Author    string    `json:"author"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+var validBrand = regexp.MustCompile(`^[A-Z0-9][A-Z0-9 .-]{0,49}$`)
+
+// Validate проверяет ключ заметки по справочникам отчётов и длину текста
+func (a Annotation) Validate() error {
+	if _, ok := reports.Markets[a.Segment]; a.Segment != "" && !ok {
+		return fmt.Errorf("unknown segment %q", a.Segment)
+	}
+	if a.Year < 2000 || a.Year > 2100 {
+		return fmt.Errorf("year %d is out of range", a.Year)
+	}
+	if err := reports.CheckMonths(a.Month); err != nil {
+		return err
+	}
+	if a.District != "" && !slices.Contains(reports.DistrictOrder(), a.District) {
+		return fmt.Errorf("unknown district %q", a.District)
+	}
+	if a.Region != "" && !reports.EnglishRegion(a.Region) {
+		return fmt.Errorf("unknown region %q", a.Region)
+	}
+	if a.Brand != "" && !validBrand.MatchString(a.Brand) {
+		return fmt.Errorf("brand %q must be a canonical brand id", a.Brand)
+	}
+	if strings.TrimSpace(a.Text) == "" || len([]rune(a.Text)) > MaxText {
+		return fmt.Errorf("text must be 1-%d characters", MaxText)
+	}
+	return nil
+}
+
+// Filter — отбор заметок к данным. Пустые поля не фильтруют; заметка
+// с пустым полем ключа подходит к любому значению фильтра
+type Filter struct {
+	Segment   string
+	Years     []int
+	MonthFrom int
+	MonthTo   int
+	Districts []string
+	Regions   []string
+	Brands    []string
+	Author    string
+}
+
+// ForPivot — заметки к данным сводной таблицы
+func ForPivot(p reports.PivotSpec) Filter {
+	return Filter{
+		Segment:   p.Segment,
+		Years:     p.Years,
+		MonthFrom: p.MonthFrom,
+		MonthTo:   p.MonthTo,
+		Districts: p.Districts,
+		Regions:   p.Regions,
+		Brands:    p.Brands,
+	}
+}
+
+const columns = `segment, district, region, brand, text, author, created_at, updated_at, id, year, month`
+
+// List возвращает заметки по периоду, затем по времени создания
+func List(ctx context.Context, f Filter) ([]Annotation, error) {
+	return query(ctx, `
+		SELECT `+columns+`
+		FROM annotations
+		WHERE ($1 = '' OR segment IN ('', $1))
+			AND (cardinality($2::int[]) = 0 OR year = ANY($2))
+			AND (month = 0 OR (($3 = 0 OR month >= $3) AND ($4 = 0 OR month <= $4)))
+			AND (cardinality($5::text[]) = 0 OR district = '' OR district = ANY($5))
+			AND (cardinality($6::text[]) = 0 OR region = '' OR region = ANY($6))
+			AND (cardinality($7::text[]) = 0 OR brand = '' OR brand = ANY($7))
+			AND ($8 = '' OR author = $8)
+		ORDER BY year, month, created_at, id
+		LIMIT $9
+	`, f.Segment, ints(f.Years), f.MonthFrom, f.MonthTo, strs(f.Districts), strs(f.Regions), strs(f.Brands), f.Author, MaxListed)
+}
+
+// Create сохраняет заметку автора
+func Create(ctx context.Context, author string, a Annotation) (Annotation, error) {
+	created, _, err := one(query(ctx, `
+		INSERT INTO annotations (segment, district, region, brand, text, author, year, month)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING `+columns,
+		a.Segment, a.District, a.Region, a.Brand, a.Text, author, a.Year, a.Month))
+	return created, err
+}
+
+// Update заменяет заметку автора; false — заметки нет или её написал другой
+func Update(ctx context.Context, author string, a Annotation) (Annotation, bool, error) {
+	return one(query(ctx, `
+		UPDATE annotations SET segment = $3, district = $4, region = $5, brand = $6, text = $7,
+			year = $8, month = $9, updated_at = now()
+		WHERE id = $1 AND author = $2
+		RETURNING `+columns,
+		a.ID, author, a.Segment, a.District, a.Region, a.Brand, a.Text, a.Year, a.Month))
+}
+
+// Delete удаляет заметку автора; false — заметки нет или её написал другой
+func Delete(ctx context.Context, author string, id int64) (bool, error) {
+	_, deleted, err := one(query(ctx, `DELETE FROM annotations WHERE id = $1 AND author = $2 RETURNING `+columns, id, author))
+	return deleted, err
+}
+
+func ints(values []int) []int {
+	if values == nil {
+		return []int{}
+	}
+	return values
+}
+
+func strs(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
+func query(ctx context.Context, sql string, args ...any) ([]Annotation, error) {
+	conn, err := db.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := conn.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	return scan(rows)
+}
+
+func scan(rows pgx.Rows) ([]Annotation, error) {
+	defer rows.Close()
+
+	result := []Annotation{}
+	for rows.Next() {
+		var a Annotation
+		if err := rows.Scan(&a.Segment, &a.District, &a.Region, &a.Brand, &a.Text, &a.Author, &a.CreatedAt, &a.UpdatedAt,
+			&a.ID, &a.Year, &a.Month); err != nil {
+			return nil, err
+		}
+		result = append(result, a)
+	}
+	return result, rows.Err()
+}
+
+// one возвращает первую заметку выборки; false — выборка пуста
+func one(list []Annotation, err error) (Annotation, bool, error) {
+	if err != nil || len(list) == 0 {
+		return Annotation{}, false, err
+	}
+	return list[0], true, nil
+}
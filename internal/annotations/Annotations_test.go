@@ -0,0 +1,53 @@
+package annotations
+
+import (
+	"strings"
+	"testing"
+
+	"truck-analytics-platform/internal/reports"
+)
+
+func TestValidate(t *testing.T) {
+	valid := Annotation{Segment: "tractors4x2", Year: 2024, Month: 8, District: "Siberia", Region: "Omsk Region", Brand: "FOTON",
+		Text: "FOTON dip due to dealer closure in Omsk"}
+
+	cases := []struct {
+		name   string
+		change func(a *Annotation)
+		err    string
+	}{
+		{"valid", func(a *Annotation) {}, ""},
+		{"whole year for all segments", func(a *Annotation) { a.Segment, a.Month, a.District, a.Region, a.Brand = "", 0, "", "", "" }, ""},
+		{"unknown segment", func(a *Annotation) { a.Segment = "buses" }, "unknown segment"},
+		{"month", func(a *Annotation) { a.Month = 13 }, "month must be"},
+		{"russian region", func(a *Annotation) { a.Region = "Омская область" }, "unknown region"},
+		{"unknown district", func(a *Annotation) { a.District = "Siberian" }, "unknown district"},
+		{"brand not canonical", func(a *Annotation) { a.Brand = "Foton" }, "canonical brand id"},
+		{"blank text", func(a *Annotation) { a.Text = " " }, "text must be"},
+		{"long text", func(a *Annotation) { a.Text = strings.Repeat("я", MaxText+1) }, "text must be"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			a := valid
+			tc.change(&a)
+
+			err := a.Validate()
+			switch {
+			case tc.err == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case tc.err != "" && (err == nil || !strings.Contains(err.Error(), tc.err)):
+				t.Fatalf("error %v, want %q", err, tc.err)
+			}
+		})
+	}
+}
+
+func TestForPivot(t *testing.T) {
+	spec := reports.PivotSpec{Segment: "tractors4x2", Years: []int{2023, 2024}, MonthFrom: 7, MonthTo: 9, Regions: []string{"Omsk Region"}}
+
+	f := ForPivot(spec)
+	if f.Segment != "tractors4x2" || len(f.Years) != 2 || f.MonthFrom != 7 || f.MonthTo != 9 || f.Regions[0] != "Omsk Region" || f.Author != "" {
+		t.Fatalf("filter %+v", f)
+	}
+}
@@ -0,0 +1,190 @@
+// Package apikeys — API-ключи для BI и плановых скриптов: ключ с набором
+// прав, сроком действия и временем последнего использования. Ключи выдаёт
+// администратор; в БД хранится только SHA-256 ключа, сам ключ показывается
+// один раз при создании
+package apikeys
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"truck-analytics-platform/internal/db"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Права ключа. Открытые данные читаются и без входа, поэтому права только
+// сужают доступ ключа: ключ без read:segments не читает отчёты, но и не даёт
+// больше анонимного запроса. Выгрузки файлами требуют входа или ключа с export
+const (
+	// ScopeReadSegments — чтение отчётов и аналитики по сегментам
+	ScopeReadSegments = "read:segments"
+	// ScopeExport — выгрузки файлами
+	ScopeExport = "export"
+	// ScopeAdmin — изменения справочников и планов, журнал аудита, управление ключами
+	ScopeAdmin = "admin"
+)
+
+// Scopes — все права, которые можно выдать ключу
+var Scopes = []string{ScopeReadSegments, ScopeExport, ScopeAdmin}
+
+// ErrNameTaken — ключ с таким именем уже выдан
+var ErrNameTaken = errors.New("api key name is already taken")
+
+// uniqueViolation — код ошибки PostgreSQL при нарушении уникальности
+const uniqueViolation = "23505"
+
+// SecretPrefix отличает API-ключи платформы от прочих секретов
+const SecretPrefix = "tap_"
+
+// shownPrefix — сколько первых символов ключа хранится открыто
+const shownPrefix = len(SecretPrefix) + 8
+
+// Key — API-ключ без секрета. Пустые ExpiresAt и LastUsedAt — бессрочный
+// и ещё не использованный ключ
+type Key struct {
+	ID         int64      `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	CreatedBy  string     `json:"created_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
+
+// Has сообщает, выдано ли ключу право scope
+func (k Key) Has(scope string) bool {
+	return slices.Contains(k.Scopes, scope)
+}
+
+// Validate проверяет имя, права и срок действия нового ключа
+func (k Key) Validate() error {
+	if name := strings.TrimSpace(k.Name); name == "" || len(name) > 100 {
+		return errors.New("name must be 1-100 characters")
+	}
+	if len(k.Scopes) == 0 {
+		return errors.New("at least one scope is required")
+	}
+	for i, scope := range k.Scopes {
+		if !slices.Contains(Scopes, scope) {
+			return fmt.Errorf("unknown scope %q", scope)
+		}
+		if slices.Contains(k.Scopes[:i], scope) {
+			return fmt.Errorf("scope %q is listed twice", scope)
+		}
+	}
+	if k.ExpiresAt != nil && !k.ExpiresAt.After(time.Now()) {
+		return errors.New("expires_at must be in the future")
+	}
+	return nil
+}
+
+// Hash — SHA-256 ключа в hex, под которым ключ хранится в БД
+func Hash(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func newSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return SecretPrefix + hex.EncodeToString(buf), nil
+}
+
+const columns = `name, prefix, array_to_string(scopes, ','), created_by, created_at, expires_at, last_used_at, id`
+
+// List возвращает ключи по времени создания
+func List(ctx context.Context) ([]Key, error) {
+	return query(ctx, `SELECT `+columns+` FROM api_keys ORDER BY created_at, id`)
+}
+
+// Create выдаёт ключ и возвращает его вместе с секретом — секрет больше
+// нигде не хранится и не показывается
+func Create(ctx context.Context, createdBy string, k Key) (Key, string, error) {
+	secret, err := newSecret()
+	if err != nil {
+		return Key{}, "", err
+	}
+	created, _, err := one(query(ctx, `
+		INSERT INTO api_keys (name, prefix, hash, scopes, created_by, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING `+columns,
+		strings.TrimSpace(k.Name), secret[:shownPrefix], Hash(secret), k.Scopes, createdBy, k.ExpiresAt))
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return Key{}, "", ErrNameTaken
+	}
+	if err != nil {
+		return Key{}, "", err
+	}
+	return created, secret, nil
+}
+
+// Delete отзывает ключ; false — ключа нет
+func Delete(ctx context.Context, id int64) (bool, error) {
+	_, deleted, err := one(query(ctx, `DELETE FROM api_keys WHERE id = $1 RETURNING `+columns, id))
+	return deleted, err
+}
+
+// Authenticate находит действующий ключ по секрету и отмечает его использование;
+// false — ключ неизвестен, отозван или истёк
+func Authenticate(ctx context.Context, secret string) (Key, bool, error) {
+	if !strings.HasPrefix(secret, SecretPrefix) {
+		return Key{}, false, nil
+	}
+	return one(query(ctx, `
+		UPDATE api_keys SET last_used_at = now()
+		WHERE hash = $1 AND (expires_at IS NULL OR expires_at > now())
+		RETURNING `+columns,
+		Hash(secret)))
+}
+
+func query(ctx context.Context, sql string, args ...any) ([]Key, error) {
+	conn, err := db.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := conn.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	return scan(rows)
+}
+
+func scan(rows pgx.Rows) ([]Key, error) {
+	defer rows.Close()
+
+	result := []Key{}
+	for rows.Next() {
+		var (
+			k      Key
+			scopes string
+		)
+		if err := rows.Scan(&k.Name, &k.Prefix, &scopes, &k.CreatedBy, &k.CreatedAt, &k.ExpiresAt, &k.LastUsedAt, &k.ID); err != nil {
+			return nil, err
+		}
+		k.Scopes = strings.Split(scopes, ",")
+		result = append(result, k)
+	}
+	return result, rows.Err()
+}
+
+// one возвращает первый ключ выборки; false — выборка пуста
+func one(list []Key, err error) (Key, bool, error) {
+	if err != nil || len(list) == 0 {
+		return Key{}, false, err
+	}
+	return list[0], true, nil
+}
@@ -0,0 +1,63 @@
+package apikeys
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"truck-analytics-platform/internal/db"
+	"truck-analytics-platform/internal/db/dbtest"
+)
+
+func TestValidateKey(t *testing.T) {
+	future := time.Now().Add(24 * time.Hour)
+	past := time.Now().Add(-time.Hour)
+
+	cases := []struct {
+		name string
+		key  Key
+		err  string
+	}{
+		{"valid", Key{Name: "powerbi", Scopes: []string{ScopeReadSegments, ScopeExport}, ExpiresAt: &future}, ""},
+		{"no expiry", Key{Name: "notebooks", Scopes: []string{ScopeReadSegments}}, ""},
+		{"blank name", Key{Name: " ", Scopes: []string{ScopeAdmin}}, "name must be"},
+		{"no scopes", Key{Name: "powerbi"}, "at least one scope"},
+		{"unknown scope", Key{Name: "powerbi", Scopes: []string{"write"}}, `unknown scope "write"`},
+		{"scope twice", Key{Name: "powerbi", Scopes: []string{ScopeExport, ScopeExport}}, "listed twice"},
+		{"expired", Key{Name: "powerbi", Scopes: []string{ScopeExport}, ExpiresAt: &past}, "must be in the future"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.key.Validate()
+			switch {
+			case tc.err == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case tc.err != "" && (err == nil || !strings.Contains(err.Error(), tc.err)):
+				t.Fatalf("error %v, want %q", err, tc.err)
+			}
+		})
+	}
+}
+
+func TestAuthenticateLooksUpByHash(t *testing.T) {
+	querier := &dbtest.Querier{}
+	db.SetQuerier(querier)
+	t.Cleanup(func() { db.SetQuerier(nil) })
+
+	// Строка без префикса ключом быть не может — в БД за ней не ходим
+	if _, ok, err := Authenticate(context.Background(), "Bearer abc"); ok || err != nil {
+		t.Fatalf("Authenticate = %v, %v for a non-key", ok, err)
+	}
+	if len(querier.Calls()) != 0 {
+		t.Fatal("non-key string was looked up")
+	}
+
+	if _, ok, err := Authenticate(context.Background(), "tap_secret"); ok || err != nil {
+		t.Fatalf("Authenticate = %v, %v for an unknown key", ok, err)
+	}
+	calls := querier.Calls()
+	if len(calls) != 1 || calls[0].Args[0] != Hash("tap_secret") {
+		t.Fatalf("lookup %v, want one query by the key's hash", calls)
+	}
+}
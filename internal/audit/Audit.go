@@ -0,0 +1,133 @@
+// Package audit — журнал аудита: попытки входа, выгрузки данных, изменения
+// справочников и загрузки данных с пользователем, временем, IP и параметрами.
+// Журнал только пополняется: изменение и удаление записей запрещены в БД
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"truck-analytics-platform/internal/db"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Действия журнала
+const (
+	ActionLogin         = "auth.login"
+	ActionExport        = "data.export"
+	ActionDataLoad      = "data.load"
+	ActionDataCheck     = "data.check"
+	ActionBrandChange   = "brand.change"
+	ActionDealerChange  = "dealer.change"
+	ActionTargetsChange = "targets.change"
+	ActionAlertsRun     = "alerts.run"
+	ActionAPIKeyChange  = "apikey.change"
+)
+
+// SystemActor — автор событий, которые приложение записывает само
+const SystemActor = "system"
+
+// MaxListed — сколько записей отдаётся за раз, в том числе в CSV
+const MaxListed = 10000
+
+// Event — запись журнала. Params — JSON-объект параметров действия,
+// без паролей и токенов
+type Event struct {
+	ID     int64           `json:"id"`
+	At     time.Time       `json:"at"`
+	Action string          `json:"action"`
+	Actor  string          `json:"actor"`
+	IP     string          `json:"ip"`
+	Method string          `json:"method"`
+	Path   string          `json:"path"`
+	Status int             `json:"status"`
+	Params json.RawMessage `json:"params"`
+}
+
+// Record дописывает событие в журнал. Сбой записи не должен ронять действие,
+// поэтому он только логируется
+func Record(ctx context.Context, e Event) {
+	// Запись переживает отмену запроса: клиент мог уже закрыть соединение
+	ctx = context.WithoutCancel(ctx)
+	if err := insert(ctx, e); err != nil {
+		slog.ErrorContext(ctx, "Audit event is not recorded", "action", e.Action, "actor", e.Actor, "error", err)
+	}
+}
+
+func insert(ctx context.Context, e Event) error {
+	params := string(e.Params)
+	if params == "" {
+		params = "{}"
+	}
+
+	conn, err := db.Connect(ctx)
+	if err != nil {
+		return err
+	}
+	rows, err := conn.Query(ctx, `
+		INSERT INTO audit_log (action, actor, ip, method, path, status, params)
+		VALUES ($1, $2, $3, $4, $5, $6, $7::jsonb)
+	`, e.Action, e.Actor, e.IP, e.Method, e.Path, e.Status, params)
+	if err != nil {
+		return err
+	}
+	rows.Close()
+	return rows.Err()
+}
+
+// Filter — отбор записей журнала. Пустые поля не фильтруют; To не включается
+type Filter struct {
+	Action string
+	Actor  string
+	From   *time.Time
+	To     *time.Time
+	Limit  int
+}
+
+// List возвращает записи журнала от новых к старым
+func List(ctx context.Context, f Filter) ([]Event, error) {
+	limit := f.Limit
+	if limit <= 0 || limit > MaxListed {
+		limit = MaxListed
+	}
+
+	conn, err := db.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := conn.Query(ctx, `
+		SELECT action, actor, ip, method, path, params::text, at, id, status
+		FROM audit_log
+		WHERE ($1 = '' OR action = $1)
+			AND ($2 = '' OR actor = $2)
+			AND ($3::timestamptz IS NULL OR at >= $3)
+			AND ($4::timestamptz IS NULL OR at < $4)
+		ORDER BY at DESC, id DESC
+		LIMIT $5
+	`, f.Action, f.Actor, f.From, f.To, limit)
+	if err != nil {
+		return nil, err
+	}
+	return scan(rows)
+}
+
+func scan(rows pgx.Rows) ([]Event, error) {
+	defer rows.Close()
+
+	result := []Event{}
+	for rows.Next() {
+		var (
+			e      Event
+			params string
+		)
+		if err := rows.Scan(&e.Action, &e.Actor, &e.IP, &e.Method, &e.Path, &params, &e.At, &e.ID, &e.Status); err != nil {
+			return nil, err
+		}
+		e.Params = json.RawMessage(params)
+		result = append(result, e)
+	}
+	return result, rows.Err()
+}
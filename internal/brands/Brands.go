@@ -0,0 +1,180 @@
+// Package brands — справочник брендов: канонические идентификаторы, группы
+// производителей и варианты написания из исходных выгрузок. Запросы отчётов
+// сводят варианты к каноническому бренду через sqlbuilder.CanonicalBrand
+package brands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"truck-analytics-platform/internal/db"
+)
+
+// Brand — бренд справочника
+type Brand struct {
+	// ID — канонический идентификатор, как в колонках отчётов: SITRAK
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// ParentID — группа производителя, например SINOTRUK для SITRAK
+	ParentID *string `json:"parent_id"`
+	// Aliases — варианты написания в выгрузках: "Sitrak/SINOTRUK"
+	Aliases []string `json:"aliases"`
+}
+
+var validID = regexp.MustCompile(`^[A-Z0-9][A-Z0-9 .-]{0,49}$`)
+
+// Validate проверяет бренд против текущего справочника: группа существует,
+// вложенность групп — один уровень, вариант написания не повторяется
+func (b Brand) Validate(existing []Brand) error {
+	if !validID.MatchString(b.ID) {
+		return errors.New("id must be 1-50 upper-case letters, digits, spaces, dots or dashes")
+	}
+	if b.Name == "" {
+		return errors.New("name is required")
+	}
+
+	byID := make(map[string]Brand, len(existing))
+	for _, e := range existing {
+		byID[e.ID] = e
+	}
+
+	if b.ParentID != nil {
+		parent, ok := byID[*b.ParentID]
+		switch {
+		case *b.ParentID == b.ID:
+			return errors.New("brand cannot be its own parent")
+		case !ok:
+			return fmt.Errorf("parent brand %q does not exist", *b.ParentID)
+		case parent.ParentID != nil:
+			return fmt.Errorf("parent brand %q is itself in group %q", parent.ID, *parent.ParentID)
+		}
+		for _, e := range existing {
+			if e.ParentID != nil && *e.ParentID == b.ID {
+				return fmt.Errorf("brand %q is a group of %q and cannot have a parent", b.ID, e.ID)
+			}
+		}
+	}
+
+	seen := map[string]bool{}
+	for _, alias := range b.Aliases {
+		if alias == "" || len(alias) > 100 {
+			return errors.New("alias must be 1-100 characters")
+		}
+		if alias == b.ID {
+			return fmt.Errorf("alias %q equals the brand id", alias)
+		}
+		if seen[alias] {
+			return fmt.Errorf("alias %q is listed twice", alias)
+		}
+		seen[alias] = true
+	}
+
+	return nil
+}
+
+// List возвращает справочник по идентификаторам
+func List(ctx context.Context) ([]Brand, error) {
+	conn, err := db.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := conn.Query(ctx, `
+		SELECT b.id, b.name, COALESCE(b.parent_id, ''), COALESCE(a.alias, '')
+		FROM brands b
+		LEFT JOIN brand_aliases a ON a.brand_id = b.id
+		ORDER BY b.id, a.alias
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []Brand{}
+	for rows.Next() {
+		var id, name, parent, alias string
+		if err := rows.Scan(&id, &name, &parent, &alias); err != nil {
+			return nil, err
+		}
+
+		if len(result) == 0 || result[len(result)-1].ID != id {
+			b := Brand{ID: id, Name: name, Aliases: []string{}}
+			if parent != "" {
+				b.ParentID = &parent
+			}
+			result = append(result, b)
+		}
+		if alias != "" {
+			last := &result[len(result)-1]
+			last.Aliases = append(last.Aliases, alias)
+		}
+	}
+
+	return result, rows.Err()
+}
+
+// Get возвращает бренд по идентификатору; false — бренда нет
+func Get(ctx context.Context, id string) (Brand, bool, error) {
+	all, err := List(ctx)
+	if err != nil {
+		return Brand{}, false, err
+	}
+	for _, b := range all {
+		if b.ID == id {
+			return b, true, nil
+		}
+	}
+	return Brand{}, false, nil
+}
+
+// Save создаёт или заменяет бренд вместе с вариантами написания одним
+// запросом. Вариант, числившийся за другим брендом, переходит к этому
+func Save(ctx context.Context, b Brand) error {
+	conn, err := db.Connect(ctx)
+	if err != nil {
+		return err
+	}
+
+	aliases := b.Aliases
+	if aliases == nil {
+		aliases = []string{}
+	}
+
+	rows, err := conn.Query(ctx, `
+		WITH brand AS (
+			INSERT INTO brands (id, name, parent_id) VALUES ($1, $2, $3)
+			ON CONFLICT (id) DO UPDATE SET name = EXCLUDED.name, parent_id = EXCLUDED.parent_id
+		),
+		removed AS (
+			DELETE FROM brand_aliases WHERE brand_id = $1 AND alias <> ALL($4)
+		)
+		INSERT INTO brand_aliases (alias, brand_id)
+		SELECT alias, $1 FROM unnest($4::text[]) AS alias
+		ON CONFLICT (alias) DO UPDATE SET brand_id = EXCLUDED.brand_id
+	`, b.ID, b.Name, b.ParentID, aliases)
+	if err != nil {
+		return err
+	}
+	rows.Close()
+	return rows.Err()
+}
+
+// Delete удаляет бренд и его варианты написания; false — бренда не было.
+// Бренды группы остаются без группы
+func Delete(ctx context.Context, id string) (bool, error) {
+	conn, err := db.Connect(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := conn.Query(ctx, `DELETE FROM brands WHERE id = $1 RETURNING id`, id)
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	deleted := rows.Next()
+	rows.Close()
+	return deleted, rows.Err()
+}
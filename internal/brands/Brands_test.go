@@ -0,0 +1,44 @@
+package brands
+
+import (
+	"strings"
+	"testing"
+)
+
+func ptr(s string) *string { return &s }
+
+func TestValidate(t *testing.T) {
+	existing := []Brand{
+		{ID: "SINOTRUK", Name: "Sinotruk"},
+		{ID: "HOWO", Name: "Howo", ParentID: ptr("SINOTRUK")},
+		{ID: "FAW", Name: "FAW"},
+	}
+
+	cases := []struct {
+		name  string
+		brand Brand
+		err   string
+	}{
+		{"valid", Brand{ID: "SITRAK", Name: "Sitrak", ParentID: ptr("SINOTRUK"), Aliases: []string{"Sitrak/SINOTRUK"}}, ""},
+		{"lower-case id", Brand{ID: "sitrak", Name: "Sitrak"}, "id must be"},
+		{"no name", Brand{ID: "SITRAK"}, "name is required"},
+		{"own parent", Brand{ID: "FAW", Name: "FAW", ParentID: ptr("FAW")}, "own parent"},
+		{"unknown parent", Brand{ID: "SITRAK", Name: "Sitrak", ParentID: ptr("CNHTC")}, "does not exist"},
+		{"nested group", Brand{ID: "SITRAK", Name: "Sitrak", ParentID: ptr("HOWO")}, "is itself in group"},
+		{"group gets parent", Brand{ID: "SINOTRUK", Name: "Sinotruk", ParentID: ptr("FAW")}, "cannot have a parent"},
+		{"duplicate alias", Brand{ID: "FAW", Name: "FAW", Aliases: []string{"F.A.W.", "F.A.W."}}, "listed twice"},
+		{"alias equals id", Brand{ID: "FAW", Name: "FAW", Aliases: []string{"FAW"}}, "equals the brand id"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.brand.Validate(existing)
+			switch {
+			case tc.err == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case tc.err != "" && (err == nil || !strings.Contains(err.Error(), tc.err)):
+				t.Fatalf("error %v, want %q", err, tc.err)
+			}
+		})
+	}
+}
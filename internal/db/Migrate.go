@@ -0,0 +1,124 @@
+package db
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MigrateRetryInterval — пауза между попытками применить миграции, пока БД поднимается
+const MigrateRetryInterval = 5 * time.Second
+
+// migrations — схема служебных таблиц приложения. Таблицы регистраций
+// загружаются снаружи и миграциями не создаются
+//
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// Migrate применяет новые миграции из migrations/ по порядку имён файлов.
+// Каждая миграция выполняется в своей транзакции и записывается в schema_migrations
+func Migrate(ctx context.Context) error {
+	if pool == nil {
+		return errors.New("database pool is not initialized")
+	}
+
+	if _, err := pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version    text PRIMARY KEY,
+			applied_at timestamptz NOT NULL DEFAULT now()
+		)
+	`); err != nil {
+		return fmt.Errorf("create schema_migrations: %w", err)
+	}
+
+	versions, err := migrationVersions()
+	if err != nil {
+		return err
+	}
+
+	for _, version := range versions {
+		if err := applyMigration(ctx, migrationFile(version), version); err != nil {
+			return fmt.Errorf("migration %s: %w", version, err)
+		}
+	}
+	return nil
+}
+
+// MigrateUntilDone повторяет Migrate, пока миграции не применятся или ctx не отменят:
+// в docker-compose API стартует раньше, чем Postgres принимает соединения
+func MigrateUntilDone(ctx context.Context) {
+	for {
+		err := Migrate(ctx)
+		if err == nil {
+			return
+		}
+		slog.Warn("Migrations failed, retrying", "error", err, "retry_in", MigrateRetryInterval.String())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(MigrateRetryInterval):
+		}
+	}
+}
+
+// migrationVersions возвращает версии встроенных миграций по порядку: 0001_brands, ...
+func migrationVersions() ([]string, error) {
+	names, err := fs.Glob(migrations, "migrations/*.sql")
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+
+	versions := make([]string, len(names))
+	for i, name := range names {
+		versions[i] = strings.TrimSuffix(strings.TrimPrefix(name, "migrations/"), ".sql")
+	}
+	return versions, nil
+}
+
+func migrationFile(version string) string {
+	return "migrations/" + version + ".sql"
+}
+
+func applyMigration(ctx context.Context, name, version string) error {
+	sql, err := migrations.ReadFile(name)
+	if err != nil {
+		return err
+	}
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// Блокировка не даёт двум экземплярам API применить миграцию одновременно
+	if _, err := tx.Exec(ctx, `LOCK TABLE schema_migrations IN EXCLUSIVE MODE`); err != nil {
+		return err
+	}
+
+	var applied bool
+	if err := tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE version = $1)`, version).Scan(&applied); err != nil {
+		return err
+	}
+	if applied {
+		return nil
+	}
+
+	if _, err := tx.Exec(ctx, string(sql)); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `INSERT INTO schema_migrations (version) VALUES ($1)`, version); err != nil {
+		return err
+	}
+
+	slog.InfoContext(ctx, "Migration applied", "version", version)
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,263 @@
+// Package dbtest содержит источники данных для тестов обработчиков: фейк,
+// который не выполняет SQL и годится только для проверки формы ответов,
+// и Postgres с миграциями и небольшим набором регистраций для проверки данных
+package dbtest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Row — строка фейкового результата. Строковые колонки и числовые колонки
+// хранятся отдельно: Scan раздаёт их получателям по типу в порядке следования,
+// поэтому одна и та же строка подходит и отчётам по регионам, и *total-отчётам.
+// Время берётся из строковых колонок в формате RFC 3339, пустая строка для
+// необязательного времени — NULL; логические значения — из числовых: не ноль — true
+type Row struct {
+	Strings []string
+	Ints    []int
+}
+
+// Querier — фейк db.Querier, возвращающий один и тот же набор строк на любой
+// запрос, кроме запросов, для которых задан свой набор в Responses. SQL не
+// выполняется, поэтому ответы на фейке не говорят о правильности выборок
+type Querier struct {
+	Rows      []Row
+	Responses []Response
+
+	mu    sync.Mutex
+	calls []Call
+}
+
+// Response — набор строк для запросов, в тексте которых есть Contains.
+// Срабатывает первый подходящий
+type Response struct {
+	Contains string
+	Rows     []Row
+}
+
+// Call — выполненный запрос: текст и параметры
+type Call struct {
+	SQL  string
+	Args []any
+}
+
+// Query запоминает запрос и возвращает строки набора
+func (q *Querier) Query(_ context.Context, sql string, args ...any) (pgx.Rows, error) {
+	q.mu.Lock()
+	q.calls = append(q.calls, Call{SQL: sql, Args: args})
+	q.mu.Unlock()
+
+	for _, response := range q.Responses {
+		if strings.Contains(sql, response.Contains) {
+			return &rows{data: response.Rows, pos: -1}, nil
+		}
+	}
+	return &rows{data: q.Rows, pos: -1}, nil
+}
+
+// Queries возвращает тексты всех выполненных запросов
+func (q *Querier) Queries() []string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	queries := make([]string, len(q.calls))
+	for i, call := range q.calls {
+		queries[i] = call.SQL
+	}
+	return queries
+}
+
+// Calls возвращает все выполненные запросы вместе с параметрами
+func (q *Querier) Calls() []Call {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return append([]Call(nil), q.calls...)
+}
+
+type rows struct {
+	data []Row
+	pos  int
+	err  error
+}
+
+func (r *rows) Close()                                       {}
+func (r *rows) Err() error                                   { return r.err }
+func (r *rows) CommandTag() pgconn.CommandTag                { return pgconn.NewCommandTag("SELECT") }
+func (r *rows) FieldDescriptions() []pgconn.FieldDescription { return nil }
+func (r *rows) RawValues() [][]byte                          { return nil }
+func (r *rows) Conn() *pgx.Conn                              { return nil }
+
+func (r *rows) Next() bool {
+	r.pos++
+	return r.pos < len(r.data)
+}
+
+func (r *rows) Values() ([]any, error) {
+	row := r.data[r.pos]
+	values := make([]any, 0, len(row.Strings)+len(row.Ints))
+	for _, s := range row.Strings {
+		values = append(values, s)
+	}
+	for _, n := range row.Ints {
+		values = append(values, n)
+	}
+	return values, nil
+}
+
+func (r *rows) Scan(dest ...any) error {
+	row := r.data[r.pos]
+	nextString, nextInt := 0, 0
+
+	for i, d := range dest {
+		switch d := d.(type) {
+		case *string:
+			if nextString >= len(row.Strings) {
+				return fmt.Errorf("dbtest: no string value for column %d", i)
+			}
+			*d = row.Strings[nextString]
+			nextString++
+		case *time.Time:
+			if nextString >= len(row.Strings) {
+				return fmt.Errorf("dbtest: no string value for column %d", i)
+			}
+			value, err := time.Parse(time.RFC3339, row.Strings[nextString])
+			if err != nil {
+				return fmt.Errorf("dbtest: column %d: %w", i, err)
+			}
+			*d = value
+			nextString++
+		case **time.Time:
+			if nextString >= len(row.Strings) {
+				return fmt.Errorf("dbtest: no string value for column %d", i)
+			}
+			*d = nil
+			if raw := row.Strings[nextString]; raw != "" {
+				value, err := time.Parse(time.RFC3339, raw)
+				if err != nil {
+					return fmt.Errorf("dbtest: column %d: %w", i, err)
+				}
+				*d = &value
+			}
+			nextString++
+		case *int, **int, *int64, **int64, *float64, **float64, *bool:
+			if nextInt >= len(row.Ints) {
+				return fmt.Errorf("dbtest: no int value for column %d", i)
+			}
+			assignInt(d, row.Ints[nextInt])
+			nextInt++
+		default:
+			return fmt.Errorf("dbtest: unsupported scan destination %T for column %d", d, i)
+		}
+	}
+
+	return nil
+}
+
+func assignInt(dest any, value int) {
+	switch d := dest.(type) {
+	case *int:
+		*d = value
+	case **int:
+		v := value
+		*d = &v
+	case *int64:
+		*d = int64(value)
+	case **int64:
+		v := int64(value)
+		*d = &v
+	case *float64:
+		*d = float64(value)
+	case **float64:
+		v := float64(value)
+		*d = &v
+	case *bool:
+		*d = value != 0
+	}
+}
+
+// Districts — федеральные округа в том виде, в каком они лежат в БД
+var Districts = []string{
+	"Центральный Федеральный Округ",
+	"Северо-Западный Федеральный Округ",
+	"Приволжский Федеральный Округ",
+	"Южный Федеральный Округ",
+	"Северо-Кавказский Федеральный Округ",
+	"Уральский Федеральный Округ",
+	"Сибирский Федеральный Округ",
+	"Дальневосточный Федеральный Округ",
+}
+
+// regionsByDistrict — по два региона на округ для детерминированного набора
+var regionsByDistrict = map[string][]string{
+	"Центральный Федеральный Округ":       {"Москва", "Тульская область"},
+	"Северо-Западный Федеральный Округ":   {"Ленинградская область", "Мурманская область"},
+	"Приволжский Федеральный Округ":       {"Татарстан Республика", "Пермский край"},
+	"Южный Федеральный Округ":             {"Краснодарский край", "Ростовская область"},
+	"Северо-Кавказский Федеральный Округ": {"Дагестан Республика", "Ставропольский край"},
+	"Уральский Федеральный Округ":         {"Свердловская область", "Челябинская область"},
+	"Сибирский Федеральный Округ":         {"Новосибирская область", "Омская область"},
+	"Дальневосточный Федеральный Округ":   {"Приморский край", "Хабаровский край"},
+}
+
+// RegionRows возвращает детерминированный набор для отчётов по регионам:
+// по каждому округу строки регионов и строку итога округа
+// (Region = Federal_district), как их отдают запросы отчётов
+func RegionRows(intColumns int) []Row {
+	var result []Row
+
+	for _, district := range Districts {
+		names := append(append([]string(nil), regionsByDistrict[district]...), district)
+		for _, name := range names {
+			result = append(result, Row{Strings: []string{district, name}, Ints: fixtureInts(len(result), intColumns)})
+		}
+	}
+
+	return result
+}
+
+// DistrictRows возвращает детерминированный набор для *total-отчётов:
+// по одной строке на федеральный округ
+func DistrictRows(intColumns int) []Row {
+	result := make([]Row, 0, len(Districts))
+
+	for _, district := range Districts {
+		result = append(result, Row{Strings: []string{district}, Ints: fixtureInts(len(result), intColumns)})
+	}
+
+	return result
+}
+
+// RegistrationRows возвращает детерминированный набор для выборок по
+// регистрациям: округ, регион, город, бренд и продажи. В каждом регионе
+// продажи двух брендов в одном городе, названном как регион
+func RegistrationRows() []Row {
+	brands := []string{"FOTON", "SHACMAN"}
+	var result []Row
+
+	for _, district := range Districts {
+		for _, region := range regionsByDistrict[district] {
+			for _, brand := range brands {
+				quantity := fixtureInts(len(result), 1)
+				result = append(result, Row{Strings: []string{district, region, region, brand}, Ints: quantity})
+			}
+		}
+	}
+
+	return result
+}
+
+// fixtureInts — числа зависят только от номера строки и колонки
+func fixtureInts(row int, columns int) []int {
+	ints := make([]int, columns)
+	for col := range ints {
+		ints[col] = (row*7+col*13)%50 + col + 1
+	}
+	return ints
+}
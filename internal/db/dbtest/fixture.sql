@@ -0,0 +1,48 @@
+-- Таблицы регистраций для тестов на Postgres: колонки, которые читают
+-- отчёты и аналитика, и по строке на каждый сегмент HDT. Все таблицы
+-- получают одни и те же строки; выгрузка HDT за 9 месяцев 2024 года —
+-- без октября, в таблицах MDT "Quantity" текстовая, как в исходных выгрузках
+CREATE TABLE truck_analytics_2023_01_12 (
+	"Federal_district"      text,
+	"Region"                text,
+	"City"                  text,
+	"Brand"                 text,
+	"Model"                 text,
+	"Engine"                text,
+	"Quantity"              integer,
+	"Wheel_formula"         text,
+	"Body_type"             text,
+	"Exact_mass"            integer,
+	"Mass_in_segment_1"     text,
+	"Weight_in_segment_4"   text,
+	"Month_of_registration" integer
+);
+
+INSERT INTO truck_analytics_2023_01_12 VALUES
+	-- tractors4x2: FOTON в Москве 3 за 9 месяцев и 7 за 10, SITRAK через вариант написания
+	('Центральный Федеральный Округ', 'Москва', 'Москва', 'FOTON', 'Auman EST', 'Cummins', 3, '4x2', 'Седельный тягач', 18000, NULL, NULL, 5),
+	('Центральный Федеральный Округ', 'Москва', 'Москва', 'Sitrak/SINOTRUK', 'C7H', 'MC11', 2, '4x2', 'Седельный тягач', 18000, NULL, NULL, 9),
+	('Центральный Федеральный Округ', 'Москва', 'Москва', 'FOTON', 'Auman EST', 'Cummins', 4, '4x2', 'Седельный тягач', 18000, NULL, NULL, 10),
+	-- tractors6x4
+	('Уральский Федеральный Округ', 'Свердловская область', 'Екатеринбург', 'SHACMAN', 'X3000', 'Weichai', 5, '6x4', 'Седельный тягач', 25000, NULL, NULL, 3),
+	-- dumpers6x4: HOWO через вариант написания
+	('Уральский Федеральный Округ', 'Свердловская область', 'Екатеринбург', 'SINOTRUK HOWO', 'T5G', 'MC11', 1, '6x4', 'Самосвал', 33000, '32001-40000', NULL, 2),
+	-- dumpers8x4
+	('Центральный Федеральный Округ', 'Тульская область', 'Тула', 'FAW', 'J6', 'CA6DM', 6, '8x4', 'Самосвал', 41000, NULL, '35001-45000', 7);
+
+CREATE TABLE truck_analytics_2024_01_09 (LIKE truck_analytics_2023_01_12);
+CREATE TABLE truck_analytics_2024_01_10 (LIKE truck_analytics_2023_01_12);
+CREATE TABLE ldt_3_5_12_truck_analytics_10_2023 (LIKE truck_analytics_2023_01_12);
+CREATE TABLE ldt_3_5_12_truck_analytics_10_2024 (LIKE truck_analytics_2023_01_12);
+CREATE TABLE mdt_12_18_truck_analytics_10_2023 (LIKE truck_analytics_2023_01_12);
+CREATE TABLE mdt_12_18_truck_analytics_10_2024 (LIKE truck_analytics_2023_01_12);
+
+ALTER TABLE mdt_12_18_truck_analytics_10_2023 ALTER COLUMN "Quantity" TYPE text;
+ALTER TABLE mdt_12_18_truck_analytics_10_2024 ALTER COLUMN "Quantity" TYPE text;
+
+INSERT INTO truck_analytics_2024_01_09 SELECT * FROM truck_analytics_2023_01_12 WHERE "Month_of_registration" <= 9;
+INSERT INTO truck_analytics_2024_01_10 SELECT * FROM truck_analytics_2023_01_12;
+INSERT INTO ldt_3_5_12_truck_analytics_10_2023 SELECT * FROM truck_analytics_2023_01_12;
+INSERT INTO ldt_3_5_12_truck_analytics_10_2024 SELECT * FROM truck_analytics_2023_01_12;
+INSERT INTO mdt_12_18_truck_analytics_10_2023 SELECT * FROM truck_analytics_2023_01_12;
+INSERT INTO mdt_12_18_truck_analytics_10_2024 SELECT * FROM truck_analytics_2023_01_12;
@@ -0,0 +1,42 @@
+-- Справочник брендов: канонические идентификаторы, группы производителей
+-- и варианты написания из исходных выгрузок
+CREATE TABLE brands (
+	id        text PRIMARY KEY,
+	name      text NOT NULL,
+	parent_id text REFERENCES brands (id) ON DELETE SET NULL,
+	CHECK (parent_id <> id)
+);
+
+CREATE TABLE brand_aliases (
+	alias    text PRIMARY KEY,
+	brand_id text NOT NULL REFERENCES brands (id) ON DELETE CASCADE
+);
+
+CREATE INDEX brand_aliases_brand_id_idx ON brand_aliases (brand_id);
+
+INSERT INTO brands (id, name) VALUES
+	('SINOTRUK', 'Sinotruk'),
+	('DONGFENG', 'Dongfeng'),
+	('FAW', 'FAW'),
+	('FOTON', 'Foton'),
+	('JAC', 'JAC'),
+	('SHACMAN', 'Shacman'),
+	('SANY', 'Sany'),
+	('GAZ', 'GAZ'),
+	('ISUZU', 'Isuzu'),
+	('KAMAZ', 'KAMAZ'),
+	('URAL', 'Ural'),
+	('DAEWOO', 'Daewoo');
+
+INSERT INTO brands (id, name, parent_id) VALUES
+	('HOWO', 'Howo', 'SINOTRUK'),
+	('SITRAK', 'Sitrak', 'SINOTRUK');
+
+INSERT INTO brand_aliases (alias, brand_id) VALUES
+	('DONG FENG', 'DONGFENG'),
+	('Dongfeng', 'DONGFENG'),
+	('Shacman (Shaanxi)', 'SHACMAN'),
+	('SHAANXI', 'SHACMAN'),
+	('Sitrak/SINOTRUK', 'SITRAK'),
+	('SINOTRUK SITRAK', 'SITRAK'),
+	('SINOTRUK HOWO', 'HOWO');
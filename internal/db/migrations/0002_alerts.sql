@@ -0,0 +1,19 @@
+-- Алерты по месячным загрузкам. Один алерт вида на месяц, территорию
+-- и бренд: повторная проверка тех же данных новых строк не создаёт
+CREATE TABLE alerts (
+	id         bigserial PRIMARY KEY,
+	kind       text NOT NULL,
+	segment    text NOT NULL,
+	year       integer NOT NULL,
+	month      integer NOT NULL,
+	district   text NOT NULL,
+	region     text NOT NULL DEFAULT '',
+	brand      text NOT NULL DEFAULT '',
+	value      double precision NOT NULL,
+	baseline   double precision NOT NULL,
+	message    text NOT NULL,
+	created_at timestamptz NOT NULL DEFAULT now(),
+	UNIQUE (kind, segment, year, month, district, region, brand)
+);
+
+CREATE INDEX alerts_created_at_idx ON alerts (created_at DESC);
@@ -0,0 +1,18 @@
+-- Дилеры и их территории. Территория — регионы целиком или отдельные
+-- города региона; регионы — английские названия, как в ответах API
+CREATE TABLE dealers (
+	id     bigserial PRIMARY KEY,
+	name   text NOT NULL,
+	city   text NOT NULL DEFAULT '',
+	region text NOT NULL,
+	brands text[] NOT NULL DEFAULT '{}'
+);
+
+-- Пустой city — регион целиком
+CREATE TABLE dealer_territories (
+	dealer_id bigint NOT NULL REFERENCES dealers (id) ON DELETE CASCADE,
+	region    text NOT NULL,
+	city      text NOT NULL DEFAULT ''
+);
+
+CREATE INDEX dealer_territories_dealer_id_idx ON dealer_territories (dealer_id);
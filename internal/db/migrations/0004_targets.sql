@@ -0,0 +1,13 @@
+-- Планы продаж бренда по месяцам, округам и сегментам: объём, доля
+-- рынка в процентах или оба. Округ — английское название, как в ответах API
+CREATE TABLE targets (
+	segment  text NOT NULL,
+	year     integer NOT NULL,
+	month    integer NOT NULL CHECK (month BETWEEN 1 AND 12),
+	district text NOT NULL,
+	brand    text NOT NULL,
+	volume   integer CHECK (volume >= 0),
+	share    double precision CHECK (share BETWEEN 0 AND 100),
+	PRIMARY KEY (segment, year, brand, district, month),
+	CHECK (volume IS NOT NULL OR share IS NOT NULL)
+);
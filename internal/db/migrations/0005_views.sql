@@ -0,0 +1,43 @@
+-- Сохранённые виды пользователей: сегмент, период, округа и бренды отчёта.
+-- token открывает вид по ссылке без входа. Вид по умолчанию у пользователя
+-- один: запись нового снимает флаг с прежнего
+CREATE TABLE saved_views (
+	id         bigserial PRIMARY KEY,
+	owner      text NOT NULL,
+	token      text NOT NULL UNIQUE,
+	name       text NOT NULL,
+	segment    text NOT NULL,
+	year       integer NOT NULL,
+	month_from integer NOT NULL DEFAULT 0,
+	month_to   integer NOT NULL DEFAULT 0,
+	level      text NOT NULL DEFAULT '',
+	districts  text[] NOT NULL DEFAULT '{}',
+	brands     text[] NOT NULL DEFAULT '{}',
+	is_default boolean NOT NULL DEFAULT false,
+	updated_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX saved_views_owner_idx ON saved_views (owner);
+
+-- Дашборды — упорядоченные наборы видов владельца
+CREATE TABLE dashboards (
+	id         bigserial PRIMARY KEY,
+	owner      text NOT NULL,
+	token      text NOT NULL UNIQUE,
+	name       text NOT NULL,
+	is_default boolean NOT NULL DEFAULT false,
+	updated_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX dashboards_owner_idx ON dashboards (owner);
+
+-- Без уникального ключа: обновление дашборда удаляет и вставляет виды одним запросом
+CREATE TABLE dashboard_views (
+	dashboard_id bigint NOT NULL REFERENCES dashboards (id) ON DELETE CASCADE,
+	position     integer NOT NULL,
+	view_id      bigint NOT NULL REFERENCES saved_views (id) ON DELETE CASCADE
+);
+
+CREATE INDEX dashboard_views_dashboard_id_idx ON dashboard_views (dashboard_id, position);
+
+CREATE INDEX dashboard_views_view_id_idx ON dashboard_views (view_id);
@@ -0,0 +1,17 @@
+-- Заметки аналитиков к данным. Пустые segment, district, region, brand
+-- и month = 0 — заметка относится ко всем значениям ключа
+CREATE TABLE annotations (
+	id         bigserial PRIMARY KEY,
+	segment    text NOT NULL DEFAULT '',
+	year       integer NOT NULL,
+	month      integer NOT NULL DEFAULT 0 CHECK (month BETWEEN 0 AND 12),
+	district   text NOT NULL DEFAULT '',
+	region     text NOT NULL DEFAULT '',
+	brand      text NOT NULL DEFAULT '',
+	text       text NOT NULL,
+	author     text NOT NULL,
+	created_at timestamptz NOT NULL DEFAULT now(),
+	updated_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX annotations_year_month_idx ON annotations (year, month);
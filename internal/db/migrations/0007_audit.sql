@@ -0,0 +1,26 @@
+-- Журнал аудита: входы, выгрузки, изменения справочников и загрузки данных.
+-- Только добавление: изменение и удаление записей запрещены триггером
+CREATE TABLE audit_log (
+	id     bigserial PRIMARY KEY,
+	at     timestamptz NOT NULL DEFAULT now(),
+	action text NOT NULL,
+	actor  text NOT NULL DEFAULT '',
+	ip     text NOT NULL DEFAULT '',
+	method text NOT NULL DEFAULT '',
+	path   text NOT NULL DEFAULT '',
+	status integer NOT NULL DEFAULT 0,
+	params jsonb NOT NULL DEFAULT '{}'
+);
+
+CREATE INDEX audit_log_at_idx ON audit_log (at);
+CREATE INDEX audit_log_action_at_idx ON audit_log (action, at);
+
+CREATE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+	RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_log_append_only
+	BEFORE UPDATE OR DELETE OR TRUNCATE ON audit_log
+	FOR EACH STATEMENT EXECUTE FUNCTION audit_log_append_only();
@@ -0,0 +1,13 @@
+-- API-ключи для доступа BI и скриптов без входа пользователя. Хранится
+-- только SHA-256 ключа; prefix — начало ключа, чтобы узнать его в списке
+CREATE TABLE api_keys (
+	id           bigserial PRIMARY KEY,
+	name         text NOT NULL UNIQUE,
+	prefix       text NOT NULL,
+	hash         text NOT NULL UNIQUE,
+	scopes       text[] NOT NULL CHECK (cardinality(scopes) > 0),
+	created_by   text NOT NULL,
+	created_at   timestamptz NOT NULL DEFAULT now(),
+	expires_at   timestamptz,
+	last_used_at timestamptz
+);
@@ -0,0 +1,230 @@
+// Package dealers — дилеры и их территории: регионы целиком или отдельные
+// города. По территориям считаются регистрации и проникновение бренда
+package dealers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"truck-analytics-platform/internal/db"
+	"truck-analytics-platform/internal/reports"
+)
+
+// Dealer — дилер: где находится, какие бренды продаёт и какую территорию обслуживает
+type Dealer struct {
+	ID     int64  `json:"id"`
+	Name   string `json:"name"`
+	City   string `json:"city"`
+	Region string `json:"region"`
+	// Brands — канонические идентификаторы брендов из справочника
+	Brands    []string `json:"brands"`
+	Territory []Area   `json:"territory"`
+}
+
+// Area — часть территории: регион целиком или город региона. Регион —
+// английское название, город — как в колонке City выгрузок
+type Area struct {
+	Region string `json:"region"`
+	City   string `json:"city,omitempty"`
+}
+
+func (a Area) String() string {
+	if a.City == "" {
+		return a.Region
+	}
+	return a.City + ", " + a.Region
+}
+
+var validBrand = regexp.MustCompile(`^[A-Z0-9][A-Z0-9 .-]{0,49}$`)
+
+// Validate проверяет дилера: регионы территории должны быть регионами выгрузок
+func (d Dealer) Validate() error {
+	if d.Name == "" || len(d.Name) > 200 {
+		return errors.New("name must be 1-200 characters")
+	}
+	if !reports.EnglishRegion(d.Region) {
+		return fmt.Errorf("unknown region %q", d.Region)
+	}
+
+	seen := map[string]bool{}
+	for _, brand := range d.Brands {
+		// Список дилеров читает бренды одной строкой через запятую: запятой в идентификаторе нет
+		if !validBrand.MatchString(brand) {
+			return fmt.Errorf("brand %q must be a canonical brand id", brand)
+		}
+		if seen[brand] {
+			return fmt.Errorf("brand %q is listed twice", brand)
+		}
+		seen[brand] = true
+	}
+
+	if len(d.Territory) == 0 {
+		return errors.New("territory must list at least one region or city")
+	}
+	areas := map[Area]bool{}
+	for _, a := range d.Territory {
+		if !reports.EnglishRegion(a.Region) {
+			return fmt.Errorf("unknown territory region %q", a.Region)
+		}
+		if areas[a] {
+			return fmt.Errorf("territory lists %s twice", a)
+		}
+		areas[a] = true
+	}
+	return nil
+}
+
+// List возвращает дилеров по идентификаторам
+func List(ctx context.Context) ([]Dealer, error) {
+	return query(ctx, 0)
+}
+
+// Get возвращает дилера; false — дилера нет
+func Get(ctx context.Context, id int64) (Dealer, bool, error) {
+	list, err := query(ctx, id)
+	if err != nil || len(list) == 0 {
+		return Dealer{}, false, err
+	}
+	return list[0], true, nil
+}
+
+// query читает дилеров с территориями; id = 0 — всех
+func query(ctx context.Context, id int64) ([]Dealer, error) {
+	conn, err := db.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := conn.Query(ctx, `
+		SELECT d.name, d.city, d.region, array_to_string(d.brands, ','),
+			COALESCE(t.region, ''), COALESCE(t.city, ''), d.id
+		FROM dealers d
+		LEFT JOIN dealer_territories t ON t.dealer_id = d.id
+		WHERE $1 = 0 OR d.id = $1
+		ORDER BY d.id, t.region, t.city
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []Dealer{}
+	for rows.Next() {
+		var d Dealer
+		var brands string
+		var a Area
+		if err := rows.Scan(&d.Name, &d.City, &d.Region, &brands, &a.Region, &a.City, &d.ID); err != nil {
+			return nil, err
+		}
+
+		if len(result) == 0 || result[len(result)-1].ID != d.ID {
+			d.Brands = []string{}
+			if brands != "" {
+				d.Brands = strings.Split(brands, ",")
+			}
+			d.Territory = []Area{}
+			result = append(result, d)
+		}
+		if a.Region != "" {
+			last := &result[len(result)-1]
+			last.Territory = append(last.Territory, a)
+		}
+	}
+
+	return result, rows.Err()
+}
+
+// Create сохраняет нового дилера с территорией и возвращает его идентификатор
+func Create(ctx context.Context, d Dealer) (int64, error) {
+	brands, regions, cities := columns(d)
+	return save(ctx, `
+		WITH dealer AS (
+			INSERT INTO dealers (name, city, region, brands) VALUES ($1, $2, $3, $4)
+			RETURNING id
+		),
+		territory AS (
+			INSERT INTO dealer_territories (dealer_id, region, city)
+			SELECT dealer.id, t.region, t.city FROM dealer, unnest($5::text[], $6::text[]) AS t (region, city)
+		)
+		SELECT id FROM dealer
+	`, d.Name, d.City, d.Region, brands, regions, cities)
+}
+
+// Update заменяет дилера вместе с территорией; false — дилера нет
+func Update(ctx context.Context, d Dealer) (bool, error) {
+	brands, regions, cities := columns(d)
+	id, err := save(ctx, `
+		WITH dealer AS (
+			UPDATE dealers SET name = $2, city = $3, region = $4, brands = $5
+			WHERE id = $1
+			RETURNING id
+		),
+		removed AS (
+			DELETE FROM dealer_territories WHERE dealer_id IN (SELECT id FROM dealer)
+		),
+		territory AS (
+			INSERT INTO dealer_territories (dealer_id, region, city)
+			SELECT dealer.id, t.region, t.city FROM dealer, unnest($6::text[], $7::text[]) AS t (region, city)
+		)
+		SELECT id FROM dealer
+	`, d.ID, d.Name, d.City, d.Region, brands, regions, cities)
+	return id != 0, err
+}
+
+// columns раскладывает бренды и территорию дилера в массивы параметров запроса
+func columns(d Dealer) (brands, regions, cities []string) {
+	brands = d.Brands
+	if brands == nil {
+		brands = []string{}
+	}
+	regions = make([]string, len(d.Territory))
+	cities = make([]string, len(d.Territory))
+	for i, a := range d.Territory {
+		regions[i], cities[i] = a.Region, a.City
+	}
+	return brands, regions, cities
+}
+
+// save выполняет запрос записи дилера и возвращает идентификатор
+// записанной строки; 0 — запрос ничего не записал
+func save(ctx context.Context, sql string, args ...any) (int64, error) {
+	conn, err := db.Connect(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	rows, err := conn.Query(ctx, sql, args...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var id int64
+	if rows.Next() {
+		if err := rows.Scan(&id); err != nil {
+			return 0, err
+		}
+	}
+	rows.Close()
+	return id, rows.Err()
+}
+
+// Delete удаляет дилера с территорией; false — дилера не было
+func Delete(ctx context.Context, id int64) (bool, error) {
+	conn, err := db.Connect(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := conn.Query(ctx, `DELETE FROM dealers WHERE id = $1 RETURNING id`, id)
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	deleted := rows.Next()
+	rows.Close()
+	return deleted, rows.Err()
+}
@@ -0,0 +1,69 @@
+package dealers
+
+import (
+	"strings"
+	"testing"
+
+	"truck-analytics-platform/internal/reports"
+)
+
+func TestValidate(t *testing.T) {
+	valid := Dealer{Name: "Foton Center", Region: "Moscow", Brands: []string{"FOTON"}, Territory: []Area{{Region: "Moscow"}}}
+
+	cases := []struct {
+		name   string
+		change func(d *Dealer)
+		err    string
+	}{
+		{"valid", func(d *Dealer) {}, ""},
+		{"city in territory", func(d *Dealer) { d.Territory = append(d.Territory, Area{Region: "Tula Region", City: "Тула"}) }, ""},
+		{"no name", func(d *Dealer) { d.Name = "" }, "name must be"},
+		{"unknown region", func(d *Dealer) { d.Region = "Moscow Oblast" }, "unknown region"},
+		{"brand not canonical", func(d *Dealer) { d.Brands = []string{"Foton"} }, "canonical brand id"},
+		{"brand twice", func(d *Dealer) { d.Brands = []string{"FOTON", "FOTON"} }, "listed twice"},
+		{"empty territory", func(d *Dealer) { d.Territory = nil }, "at least one"},
+		{"unknown territory region", func(d *Dealer) { d.Territory = []Area{{Region: "Москва"}} }, "unknown territory region"},
+		{"area twice", func(d *Dealer) { d.Territory = []Area{{Region: "Moscow"}, {Region: "Moscow"}} }, "twice"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := valid
+			d.Territory = append([]Area(nil), valid.Territory...)
+			tc.change(&d)
+
+			err := d.Validate()
+			switch {
+			case tc.err == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case tc.err != "" && (err == nil || !strings.Contains(err.Error(), tc.err)):
+				t.Fatalf("error %v, want %q", err, tc.err)
+			}
+		})
+	}
+}
+
+func TestTerritoriesMatchCityBeforeRegion(t *testing.T) {
+	dealer := Dealer{
+		ID: 1, Name: "Ural Trucks", Brands: []string{"SHACMAN"},
+		Territory: []Area{{Region: "Sverdlovsk Region"}, {Region: "Sverdlovsk Region", City: "Екатеринбург"}},
+	}
+	registrations := []reports.Registration{
+		{Region: "Sverdlovsk Region", City: "ЕКАТЕРИНБУРГ", Brand: "FOTON", Quantity: 6},
+		{Region: "Sverdlovsk Region", City: "Екатеринбург", Brand: "SHACMAN", Quantity: 4},
+		{Region: "Sverdlovsk Region", City: "Нижний Тагил", Brand: "SHACMAN", Quantity: 10},
+		{Region: "Chelyabinsk Region", City: "Челябинск", Brand: "FOTON", Quantity: 50},
+	}
+
+	result := territories([]Dealer{dealer}, registrations, "FOTON")
+	if len(result) != 1 {
+		t.Fatalf("territories = %+v", result)
+	}
+	got := result[0]
+	if got.MarketVolume != 20 || got.BrandVolume != 6 || got.Penetration != 30 || got.DealerBrandsVolume != 14 {
+		t.Errorf("territory = %+v", got.Coverage)
+	}
+	if got.Areas[0].MarketVolume != 10 || got.Areas[1].MarketVolume != 10 || got.Areas[1].Penetration != 60 {
+		t.Errorf("areas = %+v", got.Areas)
+	}
+}
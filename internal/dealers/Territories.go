@@ -0,0 +1,113 @@
+package dealers
+
+import (
+	"context"
+	"math"
+	"strings"
+	"truck-analytics-platform/internal/reports"
+)
+
+// Coverage — рынок территории, продажи бренда на ней и его доля, %
+type Coverage struct {
+	MarketVolume int     `json:"market_volume"`
+	BrandVolume  int     `json:"brand_volume"`
+	Penetration  float64 `json:"penetration"`
+}
+
+func (p *Coverage) add(quantity int, ofBrand bool) {
+	p.MarketVolume += quantity
+	if ofBrand {
+		p.BrandVolume += quantity
+	}
+}
+
+func (p *Coverage) finish() {
+	if p.MarketVolume > 0 {
+		p.Penetration = math.Round(float64(p.BrandVolume)/float64(p.MarketVolume)*1000) / 10
+	}
+}
+
+// AreaCoverage — проникновение бренда в части территории дилера
+type AreaCoverage struct {
+	Area
+	Coverage
+}
+
+// Territory — регистрации на территории дилера и проникновение бренда
+type Territory struct {
+	DealerID int64  `json:"dealer_id"`
+	Dealer   string `json:"dealer"`
+	Coverage
+	// DealerBrandsVolume — продажи брендов, которые продаёт дилер
+	DealerBrandsVolume int            `json:"dealer_brands_volume"`
+	Areas              []AreaCoverage `json:"areas"`
+}
+
+// Territories считает регистрации рынка на территории каждого дилера
+// и проникновение бренда brand. Регистрация в городе, который указан
+// в территории отдельно, относится к городу, иначе — к региону целиком
+func Territories(ctx context.Context, s reports.MarketSpec, brand string) ([]Territory, reports.Period, error) {
+	market := reports.Markets[s.Segment]
+	period := reports.Period{Year: s.Year, MonthFrom: max(s.MonthFrom, 1), MonthTo: s.MonthTo}
+	if period.MonthTo == 0 {
+		period.MonthTo = market.Months[s.Year]
+	}
+
+	list, err := List(ctx)
+	if err != nil {
+		return nil, period, err
+	}
+	registrations, err := reports.Registrations(ctx, market.Filter(s.Year, period.MonthFrom, period.MonthTo))
+	if err != nil {
+		return nil, period, err
+	}
+
+	return territories(list, registrations, brand), period, nil
+}
+
+func territories(list []Dealer, registrations []reports.Registration, brand string) []Territory {
+	result := make([]Territory, 0, len(list))
+	for _, d := range list {
+		t := Territory{DealerID: d.ID, Dealer: d.Name, Areas: make([]AreaCoverage, len(d.Territory))}
+		for i, a := range d.Territory {
+			t.Areas[i].Area = a
+		}
+
+		for _, r := range registrations {
+			i := match(d.Territory, r)
+			if i < 0 {
+				continue
+			}
+			t.add(r.Quantity, r.Brand == brand)
+			t.Areas[i].add(r.Quantity, r.Brand == brand)
+			for _, sold := range d.Brands {
+				if r.Brand == sold {
+					t.DealerBrandsVolume += r.Quantity
+				}
+			}
+		}
+
+		t.finish()
+		for i := range t.Areas {
+			t.Areas[i].finish()
+		}
+		result = append(result, t)
+	}
+	return result
+}
+
+// match возвращает часть территории, к которой относится регистрация; -1 — ни к какой
+func match(territory []Area, r reports.Registration) int {
+	region := -1
+	for i, a := range territory {
+		if a.Region != r.Region {
+			continue
+		}
+		if a.City == "" {
+			region = i
+		} else if strings.EqualFold(strings.TrimSpace(a.City), strings.TrimSpace(r.City)) {
+			return i
+		}
+	}
+	return region
+}
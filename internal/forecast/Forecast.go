@@ -0,0 +1,176 @@
+// Package forecast прогнозирует продажи полного года по фактическим месяцам
+// текущего года и помесячному профилю прошлого года: по сегменту, бренду
+// и федеральному округу, с 95% интервалом
+package forecast
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"truck-analytics-platform/internal/reports"
+)
+
+// Spec — запрос прогноза: рынок и год, территория, бренды и модель.
+// AsOf — сколько месяцев года считать фактом; 0 — все загруженные
+type Spec struct {
+	Segment string
+	Year    int
+	Level   string
+	Brands  []string
+	Model   string
+	AsOf    int
+}
+
+// Validate проверяет спецификацию: для прогноза нужна выгрузка прошлого года
+func (s Spec) Validate() error {
+	market, ok := reports.Markets[s.Segment]
+	if !ok {
+		return fmt.Errorf("unknown segment %q", s.Segment)
+	}
+	if _, ok := market.Tables[s.Year]; !ok {
+		return fmt.Errorf("no data for year %d", s.Year)
+	}
+	if _, ok := market.Tables[s.Year-1]; !ok {
+		return fmt.Errorf("forecast needs data for year %d", s.Year-1)
+	}
+	if s.Level != reports.LevelCountry && s.Level != reports.LevelDistrict {
+		return fmt.Errorf("level must be %s or %s", reports.LevelCountry, reports.LevelDistrict)
+	}
+	if !slices.Contains(Models, s.Model) {
+		return fmt.Errorf("model must be one of %v", Models)
+	}
+	if loaded := market.Months[s.Year]; s.AsOf < 0 || s.AsOf > loaded {
+		return fmt.Errorf("as_of must be between 0 (all months loaded) and %d, the months loaded for %d", loaded, s.Year)
+	}
+	return nil
+}
+
+// Period описывает, из чего построен прогноз
+type Period struct {
+	Year  int    `json:"year"`
+	Model string `json:"model"`
+	// ActualMonths — месяцы текущего года, взятые как факт
+	ActualMonths int `json:"actual_months"`
+	// PriorMonths — месяцы прошлого года в выгрузке. Недостающие месяцы
+	// профиля заменяются средним месяцем прошлого года
+	PriorMonths int     `json:"prior_months"`
+	Confidence  float64 `json:"confidence"`
+}
+
+// BrandProjection — прогноз продаж бренда
+type BrandProjection struct {
+	Brand string `json:"brand"`
+	Projection
+}
+
+// AreaForecast — прогноз сегмента на территории и прогнозы его брендов
+type AreaForecast struct {
+	Area   string            `json:"area"`
+	Total  Projection        `json:"total"`
+	Brands []BrandProjection `json:"brands"`
+}
+
+// series — помесячные продажи текущего и прошлого года
+type series struct {
+	current, prior [12]float64
+}
+
+// Run строит прогноз по спецификации, прошедшей Validate
+func Run(ctx context.Context, s Spec) ([]AreaForecast, Period, error) {
+	market := reports.Markets[s.Segment]
+	period := Period{
+		Year:         s.Year,
+		Model:        s.Model,
+		ActualMonths: s.AsOf,
+		PriorMonths:  market.Months[s.Year-1],
+		Confidence:   0.95,
+	}
+	if period.ActualMonths == 0 {
+		period.ActualMonths = market.Months[s.Year]
+	}
+
+	sales, err := market.MonthlySales(ctx, []int{s.Year, s.Year - 1})
+	if err != nil {
+		return nil, period, err
+	}
+
+	return project(sales, s, period), period, nil
+}
+
+// project раскладывает продажи по территориям и брендам и прогнозирует каждый ряд
+func project(sales []reports.MonthlySale, s Spec, period Period) []AreaForecast {
+	var areas []string
+	totals := map[string]*series{}
+	brands := map[string]map[string]*series{}
+
+	for _, sale := range sales {
+		if sale.Year == s.Year && sale.Month > period.ActualMonths {
+			continue
+		}
+
+		area := reports.CountryArea
+		if s.Level == reports.LevelDistrict {
+			area = sale.District
+		}
+		if _, ok := totals[area]; !ok {
+			areas = append(areas, area)
+			totals[area] = &series{}
+			brands[area] = map[string]*series{}
+		}
+
+		add(totals[area], sale, s.Year)
+		if len(s.Brands) == 0 || slices.Contains(s.Brands, sale.Brand) {
+			if _, ok := brands[area][sale.Brand]; !ok {
+				brands[area][sale.Brand] = &series{}
+			}
+			add(brands[area][sale.Brand], sale, s.Year)
+		}
+	}
+
+	order := map[string]int{}
+	for i, district := range reports.DistrictOrder() {
+		order[district] = i + 1
+	}
+	sort.SliceStable(areas, func(i, j int) bool { return order[areas[i]] < order[areas[j]] })
+
+	result := make([]AreaForecast, 0, len(areas))
+	for _, area := range areas {
+		f := AreaForecast{Area: area, Total: totals[area].project(s.Model, period), Brands: []BrandProjection{}}
+		for brand, ser := range brands[area] {
+			f.Brands = append(f.Brands, BrandProjection{Brand: brand, Projection: ser.project(s.Model, period)})
+		}
+		sort.Slice(f.Brands, func(i, j int) bool {
+			if f.Brands[i].Forecast != f.Brands[j].Forecast {
+				return f.Brands[i].Forecast > f.Brands[j].Forecast
+			}
+			return f.Brands[i].Brand < f.Brands[j].Brand
+		})
+		result = append(result, f)
+	}
+	return result
+}
+
+func add(ser *series, sale reports.MonthlySale, year int) {
+	if sale.Year == year {
+		ser.current[sale.Month-1] += float64(sale.Quantity)
+	} else {
+		ser.prior[sale.Month-1] += float64(sale.Quantity)
+	}
+}
+
+// project дополняет профиль прошлого года средним месяцем, если выгрузка
+// прошлого года неполная, и строит прогноз
+func (ser *series) project(model string, period Period) Projection {
+	prior := ser.prior
+	if months := period.PriorMonths; months > 0 && months < 12 {
+		var sum float64
+		for m := 0; m < months; m++ {
+			sum += prior[m]
+		}
+		for m := months; m < 12; m++ {
+			prior[m] = sum / float64(months)
+		}
+	}
+	return Project(model, ser.current, prior, period.ActualMonths)
+}
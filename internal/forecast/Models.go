@@ -0,0 +1,161 @@
+package forecast
+
+import "math"
+
+// Модели прогноза. Все три объяснимы одной фразой и считаются по помесячным
+// продажам текущего года и профилю прошлого года
+const (
+	// SeasonalNaive — оставшиеся месяцы повторяют те же месяцы прошлого года
+	SeasonalNaive = "seasonal_naive"
+	// Profile — профиль прошлого года, умноженный на отношение продаж
+	// с начала года к тем же месяцам прошлого года
+	Profile = "profile"
+	// LinearTrend — линейный тренд продаж, очищенных от сезонности прошлого года
+	LinearTrend = "linear_trend"
+)
+
+// Models — все модели прогноза
+var Models = []string{Profile, SeasonalNaive, LinearTrend}
+
+// z95 — квантиль нормального распределения для 95% интервала
+const z95 = 1.96
+
+// Month — продажи месяца: факт или прогноз
+type Month struct {
+	Month    int  `json:"month"`
+	Value    int  `json:"value"`
+	Forecast bool `json:"forecast"`
+}
+
+// Projection — прогноз продаж на полный год: факт с начала года, прогноз
+// года и 95% интервал. Нижняя граница не опускается ниже факта
+type Projection struct {
+	Actual   int     `json:"actual"`
+	Forecast int     `json:"forecast"`
+	Lower    int     `json:"lower"`
+	Upper    int     `json:"upper"`
+	Monthly  []Month `json:"monthly"`
+}
+
+// Project строит прогноз года моделью model по факту первых months месяцев
+// current и помесячным продажам прошлого года prior. Ошибка интервала —
+// стандартное отклонение остатков модели на фактических месяцах, растущее
+// как корень из числа прогнозируемых месяцев
+func Project(model string, current, prior [12]float64, months int) Projection {
+	fitted := fit(model, current, prior, months)
+
+	p := Projection{Monthly: make([]Month, 12)}
+	var actual, forecast, residuals float64
+	for m := 0; m < 12; m++ {
+		if m < months {
+			actual += current[m]
+			e := current[m] - fitted[m]
+			residuals += e * e
+			p.Monthly[m] = Month{Month: m + 1, Value: int(math.Round(current[m]))}
+			continue
+		}
+		forecast += fitted[m]
+		p.Monthly[m] = Month{Month: m + 1, Value: int(math.Round(fitted[m])), Forecast: true}
+	}
+
+	// Степени свободы: сезонная наивная модель параметров не оценивает,
+	// профиль оценивает отношение, тренд — сдвиг и наклон
+	dof := months - map[string]int{SeasonalNaive: 0, Profile: 1, LinearTrend: 2}[model]
+	sigma := 0.0
+	if dof > 0 {
+		sigma = math.Sqrt(residuals / float64(dof))
+	}
+	margin := z95 * sigma * math.Sqrt(float64(12-months))
+
+	total := actual + forecast
+	p.Actual = int(math.Round(actual))
+	p.Forecast = int(math.Round(total))
+	p.Lower = int(math.Round(math.Max(actual, total-margin)))
+	p.Upper = int(math.Round(total + margin))
+	return p
+}
+
+// fit возвращает значения модели по всем месяцам: на фактических месяцах —
+// для оценки ошибки, на остальных — прогноз
+func fit(model string, current, prior [12]float64, months int) [12]float64 {
+	switch model {
+	case SeasonalNaive:
+		return prior
+	case LinearTrend:
+		if fitted, ok := trend(current, prior, months); ok {
+			return fitted
+		}
+	}
+	return profile(current, prior, months)
+}
+
+// profile масштабирует прошлый год на отношение продаж с начала года.
+// Без продаж прошлого года за эти месяцы берётся среднее текущего года
+func profile(current, prior [12]float64, months int) [12]float64 {
+	var cur, prev float64
+	for m := 0; m < months; m++ {
+		cur += current[m]
+		prev += prior[m]
+	}
+
+	var fitted [12]float64
+	for m := range fitted {
+		switch {
+		case prev > 0:
+			fitted[m] = prior[m] * cur / prev
+		case months > 0:
+			fitted[m] = cur / float64(months)
+		}
+	}
+	return fitted
+}
+
+// trend строит МНК-прямую по продажам, делённым на сезонный индекс прошлого
+// года, и возвращает её, умноженную обратно на индекс. Нужны хотя бы две
+// точки с ненулевым индексом
+func trend(current, prior [12]float64, months int) ([12]float64, bool) {
+	index := seasonalIndex(prior)
+
+	var n, sumT, sumY, sumTT, sumTY float64
+	for m := 0; m < months; m++ {
+		if index[m] == 0 {
+			continue
+		}
+		t, y := float64(m), current[m]/index[m]
+		n++
+		sumT += t
+		sumY += y
+		sumTT += t * t
+		sumTY += t * y
+	}
+	if n < 2 || n*sumTT == sumT*sumT {
+		return [12]float64{}, false
+	}
+
+	slope := (n*sumTY - sumT*sumY) / (n*sumTT - sumT*sumT)
+	intercept := (sumY - slope*sumT) / n
+
+	var fitted [12]float64
+	for m := range fitted {
+		fitted[m] = math.Max(0, intercept+slope*float64(m)) * index[m]
+	}
+	return fitted, true
+}
+
+// seasonalIndex — отношение продаж месяца прошлого года к среднему месяцу.
+// Без продаж прошлого года сезонности нет: все индексы равны 1
+func seasonalIndex(prior [12]float64) [12]float64 {
+	var sum float64
+	for _, v := range prior {
+		sum += v
+	}
+
+	var index [12]float64
+	for m := range index {
+		index[m] = 1
+		if sum > 0 {
+			index[m] = prior[m] * 12 / sum
+		}
+	}
+	return index
+}
@@ -0,0 +1,119 @@
+package forecast
+
+import (
+	"testing"
+
+	"truck-analytics-platform/internal/reports"
+)
+
+// flat — одинаковые продажи во всех месяцах
+func flat(v float64) [12]float64 {
+	var s [12]float64
+	for m := range s {
+		s[m] = v
+	}
+	return s
+}
+
+func TestSeasonalNaiveRepeatsPriorYear(t *testing.T) {
+	prior := flat(10)
+	prior[11] = 40
+
+	p := Project(SeasonalNaive, flat(10), prior, 10)
+	if p.Actual != 100 || p.Forecast != 150 {
+		t.Errorf("actual, forecast = %d, %d, want 100, 150", p.Actual, p.Forecast)
+	}
+	// Факт совпал с прошлым годом: остатков нет, интервал нулевой
+	if p.Lower != 150 || p.Upper != 150 {
+		t.Errorf("interval = [%d, %d], want [150, 150]", p.Lower, p.Upper)
+	}
+	if !p.Monthly[10].Forecast || p.Monthly[9].Forecast || p.Monthly[11].Value != 40 {
+		t.Errorf("monthly = %+v", p.Monthly)
+	}
+}
+
+func TestProfileScalesPriorYear(t *testing.T) {
+	p := Project(Profile, flat(20), flat(10), 6)
+	if p.Actual != 120 || p.Forecast != 240 {
+		t.Errorf("actual, forecast = %d, %d, want 120, 240", p.Actual, p.Forecast)
+	}
+
+	// Без прошлого года прогноз продолжает средний месяц
+	p = Project(Profile, flat(20), [12]float64{}, 6)
+	if p.Forecast != 240 {
+		t.Errorf("forecast without prior year = %d, want 240", p.Forecast)
+	}
+}
+
+func TestLinearTrendExtendsTrend(t *testing.T) {
+	var current [12]float64
+	for m := range current {
+		current[m] = float64(10 + 2*m)
+	}
+
+	p := Project(LinearTrend, current, flat(5), 9)
+	// 10+12+…+26 = 162 факта, 28+30+32 = 90 прогноза
+	if p.Actual != 162 || p.Forecast != 252 {
+		t.Errorf("actual, forecast = %d, %d, want 162, 252", p.Actual, p.Forecast)
+	}
+	if p.Lower != p.Forecast || p.Upper != p.Forecast {
+		t.Errorf("exact trend interval = [%d, %d], want a point", p.Lower, p.Upper)
+	}
+}
+
+func TestIntervalWidensWithNoiseAndKeepsActual(t *testing.T) {
+	current := flat(10)
+	for m := 0; m < 10; m += 2 {
+		current[m] = 30
+	}
+
+	p := Project(Profile, current, flat(10), 10)
+	if p.Upper <= p.Forecast || p.Lower > p.Forecast {
+		t.Errorf("interval [%d, %d] around %d", p.Lower, p.Upper, p.Forecast)
+	}
+	if p.Lower < p.Actual {
+		t.Errorf("lower %d below actual %d", p.Lower, p.Actual)
+	}
+}
+
+func TestProjectFillsMissingPriorMonths(t *testing.T) {
+	var sales []reports.MonthlySale
+	for month := 1; month <= 10; month++ {
+		sales = append(sales,
+			reports.MonthlySale{Year: 2023, Month: month, District: "Ural", Brand: "FOTON", Quantity: 10},
+			reports.MonthlySale{Year: 2024, Month: month, District: "Ural", Brand: "FOTON", Quantity: 20},
+		)
+	}
+
+	spec := Spec{Segment: "ldt", Year: 2024, Level: reports.LevelCountry, Model: SeasonalNaive}
+	areas := project(sales, spec, Period{Year: 2024, ActualMonths: 10, PriorMonths: 10})
+
+	if len(areas) != 1 || areas[0].Area != reports.CountryArea || len(areas[0].Brands) != 1 {
+		t.Fatalf("areas = %+v", areas)
+	}
+	// Ноябрь и декабрь 2023 года не загружены и берутся средним месяцем
+	if got := areas[0].Total.Forecast; got != 220 {
+		t.Errorf("forecast = %d, want 220", got)
+	}
+}
+
+func TestSpecValidate(t *testing.T) {
+	// as_of = 0 — все загруженные месяцы
+	for _, asOf := range []int{0, 1, 10} {
+		valid := Spec{Segment: "ldt", Year: 2024, Level: reports.LevelDistrict, Model: Profile, AsOf: asOf}
+		if err := valid.Validate(); err != nil {
+			t.Fatalf("valid spec with as_of %d: %v", asOf, err)
+		}
+	}
+
+	for name, s := range map[string]Spec{
+		"no prior year": {Segment: "ldt", Year: 2023, Level: reports.LevelDistrict, Model: Profile},
+		"region level":  {Segment: "ldt", Year: 2024, Level: reports.LevelRegion, Model: Profile},
+		"unknown model": {Segment: "ldt", Year: 2024, Level: reports.LevelDistrict, Model: "holt"},
+		"as_of":         {Segment: "ldt", Year: 2024, Level: reports.LevelDistrict, Model: Profile, AsOf: 11},
+	} {
+		if err := s.Validate(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
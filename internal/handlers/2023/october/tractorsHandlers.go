@@ -1,10 +1,11 @@
 package october
 
 import (
-	"context"
-	"log/slog"
 	"net/http"
 	"truck-analytics-platform/internal/db"
+	"truck-analytics-platform/internal/handlers/utils"
+	"truck-analytics-platform/internal/reports"
+	sb "truck-analytics-platform/internal/sqlbuilder"
 
 	"github.com/gin-gonic/gin"
 	orderedmap "github.com/wk8/go-ordered-map/v2"
@@ -24,83 +25,26 @@ func TenMonth2023Tractors4x2(ctx *gin.Context) {
 	}
 
 	type TruckAnalyticsResponse struct {
-		Data  *orderedmap.OrderedMap[string, []TruckAnalytics] `json:"data"`
-		Error string                                           `json:"error,omitempty"`
+		Data *orderedmap.OrderedMap[string, []TruckAnalytics] `json:"data"`
 	}
 
 	// SQL запрос для получения данных
-	query := `
-        WITH base_data AS (
-            SELECT 
-                truck_analytics_2023_01_12."Federal_district",
-                truck_analytics_2023_01_12."Region",
-                truck_analytics_2023_01_12."Brand",
-                SUM(truck_analytics_2023_01_12."Quantity") as total_sales
-            FROM truck_analytics_2023_01_12
-            WHERE 
-                truck_analytics_2023_01_12."Wheel_formula" = '4x2'
-                AND truck_analytics_2023_01_12."Brand" IN ('DONGFENG', 'FAW', 'FOTON', 'JAC', 'SHACMAN', 'SITRAK')
-                AND truck_analytics_2023_01_12."Body_type" = 'Седельный тягач'
-                AND truck_analytics_2023_01_12."Exact_mass" = 18000
-				AND truck_analytics_2023_01_12."Month_of_registration" <= 10
-            GROUP BY 
-                truck_analytics_2023_01_12."Federal_district", 
-                truck_analytics_2023_01_12."Region", 
-                truck_analytics_2023_01_12."Brand"
-        ),
-        federal_totals AS (
-            SELECT 
-                "Federal_district",
-                "Federal_district" as "Region",
-                "Brand",
-                SUM(total_sales) as total_sales
-            FROM base_data
-            GROUP BY "Federal_district", "Brand"
-        ),
-        combined_data AS (
-            SELECT * FROM base_data
-            UNION ALL
-            SELECT * FROM federal_totals
-        )
-        SELECT 
-            "Federal_district",
-            COALESCE("Region", "Federal_district") as Region_name,
-            MAX(CASE WHEN "Brand" = 'DONGFENG' THEN total_sales END) as DONGFENG,
-            MAX(CASE WHEN "Brand" = 'FAW' THEN total_sales END) as FAW,
-            MAX(CASE WHEN "Brand" = 'FOTON' THEN total_sales END) as FOTON,
-            MAX(CASE WHEN "Brand" = 'JAC' THEN total_sales END) as JAC,
-            MAX(CASE WHEN "Brand" = 'SHACMAN' THEN total_sales END) as SHACMAN,
-            MAX(CASE WHEN "Brand" = 'SITRAK' THEN total_sales END) as SITRAK,
-            COALESCE(MAX(CASE WHEN "Brand" = 'DONGFENG' THEN total_sales END), 0) +
-            COALESCE(MAX(CASE WHEN "Brand" = 'FAW' THEN total_sales END), 0) +
-            COALESCE(MAX(CASE WHEN "Brand" = 'FOTON' THEN total_sales END), 0) +
-            COALESCE(MAX(CASE WHEN "Brand" = 'JAC' THEN total_sales END), 0) +
-            COALESCE(MAX(CASE WHEN "Brand" = 'SHACMAN' THEN total_sales END), 0) +
-            COALESCE(MAX(CASE WHEN "Brand" = 'SITRAK' THEN total_sales END), 0) as TOTAL
-        FROM combined_data
-        GROUP BY 
-            "Federal_district",
-            "Region"
-        ORDER BY 
-            "Federal_district",
-            CASE 
-                WHEN "Region" = "Federal_district" THEN 1 
-                ELSE 0 
-            END,
-            "Region"
-    `
+	query := sb.BrandPivot{
+		Table:  sb.HDT2023,
+		Where:  reports.Tractors4x2.Where(sb.Lte(sb.MonthOfRegistration, 10)),
+		Brands: []string{"DONGFENG", "FAW", "FOTON", "JAC", "SHACMAN", "SITRAK"},
+	}.RegionQuery()
 
 	// Соединение с базой данных
-	db, err := db.Connect()
+	db, err := db.Connect(ctx.Request.Context())
 	if err != nil {
-		slog.Warn("Can't connect to database")
-		ctx.JSON(http.StatusInternalServerError, TruckAnalyticsResponse{Error: "Can't connect to database"})
+		utils.RespondError(ctx, http.StatusInternalServerError, utils.CodeDatabaseUnavailable, "Can't connect to database", err)
 		return
 	}
 
-	rows, err := db.Query(context.Background(), query)
+	rows, err := db.Query(ctx.Request.Context(), query.SQL, query.Args...)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, TruckAnalyticsResponse{Error: "Failed to execute query: " + err.Error()})
+		utils.RespondError(ctx, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to execute query", err)
 		return
 	}
 	defer rows.Close()
@@ -142,7 +86,7 @@ func TenMonth2023Tractors4x2(ctx *gin.Context) {
 			&ta.TOTAL,
 		)
 		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, TruckAnalyticsResponse{Error: "Failed to scan row: " + err.Error()})
+			utils.RespondError(ctx, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to scan row", err)
 			return
 		}
 
@@ -167,7 +111,7 @@ func TenMonth2023Tractors4x2(ctx *gin.Context) {
 
 	// Проверка на ошибки при итерации
 	if err := rows.Err(); err != nil {
-		ctx.JSON(http.StatusInternalServerError, TruckAnalyticsResponse{Error: "Error iterating over rows: " + err.Error()})
+		utils.RespondError(ctx, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to iterate over rows", err)
 		return
 	}
 
@@ -192,8 +136,7 @@ func TenTractors4x2WithTotalMarket2023(ctx *gin.Context) {
 
 	// Структура для ответа
 	type Response struct {
-		Data  *orderedmap.OrderedMap[string, []DistrictData] `json:"data"`
-		Error string                                         `json:"error,omitempty"`
+		Data *orderedmap.OrderedMap[string, []DistrictData] `json:"data"`
 	}
 
 	// Мапа для перевода русских названий федеральных округов на английский
@@ -209,41 +152,23 @@ func TenTractors4x2WithTotalMarket2023(ctx *gin.Context) {
 	}
 
 	// SQL-запрос для получения данных по округам
-	query := `
-		SELECT 
-			"Federal_district",
-			COALESCE(SUM(CASE WHEN "Brand" = 'DONGFENG' THEN "Quantity" END), 0) AS DONGFENG,
-			COALESCE(SUM(CASE WHEN "Brand" = 'FAW' THEN "Quantity" END), 0) AS FAW,
-			COALESCE(SUM(CASE WHEN "Brand" = 'FOTON' THEN "Quantity" END), 0) AS FOTON,
-			COALESCE(SUM(CASE WHEN "Brand" = 'JAC' THEN "Quantity" END), 0) AS JAC,
-			COALESCE(SUM(CASE WHEN "Brand" = 'SHACMAN' THEN "Quantity" END), 0) AS SHACMAN,
-			COALESCE(SUM(CASE WHEN "Brand" = 'SITRAK' THEN "Quantity" END), 0) AS SITRAK,
-			COALESCE(SUM("Quantity"), 0) AS TOTAL
-		FROM truck_analytics_2023_01_12
-		WHERE 
-			"Wheel_formula" = '4x2'
-			AND "Brand" IN ('DONGFENG', 'FAW', 'FOTON', 'JAC', 'SHACMAN', 'SITRAK')
-			AND "Month_of_registration" <= 10
-			AND "Body_type" = 'Седельный тягач'
-			AND "Exact_mass" = 18000
-		GROUP BY "Federal_district"
-		ORDER BY "Federal_district"
-	`
+	query := sb.BrandPivot{
+		Table:  sb.HDT2023,
+		Where:  reports.Tractors4x2.Where(sb.Lte(sb.MonthOfRegistration, 10)),
+		Brands: []string{"DONGFENG", "FAW", "FOTON", "JAC", "SHACMAN", "SITRAK"},
+	}.DistrictQuery()
 
 	// Подключение к базе данных
-	db, err := db.Connect()
+	db, err := db.Connect(ctx.Request.Context())
 	if err != nil {
-		slog.Info("Can't connect to database:", err)
-		ctx.JSON(http.StatusInternalServerError, Response{Error: "Can't connect to database"})
+		utils.RespondError(ctx, http.StatusInternalServerError, utils.CodeDatabaseUnavailable, "Can't connect to database", err)
 		return
 	}
-	defer db.Close(context.Background())
 
 	// Запрос к базе данных
-	rows, err := db.Query(context.Background(), query)
+	rows, err := db.Query(ctx.Request.Context(), query.SQL, query.Args...)
 	if err != nil {
-		slog.Info("Failed to execute query:", err)
-		ctx.JSON(http.StatusInternalServerError, Response{Error: "Failed to execute query"})
+		utils.RespondError(ctx, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to execute query", err)
 		return
 	}
 	defer rows.Close()
@@ -286,8 +211,7 @@ func TenTractors4x2WithTotalMarket2023(ctx *gin.Context) {
 		var item DistrictData
 		err := rows.Scan(&item.RegionName, &item.Dongfeng, &item.Faw, &item.Foton, &item.Jac, &item.Shacman, &item.Sitrak, &item.Total)
 		if err != nil {
-			slog.Info("Failed to scan row:", err)
-			ctx.JSON(http.StatusInternalServerError, Response{Error: "Failed to scan row"})
+			utils.RespondError(ctx, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to scan row", err)
 			return
 		}
 
@@ -325,9 +249,8 @@ func TenTractors4x2WithTotalMarket2023(ctx *gin.Context) {
 		}
 	}
 
-	if rows.Err() != nil {
-		slog.Info("Failed to iterate over rows:", rows.Err())
-		ctx.JSON(http.StatusInternalServerError, Response{Error: "Failed to iterate over rows"})
+	if err := rows.Err(); err != nil {
+		utils.RespondError(ctx, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to iterate over rows", err)
 		return
 	}
 
@@ -355,82 +278,25 @@ func TenMonth2023Tractors6x4(ctx *gin.Context) {
 	}
 
 	type TruckAnalyticsResponse struct {
-		Data  *orderedmap.OrderedMap[string, []TruckAnalytics] `json:"data"`
-		Error string                                           `json:"error,omitempty"`
-	}
-
-	query := `
-		WITH base_data AS (
-			SELECT 
-				truck_analytics_2023_01_12."Federal_district",
-				truck_analytics_2023_01_12."Region",
-				truck_analytics_2023_01_12."Brand",
-				SUM(truck_analytics_2023_01_12."Quantity") as total_sales
-			FROM truck_analytics_2023_01_12
-			WHERE 
-				truck_analytics_2023_01_12."Wheel_formula" = '6x4'
-				AND truck_analytics_2023_01_12."Brand" IN ('DONGFENG', 'FAW', 'FOTON', 'HOWO', 'SHACMAN', 'SITRAK')
-				AND truck_analytics_2023_01_12."Month_of_registration" <= 10
-				AND truck_analytics_2023_01_12."Body_type" = 'Седельный тягач'
-				AND truck_analytics_2023_01_12."Exact_mass" = 25000
-			GROUP BY 
-				truck_analytics_2023_01_12."Federal_district", 
-				truck_analytics_2023_01_12."Region", 
-				truck_analytics_2023_01_12."Brand"
-		),
-		federal_totals AS (
-			SELECT 
-				"Federal_district",
-				"Federal_district" as "Region",
-				"Brand",
-				SUM(total_sales) as total_sales
-			FROM base_data
-			GROUP BY "Federal_district", "Brand"
-		),
-		combined_data AS (
-			SELECT * FROM base_data
-			UNION ALL
-			SELECT * FROM federal_totals
-		)
-		SELECT 
-			"Federal_district",
-			COALESCE("Region", "Federal_district") as Region_name,
-			MAX(CASE WHEN "Brand" = 'DONGFENG' THEN total_sales END) as DONGFENG,
-			MAX(CASE WHEN "Brand" = 'FAW' THEN total_sales END) as FAW,
-			MAX(CASE WHEN "Brand" = 'FOTON' THEN total_sales END) as FOTON,
-			MAX(CASE WHEN "Brand" = 'HOWO' THEN total_sales END) as HOWO,
-			MAX(CASE WHEN "Brand" = 'SHACMAN' THEN total_sales END) as SHACMAN,
-			MAX(CASE WHEN "Brand" = 'SITRAK' THEN total_sales END) as SITRAK,
-			COALESCE(MAX(CASE WHEN "Brand" = 'DONGFENG' THEN total_sales END), 0) +
-			COALESCE(MAX(CASE WHEN "Brand" = 'FAW' THEN total_sales END), 0) +
-			COALESCE(MAX(CASE WHEN "Brand" = 'FOTON' THEN total_sales END), 0) +
-			COALESCE(MAX(CASE WHEN "Brand" = 'HOWO' THEN total_sales END), 0) +
-			COALESCE(MAX(CASE WHEN "Brand" = 'SHACMAN' THEN total_sales END), 0) +
-			COALESCE(MAX(CASE WHEN "Brand" = 'SITRAK' THEN total_sales END), 0) as TOTAL
-		FROM combined_data
-		GROUP BY 
-			"Federal_district",
-			"Region"
-		ORDER BY 
-			"Federal_district",
-			CASE 
-				WHEN "Region" = "Federal_district" THEN 1 
-				ELSE 0 
-			END,
-			"Region"
-	`
+		Data *orderedmap.OrderedMap[string, []TruckAnalytics] `json:"data"`
+	}
+
+	query := sb.BrandPivot{
+		Table:  sb.HDT2023,
+		Where:  reports.Tractors6x4.Where(sb.Lte(sb.MonthOfRegistration, 10)),
+		Brands: []string{"DONGFENG", "FAW", "FOTON", "HOWO", "SHACMAN", "SITRAK"},
+	}.RegionQuery()
 
 	// Соединение с базой данных
-	db, err := db.Connect()
+	db, err := db.Connect(ctx.Request.Context())
 	if err != nil {
-		slog.Warn("Can't connect to database")
-		ctx.JSON(http.StatusInternalServerError, TruckAnalyticsResponse{Error: "Can't connect to database"})
+		utils.RespondError(ctx, http.StatusInternalServerError, utils.CodeDatabaseUnavailable, "Can't connect to database", err)
 		return
 	}
 
-	rows, err := db.Query(context.Background(), query)
+	rows, err := db.Query(ctx.Request.Context(), query.SQL, query.Args...)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, TruckAnalyticsResponse{Error: "Failed to execute query: " + err.Error()})
+		utils.RespondError(ctx, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to execute query", err)
 		return
 	}
 	defer rows.Close()
@@ -471,7 +337,7 @@ func TenMonth2023Tractors6x4(ctx *gin.Context) {
 			&ta.TOTAL,
 		)
 		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, TruckAnalyticsResponse{Error: "Failed to scan row: " + err.Error()})
+			utils.RespondError(ctx, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to scan row", err)
 			return
 		}
 
@@ -496,7 +362,7 @@ func TenMonth2023Tractors6x4(ctx *gin.Context) {
 
 	// Проверка на ошибки при итерации
 	if err := rows.Err(); err != nil {
-		ctx.JSON(http.StatusInternalServerError, TruckAnalyticsResponse{Error: "Error iterating over rows: " + err.Error()})
+		utils.RespondError(ctx, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to iterate over rows", err)
 		return
 	}
 
@@ -520,8 +386,7 @@ func TenTractors6x4WithTotalMarket2023(ctx *gin.Context) {
 	}
 
 	type Response struct {
-		Data  *orderedmap.OrderedMap[string, []DistrictData] `json:"data"`
-		Error string                                         `json:"error,omitempty"`
+		Data *orderedmap.OrderedMap[string, []DistrictData] `json:"data"`
 	}
 	// Мапа для перевода русских названий федеральных округов на английский
 	var regionTranslation = map[string]string{
@@ -536,39 +401,21 @@ func TenTractors6x4WithTotalMarket2023(ctx *gin.Context) {
 	}
 
 	// SQL-запрос для получения данных по округам
-	query := `
-		SELECT
-			"Federal_district",
-			COALESCE(SUM(CASE WHEN "Brand" = 'DONGFENG' THEN "Quantity" END), 0) AS DONGFENG,
-			COALESCE(SUM(CASE WHEN "Brand" = 'FAW' THEN "Quantity" END), 0) AS FAW,
-			COALESCE(SUM(CASE WHEN "Brand" = 'FOTON' THEN "Quantity" END), 0) AS FOTON,
-			COALESCE(SUM(CASE WHEN "Brand" = 'HOWO' THEN "Quantity" END), 0) AS HOWO,
-			COALESCE(SUM(CASE WHEN "Brand" = 'SHACMAN' THEN "Quantity" END), 0) AS SHACMAN,
-			COALESCE(SUM(CASE WHEN "Brand" = 'SITRAK' THEN "Quantity" END), 0) AS SITRAK,
-			COALESCE(SUM("Quantity"), 0) AS TOTAL
-		FROM truck_analytics_2023_01_12
-		WHERE
-			"Wheel_formula" = '6x4'
-			AND "Brand" IN ('DONGFENG', 'FAW', 'FOTON', 'HOWO', 'SHACMAN', 'SITRAK')
-			AND "Month_of_registration" <= 10
-			AND "Body_type" = 'Седельный тягач'
-			AND "Exact_mass" = 25000
-		GROUP BY "Federal_district"
-		ORDER BY "Federal_district"
-	`
-
-	db, err := db.Connect()
+	query := sb.BrandPivot{
+		Table:  sb.HDT2023,
+		Where:  reports.Tractors6x4.Where(sb.Lte(sb.MonthOfRegistration, 10)),
+		Brands: []string{"DONGFENG", "FAW", "FOTON", "HOWO", "SHACMAN", "SITRAK"},
+	}.DistrictQuery()
+
+	db, err := db.Connect(ctx.Request.Context())
 	if err != nil {
-		slog.Info("Can't connect to database:", err)
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Can't connect to database"})
+		utils.RespondError(ctx, http.StatusInternalServerError, utils.CodeDatabaseUnavailable, "Can't connect to database", err)
 		return
 	}
-	defer db.Close(context.Background())
 
-	rows, err := db.Query(context.Background(), query)
+	rows, err := db.Query(ctx.Request.Context(), query.SQL, query.Args...)
 	if err != nil {
-		slog.Info("Failed to execute query:", err)
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to execute query"})
+		utils.RespondError(ctx, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to execute query", err)
 		return
 	}
 	defer rows.Close()
@@ -615,8 +462,7 @@ func TenTractors6x4WithTotalMarket2023(ctx *gin.Context) {
 			&ba.TOTAL,
 		)
 		if err != nil {
-			slog.Info("Failed to scan row:", err)
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to scan row"})
+			utils.RespondError(ctx, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to scan row", err)
 			return
 		}
 
@@ -640,9 +486,8 @@ func TenTractors6x4WithTotalMarket2023(ctx *gin.Context) {
 		summary.TOTAL += ba.TOTAL
 	}
 
-	if rows.Err() != nil {
-		slog.Info("Failed to iterate over rows:", rows.Err())
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to iterate over rows"})
+	if err := rows.Err(); err != nil {
+		utils.RespondError(ctx, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to iterate over rows", err)
 		return
 	}
 
@@ -1,10 +1,11 @@
 package september
 
 import (
-	"context"
-	"log/slog"
 	"net/http"
 	"truck-analytics-platform/internal/db"
+	"truck-analytics-platform/internal/handlers/utils"
+	"truck-analytics-platform/internal/reports"
+	sb "truck-analytics-platform/internal/sqlbuilder"
 
 	"github.com/gin-gonic/gin"
 	orderedmap "github.com/wk8/go-ordered-map/v2"
@@ -22,66 +23,24 @@ func NineMonth2023Dumpers6x4(ctx *gin.Context) {
 	}
 
 	type TruckAnalyticsResponse struct {
-		Data  *orderedmap.OrderedMap[string, []TruckAnalytics] `json:"data"`
-		Error string                                           `json:"error,omitempty"`
-	}
-
-	query := `
-		WITH base_data AS (
-			SELECT 
-				truck_analytics_2023_01_12."Federal_district",
-				truck_analytics_2023_01_12."Region",
-				truck_analytics_2023_01_12."Brand",
-				SUM(truck_analytics_2023_01_12."Quantity") as total_sales
-			FROM truck_analytics_2023_01_12
-			WHERE 
-				truck_analytics_2023_01_12."Wheel_formula" = '6x4'
-				AND truck_analytics_2023_01_12."Brand" IN ('FAW', 'HOWO', 'JAC', 'SANY', 'SITRAK')
-				AND truck_analytics_2023_01_12."Month_of_registration" <= 9
-				AND truck_analytics_2023_01_12."Body_type" = 'Самосвал'
-				AND truck_analytics_2023_01_12."Mass_in_segment_1" = '32001-40000'
-			GROUP BY 
-				truck_analytics_2023_01_12."Federal_district", 
-				truck_analytics_2023_01_12."Region", 
-				truck_analytics_2023_01_12."Brand"
-		)
-		SELECT 
-			"Federal_district",
-			COALESCE("Region", "Federal_district") as Region_name,
-			MAX(CASE WHEN "Brand" = 'FAW' THEN total_sales END) as FAW,
-			MAX(CASE WHEN "Brand" = 'HOWO' THEN total_sales END) as HOWO,
-			MAX(CASE WHEN "Brand" = 'JAC' THEN total_sales END) as JAC,
-			MAX(CASE WHEN "Brand" = 'SANY' THEN total_sales END) as SANY,
-			MAX(CASE WHEN "Brand" = 'SITRAK' THEN total_sales END) as SITRAK,
-			COALESCE(MAX(CASE WHEN "Brand" = 'FAW' THEN total_sales END), 0) +
-			COALESCE(MAX(CASE WHEN "Brand" = 'HOWO' THEN total_sales END), 0) +
-			COALESCE(MAX(CASE WHEN "Brand" = 'JAC' THEN total_sales END), 0) +
-			COALESCE(MAX(CASE WHEN "Brand" = 'SANY' THEN total_sales END), 0) +
-			COALESCE(MAX(CASE WHEN "Brand" = 'SITRAK' THEN total_sales END), 0) as TOTAL
-		FROM base_data
-		GROUP BY 
-			"Federal_district",
-			"Region"
-		ORDER BY 
-			"Federal_district",
-			CASE 
-				WHEN "Region" = "Federal_district" THEN 1 
-				ELSE 0 
-			END,
-			"Region"
-	`
-
-	db, err := db.Connect()
+		Data *orderedmap.OrderedMap[string, []TruckAnalytics] `json:"data"`
+	}
+
+	query := sb.BrandPivot{
+		Table:  sb.HDT2023,
+		Where:  reports.Dumpers6x4.Where(sb.Lte(sb.MonthOfRegistration, 9)),
+		Brands: []string{"FAW", "HOWO", "JAC", "SANY", "SITRAK"},
+	}.RegionQuery()
+
+	db, err := db.Connect(ctx.Request.Context())
 	if err != nil {
-		slog.Warn("Can't connect to database")
+		utils.RespondError(ctx, http.StatusInternalServerError, utils.CodeDatabaseUnavailable, "Can't connect to database", err)
 		return
 	}
 
-	rows, err := db.Query(context.Background(), query)
+	rows, err := db.Query(ctx.Request.Context(), query.SQL, query.Args...)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, TruckAnalyticsResponse{
-			Error: "Failed to execute query: " + err.Error(),
-		})
+		utils.RespondError(ctx, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to execute query", err)
 		return
 	}
 	defer rows.Close()
@@ -123,9 +82,7 @@ func NineMonth2023Dumpers6x4(ctx *gin.Context) {
 			&ta.TOTAL,
 		)
 		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, TruckAnalyticsResponse{
-				Error: "Failed to scan row: " + err.Error(),
-			})
+			utils.RespondError(ctx, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to scan row", err)
 			return
 		}
 
@@ -183,9 +140,7 @@ func NineMonth2023Dumpers6x4(ctx *gin.Context) {
 
 	// Проверка на ошибки итерации
 	if err := rows.Err(); err != nil {
-		ctx.JSON(http.StatusInternalServerError, TruckAnalyticsResponse{
-			Error: "Error iterating over rows: " + err.Error(),
-		})
+		utils.RespondError(ctx, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to iterate over rows", err)
 		return
 	}
 
@@ -216,8 +171,7 @@ func Dumpers6x4WithTotalMarket2023(ctx *gin.Context) {
 
 	// Структура для ответа
 	type Response struct {
-		Data  *orderedmap.OrderedMap[string, []DistrictData] `json:"data"`
-		Error string                                         `json:"error,omitempty"`
+		Data *orderedmap.OrderedMap[string, []DistrictData] `json:"data"`
 	}
 
 	// Мапа для перевода русских названий федеральных округов на английский
@@ -233,40 +187,23 @@ func Dumpers6x4WithTotalMarket2023(ctx *gin.Context) {
 	}
 
 	// SQL-запрос для получения данных по округам
-	query := `
-		SELECT 
-			"Federal_district",
-			COALESCE(SUM(CASE WHEN "Brand" = 'FAW' THEN "Quantity" END), 0) AS FAW,
-			COALESCE(SUM(CASE WHEN "Brand" = 'HOWO' THEN "Quantity" END), 0) AS HOWO,
-			COALESCE(SUM(CASE WHEN "Brand" = 'JAC' THEN "Quantity" END), 0) AS JAC,
-			COALESCE(SUM(CASE WHEN "Brand" = 'SANY' THEN "Quantity" END), 0) AS SANY,
-			COALESCE(SUM(CASE WHEN "Brand" = 'SITRAK' THEN "Quantity" END), 0) AS SITRAK,
-			COALESCE(SUM("Quantity"), 0) AS TOTAL
-		FROM truck_analytics_2023_01_12
-		WHERE 
-			"Wheel_formula" = '6x4'
-			AND "Brand" IN ('FAW', 'HOWO', 'JAC', 'SANY', 'SITRAK')
-			AND "Month_of_registration" <= 9
-			AND "Body_type" = 'Самосвал'
-			AND "Mass_in_segment_1" = '32001-40000'
-		GROUP BY "Federal_district"
-		ORDER BY "Federal_district"
-	`
+	query := sb.BrandPivot{
+		Table:  sb.HDT2023,
+		Where:  reports.Dumpers6x4.Where(sb.Lte(sb.MonthOfRegistration, 9)),
+		Brands: []string{"FAW", "HOWO", "JAC", "SANY", "SITRAK"},
+	}.DistrictQuery()
 
 	// Подключение к базе данных
-	db, err := db.Connect()
+	db, err := db.Connect(ctx.Request.Context())
 	if err != nil {
-		slog.Info("Can't connect to database:", err)
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Can't connect to database"})
+		utils.RespondError(ctx, http.StatusInternalServerError, utils.CodeDatabaseUnavailable, "Can't connect to database", err)
 		return
 	}
-	defer db.Close(context.Background())
 
 	// Запрос к базе данных
-	rows, err := db.Query(context.Background(), query)
+	rows, err := db.Query(ctx.Request.Context(), query.SQL, query.Args...)
 	if err != nil {
-		slog.Info("Failed to execute query:", err)
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to execute query"})
+		utils.RespondError(ctx, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to execute query", err)
 		return
 	}
 	defer rows.Close()
@@ -307,8 +244,7 @@ func Dumpers6x4WithTotalMarket2023(ctx *gin.Context) {
 		var item DistrictData
 		err := rows.Scan(&item.RegionName, &item.Faw, &item.Howo, &item.Jac, &item.Sany, &item.Sitrak, &item.Total)
 		if err != nil {
-			slog.Info("Failed to scan row:", err)
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to scan row"})
+			utils.RespondError(ctx, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to scan row", err)
 			return
 		}
 
@@ -342,9 +278,8 @@ func Dumpers6x4WithTotalMarket2023(ctx *gin.Context) {
 			dataByDistrict.Set(translatedRegionName, append(existing, item))
 		}
 	}
-	if rows.Err() != nil {
-		slog.Info("Failed to iterate over rows:", rows.Err())
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to iterate over rows"})
+	if err := rows.Err(); err != nil {
+		utils.RespondError(ctx, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to iterate over rows", err)
 		return
 	}
 
@@ -370,78 +305,24 @@ func NineMonth2023Dumpers8x4(ctx *gin.Context) {
 	}
 
 	type TruckAnalyticsResponse struct {
-		Data  *orderedmap.OrderedMap[string, []TruckAnalytics] `json:"data"`
-		Error string                                           `json:"error,omitempty"`
-	}
-
-	query := `
-		WITH base_data AS (
-			SELECT 
-				truck_analytics_2023_01_12."Federal_district",
-				truck_analytics_2023_01_12."Region",
-				truck_analytics_2023_01_12."Brand",
-				SUM(truck_analytics_2023_01_12."Quantity") as total_sales
-			FROM truck_analytics_2023_01_12
-			WHERE 
-				truck_analytics_2023_01_12."Wheel_formula" = '8x4'
-				AND truck_analytics_2023_01_12."Brand" IN ('FAW', 'HOWO', 'SHACMAN', 'SITRAK')
-				AND truck_analytics_2023_01_12."Month_of_registration" <= 9
-				AND truck_analytics_2023_01_12."Body_type" = 'Самосвал'
-				AND truck_analytics_2023_01_12."Weight_in_segment_4" = '35001-45000'
-			GROUP BY 
-				truck_analytics_2023_01_12."Federal_district", 
-				truck_analytics_2023_01_12."Region", 
-				truck_analytics_2023_01_12."Brand"
-		),
-		federal_totals AS (
-			SELECT 
-				"Federal_district",
-				"Federal_district" as "Region",
-				"Brand",
-				SUM(total_sales) as total_sales
-			FROM base_data
-			GROUP BY "Federal_district", "Brand"
-		),
-		combined_data AS (
-			SELECT * FROM base_data
-			UNION ALL
-			SELECT * FROM federal_totals
-		)
-		SELECT 
-			"Federal_district",
-			COALESCE("Region", "Federal_district") as Region_name,
-			MAX(CASE WHEN "Brand" = 'FAW' THEN total_sales END) as FAW,
-			MAX(CASE WHEN "Brand" = 'HOWO' THEN total_sales END) as HOWO,
-			MAX(CASE WHEN "Brand" = 'SHACMAN' THEN total_sales END) as SHACMAN,
-			MAX(CASE WHEN "Brand" = 'SITRAK' THEN total_sales END) as SITRAK,
-			COALESCE(MAX(CASE WHEN "Brand" = 'FAW' THEN total_sales END), 0) +
-			COALESCE(MAX(CASE WHEN "Brand" = 'HOWO' THEN total_sales END), 0) +
-			COALESCE(MAX(CASE WHEN "Brand" = 'SHACMAN' THEN total_sales END), 0) +
-			COALESCE(MAX(CASE WHEN "Brand" = 'SITRAK' THEN total_sales END), 0) as TOTAL
-		FROM combined_data
-		GROUP BY 
-			"Federal_district",
-			"Region"
-		ORDER BY 
-			"Federal_district",
-			CASE 
-				WHEN "Region" = "Federal_district" THEN 1 
-				ELSE 0 
-			END,
-			"Region"
-	`
-
-	db, err := db.Connect()
+		Data *orderedmap.OrderedMap[string, []TruckAnalytics] `json:"data"`
+	}
+
+	query := sb.BrandPivot{
+		Table:  sb.HDT2023,
+		Where:  reports.Dumpers8x4.Where(sb.Lte(sb.MonthOfRegistration, 9)),
+		Brands: []string{"FAW", "HOWO", "SHACMAN", "SITRAK"},
+	}.RegionQuery()
+
+	db, err := db.Connect(ctx.Request.Context())
 	if err != nil {
-		slog.Warn("Can't connect to database")
+		utils.RespondError(ctx, http.StatusInternalServerError, utils.CodeDatabaseUnavailable, "Can't connect to database", err)
 		return
 	}
 
-	rows, err := db.Query(context.Background(), query)
+	rows, err := db.Query(ctx.Request.Context(), query.SQL, query.Args...)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, TruckAnalyticsResponse{
-			Error: "Failed to execute query: " + err.Error(),
-		})
+		utils.RespondError(ctx, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to execute query", err)
 		return
 	}
 	defer rows.Close()
@@ -479,9 +360,7 @@ func NineMonth2023Dumpers8x4(ctx *gin.Context) {
 			&ta.TOTAL,
 		)
 		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, TruckAnalyticsResponse{
-				Error: "Failed to scan row: " + err.Error(),
-			})
+			utils.RespondError(ctx, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to scan row", err)
 			return
 		}
 
@@ -505,9 +384,7 @@ func NineMonth2023Dumpers8x4(ctx *gin.Context) {
 	}
 
 	if err := rows.Err(); err != nil {
-		ctx.JSON(http.StatusInternalServerError, TruckAnalyticsResponse{
-			Error: "Error iterating over rows: " + err.Error(),
-		})
+		utils.RespondError(ctx, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to iterate over rows", err)
 		return
 	}
 
@@ -546,8 +423,7 @@ func Dumpers8x4WithTotalMarket2023(ctx *gin.Context) {
 
 	// Структура для ответа
 	type Response struct {
-		Data  *orderedmap.OrderedMap[string, []DistrictData] `json:"data"`
-		Error string                                         `json:"error,omitempty"`
+		Data *orderedmap.OrderedMap[string, []DistrictData] `json:"data"`
 	}
 
 	// Мапа для перевода русских названий федеральных округов на английский
@@ -563,39 +439,23 @@ func Dumpers8x4WithTotalMarket2023(ctx *gin.Context) {
 	}
 
 	// SQL-запрос для получения данных по округам
-	query := `
-		SELECT 
-			"Federal_district",
-			COALESCE(SUM(CASE WHEN "Brand" = 'FAW' THEN "Quantity" END), 0) AS FAW,
-			COALESCE(SUM(CASE WHEN "Brand" = 'HOWO' THEN "Quantity" END), 0) AS HOWO,
-			COALESCE(SUM(CASE WHEN "Brand" = 'SITRAK' THEN "Quantity" END), 0) AS SITRAK,
-			COALESCE(SUM(CASE WHEN "Brand" = 'SHACMAN' THEN "Quantity" END), 0) AS SHACMAN,
-			COALESCE(SUM("Quantity"), 0) AS TOTAL
-		FROM truck_analytics_2023_01_12
-		WHERE 
-			"Wheel_formula" = '8x4'
-			AND "Brand" IN ('FAW', 'HOWO', 'SITRAK', 'SHACMAN')
-			AND "Body_type" = 'Самосвал'
-			AND "Month_of_registration" <= 9
-			AND "Weight_in_segment_4" = '35001-45000'
-		GROUP BY "Federal_district"
-		ORDER BY "Federal_district"
-	`
+	query := sb.BrandPivot{
+		Table:  sb.HDT2023,
+		Where:  reports.Dumpers8x4.Where(sb.Lte(sb.MonthOfRegistration, 9)),
+		Brands: []string{"FAW", "HOWO", "SITRAK", "SHACMAN"},
+	}.DistrictQuery()
 
 	// Подключение к базе данных
-	db, err := db.Connect()
+	db, err := db.Connect(ctx.Request.Context())
 	if err != nil {
-		slog.Info("Can't connect to database:", err)
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Can't connect to database"})
+		utils.RespondError(ctx, http.StatusInternalServerError, utils.CodeDatabaseUnavailable, "Can't connect to database", err)
 		return
 	}
-	defer db.Close(context.Background())
 
 	// Запрос к базе данных
-	rows, err := db.Query(context.Background(), query)
+	rows, err := db.Query(ctx.Request.Context(), query.SQL, query.Args...)
 	if err != nil {
-		slog.Info("Failed to execute query:", err)
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to execute query"})
+		utils.RespondError(ctx, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to execute query", err)
 		return
 	}
 	defer rows.Close()
@@ -635,8 +495,7 @@ func Dumpers8x4WithTotalMarket2023(ctx *gin.Context) {
 		var item DistrictData
 		err := rows.Scan(&item.RegionName, &item.Faw, &item.Howo, &item.Sitrak, &item.Shacman, &item.Total)
 		if err != nil {
-			slog.Info("Failed to scan row:", err)
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to scan row"})
+			utils.RespondError(ctx, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to scan row", err)
 			return
 		}
 		// Обновляем суммарные значения
@@ -666,9 +525,8 @@ func Dumpers8x4WithTotalMarket2023(ctx *gin.Context) {
 			dataByDistrict.Set(translatedRegionName, append(existing, item))
 		}
 	}
-	if rows.Err() != nil {
-		slog.Info("Failed to iterate over rows:", rows.Err())
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to iterate over rows"})
+	if err := rows.Err(); err != nil {
+		utils.RespondError(ctx, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to iterate over rows", err)
 		return
 	}
 
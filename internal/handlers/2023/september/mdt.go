@@ -1,10 +1,10 @@
 package september
 
 import (
-	"context"
-	"log/slog"
 	"net/http"
 	"truck-analytics-platform/internal/db"
+	"truck-analytics-platform/internal/handlers/utils"
+	sb "truck-analytics-platform/internal/sqlbuilder"
 
 	"github.com/gin-gonic/gin"
 	orderedmap "github.com/wk8/go-ordered-map/v2"
@@ -26,141 +26,28 @@ func NineMonth2023Mdt(ctx *gin.Context) {
 
 	// Ответ с данными по анализу грузовиков
 	type TruckAnalyticsResponse struct {
-		Data  *orderedmap.OrderedMap[string, []*TruckAnalytics] `json:"data"`
-		Error string                                            `json:"error,omitempty"`
+		Data *orderedmap.OrderedMap[string, []*TruckAnalytics] `json:"data"`
 	}
 
 	// SQL запрос для получения данных
-	query := `
-	WITH base_data AS (
-    SELECT 
-        "Federal_district",
-        "Region",  
-        "Brand",
-        "City",
-        SUM("Quantity") AS total_sales
-    FROM mdt_12_18_truck_analytics_10_2023
-    WHERE "Brand" IN ('DONGFENG', 'FOTON', 'HOWO', 'JAC', 'KAMAZ', 'URAL', 'DAEWOO')
-    AND "Month_of_registration" <= 9  -- Filter for months <= 9
-    GROUP BY "Federal_district", "Region", "City", "Brand"
-),
-federal_totals AS (
-    SELECT 
-        "Federal_district",
-        "Region",
-        'TOTAL' AS "City",
-        "Brand",
-        SUM(total_sales) AS total_sales
-    FROM base_data
-    GROUP BY "Federal_district", "Region", "Brand"
-),
-other_brands AS (
-    SELECT 
-        "Federal_district",
-        "Region",  
-        'OTHER' AS "Brand",
-        'OTHER' AS "City",
-        SUM("Quantity") AS total_sales
-    FROM mdt_12_18_truck_analytics_10_2023
-    WHERE "Brand" NOT IN ('DONGFENG', 'FOTON', 'HOWO', 'JAC', 'KAMAZ', 'URAL', 'DAEWOO')
-    AND "Month_of_registration" <= 9  -- Filter for months <= 9
-    GROUP BY "Federal_district", "Region"
-),
-combined_data AS (
-    SELECT "Federal_district", "Region", "City", "Brand", total_sales
-    FROM base_data
-    UNION ALL
-    SELECT "Federal_district", "Region", "City", "Brand", total_sales
-    FROM federal_totals
-    UNION ALL
-    SELECT "Federal_district", "Region", "City", "Brand", total_sales
-    FROM other_brands
-),
-pivoted_data AS (
-    SELECT 
-        "Region" AS Oblast_name,
-        "Federal_district", 
-        MAX(CASE WHEN "Brand" = 'DONGFENG' THEN total_sales END) AS DONGFENG,
-        MAX(CASE WHEN "Brand" = 'FOTON' THEN total_sales END) AS FOTON,
-        MAX(CASE WHEN "Brand" = 'HOWO' THEN total_sales END) AS HOWO,
-        MAX(CASE WHEN "Brand" = 'JAC' THEN total_sales END) AS JAC,
-        MAX(CASE WHEN "Brand" = 'KAMAZ' THEN total_sales END) AS KAMAZ,
-        MAX(CASE WHEN "Brand" = 'URAL' THEN total_sales END) AS URAL,
-        MAX(CASE WHEN "Brand" = 'DAEWOO' THEN total_sales END) AS DAEWOO,
-        MAX(CASE WHEN "Brand" = 'OTHER' THEN total_sales END) AS OTHER,
-        -- Total calculated explicitly as the sum of all brand columns
-        COALESCE(MAX(CASE WHEN "Brand" = 'DONGFENG' THEN total_sales END), 0) +
-        COALESCE(MAX(CASE WHEN "Brand" = 'FOTON' THEN total_sales END), 0) +
-        COALESCE(MAX(CASE WHEN "Brand" = 'HOWO' THEN total_sales END), 0) +
-        COALESCE(MAX(CASE WHEN "Brand" = 'JAC' THEN total_sales END), 0) +
-        COALESCE(MAX(CASE WHEN "Brand" = 'KAMAZ' THEN total_sales END), 0) +
-        COALESCE(MAX(CASE WHEN "Brand" = 'URAL' THEN total_sales END), 0) +
-        COALESCE(MAX(CASE WHEN "Brand" = 'DAEWOO' THEN total_sales END), 0) +
-        COALESCE(MAX(CASE WHEN "Brand" = 'OTHER' THEN total_sales END), 0) AS TOTAL
-    FROM combined_data
-    GROUP BY "Region", "Federal_district"
-),
-federal_totals_by_region AS (
-    SELECT 
-        "Federal_district",
-        'TOTAL' AS Oblast_name,  
-        SUM(DONGFENG) AS DONGFENG,
-        SUM(FOTON) AS FOTON,
-        SUM(HOWO) AS HOWO,
-        SUM(JAC) AS JAC,
-        SUM(KAMAZ) AS KAMAZ,
-        SUM(URAL) AS URAL,
-        SUM(DAEWOO) AS DAEWOO,
-        SUM(OTHER) AS OTHER,
-        -- Total calculated as the sum of brand sums
-        SUM(DONGFENG) + SUM(FOTON) + SUM(HOWO) + SUM(JAC) + SUM(KAMAZ) +
-        SUM(URAL) + SUM(DAEWOO) + SUM(OTHER) AS TOTAL
-    FROM pivoted_data
-    GROUP BY "Federal_district"
-),
-final_data AS (
-    SELECT Oblast_name, "Federal_district", DONGFENG, FOTON, HOWO, JAC, KAMAZ, URAL, DAEWOO, OTHER, TOTAL
-    FROM pivoted_data
-    UNION ALL
-    SELECT Oblast_name, "Federal_district", DONGFENG, FOTON, HOWO, JAC, KAMAZ, URAL, DAEWOO, OTHER, TOTAL
-    FROM federal_totals_by_region
-)
-SELECT 
-    "Federal_district",
-    CASE 
-        WHEN Oblast_name = 'TOTAL' THEN "Federal_district"  
-        ELSE Oblast_name 
-    END AS Oblast_name,
-    COALESCE(DONGFENG, 0) AS DONGFENG,
-    COALESCE(FOTON, 0) AS FOTON,
-    COALESCE(HOWO, 0) AS HOWO,
-    COALESCE(JAC, 0) AS JAC,
-    COALESCE(KAMAZ, 0) AS KAMAZ,
-    COALESCE(URAL, 0) AS URAL,
-    COALESCE(DAEWOO, 0) AS DAEWOO,
-    COALESCE(OTHER, 0) AS OTHER,
-    COALESCE(TOTAL, 0) AS TOTAL
-FROM final_data
-ORDER BY 
-    "Federal_district",  
-    CASE 
-        WHEN Oblast_name = 'TOTAL' THEN 1  
-        ELSE 0
-    END,
-    Oblast_name;
-    `
+	query := sb.BrandPivot{
+		Table:    sb.MDT2023,
+		Where:    []sb.Condition{sb.Lte(sb.MonthOfRegistration, 9)},
+		Brands:   []string{"DONGFENG", "FOTON", "HOWO", "JAC", "KAMAZ", "URAL", "DAEWOO"},
+		Other:    true,
+		ZeroFill: true,
+	}.RegionQuery()
 
 	// Соединение с базой данных
-	db, err := db.Connect()
+	db, err := db.Connect(ctx.Request.Context())
 	if err != nil {
-		slog.Warn("Can't connect to database")
-		ctx.JSON(http.StatusInternalServerError, TruckAnalyticsResponse{Error: "Can't connect to database"})
+		utils.RespondError(ctx, http.StatusInternalServerError, utils.CodeDatabaseUnavailable, "Can't connect to database", err)
 		return
 	}
 
-	rows, err := db.Query(context.Background(), query)
+	rows, err := db.Query(ctx.Request.Context(), query.SQL, query.Args...)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, TruckAnalyticsResponse{Error: "Failed to execute query: " + err.Error()})
+		utils.RespondError(ctx, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to execute query", err)
 		return
 	}
 	defer rows.Close()
@@ -203,7 +90,7 @@ ORDER BY
 			&ta.TOTAL,
 		)
 		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, TruckAnalyticsResponse{Error: "Failed to scan row: " + err.Error()})
+			utils.RespondError(ctx, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to scan row", err)
 			return
 		}
 
@@ -228,7 +115,7 @@ ORDER BY
 
 	// Проверка на ошибки при итерации
 	if err := rows.Err(); err != nil {
-		ctx.JSON(http.StatusInternalServerError, TruckAnalyticsResponse{Error: "Error iterating over rows: " + err.Error()})
+		utils.RespondError(ctx, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to iterate over rows", err)
 		return
 	}
 
@@ -254,8 +141,7 @@ type DistrictDataMDT struct {
 func NineMonth2023MDTTotal(ctx *gin.Context) {
 
 	type Response struct {
-		Data  *orderedmap.OrderedMap[string, []DistrictDataMDT] `json:"data"`
-		Error string                                            `json:"error,omitempty"`
+		Data *orderedmap.OrderedMap[string, []DistrictDataMDT] `json:"data"`
 	}
 
 	// Мапа для перевода федеральных округов и регионов
@@ -271,37 +157,22 @@ func NineMonth2023MDTTotal(ctx *gin.Context) {
 	}
 
 	// SQL-запрос для получения данных по округам
-	query := `
-		SELECT 
-			"Federal_district",
-			COALESCE(SUM(CASE WHEN "Brand" = 'DONGFENG' THEN "Quantity" END), 0) AS DONGFENG,
-			COALESCE(SUM(CASE WHEN "Brand" = 'FOTON' THEN "Quantity" END), 0) AS FOTON,
-			COALESCE(SUM(CASE WHEN "Brand" = 'HOWO' THEN "Quantity" END), 0) AS HOWO,
-			COALESCE(SUM(CASE WHEN "Brand" = 'JAC' THEN "Quantity" END), 0) AS JAC,
-			COALESCE(SUM(CASE WHEN "Brand" = 'KAMAZ' THEN "Quantity" END), 0) AS KAMAZ,
-			COALESCE(SUM(CASE WHEN "Brand" = 'URAL' THEN "Quantity" END), 0) AS URAL,
-			COALESCE(SUM(CASE WHEN "Brand" = 'DAEWOO' THEN "Quantity" END), 0) AS DAEWOO,
-			COALESCE(SUM(CASE WHEN "Brand" NOT IN ('DONGFENG', 'FOTON', 'HOWO', 'JAC', 'KAMAZ', 'URAL', 'DAEWOO') THEN "Quantity" END), 0) AS OTHER,
-			COALESCE(SUM("Quantity"), 0) AS TOTAL
-		FROM mdt_12_18_truck_analytics_10_2023
-		WHERE 
-			"Month_of_registration" <= 9
-		GROUP BY "Federal_district"
-		ORDER BY "Federal_district"
-	`
-
-	db, err := db.Connect()
+	query := sb.BrandPivot{
+		Table:  sb.MDT2023,
+		Where:  []sb.Condition{sb.Lte(sb.MonthOfRegistration, 9)},
+		Brands: []string{"DONGFENG", "FOTON", "HOWO", "JAC", "KAMAZ", "URAL", "DAEWOO"},
+		Other:  true,
+	}.DistrictQuery()
+
+	db, err := db.Connect(ctx.Request.Context())
 	if err != nil {
-		slog.Info("Can't connect to database:", err)
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Can't connect to database"})
+		utils.RespondError(ctx, http.StatusInternalServerError, utils.CodeDatabaseUnavailable, "Can't connect to database", err)
 		return
 	}
-	defer db.Close(context.Background())
 
-	rows, err := db.Query(context.Background(), query)
+	rows, err := db.Query(ctx.Request.Context(), query.SQL, query.Args...)
 	if err != nil {
-		slog.Info("Failed to execute query:", err)
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to execute query"})
+		utils.RespondError(ctx, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to execute query", err)
 		return
 	}
 	defer rows.Close()
@@ -344,8 +215,7 @@ func NineMonth2023MDTTotal(ctx *gin.Context) {
 			&item.TotalMarket,
 		)
 		if err != nil {
-			slog.Info("Failed to scan row:", err)
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to scan row"})
+			utils.RespondError(ctx, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to scan row", err)
 			return
 		}
 
@@ -362,9 +232,8 @@ func NineMonth2023MDTTotal(ctx *gin.Context) {
 		}
 	}
 
-	if rows.Err() != nil {
-		slog.Info("Failed to iterate over rows:", rows.Err())
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to iterate over rows"})
+	if err := rows.Err(); err != nil {
+		utils.RespondError(ctx, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to iterate over rows", err)
 		return
 	}
 
@@ -0,0 +1,26 @@
+package october
+
+import "truck-analytics-platform/internal/reports"
+
+// hdtTable — регистрации HDT за январь–октябрь 2024
+var hdtTable = reports.Tables[2024]
+
+// Сегменты HDT отбираются так же, как в отчётах 9M 2024
+var (
+	tractors4x2 = reports.Spec{Filter: reports.Filter{Table: hdtTable, Segment: reports.Tractors4x2, MonthTo: 10}, TotalMarket: true}
+	tractors6x4 = reports.Spec{Filter: reports.Filter{Table: hdtTable, Segment: reports.Tractors6x4, MonthTo: 10}, TotalMarket: true}
+	dumpers6x4  = reports.Spec{Filter: reports.Filter{Table: hdtTable, Segment: reports.Dumpers6x4, MonthTo: 10}, TotalMarket: true}
+	dumpers8x4  = reports.Spec{Filter: reports.Filter{Table: hdtTable, Segment: reports.Dumpers8x4, MonthTo: 10}, TotalMarket: true}
+)
+
+// Отчёты HDT за 10M 2024: по регионам и total market по округам
+var (
+	TenMonth2024Tractors4x2      = reports.RegionHandler(tractors4x2)
+	TenMonth2024Tractors4x2Total = reports.DistrictHandler(tractors4x2)
+	TenMonth2024Tractors6x4      = reports.RegionHandler(tractors6x4)
+	TenMonth2024Tractors6x4Total = reports.DistrictHandler(tractors6x4)
+	TenMonth2024Dumpers6x4       = reports.RegionHandler(dumpers6x4)
+	TenMonth2024Dumpers6x4Total  = reports.DistrictHandler(dumpers6x4)
+	TenMonth2024Dumpers8x4       = reports.RegionHandler(dumpers8x4)
+	TenMonth2024Dumpers8x4Total  = reports.DistrictHandler(dumpers8x4)
+)
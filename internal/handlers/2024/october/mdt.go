@@ -1,10 +1,10 @@
 package october
 
 import (
-	"context"
-	"log/slog"
 	"net/http"
 	"truck-analytics-platform/internal/db"
+	"truck-analytics-platform/internal/handlers/utils"
+	sb "truck-analytics-platform/internal/sqlbuilder"
 
 	"github.com/gin-gonic/gin"
 	orderedmap "github.com/wk8/go-ordered-map/v2"
@@ -26,136 +26,28 @@ func TenMonth2024Mdt(ctx *gin.Context) {
 
 	// Ответ с данными по анализу грузовиков
 	type TruckAnalyticsResponse struct {
-		Data  *orderedmap.OrderedMap[string, []*TruckAnalytics] `json:"data"`
-		Error string                                            `json:"error,omitempty"`
+		Data *orderedmap.OrderedMap[string, []*TruckAnalytics] `json:"data"`
 	}
 
 	// SQL запрос для получения данных
-	query := `
-	WITH base_data AS (
-		SELECT 
-			"Federal_district",
-			"Region",  
-			"Brand",
-			"City",
-			SUM(CAST("Quantity" AS INTEGER)) AS total_sales -- Cast Quantity to INTEGER
-		FROM mdt_12_18_truck_analytics_10_2024
-		WHERE "Brand" IN ('DONGFENG', 'FOTON', 'HOWO', 'JAC', 'KAMAZ', 'URAL', 'DAEWOO')
-		GROUP BY "Federal_district", "Region", "City", "Brand"
-	),
-	federal_totals AS (
-		SELECT 
-			"Federal_district",
-			"Region",
-			'TOTAL' AS "City",
-			"Brand",
-			SUM(total_sales) AS total_sales
-		FROM base_data
-		GROUP BY "Federal_district", "Region", "Brand"
-	),
-	other_brands AS (
-		SELECT 
-			"Federal_district",
-			"Region",  
-			'OTHER' AS "Brand",
-			'OTHER' AS "City",
-			SUM(CAST("Quantity" AS INTEGER)) AS total_sales -- Cast Quantity to INTEGER
-		FROM mdt_12_18_truck_analytics_10_2024
-		WHERE "Brand" NOT IN ('DONGFENG', 'FOTON', 'HOWO', 'JAC', 'KAMAZ', 'URAL', 'DAEWOO')
-		GROUP BY "Federal_district", "Region"
-	),
-	combined_data AS (
-		SELECT "Federal_district", "Region", "City", "Brand", total_sales
-		FROM base_data
-		UNION ALL
-		SELECT "Federal_district", "Region", "City", "Brand", total_sales
-		FROM federal_totals
-		UNION ALL
-		SELECT "Federal_district", "Region", "City", "Brand", total_sales
-		FROM other_brands
-	),
-	pivoted_data AS (
-		SELECT 
-			"Region" AS Oblast_name,
-			"Federal_district", 
-			MAX(CASE WHEN "Brand" = 'DONGFENG' THEN total_sales END) AS DONGFENG,
-			MAX(CASE WHEN "Brand" = 'FOTON' THEN total_sales END) AS FOTON,
-			MAX(CASE WHEN "Brand" = 'HOWO' THEN total_sales END) AS HOWO,
-			MAX(CASE WHEN "Brand" = 'JAC' THEN total_sales END) AS JAC,
-			MAX(CASE WHEN "Brand" = 'KAMAZ' THEN total_sales END) AS KAMAZ,
-			MAX(CASE WHEN "Brand" = 'URAL' THEN total_sales END) AS URAL,
-			MAX(CASE WHEN "Brand" = 'DAEWOO' THEN total_sales END) AS DAEWOO,
-			MAX(CASE WHEN "Brand" = 'OTHER' THEN total_sales END) AS OTHER,
-			COALESCE(MAX(CASE WHEN "Brand" = 'DONGFENG' THEN total_sales END), 0) +
-			COALESCE(MAX(CASE WHEN "Brand" = 'FOTON' THEN total_sales END), 0) +
-			COALESCE(MAX(CASE WHEN "Brand" = 'HOWO' THEN total_sales END), 0) +
-			COALESCE(MAX(CASE WHEN "Brand" = 'JAC' THEN total_sales END), 0) +
-			COALESCE(MAX(CASE WHEN "Brand" = 'KAMAZ' THEN total_sales END), 0) +
-			COALESCE(MAX(CASE WHEN "Brand" = 'URAL' THEN total_sales END), 0) +
-			COALESCE(MAX(CASE WHEN "Brand" = 'DAEWOO' THEN total_sales END), 0) +
-			COALESCE(MAX(CASE WHEN "Brand" = 'OTHER' THEN total_sales END), 0) AS TOTAL
-		FROM combined_data
-		GROUP BY "Region", "Federal_district"
-	),
-	federal_totals_by_region AS (
-		SELECT 
-			"Federal_district",
-			'TOTAL' AS Oblast_name,  
-			SUM(DONGFENG) AS DONGFENG,
-			SUM(FOTON) AS FOTON,
-			SUM(HOWO) AS HOWO,
-			SUM(JAC) AS JAC,
-			SUM(KAMAZ) AS KAMAZ,
-			SUM(URAL) AS URAL,
-			SUM(DAEWOO) AS DAEWOO,
-			SUM(OTHER) AS OTHER,
-			SUM(TOTAL) AS TOTAL
-		FROM pivoted_data
-		GROUP BY "Federal_district"
-	),
-	final_data AS (
-		SELECT Oblast_name, "Federal_district", DONGFENG, FOTON, HOWO, JAC, KAMAZ, URAL, DAEWOO, OTHER, TOTAL
-		FROM pivoted_data
-		UNION ALL
-		SELECT Oblast_name, "Federal_district", DONGFENG, FOTON, HOWO, JAC, KAMAZ, URAL, DAEWOO, OTHER, TOTAL
-		FROM federal_totals_by_region
-	)
-	SELECT 
-		"Federal_district",  -- Changed column order here
-		CASE 
-			WHEN Oblast_name = 'TOTAL' THEN "Federal_district"  
-			ELSE Oblast_name 
-		END AS Oblast_name,
-		COALESCE(DONGFENG, 0) AS DONGFENG,
-		COALESCE(FOTON, 0) AS FOTON,
-		COALESCE(HOWO, 0) AS HOWO,
-		COALESCE(JAC, 0) AS JAC,
-		COALESCE(KAMAZ, 0) AS KAMAZ,
-		COALESCE(URAL, 0) AS URAL,
-		COALESCE(DAEWOO, 0) AS DAEWOO,
-		COALESCE(OTHER, 0) AS OTHER,
-		COALESCE(TOTAL, 0) AS TOTAL
-	FROM final_data
-	ORDER BY 
-		"Federal_district",  
-		CASE 
-			WHEN Oblast_name = 'TOTAL' THEN 1  
-			ELSE 0
-		END,
-		Oblast_name;
-    `
+	query := sb.BrandPivot{
+		Table:        sb.MDT2024,
+		Brands:       []string{"DONGFENG", "FOTON", "HOWO", "JAC", "KAMAZ", "URAL", "DAEWOO"},
+		Other:        true,
+		ZeroFill:     true,
+		CastQuantity: true,
+	}.RegionQuery()
 
 	// Соединение с базой данных
-	db, err := db.Connect()
+	db, err := db.Connect(ctx.Request.Context())
 	if err != nil {
-		slog.Warn("Can't connect to database")
-		ctx.JSON(http.StatusInternalServerError, TruckAnalyticsResponse{Error: "Can't connect to database"})
+		utils.RespondError(ctx, http.StatusInternalServerError, utils.CodeDatabaseUnavailable, "Can't connect to database", err)
 		return
 	}
 
-	rows, err := db.Query(context.Background(), query)
+	rows, err := db.Query(ctx.Request.Context(), query.SQL, query.Args...)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, TruckAnalyticsResponse{Error: "Failed to execute query: " + err.Error()})
+		utils.RespondError(ctx, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to execute query", err)
 		return
 	}
 	defer rows.Close()
@@ -199,7 +91,7 @@ func TenMonth2024Mdt(ctx *gin.Context) {
 			&ta.TotalMarket,
 		)
 		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, TruckAnalyticsResponse{Error: "Failed to scan row: " + err.Error()})
+			utils.RespondError(ctx, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to scan row", err)
 			return
 		}
 
@@ -224,7 +116,7 @@ func TenMonth2024Mdt(ctx *gin.Context) {
 
 	// Проверка на ошибки при итерации
 	if err := rows.Err(); err != nil {
-		ctx.JSON(http.StatusInternalServerError, TruckAnalyticsResponse{Error: "Error iterating over rows: " + err.Error()})
+		utils.RespondError(ctx, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to iterate over rows", err)
 		return
 	}
 
@@ -250,8 +142,7 @@ type DistrictDataMDT struct {
 func TenMonth2024MDTTotal(ctx *gin.Context) {
 
 	type Response struct {
-		Data  *orderedmap.OrderedMap[string, []DistrictDataMDT] `json:"data"`
-		Error string                                            `json:"error,omitempty"`
+		Data *orderedmap.OrderedMap[string, []DistrictDataMDT] `json:"data"`
 	}
 
 	// Мапа для перевода федеральных округов и регионов
@@ -267,36 +158,23 @@ func TenMonth2024MDTTotal(ctx *gin.Context) {
 	}
 
 	// SQL-запрос для получения данных по округам
-	query := `
-    SELECT 
-        "Federal_district",
-        COALESCE(SUM(CASE WHEN "Brand" = 'DONGFENG' THEN CAST("Quantity" AS INTEGER) END), 0) AS DONGFENG,
-        COALESCE(SUM(CASE WHEN "Brand" = 'FOTON' THEN CAST("Quantity" AS INTEGER) END), 0) AS FOTON,
-        COALESCE(SUM(CASE WHEN "Brand" = 'HOWO' THEN CAST("Quantity" AS INTEGER) END), 0) AS HOWO,
-        COALESCE(SUM(CASE WHEN "Brand" = 'JAC' THEN CAST("Quantity" AS INTEGER) END), 0) AS JAC,
-        COALESCE(SUM(CASE WHEN "Brand" = 'KAMAZ' THEN CAST("Quantity" AS INTEGER) END), 0) AS KAMAZ,
-        COALESCE(SUM(CASE WHEN "Brand" = 'URAL' THEN CAST("Quantity" AS INTEGER) END), 0) AS URAL,
-        COALESCE(SUM(CASE WHEN "Brand" = 'DAEWOO' THEN CAST("Quantity" AS INTEGER) END), 0) AS DAEWOO,
-        COALESCE(SUM(CASE WHEN "Brand" NOT IN ('DONGFENG', 'FOTON', 'HOWO', 'JAC', 'KAMAZ', 'URAL', 'DAEWOO') THEN CAST("Quantity" AS INTEGER) END), 0) AS OTHER,
-        COALESCE(SUM(CAST("Quantity" AS INTEGER)), 0) AS TOTAL
-    FROM mdt_12_18_truck_analytics_10_2024
-    GROUP BY "Federal_district"
-    ORDER BY "Federal_district";
-	`
+	query := sb.BrandPivot{
+		Table:        sb.MDT2024,
+		Brands:       []string{"DONGFENG", "FOTON", "HOWO", "JAC", "KAMAZ", "URAL", "DAEWOO"},
+		Other:        true,
+		CastQuantity: true,
+	}.DistrictQuery()
 
 	// Подключение к базе данных
-	db, err := db.Connect()
+	db, err := db.Connect(ctx.Request.Context())
 	if err != nil {
-		slog.Info("Can't connect to database:", err)
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Can't connect to database"})
+		utils.RespondError(ctx, http.StatusInternalServerError, utils.CodeDatabaseUnavailable, "Can't connect to database", err)
 		return
 	}
-	defer db.Close(context.Background())
 
-	rows, err := db.Query(context.Background(), query)
+	rows, err := db.Query(ctx.Request.Context(), query.SQL, query.Args...)
 	if err != nil {
-		slog.Info("Failed to execute query:", err)
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to execute query"})
+		utils.RespondError(ctx, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to execute query", err)
 		return
 	}
 	defer rows.Close()
@@ -339,8 +217,7 @@ func TenMonth2024MDTTotal(ctx *gin.Context) {
 			&item.TotalMarket,
 		)
 		if err != nil {
-			slog.Info("Failed to scan row:", err)
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to scan row"})
+			utils.RespondError(ctx, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to scan row", err)
 			return
 		}
 
@@ -357,9 +234,8 @@ func TenMonth2024MDTTotal(ctx *gin.Context) {
 		}
 	}
 
-	if rows.Err() != nil {
-		slog.Info("Failed to iterate over rows:", rows.Err())
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to iterate over rows"})
+	if err := rows.Err(); err != nil {
+		utils.RespondError(ctx, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to iterate over rows", err)
 		return
 	}
 
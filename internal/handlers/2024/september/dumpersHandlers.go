@@ -1,10 +1,11 @@
 package september
 
 import (
-	"context"
-	"log/slog"
 	"net/http"
 	"truck-analytics-platform/internal/db"
+	"truck-analytics-platform/internal/handlers/utils"
+	"truck-analytics-platform/internal/reports"
+	sb "truck-analytics-platform/internal/sqlbuilder"
 
 	"github.com/gin-gonic/gin"
 	orderedmap "github.com/wk8/go-ordered-map/v2"
@@ -26,86 +27,26 @@ func NineMonth2024Dumpers6x4(ctx *gin.Context) {
 
 	// Структура для обертки ответа
 	type TruckAnalyticsResponse struct {
-		Data  *orderedmap.OrderedMap[string, []TruckAnalytics] `json:"data"`
-		Error string                                           `json:"error,omitempty"`
+		Data *orderedmap.OrderedMap[string, []TruckAnalytics] `json:"data"`
 	}
 
 	// SQL запрос
-	query := `
-		WITH base_data AS (
-			SELECT 
-				truck_analytics_2024_01_09."Federal_district",
-				truck_analytics_2024_01_09."Region",
-				truck_analytics_2024_01_09."Brand",
-				SUM(truck_analytics_2024_01_09."Quantity") as total_sales
-			FROM truck_analytics_2024_01_09
-			WHERE 
-				truck_analytics_2024_01_09."Wheel_formula" = '6x4'
-				AND truck_analytics_2024_01_09."Brand" IN ('FAW', 'HOWO', 'JAC', 'SANY', 'SITRAK', 'SHACMAN', 'DONGFENG')
-				AND truck_analytics_2024_01_09."Body_type" = 'Самосвал'
-				AND truck_analytics_2024_01_09."Mass_in_segment_1" = '32001-40000'
-			GROUP BY 
-				truck_analytics_2024_01_09."Federal_district", 
-				truck_analytics_2024_01_09."Region", 
-				truck_analytics_2024_01_09."Brand"
-		),
-		federal_totals AS (
-			SELECT 
-				"Federal_district",
-				"Federal_district" as "Region",
-				"Brand",
-				SUM(total_sales) as total_sales
-			FROM base_data
-			GROUP BY "Federal_district", "Brand"
-		),
-		combined_data AS (
-			SELECT * FROM base_data
-			UNION ALL
-			SELECT * FROM federal_totals
-		)
-		SELECT 
-			"Federal_district",
-			COALESCE("Region", "Federal_district") as Region_name,
-			MAX(CASE WHEN "Brand" = 'FAW' THEN total_sales END) as FAW,
-			MAX(CASE WHEN "Brand" = 'HOWO' THEN total_sales END) as HOWO,
-			MAX(CASE WHEN "Brand" = 'JAC' THEN total_sales END) as JAC,
-			MAX(CASE WHEN "Brand" = 'SANY' THEN total_sales END) as SANY,
-			MAX(CASE WHEN "Brand" = 'SITRAK' THEN total_sales END) as SITRAK,
-			MAX(CASE WHEN "Brand" = 'SHACMAN' THEN total_sales END) as SHACMAN,
-			MAX(CASE WHEN "Brand" = 'DONGFENG' THEN total_sales END) as DONGFENG,
-			COALESCE(MAX(CASE WHEN "Brand" = 'FAW' THEN total_sales END), 0) +
-			COALESCE(MAX(CASE WHEN "Brand" = 'HOWO' THEN total_sales END), 0) +
-			COALESCE(MAX(CASE WHEN "Brand" = 'JAC' THEN total_sales END), 0) +
-			COALESCE(MAX(CASE WHEN "Brand" = 'SANY' THEN total_sales END), 0) +
-			COALESCE(MAX(CASE WHEN "Brand" = 'SITRAK' THEN total_sales END), 0) +
-			COALESCE(MAX(CASE WHEN "Brand" = 'SHACMAN' THEN total_sales END), 0) +
-			COALESCE(MAX(CASE WHEN "Brand" = 'DONGFENG' THEN total_sales END), 0) as TOTAL
-		FROM combined_data
-		GROUP BY 
-			"Federal_district",
-			"Region"
-		ORDER BY 
-			"Federal_district",
-			CASE 
-				WHEN "Region" = "Federal_district" THEN 1 
-				ELSE 0 
-			END,
-			"Region"
-	`
+	query := sb.BrandPivot{
+		Table:  sb.HDT2024Sep,
+		Where:  reports.Dumpers6x4.Where(),
+		Brands: []string{"FAW", "HOWO", "JAC", "SANY", "SITRAK", "SHACMAN", "DONGFENG"},
+	}.RegionQuery()
 
 	// Соединение с базой данных
-	db, err := db.Connect()
+	db, err := db.Connect(ctx.Request.Context())
 	if err != nil {
-		slog.Warn("Can't connect to database")
+		utils.RespondError(ctx, http.StatusInternalServerError, utils.CodeDatabaseUnavailable, "Can't connect to database", err)
 		return
 	}
 
-	rows, err := db.Query(context.Background(), query)
+	rows, err := db.Query(ctx.Request.Context(), query.SQL, query.Args...)
 	if err != nil {
-		response := TruckAnalyticsResponse{
-			Error: "Failed to execute query: " + err.Error(),
-		}
-		ctx.JSON(http.StatusInternalServerError, response)
+		utils.RespondError(ctx, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to execute query", err)
 		return
 	}
 	defer rows.Close()
@@ -148,10 +89,7 @@ func NineMonth2024Dumpers6x4(ctx *gin.Context) {
 			&ta.TOTAL,
 		)
 		if err != nil {
-			response := TruckAnalyticsResponse{
-				Error: "Failed to scan row: " + err.Error(),
-			}
-			ctx.JSON(http.StatusInternalServerError, response)
+			utils.RespondError(ctx, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to scan row", err)
 			return
 		}
 
@@ -175,10 +113,7 @@ func NineMonth2024Dumpers6x4(ctx *gin.Context) {
 
 	// Проверка на ошибки при итерации
 	if err := rows.Err(); err != nil {
-		response := TruckAnalyticsResponse{
-			Error: "Error iterating over rows: " + err.Error(),
-		}
-		ctx.JSON(http.StatusInternalServerError, response)
+		utils.RespondError(ctx, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to iterate over rows", err)
 		return
 	}
 
@@ -205,8 +140,7 @@ func Dumpers6x4WithTotalMarket2024(ctx *gin.Context) {
 
 	// Структура для ответа
 	type Response struct {
-		Data  *orderedmap.OrderedMap[string, []DistrictData] `json:"data"`
-		Error string                                         `json:"error,omitempty"`
+		Data *orderedmap.OrderedMap[string, []DistrictData] `json:"data"`
 	}
 
 	// Мапа для перевода русских названий федеральных округов на английский
@@ -222,41 +156,23 @@ func Dumpers6x4WithTotalMarket2024(ctx *gin.Context) {
 	}
 
 	// SQL-запрос для получения данных по округам
-	query := `
-		SELECT 
-			"Federal_district",
-			COALESCE(SUM(CASE WHEN "Brand" = 'FAW' THEN "Quantity" END), 0) AS FAW,
-			COALESCE(SUM(CASE WHEN "Brand" = 'HOWO' THEN "Quantity" END), 0) AS HOWO,
-			COALESCE(SUM(CASE WHEN "Brand" = 'JAC' THEN "Quantity" END), 0) AS JAC,
-			COALESCE(SUM(CASE WHEN "Brand" = 'SANY' THEN "Quantity" END), 0) AS SANY,
-			COALESCE(SUM(CASE WHEN "Brand" = 'SITRAK' THEN "Quantity" END), 0) AS SITRAK,
-			COALESCE(SUM(CASE WHEN "Brand" = 'SHACMAN' THEN "Quantity" END), 0) AS SHACMAN,
-			COALESCE(SUM(CASE WHEN "Brand" = 'DONGFENG' THEN "Quantity" END), 0) AS DONGFENG,
-			COALESCE(SUM("Quantity"), 0) AS TOTAL
-		FROM truck_analytics_2024_01_09
-		WHERE 
-			"Wheel_formula" = '6x4'
-			AND "Brand" IN ('FAW', 'HOWO', 'JAC', 'SANY', 'SITRAK', 'SHACMAN', 'DONGFENG')
-			AND "Body_type" = 'Самосвал'
-			AND "Mass_in_segment_1" = '32001-40000'
-		GROUP BY "Federal_district"
-		ORDER BY "Federal_district"
-	`
+	query := sb.BrandPivot{
+		Table:  sb.HDT2024Sep,
+		Where:  reports.Dumpers6x4.Where(),
+		Brands: []string{"FAW", "HOWO", "JAC", "SANY", "SITRAK", "SHACMAN", "DONGFENG"},
+	}.DistrictQuery()
 
 	// Подключение к базе данных
-	db, err := db.Connect()
+	db, err := db.Connect(ctx.Request.Context())
 	if err != nil {
-		slog.Info("Can't connect to database:", err)
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Can't connect to database"})
+		utils.RespondError(ctx, http.StatusInternalServerError, utils.CodeDatabaseUnavailable, "Can't connect to database", err)
 		return
 	}
-	defer db.Close(context.Background())
 
 	// Запрос к базе данных
-	rows, err := db.Query(context.Background(), query)
+	rows, err := db.Query(ctx.Request.Context(), query.SQL, query.Args...)
 	if err != nil {
-		slog.Info("Failed to execute query:", err)
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to execute query"})
+		utils.RespondError(ctx, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to execute query", err)
 		return
 	}
 	defer rows.Close()
@@ -299,8 +215,7 @@ func Dumpers6x4WithTotalMarket2024(ctx *gin.Context) {
 		var item DistrictData
 		err := rows.Scan(&item.RegionName, &item.Faw, &item.Howo, &item.Jac, &item.Sany, &item.Sitrak, &item.Shacman, &item.Dongfeng, &item.Total)
 		if err != nil {
-			slog.Info("Failed to scan row:", err)
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to scan row"})
+			utils.RespondError(ctx, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to scan row", err)
 			return
 		}
 		// Обновляем суммарные значения
@@ -341,9 +256,8 @@ func Dumpers6x4WithTotalMarket2024(ctx *gin.Context) {
 			dataByDistrict.Set(translatedRegionName, append(existing, item))
 		}
 	}
-	if rows.Err() != nil {
-		slog.Info("Failed to iterate over rows:", rows.Err())
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to iterate over rows"})
+	if err := rows.Err(); err != nil {
+		utils.RespondError(ctx, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to iterate over rows", err)
 		return
 	}
 
@@ -370,77 +284,24 @@ func NineMonth2024Dumpers8x4(ctx *gin.Context) {
 	}
 
 	type TruckAnalyticsResponse struct {
-		Data  *orderedmap.OrderedMap[string, []TruckAnalytics] `json:"data"`
-		Error string                                           `json:"error,omitempty"`
-	}
-
-	query := `
-		WITH base_data AS (
-			SELECT 
-				truck_analytics_2024_01_09."Federal_district",
-				truck_analytics_2024_01_09."Region",
-				truck_analytics_2024_01_09."Brand",
-				SUM(truck_analytics_2024_01_09."Quantity") as total_sales
-			FROM truck_analytics_2024_01_09
-			WHERE 
-				truck_analytics_2024_01_09."Wheel_formula" = '8x4'
-				AND truck_analytics_2024_01_09."Brand" IN ('FAW', 'HOWO', 'SHACMAN', 'SITRAK')
-				AND truck_analytics_2024_01_09."Body_type" = 'Самосвал'
-				AND truck_analytics_2024_01_09."Weight_in_segment_4" = '35001-45000'
-			GROUP BY 
-				truck_analytics_2024_01_09."Federal_district", 
-				truck_analytics_2024_01_09."Region", 
-				truck_analytics_2024_01_09."Brand"
-		),
-		federal_totals AS (
-			SELECT 
-				"Federal_district",
-				"Federal_district" as "Region",
-				"Brand",
-				SUM(total_sales) as total_sales
-			FROM base_data
-			GROUP BY "Federal_district", "Brand"
-		),
-		combined_data AS (
-			SELECT * FROM base_data
-			UNION ALL
-			SELECT * FROM federal_totals
-		)
-		SELECT 
-			"Federal_district",
-			COALESCE("Region", "Federal_district") as Region_name,
-			MAX(CASE WHEN "Brand" = 'FAW' THEN total_sales END) as FAW,
-			MAX(CASE WHEN "Brand" = 'HOWO' THEN total_sales END) as HOWO,
-			MAX(CASE WHEN "Brand" = 'SHACMAN' THEN total_sales END) as SHACMAN,
-			MAX(CASE WHEN "Brand" = 'SITRAK' THEN total_sales END) as SITRAK,
-			COALESCE(MAX(CASE WHEN "Brand" = 'FAW' THEN total_sales END), 0) +
-			COALESCE(MAX(CASE WHEN "Brand" = 'HOWO' THEN total_sales END), 0) +
-			COALESCE(MAX(CASE WHEN "Brand" = 'SHACMAN' THEN total_sales END), 0) +
-			COALESCE(MAX(CASE WHEN "Brand" = 'SITRAK' THEN total_sales END), 0) as TOTAL
-		FROM combined_data
-		GROUP BY 
-			"Federal_district",
-			"Region"
-		ORDER BY 
-			"Federal_district",
-			CASE 
-				WHEN "Region" = "Federal_district" THEN 1 
-				ELSE 0 
-			END,
-			"Region"
-	`
-
-	db, err := db.Connect()
+		Data *orderedmap.OrderedMap[string, []TruckAnalytics] `json:"data"`
+	}
+
+	query := sb.BrandPivot{
+		Table:  sb.HDT2024Sep,
+		Where:  reports.Dumpers8x4.Where(),
+		Brands: []string{"FAW", "HOWO", "SHACMAN", "SITRAK"},
+	}.RegionQuery()
+
+	db, err := db.Connect(ctx.Request.Context())
 	if err != nil {
-		slog.Warn("Can't connect to database")
+		utils.RespondError(ctx, http.StatusInternalServerError, utils.CodeDatabaseUnavailable, "Can't connect to database", err)
 		return
 	}
 
-	rows, err := db.Query(context.Background(), query)
+	rows, err := db.Query(ctx.Request.Context(), query.SQL, query.Args...)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, TruckAnalyticsResponse{
-			Error: "Failed to execute query: " + err.Error(),
-		})
+		utils.RespondError(ctx, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to execute query", err)
 		return
 	}
 	defer rows.Close()
@@ -478,9 +339,7 @@ func NineMonth2024Dumpers8x4(ctx *gin.Context) {
 			&ta.TOTAL,
 		)
 		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, TruckAnalyticsResponse{
-				Error: "Failed to scan row: " + err.Error(),
-			})
+			utils.RespondError(ctx, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to scan row", err)
 			return
 		}
 
@@ -504,9 +363,7 @@ func NineMonth2024Dumpers8x4(ctx *gin.Context) {
 	}
 
 	if err := rows.Err(); err != nil {
-		ctx.JSON(http.StatusInternalServerError, TruckAnalyticsResponse{
-			Error: "Error iterating over rows: " + err.Error(),
-		})
+		utils.RespondError(ctx, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to iterate over rows", err)
 		return
 	}
 
@@ -537,8 +394,7 @@ func Dumpers8x4WithTotalMarket2024(ctx *gin.Context) {
 
 	// Структура для ответа
 	type Response struct {
-		Data  *orderedmap.OrderedMap[string, []DistrictData] `json:"data"`
-		Error string                                         `json:"error,omitempty"`
+		Data *orderedmap.OrderedMap[string, []DistrictData] `json:"data"`
 	}
 
 	// Мапа для перевода русских названий федеральных округов на английский
@@ -554,38 +410,23 @@ func Dumpers8x4WithTotalMarket2024(ctx *gin.Context) {
 	}
 
 	// SQL-запрос для получения данных по округам
-	query := `
-		SELECT 
-			"Federal_district",
-			COALESCE(SUM(CASE WHEN "Brand" = 'FAW' THEN "Quantity" END), 0) AS FAW,
-			COALESCE(SUM(CASE WHEN "Brand" = 'HOWO' THEN "Quantity" END), 0) AS HOWO,
-			COALESCE(SUM(CASE WHEN "Brand" = 'SITRAK' THEN "Quantity" END), 0) AS SITRAK,
-			COALESCE(SUM(CASE WHEN "Brand" = 'SHACMAN' THEN "Quantity" END), 0) AS SHACMAN,
-			COALESCE(SUM("Quantity"), 0) AS TOTAL
-		FROM truck_analytics_2024_01_09
-		WHERE 
-			"Wheel_formula" = '8x4'
-			AND "Brand" IN ('FAW', 'HOWO', 'SITRAK', 'SHACMAN')
-			AND "Body_type" = 'Самосвал'
-			AND "Weight_in_segment_4" = '35001-45000'
-		GROUP BY "Federal_district"
-		ORDER BY "Federal_district"
-	`
+	query := sb.BrandPivot{
+		Table:  sb.HDT2024Sep,
+		Where:  reports.Dumpers8x4.Where(),
+		Brands: []string{"FAW", "HOWO", "SITRAK", "SHACMAN"},
+	}.DistrictQuery()
 
 	// Подключение к базе данных
-	db, err := db.Connect()
+	db, err := db.Connect(ctx.Request.Context())
 	if err != nil {
-		slog.Info("Can't connect to database:", err)
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Can't connect to database"})
+		utils.RespondError(ctx, http.StatusInternalServerError, utils.CodeDatabaseUnavailable, "Can't connect to database", err)
 		return
 	}
-	defer db.Close(context.Background())
 
 	// Запрос к базе данных
-	rows, err := db.Query(context.Background(), query)
+	rows, err := db.Query(ctx.Request.Context(), query.SQL, query.Args...)
 	if err != nil {
-		slog.Info("Failed to execute query:", err)
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to execute query"})
+		utils.RespondError(ctx, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to execute query", err)
 		return
 	}
 	defer rows.Close()
@@ -625,8 +466,7 @@ func Dumpers8x4WithTotalMarket2024(ctx *gin.Context) {
 		var item DistrictData
 		err := rows.Scan(&item.RegionName, &item.Faw, &item.Howo, &item.Sitrak, &item.Shacman, &item.Total)
 		if err != nil {
-			slog.Info("Failed to scan row:", err)
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to scan row"})
+			utils.RespondError(ctx, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to scan row", err)
 			return
 		}
 		// Обновляем суммарные значения
@@ -656,9 +496,8 @@ func Dumpers8x4WithTotalMarket2024(ctx *gin.Context) {
 			dataByDistrict.Set(translatedRegionName, append(existing, item))
 		}
 	}
-	if rows.Err() != nil {
-		slog.Info("Failed to iterate over rows:", rows.Err())
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to iterate over rows"})
+	if err := rows.Err(); err != nil {
+		utils.RespondError(ctx, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to iterate over rows", err)
 		return
 	}
 
@@ -1,10 +1,10 @@
 package september
 
 import (
-	"context"
-	"log/slog"
 	"net/http"
 	"truck-analytics-platform/internal/db"
+	"truck-analytics-platform/internal/handlers/utils"
+	sb "truck-analytics-platform/internal/sqlbuilder"
 
 	"github.com/gin-gonic/gin"
 	orderedmap "github.com/wk8/go-ordered-map/v2"
@@ -25,131 +25,28 @@ func NineMonth2024Ldt(ctx *gin.Context) {
 
 	// Ответ с данными по анализу грузовиков
 	type TruckAnalyticsResponse struct {
-		Data  *orderedmap.OrderedMap[string, []*TruckAnalytics] `json:"data"`
-		Error string                                            `json:"error,omitempty"`
+		Data *orderedmap.OrderedMap[string, []*TruckAnalytics] `json:"data"`
 	}
 
 	// SQL запрос для получения данных
-	query := `
-WITH base_data AS (
-    SELECT 
-        "Federal_district",
-        "Region",  
-        "Brand",
-        "City",
-        SUM("Quantity") AS total_sales
-    FROM ldt_3_5_12_truck_analytics_10_2024
-    WHERE "Brand" IN ('DONGFENG', 'FOTON', 'GAZ', 'ISUZU', 'JAC', 'KAMAZ')
-    AND "Month_of_registration" <= 9   -- Filter for months <= 9
-    GROUP BY "Federal_district", "Region", "City", "Brand"
-),
-federal_totals AS (
-    SELECT 
-        "Federal_district",
-        "Region",
-        'TOTAL' AS "City",
-        "Brand",
-        SUM(total_sales) AS total_sales
-    FROM base_data
-    GROUP BY "Federal_district", "Region", "Brand"
-),
-other_brands AS (
-    SELECT 
-        "Federal_district",
-        "Region",  
-        'OTHER' AS "Brand",
-        'OTHER' AS "City",
-        SUM("Quantity") AS total_sales
-    FROM ldt_3_5_12_truck_analytics_10_2024
-    WHERE "Brand" NOT IN ('DONGFENG', 'FOTON', 'GAZ', 'ISUZU', 'JAC', 'KAMAZ')
-    AND "Month_of_registration" <= 9  -- Filter for months <= 9
-    GROUP BY "Federal_district", "Region"
-),
-combined_data AS (
-    SELECT "Federal_district", "Region", "City", "Brand", total_sales
-    FROM base_data
-    UNION ALL
-    SELECT "Federal_district", "Region", "City", "Brand", total_sales
-    FROM federal_totals
-    UNION ALL
-    SELECT "Federal_district", "Region", "City", "Brand", total_sales
-    FROM other_brands
-),
-pivoted_data AS (
-    SELECT 
-        "Region" AS Oblast_name,
-        "Federal_district", 
-        MAX(CASE WHEN "Brand" = 'DONGFENG' THEN total_sales END) AS DONGFENG,
-        MAX(CASE WHEN "Brand" = 'FOTON' THEN total_sales END) AS FOTON,
-        MAX(CASE WHEN "Brand" = 'GAZ' THEN total_sales END) AS GAZ,
-        MAX(CASE WHEN "Brand" = 'ISUZU' THEN total_sales END) AS ISUZU,
-        MAX(CASE WHEN "Brand" = 'JAC' THEN total_sales END) AS JAC,
-        MAX(CASE WHEN "Brand" = 'KAMAZ' THEN total_sales END) AS KAMAZ,
-        MAX(CASE WHEN "Brand" = 'OTHER' THEN total_sales END) AS OTHER,
-        COALESCE(MAX(CASE WHEN "Brand" = 'DONGFENG' THEN total_sales END), 0) +
-        COALESCE(MAX(CASE WHEN "Brand" = 'FOTON' THEN total_sales END), 0) +
-        COALESCE(MAX(CASE WHEN "Brand" = 'GAZ' THEN total_sales END), 0) +
-        COALESCE(MAX(CASE WHEN "Brand" = 'ISUZU' THEN total_sales END), 0) +
-        COALESCE(MAX(CASE WHEN "Brand" = 'JAC' THEN total_sales END), 0) +
-        COALESCE(MAX(CASE WHEN "Brand" = 'KAMAZ' THEN total_sales END), 0) +
-        COALESCE(MAX(CASE WHEN "Brand" = 'OTHER' THEN total_sales END), 0) AS TOTAL
-    FROM combined_data
-    GROUP BY "Region", "Federal_district"
-),
-federal_totals_by_region AS (
-    SELECT 
-        "Federal_district",
-        "Federal_district" AS Oblast_name,  -- Use the Federal_district name in the total row
-        SUM(DONGFENG) AS DONGFENG,
-        SUM(FOTON) AS FOTON,
-        SUM(GAZ) AS GAZ,
-        SUM(ISUZU) AS ISUZU,
-        SUM(JAC) AS JAC,
-        SUM(KAMAZ) AS KAMAZ,
-        SUM(OTHER) AS OTHER,
-        SUM(TOTAL) AS TOTAL
-    FROM pivoted_data
-    GROUP BY "Federal_district"
-),
-final_data AS (
-    SELECT Oblast_name, "Federal_district", DONGFENG, FOTON, GAZ, ISUZU, JAC, KAMAZ, OTHER, TOTAL
-    FROM pivoted_data
-    UNION ALL
-    SELECT Oblast_name, "Federal_district", DONGFENG, FOTON, GAZ, ISUZU, JAC, KAMAZ, OTHER, TOTAL
-    FROM federal_totals_by_region
-)
-SELECT 
-    "Federal_district",  -- Federal_district comes first
-    Oblast_name,  -- Oblast_name comes second
-    COALESCE(DONGFENG, 0) AS DONGFENG,
-    COALESCE(FOTON, 0) AS FOTON,
-    COALESCE(GAZ, 0) AS GAZ,
-    COALESCE(ISUZU, 0) AS ISUZU,
-    COALESCE(JAC, 0) AS JAC,
-    COALESCE(KAMAZ, 0) AS KAMAZ,
-    COALESCE(OTHER, 0) AS OTHER,
-    COALESCE(TOTAL, 0) AS TOTAL
-FROM final_data
-ORDER BY 
-    "Federal_district",  -- Sort by Federal_district
-    CASE 
-        WHEN Oblast_name = "Federal_district" THEN 1  
-        ELSE 0
-    END,
-    Oblast_name;
-    `
+	query := sb.BrandPivot{
+		Table:    sb.LDT2024,
+		Where:    []sb.Condition{sb.Lte(sb.MonthOfRegistration, 9)},
+		Brands:   []string{"DONGFENG", "FOTON", "GAZ", "ISUZU", "JAC", "KAMAZ"},
+		Other:    true,
+		ZeroFill: true,
+	}.RegionQuery()
 
 	// Соединение с базой данных
-	db, err := db.Connect()
+	db, err := db.Connect(ctx.Request.Context())
 	if err != nil {
-		slog.Warn("Can't connect to database")
-		ctx.JSON(http.StatusInternalServerError, TruckAnalyticsResponse{Error: "Can't connect to database"})
+		utils.RespondError(ctx, http.StatusInternalServerError, utils.CodeDatabaseUnavailable, "Can't connect to database", err)
 		return
 	}
 
-	rows, err := db.Query(context.Background(), query)
+	rows, err := db.Query(ctx.Request.Context(), query.SQL, query.Args...)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, TruckAnalyticsResponse{Error: "Failed to execute query: " + err.Error()})
+		utils.RespondError(ctx, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to execute query", err)
 		return
 	}
 	defer rows.Close()
@@ -191,7 +88,7 @@ ORDER BY
 			&ta.TotalMarket,
 		)
 		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, TruckAnalyticsResponse{Error: "Failed to scan row: " + err.Error()})
+			utils.RespondError(ctx, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to scan row", err)
 			return
 		}
 
@@ -216,7 +113,7 @@ ORDER BY
 
 	// Проверка на ошибки при итерации
 	if err := rows.Err(); err != nil {
-		ctx.JSON(http.StatusInternalServerError, TruckAnalyticsResponse{Error: "Error iterating over rows: " + err.Error()})
+		utils.RespondError(ctx, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to iterate over rows", err)
 		return
 	}
 
@@ -240,8 +137,7 @@ type DistrictData struct {
 
 func NineMonth2024LDTTotal(ctx *gin.Context) {
 	type Response struct {
-		Data  *orderedmap.OrderedMap[string, []DistrictData] `json:"data"`
-		Error string                                         `json:"error,omitempty"`
+		Data *orderedmap.OrderedMap[string, []DistrictData] `json:"data"`
 	}
 
 	var districtTranslations = map[string]string{
@@ -255,36 +151,22 @@ func NineMonth2024LDTTotal(ctx *gin.Context) {
 		"Южный Федеральный Округ":             "South",
 	}
 
-	query := `
-		SELECT 
-			"Federal_district",
-			COALESCE(SUM(CASE WHEN "Brand" = 'DONGFENG' THEN "Quantity" END), 0) AS DONGFENG,
-			COALESCE(SUM(CASE WHEN "Brand" = 'FOTON' THEN "Quantity" END), 0) AS FOTON,
-			COALESCE(SUM(CASE WHEN "Brand" = 'GAZ' THEN "Quantity" END), 0) AS GAZ,
-			COALESCE(SUM(CASE WHEN "Brand" = 'ISUZU' THEN "Quantity" END), 0) AS ISUZU,
-			COALESCE(SUM(CASE WHEN "Brand" = 'JAC' THEN "Quantity" END), 0) AS JAC,
-			COALESCE(SUM(CASE WHEN "Brand" = 'KAMAZ' THEN "Quantity" END), 0) AS KAMAZ,
-			COALESCE(SUM(CASE WHEN "Brand" NOT IN ('DONGFENG', 'FOTON', 'GAZ', 'ISUZU', 'JAC', 'KAMAZ') THEN "Quantity" END), 0) AS OTHER,
-			COALESCE(SUM("Quantity"), 0) AS TOTAL
-		FROM ldt_3_5_12_truck_analytics_10_2024
-		WHERE 
-			"Month_of_registration" <= 9
-		GROUP BY "Federal_district"
-		ORDER BY "Federal_district"
-	`
-
-	db, err := db.Connect()
+	query := sb.BrandPivot{
+		Table:  sb.LDT2024,
+		Where:  []sb.Condition{sb.Lte(sb.MonthOfRegistration, 9)},
+		Brands: []string{"DONGFENG", "FOTON", "GAZ", "ISUZU", "JAC", "KAMAZ"},
+		Other:  true,
+	}.DistrictQuery()
+
+	db, err := db.Connect(ctx.Request.Context())
 	if err != nil {
-		slog.Info("Can't connect to database:", err)
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Can't connect to database"})
+		utils.RespondError(ctx, http.StatusInternalServerError, utils.CodeDatabaseUnavailable, "Can't connect to database", err)
 		return
 	}
-	defer db.Close(context.Background())
 
-	rows, err := db.Query(context.Background(), query)
+	rows, err := db.Query(ctx.Request.Context(), query.SQL, query.Args...)
 	if err != nil {
-		slog.Info("Failed to execute query:", err)
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to execute query"})
+		utils.RespondError(ctx, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to execute query", err)
 		return
 	}
 	defer rows.Close()
@@ -324,8 +206,7 @@ func NineMonth2024LDTTotal(ctx *gin.Context) {
 			&item.TotalMarket,
 		)
 		if err != nil {
-			slog.Info("Failed to scan row:", err)
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to scan row"})
+			utils.RespondError(ctx, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to scan row", err)
 			return
 		}
 
@@ -342,9 +223,8 @@ func NineMonth2024LDTTotal(ctx *gin.Context) {
 		}
 	}
 
-	if rows.Err() != nil {
-		slog.Info("Failed to iterate over rows:", rows.Err())
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to iterate over rows"})
+	if err := rows.Err(); err != nil {
+		utils.RespondError(ctx, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to iterate over rows", err)
 		return
 	}
 
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"truck-analytics-platform/internal/apikeys"
+	"truck-analytics-platform/internal/handlers/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeysPath — API-ключи для BI и скриптов, управляют ими администраторы
+const APIKeysPath = APIPrefix + "/api-keys"
+
+// ListAPIKeysHandler отдаёт выданные ключи без секретов
+func ListAPIKeysHandler(c *gin.Context) {
+	keys, err := apikeys.List(c.Request.Context())
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to load API keys", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": keys})
+}
+
+// CreateAPIKeyHandler выдаёт ключ. Секрет возвращается в поле key только
+// в этом ответе — в БД хранится его хэш
+func CreateAPIKeyHandler(c *gin.Context) {
+	var key apikeys.Key
+	if err := c.ShouldBindJSON(&key); err != nil {
+		utils.RespondError(c, http.StatusBadRequest, utils.CodeBadRequest, "Invalid API key", nil)
+		return
+	}
+	if err := key.Validate(); err != nil {
+		utils.RespondError(c, http.StatusBadRequest, utils.CodeBadRequest, err.Error(), nil)
+		return
+	}
+
+	created, secret, err := apikeys.Create(c.Request.Context(), c.GetString(UserKey), key)
+	if errors.Is(err, apikeys.ErrNameTaken) {
+		utils.RespondError(c, http.StatusConflict, utils.CodeConflict, "API key name is already taken", nil)
+		return
+	}
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to create API key", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, struct {
+		apikeys.Key
+		Secret string `json:"key"`
+	}{created, secret})
+}
+
+// DeleteAPIKeyHandler отзывает ключ
+func DeleteAPIKeyHandler(c *gin.Context) {
+	id, ok := pathID(c)
+	if !ok {
+		return
+	}
+
+	deleted, err := apikeys.Delete(c.Request.Context(), id)
+	respondDeleted(c, deleted, err, "API key not found", "Failed to delete API key")
+}
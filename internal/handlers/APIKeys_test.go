@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"truck-analytics-platform/internal/apikeys"
+	"truck-analytics-platform/internal/db"
+	"truck-analytics-platform/internal/db/dbtest"
+	"truck-analytics-platform/internal/handlers/utils"
+)
+
+func TestCreateAPIKeyStoresHash(t *testing.T) {
+	querier := &dbtest.Querier{Rows: apiKeyRows[:1]}
+	db.SetQuerier(querier)
+	t.Cleanup(func() { db.SetQuerier(nil) })
+
+	token, err := utils.CreateJWT("foton-trucks", "foton1996")
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodPost, APIKeysPath, strings.NewReader(`{"name":"powerbi","scopes":["read:segments","export"]}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", token)
+	w := httptest.NewRecorder()
+	NewRouter().ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("status %d: %s", w.Code, w.Body.String())
+	}
+
+	var created struct {
+		Key string `json:"key"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(created.Key, apikeys.SecretPrefix) {
+		t.Fatalf("key %q has no %s prefix", created.Key, apikeys.SecretPrefix)
+	}
+
+	for _, call := range querier.Calls() {
+		if !strings.Contains(call.SQL, "INSERT INTO api_keys") {
+			continue
+		}
+		// name, prefix, hash, scopes, created_by, expires_at
+		if call.Args[1] != created.Key[:12] || call.Args[2] != apikeys.Hash(created.Key) {
+			t.Errorf("stored prefix %v and hash %v, want %q and the key's hash", call.Args[1], call.Args[2], created.Key[:12])
+		}
+		for _, arg := range call.Args {
+			if arg == created.Key {
+				t.Error("the key itself is stored")
+			}
+		}
+		return
+	}
+	t.Fatal("key is not stored")
+}
+
+func TestAPIKeyScopes(t *testing.T) {
+	// Ключ powerbi с правами read:segments и export
+	querier := &dbtest.Querier{
+		Rows:      pivotRows,
+		Responses: []dbtest.Response{{Contains: "UPDATE api_keys", Rows: apiKeyRows[:1]}},
+	}
+	db.SetQuerier(querier)
+	t.Cleanup(func() { db.SetQuerier(nil) })
+	router := NewRouter()
+
+	cases := []struct {
+		method, path, body string
+		status             int
+	}{
+		{http.MethodPost, PivotPath, pivotGoldenSpec, http.StatusOK},
+		{http.MethodPost, PivotPath + "?format=csv", pivotGoldenSpec, http.StatusOK},
+		{http.MethodPut, BrandsPath + "/HOWO", `{"name":"Howo"}`, http.StatusForbidden},
+		{http.MethodGet, AuditPath, "", http.StatusForbidden},
+		{http.MethodGet, APIKeysPath, "", http.StatusForbidden},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(APIKeyHeader, "tap_secret")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != tc.status {
+			t.Errorf("%s %s: status %d, want %d: %s", tc.method, tc.path, w.Code, tc.status, w.Body.String())
+		}
+	}
+}
+
+func TestUnknownAPIKeyIsRejected(t *testing.T) {
+	db.SetQuerier(&dbtest.Querier{
+		Rows:      pivotRows,
+		Responses: []dbtest.Response{{Contains: "UPDATE api_keys", Rows: nil}},
+	})
+	t.Cleanup(func() { db.SetQuerier(nil) })
+
+	// Открытый маршрут без ключа доступен, с неизвестным ключом — нет
+	for header, status := range map[string]int{"": http.StatusOK, "tap_revoked": http.StatusUnauthorized} {
+		req := httptest.NewRequest(http.MethodPost, PivotPath, strings.NewReader(pivotGoldenSpec))
+		req.Header.Set("Content-Type", "application/json")
+		if header != "" {
+			req.Header.Set(APIKeyHeader, header)
+		}
+		w := httptest.NewRecorder()
+		NewRouter().ServeHTTP(w, req)
+		if w.Code != status {
+			t.Errorf("key %q: status %d, want %d", header, w.Code, status)
+		}
+	}
+}
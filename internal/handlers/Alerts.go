@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+
+	"truck-analytics-platform/internal/alerts"
+	"truck-analytics-platform/internal/handlers/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AlertsPath — алерты по месячным загрузкам
+const AlertsPath = APIPrefix + "/alerts"
+
+// DefaultListedAlerts — сколько алертов отдаётся без параметра limit
+const DefaultListedAlerts = 100
+
+// ListAlertsHandler отдаёт алерты от новых к старым:
+// ?segment=tractors4x2&kind=share_drop&year=2024&month=10
+func ListAlertsHandler(c *gin.Context) {
+	filter := alerts.Filter{
+		Segment:  c.Query("segment"),
+		Kind:     c.Query("kind"),
+		District: c.Query("district"),
+		Brand:    c.Query("brand"),
+	}
+	if filter.Kind != "" && !slices.Contains(alerts.Kinds, filter.Kind) {
+		utils.RespondError(c, http.StatusBadRequest, utils.CodeBadRequest, "unknown alert kind", nil)
+		return
+	}
+	for _, param := range []struct {
+		name  string
+		value *int
+		def   int
+	}{
+		{"year", &filter.Year, 0},
+		{"month", &filter.Month, 0},
+		{"limit", &filter.Limit, DefaultListedAlerts},
+	} {
+		var err error
+		if *param.value, err = queryInt(c, param.name, param.def); err != nil {
+			utils.RespondError(c, http.StatusBadRequest, utils.CodeBadRequest, err.Error(), nil)
+			return
+		}
+	}
+	if filter.Limit < 1 || filter.Limit > alerts.MaxListed {
+		utils.RespondError(c, http.StatusBadRequest, utils.CodeBadRequest, "limit must be between 1 and 500", nil)
+		return
+	}
+
+	list, err := alerts.List(c.Request.Context(), filter)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to load alerts", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": list})
+}
+
+// RunAlertsHandler проверяет последний месяц всех рынков, не дожидаясь
+// следующей загрузки, и отдаёт новые алерты. Новые алерты рассылаются по почте
+func RunAlertsHandler(c *gin.Context) {
+	created, err := alerts.Run(c.Request.Context(), true)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to detect alerts", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": created})
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"net/http"
+
+	"truck-analytics-platform/internal/annotations"
+	"truck-analytics-platform/internal/handlers/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AnnotationsPath — заметки аналитиков к данным отчётов
+const AnnotationsPath = APIPrefix + "/annotations"
+
+// ListAnnotationsHandler отдаёт заметки к данным:
+// ?segment=tractors4x2&year=2024&month_from=7&month_to=9&region=Omsk Region&brand=FOTON.
+// Заметка без сегмента, региона или бренда подходит к любому значению фильтра
+func ListAnnotationsHandler(c *gin.Context) {
+	filter := annotations.Filter{
+		Segment:   c.Query("segment"),
+		Districts: c.QueryArray("district"),
+		Regions:   c.QueryArray("region"),
+		Brands:    c.QueryArray("brand"),
+		Author:    c.Query("author"),
+	}
+	var year int
+	for _, param := range []struct {
+		name  string
+		value *int
+	}{
+		{"year", &year},
+		{"month_from", &filter.MonthFrom},
+		{"month_to", &filter.MonthTo},
+	} {
+		var err error
+		if *param.value, err = queryInt(c, param.name, 0); err != nil {
+			utils.RespondError(c, http.StatusBadRequest, utils.CodeBadRequest, err.Error(), nil)
+			return
+		}
+	}
+	if year != 0 {
+		filter.Years = []int{year}
+	}
+
+	list, err := annotations.List(c.Request.Context(), filter)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to load annotations", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": list})
+}
+
+// CreateAnnotationHandler сохраняет заметку; автор — пользователь токена
+func CreateAnnotationHandler(c *gin.Context) {
+	annotation, ok := bindAnnotation(c)
+	if !ok {
+		return
+	}
+
+	created, err := annotations.Create(c.Request.Context(), c.GetString(UserKey), annotation)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to save annotation", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, created)
+}
+
+// PutAnnotationHandler заменяет заметку; менять заметку может только её автор
+func PutAnnotationHandler(c *gin.Context) {
+	id, ok := pathID(c)
+	if !ok {
+		return
+	}
+	annotation, ok := bindAnnotation(c)
+	if !ok {
+		return
+	}
+	annotation.ID = id
+
+	saved, ok, err := annotations.Update(c.Request.Context(), c.GetString(UserKey), annotation)
+	switch {
+	case err != nil:
+		utils.RespondError(c, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to save annotation", err)
+	case !ok:
+		utils.RespondError(c, http.StatusNotFound, utils.CodeNotFound, "Annotation not found", nil)
+	default:
+		c.JSON(http.StatusOK, saved)
+	}
+}
+
+// DeleteAnnotationHandler удаляет заметку; удалить заметку может только её автор
+func DeleteAnnotationHandler(c *gin.Context) {
+	id, ok := pathID(c)
+	if !ok {
+		return
+	}
+
+	deleted, err := annotations.Delete(c.Request.Context(), c.GetString(UserKey), id)
+	respondDeleted(c, deleted, err, "Annotation not found", "Failed to delete annotation")
+}
+
+// bindAnnotation читает и проверяет заметку из тела запроса
+func bindAnnotation(c *gin.Context) (annotations.Annotation, bool) {
+	var annotation annotations.Annotation
+	if err := c.ShouldBindJSON(&annotation); err != nil {
+		utils.RespondError(c, http.StatusBadRequest, utils.CodeBadRequest, "Invalid annotation", nil)
+		return annotation, false
+	}
+	if err := annotation.Validate(); err != nil {
+		utils.RespondError(c, http.StatusBadRequest, utils.CodeBadRequest, err.Error(), nil)
+		return annotation, false
+	}
+	return annotation, true
+}
@@ -0,0 +1,243 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"truck-analytics-platform/internal/apikeys"
+	"truck-analytics-platform/internal/audit"
+	"truck-analytics-platform/internal/handlers/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AuditPath — журнал аудита для администраторов
+const AuditPath = APIPrefix + "/audit"
+
+// DefaultListedAuditEvents — сколько записей журнала отдаётся в JSON без параметра limit
+const DefaultListedAuditEvents = 100
+
+// DefaultAdmin — администратор, если ADMIN_LOGINS не задан
+const DefaultAdmin = "foton-trucks"
+
+// loginAttemptKey — ключ gin-контекста с логином из попытки входа:
+// при неудачном входе пользователя в контексте ещё нет
+const loginAttemptKey = "login_attempt"
+
+// sensitiveParams не попадают в журнал
+var sensitiveParams = []string{"password", "token"}
+
+// Размер записи журнала от клиента ограничен: журнал только пополняется,
+// а попытки входа записываются и без авторизации
+const (
+	// MaxAuditedText — сколько символов пользователя, параметров и строковых
+	// полей тела сохраняется в журнале
+	MaxAuditedText = 100
+	// MaxAuditedParams — сколько байт параметров сохраняется; вместо более
+	// длинных записывается только их размер
+	MaxAuditedParams = 4 << 10
+)
+
+// Admins возвращает логины администраторов из ADMIN_LOGINS через запятую
+func Admins() []string {
+	var admins []string
+	for _, login := range strings.Split(os.Getenv("ADMIN_LOGINS"), ",") {
+		if login = strings.TrimSpace(login); login != "" {
+			admins = append(admins, login)
+		}
+	}
+	if len(admins) == 0 {
+		return []string{DefaultAdmin}
+	}
+	return admins
+}
+
+// AdminRequired пропускает только администраторов и API-ключи с правом admin;
+// ставится после AuthRequired
+func AdminRequired() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		admin := slices.Contains(Admins(), c.GetString(UserKey))
+		if _, isKey := c.Get(apiKeyScopesKey); isKey {
+			admin = hasScope(c, apikeys.ScopeAdmin)
+		}
+		if !admin {
+			utils.RespondError(c, http.StatusForbidden, utils.CodeForbidden, "Administrator access is required", nil)
+			return
+		}
+		c.Next()
+	}
+}
+
+// Audited записывает выполненный запрос в журнал аудита: кто, с какого IP,
+// какой маршрут, параметры пути, query-строки и тела и итоговый статус.
+// Записываются и отклонённые запросы — попытки тоже важны
+func Audited(action string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		body, ok := peekBody(c)
+		if ok {
+			c.Next()
+		}
+
+		params := map[string]any{}
+		for key, value := range requestParams(c) {
+			params[key] = auditedText(value)
+		}
+		if len(body) > 0 {
+			params["body"] = bodyParams(body)
+		}
+		recordAudit(c, action, params)
+	}
+}
+
+// recordAudit дописывает в журнал действие текущего запроса
+func recordAudit(c *gin.Context, action string, params map[string]any) {
+	for _, key := range sensitiveParams {
+		delete(params, key)
+	}
+	encoded, err := json.Marshal(params)
+	if err != nil {
+		slog.WarnContext(c.Request.Context(), "Audit params are not encoded", "action", action, "error", err)
+		encoded = nil
+	}
+	if len(encoded) > MaxAuditedParams {
+		encoded, _ = json.Marshal(map[string]any{"truncated": true, "bytes": len(encoded)})
+	}
+
+	actor := requestUser(c)
+	if actor == "" {
+		actor = c.GetString(loginAttemptKey)
+	}
+	audit.Record(c.Request.Context(), audit.Event{
+		Action: action,
+		Actor:  auditedText(actor),
+		IP:     c.ClientIP(),
+		Method: c.Request.Method,
+		Path:   requestPath(c),
+		Status: c.Writer.Status(),
+		Params: encoded,
+	})
+}
+
+// bodyParams — тело запроса для журнала: поля JSON-объекта без паролей
+// со строками не длиннее MaxAuditedText, для списка — число элементов,
+// для прочих тел (CSV) — размер
+func bodyParams(body []byte) any {
+	var object map[string]any
+	if json.Unmarshal(body, &object) == nil {
+		for _, key := range sensitiveParams {
+			delete(object, key)
+		}
+		for key, value := range object {
+			if text, ok := value.(string); ok {
+				object[key] = auditedText(text)
+			}
+		}
+		return object
+	}
+	var list []json.RawMessage
+	if json.Unmarshal(body, &list) == nil {
+		return map[string]any{"items": len(list)}
+	}
+	return map[string]any{"bytes": len(body)}
+}
+
+// auditedText обрезает текст клиента до MaxAuditedText символов
+func auditedText(s string) string {
+	if utf8.RuneCountInString(s) <= MaxAuditedText {
+		return s
+	}
+	return string([]rune(s)[:MaxAuditedText])
+}
+
+// ListAuditHandler отдаёт журнал аудита от новых записей к старым:
+// ?action=auth.login&actor=foton-trucks&from=2024-10-01&to=2024-11-01&limit=100.
+// ?format=csv выгружает журнал файлом; сама выгрузка тоже записывается в журнал
+func ListAuditHandler(c *gin.Context) {
+	filter := audit.Filter{
+		Action: c.Query("action"),
+		Actor:  c.Query("actor"),
+	}
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "csv" {
+		utils.RespondError(c, http.StatusBadRequest, utils.CodeBadRequest, "format must be json or csv", nil)
+		return
+	}
+	def := DefaultListedAuditEvents
+	if format == "csv" {
+		def = audit.MaxListed
+	}
+	limit, err := queryInt(c, "limit", def)
+	if err != nil || limit < 1 || limit > audit.MaxListed {
+		utils.RespondError(c, http.StatusBadRequest, utils.CodeBadRequest,
+			"limit must be between 1 and "+strconv.Itoa(audit.MaxListed), nil)
+		return
+	}
+	filter.Limit = limit
+	for _, param := range []struct {
+		name  string
+		value **time.Time
+	}{
+		{"from", &filter.From},
+		{"to", &filter.To},
+	} {
+		raw := c.Query(param.name)
+		if raw == "" {
+			continue
+		}
+		parsed, err := parseAuditTime(raw)
+		if err != nil {
+			utils.RespondError(c, http.StatusBadRequest, utils.CodeBadRequest,
+				param.name+" must be a date (2006-01-02) or RFC 3339 time", nil)
+			return
+		}
+		*param.value = &parsed
+	}
+
+	events, err := audit.List(c.Request.Context(), filter)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to load audit log", err)
+		return
+	}
+
+	if format == "csv" {
+		writeAuditCSV(c, events)
+		recordAudit(c, audit.ActionExport, map[string]any{
+			"export": "audit", "action": filter.Action, "actor": filter.Actor,
+			"from": c.Query("from"), "to": c.Query("to"), "rows": len(events),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": events})
+}
+
+func parseAuditTime(raw string) (time.Time, error) {
+	if parsed, err := time.Parse(time.DateOnly, raw); err == nil {
+		return parsed, nil
+	}
+	return time.Parse(time.RFC3339, raw)
+}
+
+func writeAuditCSV(c *gin.Context, events []audit.Event) {
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", `attachment; filename="audit.csv"`)
+	c.Status(http.StatusOK)
+
+	w := csv.NewWriter(c.Writer)
+	w.Write([]string{"id", "at", "action", "actor", "ip", "method", "path", "status", "params"})
+	for _, e := range events {
+		w.Write([]string{strconv.FormatInt(e.ID, 10), e.At.UTC().Format(time.RFC3339), csvText(e.Action), csvText(e.Actor),
+			csvText(e.IP), csvText(e.Method), csvText(e.Path), strconv.Itoa(e.Status), csvText(string(e.Params))})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		slog.WarnContext(c.Request.Context(), "Audit CSV export failed", "error", err)
+	}
+}
@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"truck-analytics-platform/internal/db"
+	"truck-analytics-platform/internal/db/dbtest"
+	"truck-analytics-platform/internal/handlers/utils"
+)
+
+// auditCalls возвращает записи в журнал аудита, выполненные фейком
+func auditCalls(querier *dbtest.Querier) []dbtest.Call {
+	var calls []dbtest.Call
+	for _, call := range querier.Calls() {
+		if strings.Contains(call.SQL, "INSERT INTO audit_log") {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+func TestLoginAttemptIsAudited(t *testing.T) {
+	querier := &dbtest.Querier{}
+	db.SetQuerier(querier)
+	t.Cleanup(func() { db.SetQuerier(nil) })
+
+	req := httptest.NewRequest(http.MethodPost, APIPrefix+"/auth/token", strings.NewReader(`{"login":"analyst","password":"secret"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.RemoteAddr = "10.0.0.7:52100"
+	// Прокси не настроены, поэтому подставной заголовок не меняет IP в журнале
+	req.Header.Set("X-Forwarded-For", "198.51.100.23")
+	w := httptest.NewRecorder()
+	NewRouter().ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("status %d, want 401", w.Code)
+	}
+
+	calls := auditCalls(querier)
+	if len(calls) != 1 {
+		t.Fatalf("%d audit records, want 1", len(calls))
+	}
+	// action, actor, ip, method, path, status, params
+	args := calls[0].Args
+	want := []any{"auth.login", "analyst", "10.0.0.7", http.MethodPost, APIPrefix + "/auth/token", http.StatusUnauthorized}
+	for i, value := range want {
+		if args[i] != value {
+			t.Errorf("argument %d = %#v, want %#v", i+1, args[i], value)
+		}
+	}
+	if params := fmt.Sprint(args[6]); strings.Contains(params, "secret") || !strings.Contains(params, `"login":"analyst"`) {
+		t.Errorf("params %s must keep the login and drop the password", params)
+	}
+}
+
+func TestLoginAttemptAuditIsBounded(t *testing.T) {
+	querier := &dbtest.Querier{}
+	db.SetQuerier(querier)
+	t.Cleanup(func() { db.SetQuerier(nil) })
+
+	login := strings.Repeat("ю", 1000)
+	fields := ""
+	for i := range 100 {
+		fields += fmt.Sprintf(`,"field%d":%q`, i, strings.Repeat("x", 1000))
+	}
+	for _, body := range []string{
+		`{"login":"` + login + `","password":"secret","note":"` + strings.Repeat("x", 1000) + `"}`,
+		`{"login":"` + login + `","password":"secret"` + fields + `}`,
+	} {
+		serve(t, NewRouter(), http.MethodPost, APIPrefix+"/auth/token", body, http.StatusUnauthorized)
+	}
+
+	calls := auditCalls(querier)
+	if len(calls) != 2 {
+		t.Fatalf("%d audit records, want 2", len(calls))
+	}
+	for _, call := range calls {
+		if actor := call.Args[1].(string); actor != strings.Repeat("ю", MaxAuditedText) {
+			t.Errorf("actor has %d characters, want %d", len([]rune(actor)), MaxAuditedText)
+		}
+		if params := call.Args[6].(string); len(params) > MaxAuditedParams {
+			t.Errorf("params have %d bytes, want at most %d", len(params), MaxAuditedParams)
+		}
+	}
+	if params := calls[0].Args[6].(string); !strings.Contains(params, `"note":"`+strings.Repeat("x", MaxAuditedText)+`"`) {
+		t.Errorf("body field is not cut to %d characters: %s", MaxAuditedText, params)
+	}
+	if params := calls[1].Args[6].(string); !strings.Contains(params, `"truncated":true`) {
+		t.Errorf("oversized params are not replaced with their size: %s", params)
+	}
+}
+
+func TestAuditRequiresAdmin(t *testing.T) {
+	db.SetQuerier(&dbtest.Querier{Rows: auditRows})
+	t.Cleanup(func() { db.SetQuerier(nil) })
+	t.Setenv("ADMIN_LOGINS", "security-officer")
+
+	token, err := utils.CreateJWT("foton-trucks", "foton1996")
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodGet, AuditPath, nil)
+	req.Header.Set("Authorization", token)
+	w := httptest.NewRecorder()
+	NewRouter().ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("status %d, want 403: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestAuditCSVExport(t *testing.T) {
+	// Логин неудачной попытки входа задаёт кто угодно, поэтому он экранируется
+	rows := append(append([]dbtest.Row(nil), auditRows...),
+		dbtest.Row{Strings: []string{"auth.login", `=HYPERLINK("http://example.com","x")`, "10.0.0.9", "POST", "/auth", `{}`,
+			"2024-11-05T08:00:00Z"}, Ints: []int{3, 401}},
+		dbtest.Row{Strings: []string{"auth.login", "@SUM(1+1)", "10.0.0.9", "POST", "/auth", `{}`,
+			"2024-11-05T08:00:00Z"}, Ints: []int{4, 401}},
+		dbtest.Row{Strings: []string{"auth.login", "\t=1+1", "10.0.0.9", "POST", "/auth", `{}`,
+			"2024-11-05T08:00:00Z"}, Ints: []int{5, 401}},
+	)
+	querier := &dbtest.Querier{Rows: rows}
+	db.SetQuerier(querier)
+	t.Cleanup(func() { db.SetQuerier(nil) })
+
+	token, err := utils.CreateJWT("foton-trucks", "foton1996")
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodGet, AuditPath+"?format=csv", nil)
+	req.Header.Set("Authorization", token)
+	w := httptest.NewRecorder()
+	NewRouter().ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body.String())
+	}
+
+	want := "id,at,action,actor,ip,method,path,status,params\n" +
+		`2,2024-11-05T09:30:00Z,data.export,foton-trucks,10.0.0.7,POST,/api/v1/pivot,200,"{""export"": ""pivot"", ""rows"": 9}"` + "\n"
+	if !strings.HasPrefix(w.Body.String(), want) {
+		t.Errorf("CSV starts with\n%s\nwant\n%s", w.Body.String(), want)
+	}
+	for _, actor := range []string{`"'=HYPERLINK(""http://example.com"",""x"")"`, ",'@SUM(1+1),", ",'\t=1+1,"} {
+		if !strings.Contains(w.Body.String(), actor) {
+			t.Errorf("CSV has no escaped actor %s:\n%s", actor, w.Body.String())
+		}
+	}
+	// Выгрузка журнала сама попадает в журнал
+	if calls := auditCalls(querier); len(calls) != 1 || calls[0].Args[0] != "data.export" {
+		t.Errorf("audit records %v, want one data.export", calls)
+	}
+}
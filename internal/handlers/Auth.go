@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+
+	"truck-analytics-platform/internal/apikeys"
+	"truck-analytics-platform/internal/handlers/utils"
+	"truck-analytics-platform/internal/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyHeader — заголовок с API-ключом для BI и скриптов без входа пользователя
+const APIKeyHeader = "X-API-Key"
+
+// APIKeyUserPrefix — пользователь запроса по API-ключу в контексте и журналах: apikey:powerbi
+const APIKeyUserPrefix = "apikey:"
+
+// apiKeyScopesKey — ключ gin-контекста с правами API-ключа; у пользователей с JWT его нет
+const apiKeyScopesKey = "api_key_scopes"
+
+// AuthRequired пропускает только запросы с валидным JWT в заголовке
+// Authorization или действующим API-ключом в X-API-Key и кладёт пользователя
+// в контекст под UserKey. API-ключу нужны все права scopes, пользователю с JWT — нет
+func AuthRequired(scopes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if authenticate(c, true, scopes) {
+			c.Next()
+		}
+	}
+}
+
+// OptionalAuth — проверка для открытых маршрутов: запрос без учётных данных
+// проходит анонимно, с ними — проверяется как в AuthRequired. Ключ без прав
+// scopes не получает доступа и там, где вход не обязателен
+func OptionalAuth(scopes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if authenticate(c, false, scopes) {
+			c.Next()
+		}
+	}
+}
+
+// ExportScope пускает к выгрузкам ?format=csv только пользователей с JWT
+// и API-ключи с правом export, чтобы выгрузка попала в журнал аудита
+// под настоящим пользователем. Ставится после OptionalAuth или AuthRequired
+func ExportScope() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Query("format") != "csv" {
+			c.Next()
+			return
+		}
+		if c.GetString(UserKey) == "" {
+			metrics.AuthFailures.WithLabelValues("missing_token").Inc()
+			utils.RespondError(c, http.StatusUnauthorized, utils.CodeUnauthorized, "Token or API key is required for exports", nil)
+			return
+		}
+		if !hasScope(c, apikeys.ScopeExport) {
+			utils.RespondError(c, http.StatusForbidden, utils.CodeForbidden, "API key has no export scope", nil)
+			return
+		}
+		c.Next()
+	}
+}
+
+// authenticate проверяет API-ключ или JWT запроса; false — ответ с ошибкой уже отдан
+func authenticate(c *gin.Context, required bool, scopes []string) bool {
+	if secret := c.GetHeader(APIKeyHeader); secret != "" {
+		key, ok, err := apikeys.Authenticate(c.Request.Context(), secret)
+		if err != nil {
+			utils.RespondError(c, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to check API key", err)
+			return false
+		}
+		if !ok {
+			metrics.AuthFailures.WithLabelValues("invalid_api_key").Inc()
+			utils.RespondError(c, http.StatusUnauthorized, utils.CodeUnauthorized, "Invalid or expired API key", nil)
+			return false
+		}
+		for _, scope := range scopes {
+			if !key.Has(scope) {
+				utils.RespondError(c, http.StatusForbidden, utils.CodeForbidden, "API key has no "+scope+" scope", nil)
+				return false
+			}
+		}
+
+		c.Set(UserKey, APIKeyUserPrefix+key.Name)
+		c.Set(apiKeyScopesKey, key.Scopes)
+		return true
+	}
+
+	token := c.GetHeader("Authorization")
+	if token == "" {
+		if !required {
+			return true
+		}
+		metrics.AuthFailures.WithLabelValues("missing_token").Inc()
+		utils.RespondError(c, http.StatusUnauthorized, utils.CodeUnauthorized, "Token is required", nil)
+		return false
+	}
+
+	login, err := utils.ParseJWT(token)
+	if err != nil {
+		metrics.AuthFailures.WithLabelValues("invalid_token").Inc()
+		utils.RespondError(c, http.StatusUnauthorized, utils.CodeUnauthorized, "Invalid token", nil)
+		return false
+	}
+
+	c.Set(UserKey, login)
+	return true
+}
+
+// hasScope сообщает, есть ли у запроса право scope: ограничены права только
+// у API-ключей, пользователь с JWT или анонимный запрос проходят. Открытые
+// данные доступны и без входа, поэтому права лишь сужают доступ ключа
+func hasScope(c *gin.Context, scope string) bool {
+	scopes, isKey := c.Get(apiKeyScopesKey)
+	return !isKey || slices.Contains(scopes.([]string), scope)
+}
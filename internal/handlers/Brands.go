@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"net/http"
+
+	"truck-analytics-platform/internal/brands"
+	"truck-analytics-platform/internal/handlers/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BrandsPath — справочник брендов. Изменения сразу попадают в отчёты
+const BrandsPath = APIPrefix + "/brands"
+
+// ListBrandsHandler отдаёт справочник брендов с группами и вариантами написания
+func ListBrandsHandler(c *gin.Context) {
+	list, err := brands.List(c.Request.Context())
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to load brands", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": list})
+}
+
+// GetBrandHandler отдаёт один бренд
+func GetBrandHandler(c *gin.Context) {
+	brand, ok, err := brands.Get(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to load brand", err)
+		return
+	}
+	if !ok {
+		utils.RespondError(c, http.StatusNotFound, utils.CodeNotFound, "Brand not found", nil)
+		return
+	}
+
+	c.JSON(http.StatusOK, brand)
+}
+
+// PutBrandHandler создаёт или заменяет бренд. Идентификатор берётся из пути
+func PutBrandHandler(c *gin.Context) {
+	var brand brands.Brand
+	if err := c.ShouldBindJSON(&brand); err != nil {
+		utils.RespondError(c, http.StatusBadRequest, utils.CodeBadRequest, "Invalid brand", nil)
+		return
+	}
+	brand.ID = c.Param("id")
+
+	ctx := c.Request.Context()
+	existing, err := brands.List(ctx)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to load brands", err)
+		return
+	}
+	if err := brand.Validate(existing); err != nil {
+		utils.RespondError(c, http.StatusBadRequest, utils.CodeBadRequest, err.Error(), nil)
+		return
+	}
+
+	if err := brands.Save(ctx, brand); err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to save brand", err)
+		return
+	}
+	if brand.Aliases == nil {
+		brand.Aliases = []string{}
+	}
+
+	c.JSON(http.StatusOK, brand)
+}
+
+// DeleteBrandHandler удаляет бренд и его варианты написания
+func DeleteBrandHandler(c *gin.Context) {
+	deleted, err := brands.Delete(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to delete brand", err)
+		return
+	}
+	if !deleted {
+		utils.RespondError(c, http.StatusNotFound, utils.CodeNotFound, "Brand not found", nil)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
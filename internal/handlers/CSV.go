@@ -0,0 +1,14 @@
+package handlers
+
+import "strings"
+
+// csvText экранирует текстовую ячейку CSV-выгрузки: ячейку, которая начинается
+// с =, +, - или @, Excel выполняет как формулу, а ведущие табуляция и возврат
+// каретки позволяют спрятать такой символ. Текст в выгрузки попадает и от
+// пользователей без входа, например логин неудачной попытки в журнале аудита
+func csvText(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+
+	"truck-analytics-platform/internal/handlers/utils"
+	"truck-analytics-platform/internal/reports"
+
+	"github.com/gin-gonic/gin"
+	orderedmap "github.com/wk8/go-ordered-map/v2"
+)
+
+// ConcentrationPath — индексы концентрации рынка по территориям
+const ConcentrationPath = APIPrefix + "/concentration"
+
+// ConcentrationParam включает колонки концентрации в *total-отчётах: ?concentration=true
+const ConcentrationParam = "concentration"
+
+// ConcentrationHandler отдаёт HHI, CR3 и CR5 по территориям с изменением к прошлому году:
+// ?segment=ldt&year=2024&level=district
+func ConcentrationHandler(c *gin.Context) {
+	spec, ok := marketSpec(c, reports.LevelDistrict)
+	if !ok {
+		return
+	}
+	if err := spec.Validate(reports.LevelCountry, reports.LevelDistrict, reports.LevelRegion); err != nil {
+		utils.RespondError(c, http.StatusBadRequest, utils.CodeBadRequest, err.Error(), nil)
+		return
+	}
+
+	areas, period, err := reports.MarketConcentration(c.Request.Context(), spec)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to compute concentration", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"period": period, "data": areas})
+}
+
+// concentrationColumns — колонки, которые добавляются в строки *total-отчётов
+var concentrationColumns = []string{"hhi", "cr3", "cr5", "hhi_change", "cr3_change", "cr5_change"}
+
+// bufferedWriter придерживает тело ответа, чтобы дополнить его перед отправкой
+type bufferedWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *bufferedWriter) Write(data []byte) (int, error) {
+	return w.body.Write(data)
+}
+
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	return w.body.WriteString(s)
+}
+
+// ConcentrationColumns добавляет в строки *total-отчёта колонки концентрации
+// округа, а в строку Summary — по стране, если запрос пришёл с ?concentration=true.
+// Обработчики отчётов при этом не меняются. Индексы считаются по рынку отчёта
+// за те же месяцы, прошлый год — за те же месяцы прошлого года
+func ConcentrationColumns(route reportRoute) gin.HandlerFunc {
+	spec := route.MarketSpec()
+
+	return func(c *gin.Context) {
+		if c.Query(ConcentrationParam) != "true" {
+			c.Next()
+			return
+		}
+
+		writer := c.Writer
+		buffered := &bufferedWriter{ResponseWriter: writer}
+		c.Writer = buffered
+		c.Next()
+		c.Writer = writer
+
+		if writer.Status() != http.StatusOK {
+			_, _ = writer.Write(buffered.body.Bytes())
+			return
+		}
+
+		// Заголовки ещё не отправлены, поэтому ошибку можно отдать вместо отчёта
+		enriched, err := withConcentration(c, spec, buffered.body.Bytes())
+		if err != nil {
+			utils.RespondError(c, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to compute concentration", err)
+			return
+		}
+		_, _ = writer.Write(enriched)
+	}
+}
+
+// withConcentration дописывает колонки концентрации в строки ответа отчёта
+func withConcentration(c *gin.Context, spec reports.MarketSpec, body []byte) ([]byte, error) {
+	indices, err := reports.DistrictConcentration(c.Request.Context(), spec)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Data *orderedmap.OrderedMap[string, []*orderedmap.OrderedMap[string, any]] `json:"data"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, err
+	}
+
+	for pair := response.Data.Oldest(); pair != nil; pair = pair.Next() {
+		area := pair.Key
+		if area == "Summary" {
+			area = reports.CountryArea
+		}
+		index, ok := indices[area]
+
+		for _, row := range pair.Value {
+			values := []any{nil, nil, nil, nil, nil, nil}
+			if ok {
+				values = []any{index.HHI, index.CR3, index.CR5, index.HHIChange, index.CR3Change, index.CR5Change}
+			}
+			for i, column := range concentrationColumns {
+				row.Set(column, values[i])
+			}
+		}
+	}
+
+	return json.Marshal(response)
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"truck-analytics-platform/internal/db"
+	"truck-analytics-platform/internal/db/dbtest"
+)
+
+func TestTotalReportsAddConcentrationColumns(t *testing.T) {
+	spec := loadSpec(t)
+	db.SetQuerier(&dbtest.Querier{
+		Rows:      dbtest.DistrictRows(12),
+		Responses: []dbtest.Response{{Contains: "::int AS year", Rows: salesRows}},
+	})
+	t.Cleanup(func() { db.SetQuerier(nil) })
+
+	router := NewRouter()
+	for _, route := range reportRoutes {
+		if !route.Total || route.Segment != "ldt" {
+			continue
+		}
+
+		t.Run(route.Path(), func(t *testing.T) {
+			path := route.Path() + "?" + ConcentrationParam + "=true"
+			body := serve(t, router, http.MethodGet, path, "", http.StatusOK)
+			if err := validate(spec, responseSchema(t, spec, path, "get", "200"), body, "$"); err != nil {
+				t.Fatal(err)
+			}
+
+			data := body.(map[string]any)["data"].(map[string]any)
+			summary := data["Summary"].([]any)[0].(map[string]any)
+			if summary["hhi"] == nil || summary["cr3"] == nil {
+				t.Errorf("Summary has no concentration: %v", summary)
+			}
+			// В фикстуре у Северо-Запада нет продаж — индексы пустые
+			northwest := data["North West"].([]any)[0].(map[string]any)
+			if _, ok := northwest["hhi"]; !ok || northwest["hhi"] != nil {
+				t.Errorf("Northwest hhi = %v, want null", northwest["hhi"])
+			}
+
+			// Новые колонки идут после total, порядок колонок отчёта сохраняется
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+			raw := w.Body.String()
+			if strings.Index(raw, `"total"`) > strings.Index(raw, `"hhi"`) {
+				t.Errorf("hhi comes before total: %s", raw)
+			}
+		})
+	}
+
+	// Без параметра ответ не меняется
+	body := serve(t, router, http.MethodGet, reportRoutes[len(reportRoutes)-1].Path(), "", http.StatusOK)
+	raw, _ := json.Marshal(body)
+	if strings.Contains(string(raw), `"hhi"`) {
+		t.Error("concentration columns without the parameter")
+	}
+}
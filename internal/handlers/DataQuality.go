@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"maps"
+	"net/http"
+	"slices"
+
+	"truck-analytics-platform/internal/handlers/utils"
+	"truck-analytics-platform/internal/quality"
+	"truck-analytics-platform/internal/reports"
+	sb "truck-analytics-platform/internal/sqlbuilder"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DataQualityPath — отчёт о качестве загруженных данных
+const DataQualityPath = APIPrefix + "/data-quality"
+
+// DataQualityHandler отдаёт последний отчёт о качестве данных
+func DataQualityHandler(c *gin.Context) {
+	report, ok := quality.Latest()
+	if !ok {
+		utils.RespondError(c, http.StatusNotFound, utils.CodeNotFound, "Data quality has not been checked yet", nil)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// RunDataQualityHandler перепроверяет все таблицы, не дожидаясь следующей загрузки
+func RunDataQualityHandler(c *gin.Context) {
+	report, err := quality.Run(c.Request.Context())
+	if err != nil {
+		utils.RespondError(c, http.StatusServiceUnavailable, utils.CodeDatabaseUnavailable, "Database is not ready", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// QualityGate не публикует отчёт, если в данных его таблицы найдены ошибки
+// и блокировка включена
+func QualityGate(table sb.Table) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if quality.Blocked(table) {
+			dataNotPublished(c)
+			return
+		}
+		c.Next()
+	}
+}
+
+// MarketQualityGate — QualityGate для выборок по рынку из ?segment, которые
+// могут читать любой год рынка: блокирует, если ошибки есть в какой-то из
+// таблиц рынка. Без сегмента, а также для выборок с фильтром в теле
+// запроса (pivot, GraphQL) проверяются все таблицы
+func MarketQualityGate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tables := sb.Tables
+		if market, ok := reports.Markets[c.Query("segment")]; ok {
+			tables = slices.Collect(maps.Values(market.Tables))
+		}
+		for _, table := range tables {
+			if quality.Blocked(table) {
+				dataNotPublished(c)
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+func dataNotPublished(c *gin.Context) {
+	utils.RespondError(c, http.StatusServiceUnavailable, utils.CodeDataNotPublished,
+		"Report data failed quality checks and is not published", nil)
+}
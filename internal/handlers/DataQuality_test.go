@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"truck-analytics-platform/internal/db"
+	"truck-analytics-platform/internal/db/dbtest"
+	"truck-analytics-platform/internal/handlers/utils"
+)
+
+func TestQualityGateBlocksReports(t *testing.T) {
+	t.Setenv("DATA_QUALITY_BLOCKING", "true")
+
+	// Строки не подходят ни одной проверке, поэтому каждая таблица получает ошибку check failed
+	db.SetQuerier(&dbtest.Querier{Rows: []dbtest.Row{{Ints: []int{1}}}})
+	runQuality(t)
+	t.Cleanup(func() {
+		db.SetQuerier(&dbtest.Querier{})
+		runQuality(t)
+		db.SetQuerier(nil)
+	})
+
+	route := reportRoutes[0]
+	paths := []string{
+		route.Path(), route.LegacyPath(),
+		ConcentrationPath + "?segment=ldt&year=2024",
+		RankingsPath + "/brands?segment=tractors4x2&year=2024",
+		ForecastPath + "?segment=mdt&year=2024",
+	}
+	for _, path := range paths {
+		body := serve(t, NewRouter(), http.MethodGet, path, "", http.StatusServiceUnavailable)
+		code := body.(map[string]any)["error"].(map[string]any)["code"]
+		if code != utils.CodeDataNotPublished {
+			t.Errorf("%s: error code %v, want %s", path, code, utils.CodeDataNotPublished)
+		}
+	}
+}
+
+func TestDataQualityRunRequiresAdmin(t *testing.T) {
+	db.SetQuerier(&dbtest.Querier{})
+	t.Cleanup(func() { db.SetQuerier(nil) })
+
+	serve(t, NewRouter(), http.MethodPost, DataQualityPath+"/run", "", http.StatusUnauthorized)
+}
@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"truck-analytics-platform/internal/dealers"
+	"truck-analytics-platform/internal/handlers/utils"
+	"truck-analytics-platform/internal/reports"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DealersPath — дилеры и их территории
+const DealersPath = APIPrefix + "/dealers"
+
+// DealerTerritoriesPath — регистрации и проникновение бренда по территориям дилеров
+const DealerTerritoriesPath = APIPrefix + "/dealer-territories"
+
+// ListDealersHandler отдаёт всех дилеров с территориями
+func ListDealersHandler(c *gin.Context) {
+	list, err := dealers.List(c.Request.Context())
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to load dealers", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": list})
+}
+
+// GetDealerHandler отдаёт одного дилера
+func GetDealerHandler(c *gin.Context) {
+	id, ok := pathID(c)
+	if !ok {
+		return
+	}
+
+	dealer, ok, err := dealers.Get(c.Request.Context(), id)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to load dealer", err)
+		return
+	}
+	if !ok {
+		utils.RespondError(c, http.StatusNotFound, utils.CodeNotFound, "Dealer not found", nil)
+		return
+	}
+
+	c.JSON(http.StatusOK, dealer)
+}
+
+// CreateDealerHandler заводит дилера. Идентификатор выдаёт БД
+func CreateDealerHandler(c *gin.Context) {
+	dealer, ok := bindDealer(c)
+	if !ok {
+		return
+	}
+
+	id, err := dealers.Create(c.Request.Context(), dealer)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to save dealer", err)
+		return
+	}
+	dealer.ID = id
+
+	c.JSON(http.StatusCreated, dealer)
+}
+
+// PutDealerHandler заменяет дилера вместе с территорией
+func PutDealerHandler(c *gin.Context) {
+	id, ok := pathID(c)
+	if !ok {
+		return
+	}
+	dealer, ok := bindDealer(c)
+	if !ok {
+		return
+	}
+	dealer.ID = id
+
+	updated, err := dealers.Update(c.Request.Context(), dealer)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to save dealer", err)
+		return
+	}
+	if !updated {
+		utils.RespondError(c, http.StatusNotFound, utils.CodeNotFound, "Dealer not found", nil)
+		return
+	}
+
+	c.JSON(http.StatusOK, dealer)
+}
+
+// DeleteDealerHandler удаляет дилера с территорией
+func DeleteDealerHandler(c *gin.Context) {
+	id, ok := pathID(c)
+	if !ok {
+		return
+	}
+
+	deleted, err := dealers.Delete(c.Request.Context(), id)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to delete dealer", err)
+		return
+	}
+	if !deleted {
+		utils.RespondError(c, http.StatusNotFound, utils.CodeNotFound, "Dealer not found", nil)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// DealerTerritoriesHandler отдаёт рынок и проникновение бренда на территории
+// каждого дилера: ?segment=tractors4x2&year=2024&brand=FOTON
+func DealerTerritoriesHandler(c *gin.Context) {
+	spec, ok := marketSpec(c, reports.LevelRegion)
+	if !ok {
+		return
+	}
+	if err := spec.Validate(reports.LevelRegion); err != nil {
+		utils.RespondError(c, http.StatusBadRequest, utils.CodeBadRequest, err.Error(), nil)
+		return
+	}
+	brand := c.DefaultQuery("brand", "FOTON")
+
+	list, period, err := dealers.Territories(c.Request.Context(), spec, brand)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to compute dealer territories", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"period": period, "brand": brand, "data": list})
+}
+
+// pathID читает числовой идентификатор из пути
+func pathID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id < 1 {
+		utils.RespondError(c, http.StatusBadRequest, utils.CodeBadRequest, "id must be a positive integer", nil)
+		return 0, false
+	}
+	return id, true
+}
+
+// bindDealer читает и проверяет дилера из тела запроса
+func bindDealer(c *gin.Context) (dealers.Dealer, bool) {
+	var dealer dealers.Dealer
+	if err := c.ShouldBindJSON(&dealer); err != nil {
+		utils.RespondError(c, http.StatusBadRequest, utils.CodeBadRequest, "Invalid dealer", nil)
+		return dealer, false
+	}
+	if err := dealer.Validate(); err != nil {
+		utils.RespondError(c, http.StatusBadRequest, utils.CodeBadRequest, err.Error(), nil)
+		return dealer, false
+	}
+	if dealer.Brands == nil {
+		dealer.Brands = []string{}
+	}
+	return dealer, true
+}
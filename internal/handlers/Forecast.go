@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"net/http"
+
+	"truck-analytics-platform/internal/forecast"
+	"truck-analytics-platform/internal/handlers/utils"
+	"truck-analytics-platform/internal/reports"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ForecastPath — прогноз продаж на полный год по факту с начала года
+const ForecastPath = APIPrefix + "/forecast"
+
+// ForecastHandler отдаёт прогноз года по сегменту, брендам и округам с 95% интервалом:
+// ?segment=ldt&year=2024&level=district&brand=FOTON&model=profile
+func ForecastHandler(c *gin.Context) {
+	spec := forecast.Spec{
+		Segment: c.Query("segment"),
+		Level:   c.DefaultQuery("level", reports.LevelCountry),
+		Brands:  c.QueryArray("brand"),
+		Model:   c.DefaultQuery("model", forecast.Profile),
+	}
+	for _, param := range []struct {
+		name  string
+		value *int
+	}{
+		{"year", &spec.Year},
+		{"as_of", &spec.AsOf},
+	} {
+		var err error
+		if *param.value, err = queryInt(c, param.name, 0); err != nil {
+			utils.RespondError(c, http.StatusBadRequest, utils.CodeBadRequest, err.Error(), nil)
+			return
+		}
+	}
+	if err := spec.Validate(); err != nil {
+		utils.RespondError(c, http.StatusBadRequest, utils.CodeBadRequest, err.Error(), nil)
+		return
+	}
+
+	areas, period, err := forecast.Run(c.Request.Context(), spec)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to build forecast", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"period": period, "data": areas})
+}
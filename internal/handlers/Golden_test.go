@@ -0,0 +1,645 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"truck-analytics-platform/internal/db"
+	"truck-analytics-platform/internal/db/dbtest"
+	"truck-analytics-platform/internal/handlers/utils"
+	"truck-analytics-platform/internal/quality"
+	"truck-analytics-platform/internal/reports"
+)
+
+// goldenRequest — запрос к маршруту и строки, которые фейковая БД
+// вернёт на его запросы независимо от текста SQL
+type goldenRequest struct {
+	// path — путь запроса для маршрутов с параметрами
+	path   string
+	body   string
+	header map[string]string
+	rows   []dbtest.Row
+	// responses — свои наборы строк для отдельных запросов обработчика
+	responses []dbtest.Response
+	status    int
+	// setup готовит состояние, от которого зависит ответ
+	setup func(t *testing.T)
+	// name — имя golden-файла; у алиасов совпадает с именем пути-преемника
+	name string
+}
+
+// skipGolden — маршруты, ответ которых не детерминирован
+var skipGolden = map[string]string{
+	"GET /metrics": "runtime metrics change between runs",
+}
+
+// pivotRows — ответ сводной таблицы округ × бренд с итогами:
+// значение округа, бренд, маска GROUPING, продажи
+var pivotRows = []dbtest.Row{
+	{Strings: []string{"Уральский Федеральный Округ", "FOTON"}, Ints: []int{0, 12}},
+	{Strings: []string{"Уральский Федеральный Округ", "SHACMAN"}, Ints: []int{0, 5}},
+	{Strings: []string{"Центральный Федеральный Округ", "FOTON"}, Ints: []int{0, 30}},
+	{Strings: []string{"", "FOTON"}, Ints: []int{1, 42}},
+	{Strings: []string{"", "SHACMAN"}, Ints: []int{1, 5}},
+}
+
+// brandRows — справочник брендов: id, название, группа, вариант написания
+var brandRows = []dbtest.Row{
+	{Strings: []string{"HOWO", "Howo", "SINOTRUK", "SINOTRUK HOWO"}},
+	{Strings: []string{"SINOTRUK", "Sinotruk", "", ""}},
+	{Strings: []string{"SITRAK", "Sitrak", "SINOTRUK", "SINOTRUK SITRAK"}},
+	{Strings: []string{"SITRAK", "Sitrak", "SINOTRUK", "Sitrak/SINOTRUK"}},
+}
+
+// modelResponses — таблица с колонкой Model и рейтинг моделей по округам:
+// округ, бренд, модель, продажи модели, продажи сегмента в округе, место
+var modelResponses = []dbtest.Response{
+	{Contains: "information_schema.columns", Rows: []dbtest.Row{{Strings: []string{"Model"}}}},
+	{Contains: "model_rank", Rows: []dbtest.Row{
+		{Strings: []string{"Уральский Федеральный Округ", "SITRAK", "C7H"}, Ints: []int{40, 120, 1}},
+		{Strings: []string{"Уральский Федеральный Округ", "SHACMAN", "X3000"}, Ints: []int{25, 120, 2}},
+		{Strings: []string{"Центральный Федеральный Округ", "FOTON", "Auman EST"}, Ints: []int{33, 99, 1}},
+	}},
+}
+
+// salesRows — продажи брендов по регионам за два года:
+// округ, регион, бренд, год, продажи
+var salesRows = []dbtest.Row{
+	{Strings: []string{"Центральный Федеральный Округ", "Москва", "FOTON"}, Ints: []int{2023, 40}},
+	{Strings: []string{"Центральный Федеральный Округ", "Москва", "SITRAK"}, Ints: []int{2023, 60}},
+	{Strings: []string{"Центральный Федеральный Округ", "Тульская область", "FOTON"}, Ints: []int{2023, 10}},
+	{Strings: []string{"Уральский Федеральный Округ", "Свердловская область", "SHACMAN"}, Ints: []int{2023, 30}},
+	{Strings: []string{"Центральный Федеральный Округ", "Москва", "FOTON"}, Ints: []int{2024, 70}},
+	{Strings: []string{"Центральный Федеральный Округ", "Москва", "SITRAK"}, Ints: []int{2024, 50}},
+	{Strings: []string{"Центральный Федеральный Округ", "Тульская область", "SHACMAN"}, Ints: []int{2024, 5}},
+	{Strings: []string{"Уральский Федеральный Округ", "Свердловская область", "FOTON"}, Ints: []int{2024, 15}},
+	{Strings: []string{"Уральский Федеральный Округ", "Свердловская область", "SHACMAN"}, Ints: []int{2024, 25}},
+}
+
+// monthlyRows — помесячные продажи двух брендов в двух регионах за 10 месяцев
+// 2023 и 2024 года: округ, регион, бренд, год, месяц, продажи. В 2024 году FOTON
+// растёт, SITRAK повторяет прошлый год
+func monthlyRows() []dbtest.Row {
+	var rows []dbtest.Row
+	for _, year := range []int{2023, 2024} {
+		for month := 1; month <= 10; month++ {
+			foton := 20 + 2*month
+			if year == 2024 {
+				foton += 5 + month
+			}
+			rows = append(rows,
+				dbtest.Row{Strings: []string{"Центральный Федеральный Округ", "Москва", "FOTON"}, Ints: []int{year, month, foton}},
+				dbtest.Row{Strings: []string{"Уральский Федеральный Округ", "Свердловская область", "SITRAK"}, Ints: []int{year, month, 10 + month%3}},
+			)
+		}
+	}
+	return rows
+}
+
+// alertRows — сохранённые алерты: вид, сегмент, округ, регион, бренд,
+// сообщение, время; id, год, месяц, значение, база
+var alertRows = []dbtest.Row{
+	{
+		Strings: []string{"share_drop", "tractors4x2", "Central", "Moscow", "FOTON",
+			"FOTON share in Moscow (Central) fell from 40.0% to 25.0% in 10/2024 (-15.0 pp)", "2024-11-05T08:00:00Z"},
+		Ints: []int{2, 2024, 10, 25, 40},
+	},
+	{
+		Strings: []string{"new_entrant", "ldt", "Ural", "Sverdlovsk Region", "SITRAK",
+			"SITRAK registered 3 trucks in Sverdlovsk Region (Ural) for the first time in 10/2024", "2024-11-05T08:00:00Z"},
+		Ints: []int{1, 2024, 10, 3, 0},
+	},
+}
+
+// dealerRows — дилеры по строке на часть территории: название, город,
+// регион, бренды через запятую, регион и город территории; id
+var dealerRows = []dbtest.Row{
+	{Strings: []string{"Foton Center", "Moscow", "Moscow", "FOTON", "Moscow", ""}, Ints: []int{1}},
+	{Strings: []string{"Foton Center", "Moscow", "Moscow", "FOTON", "Tula Region", "Тульская область"}, Ints: []int{1}},
+	{Strings: []string{"Ural Trucks", "Yekaterinburg", "Sverdlovsk Region", "FOTON,SHACMAN", "Chelyabinsk Region", ""}, Ints: []int{2}},
+	{Strings: []string{"Ural Trucks", "Yekaterinburg", "Sverdlovsk Region", "FOTON,SHACMAN", "Sverdlovsk Region", ""}, Ints: []int{2}},
+}
+
+// targetRows — планы FOTON по ldt на 2024 год: сегмент, округ, бренд;
+// год, месяц, объём, доля
+func targetRows() []dbtest.Row {
+	var rows []dbtest.Row
+	for _, district := range []struct {
+		name          string
+		volume, share int
+	}{{"Central", 40, 60}, {"Ural", 5, 10}} {
+		for month := 1; month <= 12; month++ {
+			rows = append(rows, dbtest.Row{
+				Strings: []string{"ldt", district.name, "FOTON"},
+				Ints:    []int{2024, month, district.volume, district.share},
+			})
+		}
+	}
+	return rows
+}
+
+// viewRows — сохранённые виды: владелец, токен, название, сегмент, уровень,
+// округа и бренды через запятую, время; id, год, месяцы, флаг по умолчанию
+var viewRows = []dbtest.Row{
+	{
+		Strings: []string{"analyst", "5f0c6b1e2a9d4c3b8e7f6a5d4c3b2a19", "LDT Central", "ldt", "district", "Central", "FOTON,SITRAK", "2024-11-05T08:00:00Z"},
+		Ints:    []int{1, 2024, 0, 9, 1},
+	},
+	{
+		Strings: []string{"analyst", "9a8b7c6d5e4f30211a2b3c4d5e6f7081", "Tractors by region", "tractors4x2", "region", "", "", "2024-11-05T08:00:00Z"},
+		Ints:    []int{2, 2024, 1, 10, 0},
+	},
+}
+
+// dashboardResponses — дашборд из двух видов: проверка владельца видов,
+// запись, сам дашборд (владелец, токен, название, виды, время; id, флаг) и его виды
+var dashboardResponses = []dbtest.Response{
+	{Contains: "count(*) FROM saved_views", Rows: []dbtest.Row{{Ints: []int{2}}}},
+	{Contains: "JOIN saved_views", Rows: viewRows},
+	{Contains: "INSERT INTO dashboards", Rows: []dbtest.Row{{Ints: []int{1}}}},
+	{Contains: "UPDATE dashboards", Rows: []dbtest.Row{{Ints: []int{1}}}},
+	{Contains: "FROM dashboards d", Rows: []dbtest.Row{{
+		Strings: []string{"analyst", "0d1e2f3a4b5c6d7e8f90a1b2c3d4e5f6", "Monthly review", "1,2", "2024-11-05T08:00:00Z"},
+		Ints:    []int{1, 1},
+	}}},
+}
+
+const viewGoldenBody = `{"name":"LDT Central","segment":"ldt","year":2024,"month_to":9,"level":"district",` +
+	`"districts":["Central"],"brands":["FOTON","SITRAK"],"default":true}`
+
+const dealerGoldenBody = `{"name":"Ural Trucks","city":"Yekaterinburg","region":"Sverdlovsk Region","brands":["FOTON","SHACMAN"],` +
+	`"territory":[{"region":"Sverdlovsk Region"},{"region":"Chelyabinsk Region","city":"Челябинск"}]}`
+
+const graphQLGoldenQuery = `{"query":"{ registrations(filter: {year: 2024, segment: tractors4x2, monthTo: 9}) { name total brands { brand quantity } districts { name total regions { name total cities { name total brands { brand quantity } } } } } }"}`
+
+// annotationRows — заметки: сегмент, округ, регион, бренд, текст, автор,
+// время создания и изменения; id, год, месяц
+var annotationRows = []dbtest.Row{
+	{
+		Strings: []string{"tractors4x2", "Siberia", "Omsk Region", "FOTON", "FOTON dip due to dealer closure in Omsk", "analyst",
+			"2024-09-10T08:00:00Z", "2024-09-10T08:00:00Z"},
+		Ints: []int{1, 2024, 8},
+	},
+	{
+		Strings: []string{"", "", "", "", "Registration office outage, data is incomplete", "analyst",
+			"2024-11-05T08:00:00Z", "2024-11-05T08:00:00Z"},
+		Ints: []int{2, 2024, 10},
+	},
+}
+
+const annotationGoldenBody = `{"segment":"tractors4x2","year":2024,"month":8,"region":"Omsk Region","brand":"FOTON",` +
+	`"text":"FOTON dip due to dealer closure in Omsk"}`
+
+// auditRows — журнал аудита: действие, пользователь, IP, метод, путь,
+// параметры, время; id, статус
+var auditRows = []dbtest.Row{
+	{
+		Strings: []string{"data.export", "foton-trucks", "10.0.0.7", "POST", "/api/v1/pivot", `{"export": "pivot", "rows": 9}`,
+			"2024-11-05T09:30:00Z"},
+		Ints: []int{2, 200},
+	},
+	{
+		Strings: []string{"auth.login", "foton-trucks", "10.0.0.7", "POST", "/api/v1/auth/token", `{"body": {"login": "foton-trucks"}}`,
+			"2024-11-05T09:00:00Z"},
+		Ints: []int{1, 401},
+	},
+}
+
+// apiKeyRows — API-ключи: имя, префикс, права, кто выдал, время выдачи,
+// срок действия и последнего использования (пусто — NULL); id
+var apiKeyRows = []dbtest.Row{
+	{
+		Strings: []string{"powerbi", "tap_3f9c2a71", "read:segments,export", "foton-trucks", "2024-11-01T08:00:00Z",
+			"2025-11-01T00:00:00Z", "2024-11-05T06:00:00Z"},
+		Ints: []int{1},
+	},
+	{
+		Strings: []string{"notebooks", "tap_b04e1d96", "read:segments", "foton-trucks", "2024-11-02T08:00:00Z", "", ""},
+		Ints:    []int{2},
+	},
+}
+
+const pivotGoldenSpec = `{"rows":["district"],"columns":["brand"],"years":[2024],"segment":"tractors4x2","subtotals":true}`
+
+// goldenRequests описывает запрос к каждому маршруту роутера
+func goldenRequests(t *testing.T) map[string]goldenRequest {
+	t.Helper()
+
+	token, err := utils.CreateJWT("foton-trucks", "foton1996")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	requests := map[string]goldenRequest{
+		"GET /healthz": {status: http.StatusOK},
+		// Пула БД в тестах нет, поэтому /readyz стабильно отвечает 503
+		"GET /readyz":       {status: http.StatusServiceUnavailable},
+		"GET /openapi.json": {status: http.StatusOK},
+		"GET /docs":         {status: http.StatusOK},
+
+		"POST " + GraphQLPath: {body: graphQLGoldenQuery, rows: dbtest.RegistrationRows(), status: http.StatusOK},
+		"POST " + PivotPath: {
+			body:      pivotGoldenSpec,
+			rows:      pivotRows,
+			responses: []dbtest.Response{{Contains: "FROM annotations", Rows: annotationRows}},
+			status:    http.StatusOK,
+		},
+		"GET " + TopModelsPath: {
+			path:      TopModelsPath + "?segment=tractors4x2&year=2024&limit=2",
+			responses: modelResponses,
+			status:    http.StatusOK,
+		},
+
+		"GET " + ConcentrationPath: {
+			path:   ConcentrationPath + "?segment=tractors6x4&year=2024",
+			rows:   salesRows,
+			status: http.StatusOK,
+		},
+		"GET " + RankingsPath + "/regions": {
+			path:   RankingsPath + "/regions?brand=FOTON&segment=tractors4x2&year=2024&sort=growth",
+			rows:   salesRows,
+			status: http.StatusOK,
+		},
+		"GET " + RankingsPath + "/brands": {
+			path:   RankingsPath + "/brands?segment=mdt&year=2024&sort=share&limit=2",
+			rows:   salesRows,
+			status: http.StatusOK,
+		},
+		"GET " + RankingsPath + "/share-changes": {
+			path:   RankingsPath + "/share-changes?segment=ldt&year=2024&limit=1",
+			rows:   salesRows,
+			status: http.StatusOK,
+		},
+		"GET " + WhitespacePath: {
+			path:   WhitespacePath + "?brand=SITRAK&segment=ldt&year=2024",
+			rows:   salesRows,
+			status: http.StatusOK,
+		},
+
+		"GET " + ForecastPath: {
+			path:   ForecastPath + "?segment=ldt&year=2024&level=district&as_of=9",
+			rows:   monthlyRows(),
+			status: http.StatusOK,
+		},
+
+		"GET " + AlertsPath: {path: AlertsPath + "?year=2024", rows: alertRows, status: http.StatusOK},
+		"POST " + AlertsPath + "/run": {
+			header: map[string]string{"Authorization": token},
+			responses: []dbtest.Response{
+				{Contains: "INSERT INTO alerts", Rows: alertRows[:1]},
+				// SHACMAN впервые продаёт в Москве в октябре 2024 года
+				{Contains: "total_sales", Rows: append(monthlyRows(), dbtest.Row{
+					Strings: []string{"Центральный Федеральный Округ", "Москва", "SHACMAN"}, Ints: []int{2024, 10, 30},
+				})},
+			},
+			status: http.StatusOK,
+		},
+
+		// На пустом наборе проверки качества проходят без замечаний
+		"POST " + DataQualityPath + "/run": {header: map[string]string{"Authorization": token}, status: http.StatusOK},
+		"GET " + DataQualityPath:           {status: http.StatusOK, setup: runQuality},
+
+		"GET " + DealersPath:          {rows: dealerRows, status: http.StatusOK},
+		"GET " + DealersPath + "/:id": {path: DealersPath + "/2", rows: dealerRows[2:], status: http.StatusOK},
+		"POST " + DealersPath: {
+			body:   dealerGoldenBody,
+			header: map[string]string{"Authorization": token},
+			rows:   []dbtest.Row{{Ints: []int{3}}},
+			status: http.StatusCreated,
+		},
+		"PUT " + DealersPath + "/:id": {
+			path:   DealersPath + "/2",
+			body:   dealerGoldenBody,
+			header: map[string]string{"Authorization": token},
+			rows:   []dbtest.Row{{Ints: []int{2}}},
+			status: http.StatusOK,
+		},
+		"DELETE " + DealersPath + "/:id": {
+			path:   DealersPath + "/2",
+			header: map[string]string{"Authorization": token},
+			rows:   []dbtest.Row{{Ints: []int{2}}},
+			status: http.StatusNoContent,
+		},
+		"GET " + DealerTerritoriesPath: {
+			path: DealerTerritoriesPath + "?segment=tractors4x2&year=2024",
+			responses: []dbtest.Response{
+				{Contains: "FROM dealers", Rows: dealerRows},
+				{Contains: "total_sales", Rows: dbtest.RegistrationRows()},
+			},
+			status: http.StatusOK,
+		},
+
+		"GET " + TargetsPath: {path: TargetsPath + "?segment=ldt&year=2024", rows: targetRows()[:3], status: http.StatusOK},
+		"POST " + TargetsPath: {
+			body: `[{"segment":"ldt","year":2025,"month":1,"district":"Central","volume":45},` +
+				`{"segment":"ldt","year":2025,"month":1,"district":"Ural","brand":"SITRAK","share":12.5}]`,
+			header: map[string]string{"Authorization": token},
+			status: http.StatusOK,
+		},
+		"DELETE " + TargetsPath: {
+			path:   TargetsPath + "?segment=ldt&year=2024",
+			header: map[string]string{"Authorization": token},
+			rows:   []dbtest.Row{{Ints: []int{1}}, {Ints: []int{2}}},
+			status: http.StatusNoContent,
+		},
+		"GET " + TargetProgressPath: {
+			path: TargetProgressPath + "?segment=ldt&year=2024&month_to=9",
+			responses: []dbtest.Response{
+				{Contains: "FROM targets", Rows: targetRows()},
+				{Contains: "total_sales", Rows: monthlyRows()},
+			},
+			status: http.StatusOK,
+		},
+
+		"GET " + ViewsPath:          {header: map[string]string{"Authorization": token}, rows: viewRows, status: http.StatusOK},
+		"GET " + ViewsPath + "/:id": {path: ViewsPath + "/1", header: map[string]string{"Authorization": token}, rows: viewRows[:1], status: http.StatusOK},
+		"POST " + ViewsPath: {
+			body:   viewGoldenBody,
+			header: map[string]string{"Authorization": token},
+			rows:   viewRows[:1],
+			status: http.StatusCreated,
+		},
+		"PUT " + ViewsPath + "/:id": {
+			path:   ViewsPath + "/1",
+			body:   viewGoldenBody,
+			header: map[string]string{"Authorization": token},
+			rows:   viewRows[:1],
+			status: http.StatusOK,
+		},
+		"DELETE " + ViewsPath + "/:id": {
+			path:   ViewsPath + "/1",
+			header: map[string]string{"Authorization": token},
+			rows:   []dbtest.Row{{Ints: []int{1}}},
+			status: http.StatusNoContent,
+		},
+		"GET " + SharedPath + "/views/:token": {
+			path:   SharedPath + "/views/5f0c6b1e2a9d4c3b8e7f6a5d4c3b2a19",
+			rows:   viewRows[:1],
+			status: http.StatusOK,
+		},
+		"GET " + DashboardsPath:          {header: map[string]string{"Authorization": token}, responses: dashboardResponses, status: http.StatusOK},
+		"GET " + DashboardsPath + "/:id": {path: DashboardsPath + "/1", header: map[string]string{"Authorization": token}, responses: dashboardResponses, status: http.StatusOK},
+		"POST " + DashboardsPath: {
+			body:      `{"name":"Monthly review","default":true,"view_ids":[1,2]}`,
+			header:    map[string]string{"Authorization": token},
+			responses: dashboardResponses,
+			status:    http.StatusCreated,
+		},
+		"PUT " + DashboardsPath + "/:id": {
+			path:      DashboardsPath + "/1",
+			body:      `{"name":"Monthly review","default":true,"view_ids":[1,2]}`,
+			header:    map[string]string{"Authorization": token},
+			responses: dashboardResponses,
+			status:    http.StatusOK,
+		},
+		"DELETE " + DashboardsPath + "/:id": {
+			path:   DashboardsPath + "/1",
+			header: map[string]string{"Authorization": token},
+			rows:   []dbtest.Row{{Ints: []int{1}}},
+			status: http.StatusNoContent,
+		},
+		"GET " + SharedPath + "/dashboards/:token": {
+			path:      SharedPath + "/dashboards/0d1e2f3a4b5c6d7e8f90a1b2c3d4e5f6",
+			responses: dashboardResponses,
+			status:    http.StatusOK,
+		},
+
+		"GET " + AnnotationsPath: {path: AnnotationsPath + "?segment=tractors4x2&year=2024", rows: annotationRows, status: http.StatusOK},
+		"POST " + AnnotationsPath: {
+			body:   annotationGoldenBody,
+			header: map[string]string{"Authorization": token},
+			rows:   annotationRows[:1],
+			status: http.StatusCreated,
+		},
+		"PUT " + AnnotationsPath + "/:id": {
+			path:   AnnotationsPath + "/1",
+			body:   annotationGoldenBody,
+			header: map[string]string{"Authorization": token},
+			rows:   annotationRows[:1],
+			status: http.StatusOK,
+		},
+		"DELETE " + AnnotationsPath + "/:id": {
+			path:   AnnotationsPath + "/1",
+			header: map[string]string{"Authorization": token},
+			rows:   annotationRows[:1],
+			status: http.StatusNoContent,
+		},
+
+		"GET " + AuditPath: {
+			path:   AuditPath + "?action=auth.login&from=2024-11-01",
+			header: map[string]string{"Authorization": token},
+			rows:   auditRows,
+			status: http.StatusOK,
+		},
+
+		"GET " + APIKeysPath: {header: map[string]string{"Authorization": token}, rows: apiKeyRows, status: http.StatusOK},
+		// Секрет ключа случаен, поэтому для выдачи ключа фиксируется отказ
+		"POST " + APIKeysPath: {
+			body:   `{"name":"powerbi","scopes":["read:segments","write"]}`,
+			header: map[string]string{"Authorization": token},
+			status: http.StatusBadRequest,
+		},
+		"DELETE " + APIKeysPath + "/:id": {
+			path:   APIKeysPath + "/1",
+			header: map[string]string{"Authorization": token},
+			rows:   apiKeyRows[:1],
+			status: http.StatusNoContent,
+		},
+
+		"GET " + BrandsPath:          {rows: brandRows, status: http.StatusOK},
+		"GET " + BrandsPath + "/:id": {path: BrandsPath + "/SITRAK", rows: brandRows, status: http.StatusOK},
+		"PUT " + BrandsPath + "/:id": {
+			path:   BrandsPath + "/HOWO",
+			body:   `{"name":"Howo","parent_id":"SINOTRUK","aliases":["SINOTRUK HOWO","HOWO TRUCKS"]}`,
+			header: map[string]string{"Authorization": token},
+			rows:   brandRows,
+			status: http.StatusOK,
+		},
+		"DELETE " + BrandsPath + "/:id": {
+			path:   BrandsPath + "/SITRAK",
+			header: map[string]string{"Authorization": token},
+			rows:   brandRows,
+			status: http.StatusNoContent,
+		},
+
+		// Токен содержит время истечения, поэтому для выдачи токена фиксируется отказ
+		"POST " + APIPrefix + "/auth/token": {body: `{"login":"x","password":"y"}`, status: http.StatusUnauthorized},
+		"GET " + APIPrefix + "/auth/verify": {header: map[string]string{"Authorization": token}, status: http.StatusOK},
+	}
+	requests["POST /auth"] = withName(requests["POST "+APIPrefix+"/auth/token"], "POST "+APIPrefix+"/auth/token")
+	requests["GET /verify-token"] = withName(requests["GET "+APIPrefix+"/auth/verify"], "GET "+APIPrefix+"/auth/verify")
+
+	for _, route := range reportRoutes {
+		rows := dbtest.RegionRows(12)
+		if route.Total {
+			rows = dbtest.DistrictRows(12)
+		}
+
+		request := goldenRequest{rows: rows, status: http.StatusOK}
+		requests["GET "+route.Path()] = request
+		requests["GET "+route.LegacyPath()] = withName(request, "GET "+route.Path())
+	}
+
+	return requests
+}
+
+func runQuality(t *testing.T) {
+	t.Helper()
+	if _, err := quality.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func withName(r goldenRequest, name string) goldenRequest {
+	r.name = name
+	return r
+}
+
+// timestamp — время в ответах меняется от запуска к запуску и в golden-файлах заменяется
+var timestamp = regexp.MustCompile(`"\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:\d{2})"`)
+
+// goldenFile: "GET /api/v1/pivot" -> responses/GET_api_v1_pivot.json
+func goldenFile(name, contentType string) string {
+	file := strings.NewReplacer(" /", "_", "/", "_", ":", "").Replace(name)
+	ext := ".json"
+	if strings.HasPrefix(contentType, "text/html") {
+		ext = ".html"
+	}
+	return filepath.Join("responses", strings.TrimSuffix(file, ext)+ext)
+}
+
+// TestRouteShapesMatchGolden прогоняет каждый маршрут роутера на фейковой БД
+// и сравнивает ответ с testdata/responses. Фейк отдаёт заданные строки на любой
+// SQL, поэтому golden-файлы фиксируют форму ответа — статус, поля, порядок,
+// перевод названий, — а не данные: выборки на настоящей БД проверяет
+// Postgres_test.go. Новый маршрут без описания запроса в goldenRequests роняет тест
+func TestRouteShapesMatchGolden(t *testing.T) {
+	requests := goldenRequests(t)
+
+	for _, route := range NewRouter().Routes() {
+		key := route.Method + " " + route.Path
+		if _, ok := skipGolden[key]; ok {
+			continue
+		}
+
+		request, ok := requests[key]
+		if !ok {
+			t.Errorf("%s: no golden request, add it to goldenRequests", key)
+			continue
+		}
+
+		t.Run(key, func(t *testing.T) {
+			db.SetQuerier(&dbtest.Querier{Rows: request.rows, Responses: request.responses})
+			reports.ResetColumnsCache()
+			t.Cleanup(func() { db.SetQuerier(nil) })
+			if request.setup != nil {
+				request.setup(t)
+			}
+
+			path := route.Path
+			if request.path != "" {
+				path = request.path
+			}
+			req := httptest.NewRequest(route.Method, path, strings.NewReader(request.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set(RequestIDHeader, "golden")
+			for name, value := range request.header {
+				req.Header.Set(name, value)
+			}
+
+			// Свежий роутер на каждый запрос, чтобы не копились счётчики квот
+			w := httptest.NewRecorder()
+			NewRouter().ServeHTTP(w, req)
+			if w.Code != request.status {
+				t.Fatalf("status %d, want %d: %s", w.Code, request.status, w.Body.String())
+			}
+
+			body := timestamp.ReplaceAll(w.Body.Bytes(), []byte(`"<time>"`))
+			contentType := w.Header().Get("Content-Type")
+			if strings.HasPrefix(contentType, "application/json") {
+				var indented bytes.Buffer
+				if err := json.Indent(&indented, body, "", "  "); err != nil {
+					t.Fatalf("invalid JSON: %v", err)
+				}
+				indented.WriteByte('\n')
+				body = indented.Bytes()
+			}
+
+			name := request.name
+			if name == "" {
+				name = key
+			}
+			golden(t, goldenFile(name, contentType), body)
+		})
+	}
+}
+
+// pivotCSVRequest — выгрузка сводной таблицы в CSV от пользователя с JWT
+func pivotCSVRequest(t *testing.T) *http.Request {
+	t.Helper()
+
+	token, err := utils.CreateJWT("foton-trucks", "foton1996")
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodPost, PivotPath+"?format=csv", strings.NewReader(pivotGoldenSpec))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", token)
+	return req
+}
+
+// TestPivotCSVExport сверяет выгрузку сводной таблицы с заметками
+func TestPivotCSVExport(t *testing.T) {
+	db.SetQuerier(&dbtest.Querier{
+		Rows:      pivotRows,
+		Responses: []dbtest.Response{{Contains: "FROM annotations", Rows: annotationRows}},
+	})
+	t.Cleanup(func() { db.SetQuerier(nil) })
+
+	// Выгрузка без входа не отдаётся, даже если таблица открыта для чтения
+	serve(t, NewRouter(), http.MethodPost, PivotPath+"?format=csv", pivotGoldenSpec, http.StatusUnauthorized)
+
+	w := httptest.NewRecorder()
+	NewRouter().ServeHTTP(w, pivotCSVRequest(t))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get("Content-Type"); !strings.HasPrefix(got, "text/csv") {
+		t.Fatalf("Content-Type %q, want text/csv", got)
+	}
+	golden(t, filepath.Join("responses", "POST_api_v1_pivot.csv"), w.Body.Bytes())
+}
+
+// TestPivotCSVEscapesFormulas проверяет, что текст заметки не выполняется
+// в Excel как формула
+func TestPivotCSVEscapesFormulas(t *testing.T) {
+	note := annotationRows[0]
+	note.Strings = append([]string(nil), note.Strings...)
+	note.Strings[4] = `=HYPERLINK("http://example.com","FOTON")`
+	note.Strings[5] = "@analyst"
+	db.SetQuerier(&dbtest.Querier{
+		Rows:      pivotRows,
+		Responses: []dbtest.Response{{Contains: "FROM annotations", Rows: []dbtest.Row{note}}},
+	})
+	t.Cleanup(func() { db.SetQuerier(nil) })
+
+	w := httptest.NewRecorder()
+	NewRouter().ServeHTTP(w, pivotCSVRequest(t))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body.String())
+	}
+
+	for _, want := range []string{`"'=HYPERLINK(""http://example.com"",""FOTON"")"`, ",'@analyst,"} {
+		if !strings.Contains(w.Body.String(), want) {
+			t.Errorf("CSV has no escaped cell %s:\n%s", want, w.Body.String())
+		}
+	}
+}
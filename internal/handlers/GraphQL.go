@@ -0,0 +1,277 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"sync"
+
+	"truck-analytics-platform/internal/handlers/utils"
+	"truck-analytics-platform/internal/reports"
+
+	"github.com/gin-gonic/gin"
+	"github.com/graphql-go/graphql"
+)
+
+// GraphQLPath — эндпоинт GraphQL-запросов к данным регистраций
+const GraphQLPath = APIPrefix + "/graphql"
+
+var (
+	graphQLOnce   sync.Once
+	graphQLSchema graphql.Schema
+	graphQLErr    error
+)
+
+type graphQLRequest struct {
+	Query         string         `json:"query" binding:"required"`
+	Variables     map[string]any `json:"variables"`
+	OperationName string         `json:"operationName"`
+}
+
+// GraphQLHandler выполняет GraphQL-запрос. Ошибки запроса возвращаются
+// по спецификации GraphQL в поле errors со статусом 200
+func GraphQLHandler(c *gin.Context) {
+	var req graphQLRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondError(c, http.StatusBadRequest, utils.CodeBadRequest, "Invalid GraphQL request", nil)
+		return
+	}
+
+	schema, err := GraphQLSchema()
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, utils.CodeInternal, "GraphQL schema is invalid", err)
+		return
+	}
+
+	result := graphql.Do(graphql.Params{
+		Schema:         schema,
+		RequestString:  req.Query,
+		VariableValues: req.Variables,
+		OperationName:  req.OperationName,
+		Context:        c.Request.Context(),
+	})
+	c.JSON(http.StatusOK, result)
+}
+
+// GraphQLSchema возвращает схему, собранную один раз
+func GraphQLSchema() (graphql.Schema, error) {
+	graphQLOnce.Do(func() {
+		graphQLSchema, graphQLErr = buildGraphQLSchema()
+	})
+	return graphQLSchema, graphQLErr
+}
+
+// marketNode — узел агрегата: страна, округ, регион или город
+// с итогом и продажами по брендам
+type marketNode struct {
+	Name     string        `json:"name"`
+	Total    int           `json:"total"`
+	Brands   []brandTotal  `json:"brands"`
+	Children []*marketNode `json:"-"`
+
+	brands   map[string]int
+	children map[string]*marketNode
+}
+
+type brandTotal struct {
+	Brand    string `json:"brand"`
+	Quantity int    `json:"quantity"`
+}
+
+func newMarketNode(name string) *marketNode {
+	return &marketNode{
+		Name:     name,
+		Brands:   []brandTotal{},
+		Children: []*marketNode{},
+		brands:   map[string]int{},
+		children: map[string]*marketNode{},
+	}
+}
+
+func (n *marketNode) add(brand string, quantity int) {
+	n.Total += quantity
+	n.brands[brand] += quantity
+}
+
+func (n *marketNode) child(name string) *marketNode {
+	child, ok := n.children[name]
+	if !ok {
+		child = newMarketNode(name)
+		n.children[name] = child
+		n.Children = append(n.Children, child)
+	}
+	return child
+}
+
+// finish раскладывает бренды в срезы: по убыванию продаж, затем по имени
+func (n *marketNode) finish() {
+	for brand, quantity := range n.brands {
+		n.Brands = append(n.Brands, brandTotal{Brand: brand, Quantity: quantity})
+	}
+	sort.Slice(n.Brands, func(i, j int) bool {
+		if n.Brands[i].Quantity != n.Brands[j].Quantity {
+			return n.Brands[i].Quantity > n.Brands[j].Quantity
+		}
+		return n.Brands[i].Brand < n.Brands[j].Brand
+	})
+
+	for _, child := range n.Children {
+		child.finish()
+	}
+}
+
+// buildMarket собирает дерево округ → регион → город из строк фильтра
+func buildMarket(registrations []reports.Registration) *marketNode {
+	market := newMarketNode("Russia")
+
+	for _, r := range registrations {
+		district := market.child(r.District)
+		region := district.child(r.Region)
+		city := region.child(r.City)
+
+		for _, node := range []*marketNode{market, district, region, city} {
+			node.add(r.Brand, r.Quantity)
+		}
+	}
+
+	market.finish()
+	return market
+}
+
+// allHDT — рынок запроса без сегмента: все регистрации HDT
+var allHDT = reports.Market{Tables: reports.Tables}
+
+// registrationFilter переводит аргумент filter в фильтр построителя запросов.
+// Таблица и условия берутся из рынка сегмента, как в REST-отчётах
+func registrationFilter(args map[string]any) (reports.Filter, error) {
+	var f reports.Filter
+
+	market := allHDT
+	if key, ok := args["segment"].(string); ok {
+		market = reports.Markets[key]
+	}
+	year, _ := args["year"].(int)
+	if _, ok := market.Tables[year]; !ok {
+		return f, fmt.Errorf("no data for year %d", year)
+	}
+	f = market.Filter(year, 0, 0)
+
+	f.Brands = stringList(args["brands"])
+	f.Districts = stringList(args["districts"])
+	f.Regions = stringList(args["regions"])
+
+	f.GroupByParent, _ = args["groupByParent"].(bool)
+
+	f.MonthFrom, _ = args["monthFrom"].(int)
+	f.MonthTo, _ = args["monthTo"].(int)
+	if err := reports.CheckMonths(f.MonthFrom, f.MonthTo); err != nil {
+		return f, err
+	}
+	if f.MonthFrom > 0 && f.MonthTo > 0 && f.MonthFrom > f.MonthTo {
+		return f, errors.New("monthFrom must not be after monthTo")
+	}
+
+	return f, nil
+}
+
+func stringList(value any) []string {
+	items, _ := value.([]any)
+	result := make([]string, 0, len(items))
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+func buildGraphQLSchema() (graphql.Schema, error) {
+	segmentValues := graphql.EnumValueConfigMap{}
+	for _, key := range reports.MarketKeys() {
+		segmentValues[key] = &graphql.EnumValueConfig{Value: key}
+	}
+	segmentEnum := graphql.NewEnum(graphql.EnumConfig{
+		Name:        "Segment",
+		Description: "Market segment, same as in REST report paths: HDT segments, ldt or mdt",
+		Values:      segmentValues,
+	})
+
+	filterInput := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "RegistrationFilter",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"year":      &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.Int)},
+			"segment":   &graphql.InputObjectFieldConfig{Type: segmentEnum, Description: "All HDT registrations when omitted"},
+			"brands":    &graphql.InputObjectFieldConfig{Type: graphql.NewList(graphql.NewNonNull(graphql.String)), Description: "Canonical brand ids from the brand master, e.g. FOTON"},
+			"districts": &graphql.InputObjectFieldConfig{Type: graphql.NewList(graphql.NewNonNull(graphql.String)), Description: "Federal districts in English, e.g. Ural"},
+			"regions":   &graphql.InputObjectFieldConfig{Type: graphql.NewList(graphql.NewNonNull(graphql.String)), Description: "Regions in English, e.g. Sverdlovsk Region"},
+			"monthFrom": &graphql.InputObjectFieldConfig{Type: graphql.Int},
+			"monthTo":   &graphql.InputObjectFieldConfig{Type: graphql.Int},
+			"groupByParent": &graphql.InputObjectFieldConfig{
+				Type:        graphql.Boolean,
+				Description: "Report manufacturer groups (e.g. SINOTRUK) instead of brands",
+			},
+		},
+	})
+
+	brandType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "BrandTotal",
+		Fields: graphql.Fields{
+			"brand":    &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"quantity": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		},
+	})
+
+	// nodeType — тип уровня агрегата; children — поле со следующим уровнем
+	nodeType := func(name string, children string, childType *graphql.Object) *graphql.Object {
+		fields := graphql.Fields{
+			"name":   &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"total":  &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"brands": &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(brandType)))},
+		}
+		if childType != nil {
+			fields[children] = &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(childType))),
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					return p.Source.(*marketNode).Children, nil
+				},
+			}
+		}
+		return graphql.NewObject(graphql.ObjectConfig{Name: name, Fields: fields})
+	}
+
+	cityType := nodeType("City", "", nil)
+	regionType := nodeType("Region", "cities", cityType)
+	districtType := nodeType("District", "regions", regionType)
+	marketType := nodeType("Market", "districts", districtType)
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"registrations": &graphql.Field{
+				Type:        graphql.NewNonNull(marketType),
+				Description: "Registrations matching the filter, aggregated by district, region and city",
+				Args: graphql.FieldConfigArgument{
+					"filter": &graphql.ArgumentConfig{Type: graphql.NewNonNull(filterInput)},
+				},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					args, _ := p.Args["filter"].(map[string]any)
+					filter, err := registrationFilter(args)
+					if err != nil {
+						return nil, err
+					}
+
+					registrations, err := reports.Registrations(p.Context, filter)
+					if err != nil {
+						slog.ErrorContext(p.Context, "GraphQL registrations query failed", "error", err)
+						return nil, errors.New("failed to load registrations")
+					}
+					return buildMarket(registrations), nil
+				},
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: query})
+}
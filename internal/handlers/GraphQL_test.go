@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"truck-analytics-platform/internal/db"
+	"truck-analytics-platform/internal/db/dbtest"
+	"truck-analytics-platform/internal/reports"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestGraphQLReadsMarketTables(t *testing.T) {
+	for _, segment := range []string{"ldt", "mdt", "dumpers8x4"} {
+		t.Run(segment, func(t *testing.T) {
+			querier := &dbtest.Querier{Rows: dbtest.RegistrationRows()}
+			db.SetQuerier(querier)
+			t.Cleanup(func() { db.SetQuerier(nil) })
+
+			body := `{"query":"{ registrations(filter: {year: 2023, segment: ` + segment + `}) { total } }"}`
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, GraphQLPath, strings.NewReader(body))
+			c.Request.Header.Set("Content-Type", "application/json")
+			GraphQLHandler(c)
+			if w.Code != http.StatusOK || strings.Contains(w.Body.String(), `"errors"`) {
+				t.Fatalf("status %d: %s", w.Code, w.Body.String())
+			}
+
+			calls := querier.Calls()
+			if len(calls) != 1 {
+				t.Fatalf("resolver ran %d queries, want 1", len(calls))
+			}
+			table := reports.Markets[segment].Tables[2023]
+			if !strings.Contains(calls[0].SQL, "FROM "+string(table)) {
+				t.Errorf("query does not read %s:\n%s", table, calls[0].SQL)
+			}
+		})
+	}
+}
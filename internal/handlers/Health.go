@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+	"truck-analytics-platform/internal/db"
+	"truck-analytics-platform/internal/handlers/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReadinessTimeout ограничивает проверку БД, чтобы /readyz не зависал
+const ReadinessTimeout = 2 * time.Second
+
+// HealthHandler сообщает, что процесс жив
+func HealthHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// ReadyHandler проверяет доступность БД, применение миграций и наличие таблиц с данными
+func ReadyHandler(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), ReadinessTimeout)
+	defer cancel()
+
+	if err := db.Ready(ctx); err != nil {
+		utils.RespondError(c, http.StatusServiceUnavailable, utils.CodeDatabaseUnavailable, "Database is not ready", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
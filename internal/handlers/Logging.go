@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+	"truck-analytics-platform/internal/handlers/utils"
+	"truck-analytics-platform/internal/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDHeader — заголовок с идентификатором запроса. Входящий идентификатор
+// от nginx или клиента переиспользуется, иначе генерируется новый
+const RequestIDHeader = "X-Request-ID"
+
+// UserKey — ключ gin-контекста с логином авторизованного пользователя
+const UserKey = "user"
+
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// redactedParams — параметры пути, значения которых не пишутся в лог:
+// токен ссылки на сохранённый вид сам по себе даёт доступ к нему
+var redactedParams = []string{"token"}
+
+const redacted = "<redacted>"
+
+// RequestLogger присваивает запросу идентификатор и пишет по нему
+// структурированную запись: маршрут, пользователь, статус, длительность, параметры
+func RequestLogger() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		requestID := ctx.GetHeader(RequestIDHeader)
+		if !validRequestID.MatchString(requestID) {
+			requestID = newRequestID()
+		}
+
+		ctx.Request = ctx.Request.WithContext(logging.WithRequestID(ctx.Request.Context(), requestID))
+		ctx.Header(RequestIDHeader, requestID)
+
+		start := time.Now()
+		ctx.Next()
+
+		status := ctx.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+
+		attrs := []slog.Attr{
+			slog.String("method", ctx.Request.Method),
+			slog.String("route", ctx.FullPath()),
+			slog.String("path", requestPath(ctx)),
+			slog.Int("status", status),
+			slog.Float64("duration_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.String("client_ip", ctx.ClientIP()),
+			slog.String("user", requestUser(ctx)),
+		}
+		if params := requestParams(ctx); len(params) > 0 {
+			attrs = append(attrs, slog.Any("params", params))
+		}
+
+		slog.LogAttrs(ctx.Request.Context(), level, "HTTP request", attrs...)
+	}
+}
+
+// RecoveryHandler отвечает на панику единым конвертом ошибки
+func RecoveryHandler(ctx *gin.Context, recovered any) {
+	slog.ErrorContext(ctx.Request.Context(), "Panic recovered", "panic", recovered)
+	utils.RespondError(ctx, http.StatusInternalServerError, utils.CodeInternal, "Internal server error", nil)
+}
+
+// requestUser возвращает логин из контекста или из токена в заголовке Authorization
+func requestUser(ctx *gin.Context) string {
+	if user := ctx.GetString(UserKey); user != "" {
+		return user
+	}
+
+	token := ctx.GetHeader("Authorization")
+	if token == "" {
+		return ""
+	}
+
+	login, err := utils.ParseJWT(token)
+	if err != nil {
+		return ""
+	}
+	return login
+}
+
+// requestParams собирает параметры пути и query-строки — по ним видно,
+// какой сегмент и период запрашивался. Значения redactedParams скрываются
+func requestParams(ctx *gin.Context) map[string]string {
+	params := make(map[string]string, len(ctx.Params))
+	for _, p := range ctx.Params {
+		params[p.Key] = p.Value
+	}
+	for key, values := range ctx.Request.URL.Query() {
+		if len(values) > 0 {
+			params[key] = values[0]
+		}
+	}
+	for _, key := range redactedParams {
+		if _, ok := params[key]; ok {
+			params[key] = redacted
+		}
+	}
+	return params
+}
+
+// requestPath — путь запроса со скрытыми значениями redactedParams
+func requestPath(ctx *gin.Context) string {
+	path := ctx.Request.URL.Path
+	for _, key := range redactedParams {
+		if value := ctx.Param(key); value != "" {
+			path = strings.ReplaceAll(path, value, redacted)
+		}
+	}
+	return path
+}
+
+func newRequestID() string {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return time.Now().Format("20060102150405.000000000")
+	}
+	return hex.EncodeToString(buf)
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"truck-analytics-platform/internal/db"
+	"truck-analytics-platform/internal/db/dbtest"
+)
+
+func TestRequestLogRedactsShareToken(t *testing.T) {
+	var logs bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	db.SetQuerier(&dbtest.Querier{Rows: viewRows[:1]})
+	t.Cleanup(func() { db.SetQuerier(nil) })
+
+	const token = "5f0c6b1e2a9d4c3b8e7f6a5d4c3b2a19"
+	w := httptest.NewRecorder()
+	NewRouter().ServeHTTP(w, httptest.NewRequest(http.MethodGet, SharedPath+"/views/"+token, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body.String())
+	}
+
+	if strings.Contains(logs.String(), token) {
+		t.Errorf("share token is logged:\n%s", logs.String())
+	}
+	if !strings.Contains(logs.String(), "path="+SharedPath+"/views/"+redacted) {
+		t.Errorf("request is not logged with a redacted path:\n%s", logs.String())
+	}
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"truck-analytics-platform/internal/handlers/utils"
+	"truck-analytics-platform/internal/reports"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TopModelsPath — рейтинг моделей по округам или регионам
+const TopModelsPath = APIPrefix + "/models/top"
+
+// DefaultTopModels — сколько моделей на территорию отдаётся без параметра limit
+const DefaultTopModels = 10
+
+// TopModelsHandler отдаёт самые продаваемые модели сегмента на каждой
+// территории с их долей в продажах сегмента
+func TopModelsHandler(c *gin.Context) {
+	spec := reports.TopModelsSpec{
+		Segment:   c.Query("segment"),
+		Level:     c.DefaultQuery("level", reports.LevelDistrict),
+		Districts: c.QueryArray("district"),
+		Regions:   c.QueryArray("region"),
+	}
+
+	var err error
+	for _, param := range []struct {
+		name  string
+		value *int
+		def   int
+	}{
+		{"year", &spec.Year, 0},
+		{"limit", &spec.Limit, DefaultTopModels},
+		{"month_from", &spec.MonthFrom, 0},
+		{"month_to", &spec.MonthTo, 0},
+	} {
+		if *param.value, err = queryInt(c, param.name, param.def); err != nil {
+			utils.RespondError(c, http.StatusBadRequest, utils.CodeBadRequest, err.Error(), nil)
+			return
+		}
+	}
+	if err := spec.Validate(); err != nil {
+		utils.RespondError(c, http.StatusBadRequest, utils.CodeBadRequest, err.Error(), nil)
+		return
+	}
+
+	areas, err := reports.TopModels(c.Request.Context(), spec)
+	if errors.Is(err, reports.ErrUnavailable) {
+		utils.RespondError(c, http.StatusNotFound, utils.CodeNotFound, err.Error(), nil)
+		return
+	}
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to rank models", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": areas})
+}
+
+// queryInt читает целый параметр строки запроса; без параметра — def
+func queryInt(c *gin.Context, name string, def int) (int, error) {
+	raw, ok := c.GetQuery(name)
+	if !ok {
+		return def, nil
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be an integer", name)
+	}
+	return value, nil
+}
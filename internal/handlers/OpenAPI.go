@@ -0,0 +1,1624 @@
+package handlers
+
+import (
+	"net/http"
+	"sync"
+
+	"truck-analytics-platform/internal/alerts"
+	"truck-analytics-platform/internal/annotations"
+	"truck-analytics-platform/internal/apikeys"
+	"truck-analytics-platform/internal/audit"
+	"truck-analytics-platform/internal/forecast"
+	"truck-analytics-platform/internal/reports"
+
+	"github.com/gin-gonic/gin"
+)
+
+// openAPIVersion — версия документа API, поднимается при изменении контрактов
+const openAPIVersion = "1.0.0"
+
+var (
+	openAPIOnce sync.Once
+	openAPIDoc  map[string]any
+)
+
+// OpenAPIHandler отдаёт OpenAPI 3 документ со всеми эндпоинтами API
+func OpenAPIHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, OpenAPISpec())
+}
+
+// SwaggerUIHandler отдаёт Swagger UI, который читает /openapi.json
+func SwaggerUIHandler(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(swaggerUIPage))
+}
+
+// OpenAPISpec возвращает OpenAPI-документ. Пути отчётов строятся из той же
+// таблицы reportRoutes, по которой регистрируются маршруты
+func OpenAPISpec() map[string]any {
+	openAPIOnce.Do(func() {
+		openAPIDoc = buildOpenAPI()
+	})
+	return openAPIDoc
+}
+
+func buildOpenAPI() map[string]any {
+	// Прогноз бренда — те же поля, что у прогноза сегмента, плюс бренд
+	projection := map[string]any{
+		"actual":   map[string]any{"type": "integer", "description": "Sales over the actual months"},
+		"forecast": map[string]any{"type": "integer", "description": "Expected full-year sales"},
+		"lower":    map[string]any{"type": "integer", "description": "Lower bound of the 95% interval; never below actual"},
+		"upper":    map[string]any{"type": "integer", "description": "Upper bound of the 95% interval"},
+		"monthly":  map[string]any{"type": "array", "items": ref("ForecastMonth")},
+	}
+	projectionRequired := []string{"actual", "forecast", "lower", "upper", "monthly"}
+	withBrand := map[string]any{"brand": str()}
+	for name, property := range projection {
+		withBrand[name] = property
+	}
+
+	schemas := map[string]any{
+		"ErrorResponse": errorResponseSchema(),
+		"LoginRequest": object(map[string]any{
+			"login":    str(),
+			"password": str(),
+		}, "login", "password"),
+		"TokenResponse": object(map[string]any{"token": str()}, "token"),
+		"GraphQLRequest": object(map[string]any{
+			"query":         str(),
+			"variables":     map[string]any{"type": "object", "additionalProperties": true},
+			"operationName": str(),
+		}, "query"),
+		"PivotSpec": object(map[string]any{
+			"rows": map[string]any{
+				"type":  "array",
+				"items": map[string]any{"type": "string", "enum": []string{"district", "region", "city", "body_type", "wheel_formula", "mass_segment", "gvw", "model", "engine"}},
+			},
+			"columns": map[string]any{
+				"type":  "array",
+				"items": map[string]any{"type": "string", "enum": []string{"brand", "manufacturer", "month", "year"}},
+			},
+			"years":      map[string]any{"type": "array", "items": integer(false)},
+			"segment":    str(),
+			"brands":     map[string]any{"type": "array", "items": str()},
+			"districts":  map[string]any{"type": "array", "items": str()},
+			"regions":    map[string]any{"type": "array", "items": str()},
+			"month_from": integer(false),
+			"month_to":   integer(false),
+			"subtotals":  map[string]any{"type": "boolean"},
+		}, "years"),
+		"PivotRow": object(map[string]any{
+			"keys":     map[string]any{"type": "array", "items": map[string]any{"type": "string", "nullable": true}},
+			"subtotal": map[string]any{"type": "boolean"},
+			"values":   map[string]any{"type": "array", "items": integer(false)},
+			"total":    integer(false),
+		}, "keys", "subtotal", "values", "total"),
+		"Pivot": object(map[string]any{
+			"rows":        map[string]any{"type": "array", "items": str()},
+			"columns":     map[string]any{"type": "array", "items": str()},
+			"column_keys": map[string]any{"type": "array", "items": map[string]any{"type": "array", "items": str()}},
+			"grid":        map[string]any{"type": "array", "items": ref("PivotRow")},
+			"annotations": map[string]any{"type": "array", "items": ref("Annotation"), "description": "Analyst notes on the pivot's segment, years, months, areas and brands"},
+		}, "rows", "columns", "column_keys", "grid", "annotations"),
+		"Annotation": object(map[string]any{
+			"id":         integer(false),
+			"segment":    map[string]any{"type": "string", "description": "Segment key; empty for all segments"},
+			"year":       integer(false),
+			"month":      map[string]any{"type": "integer", "description": "0 for the whole year"},
+			"district":   map[string]any{"type": "string", "description": "District in English; empty for all"},
+			"region":     map[string]any{"type": "string", "description": "Region in English; empty for all"},
+			"brand":      map[string]any{"type": "string", "description": "Canonical brand id; empty for all"},
+			"text":       str(),
+			"author":     str(),
+			"created_at": map[string]any{"type": "string", "format": "date-time"},
+			"updated_at": map[string]any{"type": "string", "format": "date-time"},
+		}, "id", "segment", "year", "month", "district", "region", "brand", "text", "author", "created_at", "updated_at"),
+		"AnnotationInput": object(map[string]any{
+			"segment":    map[string]any{"type": "string", "enum": append([]string{""}, reports.MarketKeys()...)},
+			"year":       integer(false),
+			"month":      map[string]any{"type": "integer", "minimum": 0, "maximum": 12},
+			"district":   str(),
+			"region":     str(),
+			"brand":      str(),
+			"text":       map[string]any{"type": "string", "maxLength": annotations.MaxText},
+			"id":         map[string]any{"type": "integer", "description": "Ignored, the id is assigned or taken from the path"},
+			"author":     map[string]any{"type": "string", "description": "Ignored, the author is the token's user"},
+			"created_at": map[string]any{"type": "string", "description": "Ignored"},
+			"updated_at": map[string]any{"type": "string", "description": "Ignored"},
+		}, "year", "text"),
+		"AnnotationList": object(map[string]any{
+			"data": map[string]any{"type": "array", "items": ref("Annotation")},
+		}, "data"),
+		"AuditEvent": object(map[string]any{
+			"id":     integer(false),
+			"at":     map[string]any{"type": "string", "format": "date-time"},
+			"action": map[string]any{"type": "string", "description": "auth.login, data.export, data.load, data.check, brand.change, dealer.change, targets.change, alerts.run or apikey.change"},
+			"actor":  map[string]any{"type": "string", "description": "Login of the user or of the login attempt; system for data loads"},
+			"ip":     str(),
+			"method": str(),
+			"path":   str(),
+			"status": map[string]any{"type": "integer", "description": "HTTP status of the request; 0 for data loads"},
+			"params": map[string]any{"type": "object", "additionalProperties": true, "description": "Path, query and body parameters without passwords; text is cut to 100 characters and params over 4 KiB are replaced with their size"},
+		}, "id", "at", "action", "actor", "ip", "method", "path", "status", "params"),
+		"AuditEventList": object(map[string]any{
+			"data": map[string]any{"type": "array", "items": ref("AuditEvent")},
+		}, "data"),
+		"APIKey": object(map[string]any{
+			"id":           integer(false),
+			"name":         str(),
+			"prefix":       map[string]any{"type": "string", "description": "First characters of the key to recognise it"},
+			"scopes":       map[string]any{"type": "array", "items": map[string]any{"type": "string", "enum": apikeys.Scopes}},
+			"created_by":   str(),
+			"created_at":   map[string]any{"type": "string", "format": "date-time"},
+			"expires_at":   map[string]any{"type": "string", "format": "date-time", "nullable": true, "description": "Null for a key without expiry"},
+			"last_used_at": map[string]any{"type": "string", "format": "date-time", "nullable": true},
+		}, "id", "name", "prefix", "scopes", "created_by", "created_at", "expires_at", "last_used_at"),
+		"APIKeyInput": object(map[string]any{
+			"name":       map[string]any{"type": "string", "maxLength": 100},
+			"scopes":     map[string]any{"type": "array", "minItems": 1, "items": map[string]any{"type": "string", "enum": apikeys.Scopes}},
+			"expires_at": map[string]any{"type": "string", "format": "date-time", "nullable": true},
+		}, "name", "scopes"),
+		"CreatedAPIKey": object(map[string]any{
+			"id":           integer(false),
+			"name":         str(),
+			"prefix":       str(),
+			"scopes":       map[string]any{"type": "array", "items": str()},
+			"created_by":   str(),
+			"created_at":   map[string]any{"type": "string", "format": "date-time"},
+			"expires_at":   map[string]any{"type": "string", "format": "date-time", "nullable": true},
+			"last_used_at": map[string]any{"type": "string", "format": "date-time", "nullable": true},
+			"key":          map[string]any{"type": "string", "description": "The key itself; shown only in this response"},
+		}, "id", "name", "prefix", "scopes", "created_by", "created_at", "expires_at", "last_used_at", "key"),
+		"APIKeyList": object(map[string]any{
+			"data": map[string]any{"type": "array", "items": ref("APIKey")},
+		}, "data"),
+		"GraphQLResponse": map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"data":   map[string]any{"type": "object", "nullable": true, "additionalProperties": true},
+				"errors": map[string]any{"type": "array", "items": map[string]any{"type": "object", "additionalProperties": true}},
+			},
+		},
+		"DataQualityIssue": object(map[string]any{
+			"check":    str(),
+			"severity": map[string]any{"type": "string", "enum": []string{"error", "warning"}},
+			"value":    str(),
+			"rows":     integer(false),
+			"message":  str(),
+		}, "check", "severity", "rows", "message"),
+		"DataQualityTable": object(map[string]any{
+			"table":      str(),
+			"checked_at": map[string]any{"type": "string", "format": "date-time"},
+			"rows":       integer(false),
+			"errors":     integer(false),
+			"warnings":   integer(false),
+			"issues":     map[string]any{"type": "array", "items": ref("DataQualityIssue")},
+		}, "table", "checked_at", "rows", "errors", "warnings", "issues"),
+		"DataQualityReport": object(map[string]any{
+			"checked_at": map[string]any{"type": "string", "format": "date-time"},
+			"blocking":   map[string]any{"type": "boolean"},
+			"tables":     map[string]any{"type": "array", "items": ref("DataQualityTable")},
+		}, "checked_at", "blocking", "tables"),
+		"Brand": object(map[string]any{
+			"id":        str(),
+			"name":      str(),
+			"parent_id": map[string]any{"type": "string", "nullable": true},
+			"aliases":   map[string]any{"type": "array", "items": str()},
+		}, "id", "name", "parent_id", "aliases"),
+		"BrandInput": object(map[string]any{
+			"name":      str(),
+			"parent_id": map[string]any{"type": "string", "nullable": true},
+			"aliases":   map[string]any{"type": "array", "items": str()},
+			"id":        map[string]any{"type": "string", "description": "Ignored, the id is taken from the path"},
+		}, "name"),
+		"BrandList": object(map[string]any{
+			"data": map[string]any{"type": "array", "items": ref("Brand")},
+		}, "data"),
+		"DealerArea": object(map[string]any{
+			"region": map[string]any{"type": "string", "description": "Region in English"},
+			"city":   map[string]any{"type": "string", "description": "City as in the registration data; the whole region when omitted"},
+		}, "region"),
+		"Dealer": object(map[string]any{
+			"id":        integer(false),
+			"name":      str(),
+			"city":      str(),
+			"region":    map[string]any{"type": "string", "description": "Region in English"},
+			"brands":    map[string]any{"type": "array", "items": str(), "description": "Canonical brand ids the dealer sells"},
+			"territory": map[string]any{"type": "array", "items": ref("DealerArea")},
+		}, "id", "name", "city", "region", "brands", "territory"),
+		"DealerInput": object(map[string]any{
+			"name":      str(),
+			"city":      str(),
+			"region":    map[string]any{"type": "string", "description": "Region in English"},
+			"brands":    map[string]any{"type": "array", "items": str()},
+			"territory": map[string]any{"type": "array", "items": ref("DealerArea"), "minItems": 1},
+			"id":        map[string]any{"type": "integer", "description": "Ignored, the id is assigned or taken from the path"},
+		}, "name", "region", "territory"),
+		"DealerList": object(map[string]any{
+			"data": map[string]any{"type": "array", "items": ref("Dealer")},
+		}, "data"),
+		"DealerTerritories": object(map[string]any{
+			"period": ref("RankingPeriod"),
+			"brand":  str(),
+			"data": map[string]any{"type": "array", "items": object(map[string]any{
+				"dealer_id":            integer(false),
+				"dealer":               str(),
+				"market_volume":        map[string]any{"type": "integer", "description": "Registrations of the segment in the territory"},
+				"brand_volume":         integer(false),
+				"penetration":          map[string]any{"type": "number", "description": "Brand share of the territory market, %"},
+				"dealer_brands_volume": map[string]any{"type": "integer", "description": "Registrations of the brands the dealer sells"},
+				"areas": map[string]any{"type": "array", "items": object(map[string]any{
+					"region":        str(),
+					"city":          str(),
+					"market_volume": integer(false),
+					"brand_volume":  integer(false),
+					"penetration":   map[string]any{"type": "number"},
+				}, "region", "market_volume", "brand_volume", "penetration")},
+			}, "dealer_id", "dealer", "market_volume", "brand_volume", "penetration", "dealer_brands_volume", "areas")},
+		}, "period", "brand", "data"),
+		"Target": object(map[string]any{
+			"segment":  map[string]any{"type": "string", "enum": reports.MarketKeys()},
+			"year":     integer(false),
+			"month":    map[string]any{"type": "integer", "minimum": 1, "maximum": 12},
+			"district": map[string]any{"type": "string", "enum": reports.DistrictOrder()},
+			"brand":    map[string]any{"type": "string", "description": "Canonical brand id; FOTON when omitted on upload"},
+			"volume":   map[string]any{"type": "integer", "nullable": true, "minimum": 0, "description": "Planned registrations"},
+			"share":    map[string]any{"type": "number", "nullable": true, "minimum": 0, "maximum": 100, "description": "Planned share of the district market, %"},
+		}, "segment", "year", "month", "district"),
+		"TargetList": object(map[string]any{
+			"data": map[string]any{"type": "array", "items": ref("Target")},
+		}, "data"),
+		"TargetProgress": object(map[string]any{
+			"period": ref("RankingPeriod"),
+			"brand":  str(),
+			"data": map[string]any{"type": "array", "items": object(map[string]any{
+				"area":          map[string]any{"type": "string", "description": "District in English or Total for the districts with a plan"},
+				"market_volume": integer(false),
+				"brand_volume":  integer(false),
+				"volume": nullable(object(map[string]any{
+					"plan":                  map[string]any{"type": "integer", "description": "Planned registrations from January to month_to"},
+					"actual":                integer(false),
+					"gap":                   map[string]any{"type": "integer", "description": "Actual minus plan"},
+					"achievement":           map[string]any{"type": "number", "nullable": true, "description": "Actual of plan, %; null when the plan is zero"},
+					"year_plan":             integer(false),
+					"run_rate":              map[string]any{"type": "integer", "description": "Full-year registrations at the year-to-date pace"},
+					"projected_achievement": map[string]any{"type": "number", "nullable": true, "description": "Run rate of the year plan, %"},
+				}, "plan", "actual", "gap", "achievement", "year_plan", "run_rate", "projected_achievement")),
+				"share": nullable(object(map[string]any{
+					"plan":   map[string]any{"type": "number", "description": "Planned share weighted by the market of each month, %"},
+					"actual": map[string]any{"type": "number", "description": "Actual share over the months with a share plan, %"},
+					"gap":    map[string]any{"type": "number", "description": "Actual minus plan, percentage points"},
+				}, "plan", "actual", "gap")),
+			}, "area", "market_volume", "brand_volume", "volume", "share")},
+		}, "period", "brand", "data"),
+		"SavedView": object(map[string]any{
+			"id":         integer(false),
+			"owner":      map[string]any{"type": "string", "description": "Login of the user who saved the view"},
+			"name":       str(),
+			"segment":    map[string]any{"type": "string", "enum": reports.MarketKeys()},
+			"year":       integer(false),
+			"month_from": map[string]any{"type": "integer", "description": "0 for the first month"},
+			"month_to":   map[string]any{"type": "integer", "description": "0 for the last month loaded"},
+			"level":      map[string]any{"type": "string", "description": "country, district, region or empty for the report default"},
+			"districts":  map[string]any{"type": "array", "items": str(), "description": "Districts in English; empty for all"},
+			"brands":     map[string]any{"type": "array", "items": str(), "description": "Canonical brand ids; empty for all"},
+			"default":    map[string]any{"type": "boolean", "description": "The user's default view; saving a default clears the previous one"},
+			"token":      map[string]any{"type": "string", "description": "Opens the view at /api/v1/shared/views/{token} without signing in"},
+			"updated_at": map[string]any{"type": "string", "format": "date-time"},
+		}, "id", "owner", "name", "segment", "year", "month_from", "month_to", "level", "districts", "brands", "default", "token", "updated_at"),
+		"SavedViewInput": object(map[string]any{
+			"name":       str(),
+			"segment":    map[string]any{"type": "string", "enum": reports.MarketKeys()},
+			"year":       integer(false),
+			"month_from": integer(false),
+			"month_to":   integer(false),
+			"level":      map[string]any{"type": "string", "enum": []string{"", reports.LevelCountry, reports.LevelDistrict, reports.LevelRegion}},
+			"districts":  map[string]any{"type": "array", "items": map[string]any{"type": "string", "enum": reports.DistrictOrder()}},
+			"brands":     map[string]any{"type": "array", "items": str()},
+			"default":    map[string]any{"type": "boolean"},
+		}, "name", "segment", "year"),
+		"SavedViewList": object(map[string]any{
+			"data": map[string]any{"type": "array", "items": ref("SavedView")},
+		}, "data"),
+		"Dashboard": object(map[string]any{
+			"id":         integer(false),
+			"owner":      str(),
+			"name":       str(),
+			"default":    map[string]any{"type": "boolean"},
+			"token":      map[string]any{"type": "string", "description": "Opens the dashboard at /api/v1/shared/dashboards/{token} without signing in"},
+			"view_ids":   map[string]any{"type": "array", "items": integer(false), "description": "Saved views in dashboard order"},
+			"views":      map[string]any{"type": "array", "items": ref("SavedView"), "description": "View definitions; present when a single dashboard is read"},
+			"updated_at": map[string]any{"type": "string", "format": "date-time"},
+		}, "id", "owner", "name", "default", "token", "view_ids", "updated_at"),
+		"DashboardInput": object(map[string]any{
+			"name":     str(),
+			"default":  map[string]any{"type": "boolean"},
+			"view_ids": map[string]any{"type": "array", "items": integer(false), "description": "The user's own saved views in display order"},
+		}, "name"),
+		"DashboardList": object(map[string]any{
+			"data": map[string]any{"type": "array", "items": ref("Dashboard")},
+		}, "data"),
+		"ModelShare": object(map[string]any{
+			"rank":     integer(false),
+			"brand":    str(),
+			"model":    str(),
+			"quantity": integer(false),
+			"share":    map[string]any{"type": "number", "description": "Share of segment sales in the area, %"},
+		}, "rank", "brand", "model", "quantity", "share"),
+		"ModelArea": object(map[string]any{
+			"district": str(),
+			"region":   map[string]any{"type": "string", "description": "Present when level is region"},
+			"total":    integer(false),
+			"models":   map[string]any{"type": "array", "items": ref("ModelShare")},
+		}, "district", "total", "models"),
+		"TopModels": object(map[string]any{
+			"data": map[string]any{"type": "array", "items": ref("ModelArea")},
+		}, "data"),
+		"RankingPeriod": object(map[string]any{
+			"year":          integer(false),
+			"month_from":    integer(false),
+			"month_to":      integer(false),
+			"compared_with": map[string]any{"type": "integer", "nullable": true, "description": "Previous year compared over the same months"},
+		}, "year", "month_from", "month_to", "compared_with"),
+		"Ranked": object(map[string]any{
+			"rank":         integer(false),
+			"name":         str(),
+			"district":     map[string]any{"type": "string", "description": "District of a ranked region"},
+			"volume":       integer(false),
+			"prev_volume":  integer(true),
+			"growth":       map[string]any{"type": "number", "nullable": true, "description": "Volume growth, %"},
+			"share":        map[string]any{"type": "number", "description": "Share of the area's market, %"},
+			"prev_share":   map[string]any{"type": "number", "nullable": true},
+			"share_change": map[string]any{"type": "number", "nullable": true, "description": "Share change, percentage points"},
+		}, "rank", "name", "volume", "prev_volume", "growth", "share", "prev_share", "share_change"),
+		"RegionRanking": object(map[string]any{
+			"period": ref("RankingPeriod"),
+			"data":   map[string]any{"type": "array", "items": ref("Ranked")},
+		}, "period", "data"),
+		"BrandRanking": object(map[string]any{
+			"period": ref("RankingPeriod"),
+			"data": map[string]any{"type": "array", "items": object(map[string]any{
+				"area":  str(),
+				"items": map[string]any{"type": "array", "items": ref("Ranked")},
+			}, "area", "items")},
+		}, "period", "data"),
+		"ShareChanges": object(map[string]any{
+			"period": ref("RankingPeriod"),
+			"data": map[string]any{"type": "array", "items": object(map[string]any{
+				"area":   str(),
+				"gains":  map[string]any{"type": "array", "items": ref("Ranked")},
+				"losses": map[string]any{"type": "array", "items": ref("Ranked")},
+			}, "area", "gains", "losses")},
+		}, "period", "data"),
+		"Concentration": object(map[string]any{
+			"area":       str(),
+			"district":   map[string]any{"type": "string", "description": "District of a region"},
+			"volume":     integer(false),
+			"brands":     integer(false),
+			"hhi":        map[string]any{"type": "number", "description": "Herfindahl-Hirschman index over brand shares in %, 0-10000"},
+			"cr3":        map[string]any{"type": "number", "description": "Share of the top 3 brands, %"},
+			"cr5":        map[string]any{"type": "number", "description": "Share of the top 5 brands, %"},
+			"prev_hhi":   map[string]any{"type": "number", "nullable": true},
+			"prev_cr3":   map[string]any{"type": "number", "nullable": true},
+			"prev_cr5":   map[string]any{"type": "number", "nullable": true},
+			"hhi_change": map[string]any{"type": "number", "nullable": true},
+			"cr3_change": map[string]any{"type": "number", "nullable": true, "description": "Percentage points"},
+			"cr5_change": map[string]any{"type": "number", "nullable": true, "description": "Percentage points"},
+		}, "area", "volume", "brands", "hhi", "cr3", "cr5", "prev_hhi", "prev_cr3", "prev_cr5", "hhi_change", "cr3_change", "cr5_change"),
+		"ConcentrationList": object(map[string]any{
+			"period": ref("RankingPeriod"),
+			"data":   map[string]any{"type": "array", "items": ref("Concentration")},
+		}, "period", "data"),
+		"BrandVolume": object(map[string]any{
+			"brand":  str(),
+			"volume": integer(false),
+		}, "brand", "volume"),
+		"Gap": object(map[string]any{
+			"area":              str(),
+			"district":          map[string]any{"type": "string", "description": "District of a region"},
+			"market_volume":     integer(false),
+			"competitors":       map[string]any{"type": "integer", "description": "Competitors with registrations in the area"},
+			"leaders":           map[string]any{"type": "array", "items": ref("BrandVolume"), "description": "Largest competitors, at most 3"},
+			"prev_brand_volume": map[string]any{"type": "integer", "nullable": true, "description": "The brand's sales a year earlier; above zero when the area was lost"},
+		}, "area", "market_volume", "competitors", "leaders", "prev_brand_volume"),
+		"Presence": object(map[string]any{
+			"area":        str(),
+			"district":    map[string]any{"type": "string", "description": "District of a region"},
+			"brand":       str(),
+			"volume":      integer(false),
+			"prev_volume": integer(false),
+		}, "area", "brand", "volume", "prev_volume"),
+		"Whitespace": object(map[string]any{
+			"period": ref("RankingPeriod"),
+			"data": object(map[string]any{
+				"brand":      str(),
+				"whitespace": map[string]any{"type": "array", "items": ref("Gap"), "description": "Areas without the brand's registrations, largest markets first"},
+				"entries":    map[string]any{"type": "array", "items": ref("Presence"), "description": "Competitors with registrations now and none a year earlier"},
+				"exits":      map[string]any{"type": "array", "items": ref("Presence"), "description": "Competitors with registrations a year earlier and none now"},
+			}, "brand", "whitespace", "entries", "exits"),
+		}, "period", "data"),
+		"ForecastMonth": object(map[string]any{
+			"month":    integer(false),
+			"value":    integer(false),
+			"forecast": map[string]any{"type": "boolean", "description": "False for actual months"},
+		}, "month", "value", "forecast"),
+		"Projection":      object(projection, projectionRequired...),
+		"BrandProjection": object(withBrand, append([]string{"brand"}, projectionRequired...)...),
+		"Forecast": object(map[string]any{
+			"period": object(map[string]any{
+				"year":          integer(false),
+				"model":         map[string]any{"type": "string", "enum": forecast.Models},
+				"actual_months": integer(false),
+				"prior_months":  map[string]any{"type": "integer", "description": "Months of the previous year loaded; missing months of its profile are filled with its average month"},
+				"confidence":    map[string]any{"type": "number"},
+			}, "year", "model", "actual_months", "prior_months", "confidence"),
+			"data": map[string]any{"type": "array", "items": object(map[string]any{
+				"area":   str(),
+				"total":  ref("Projection"),
+				"brands": map[string]any{"type": "array", "items": ref("BrandProjection")},
+			}, "area", "total", "brands")},
+		}, "period", "data"),
+		"Alert": object(map[string]any{
+			"id":         integer(false),
+			"kind":       map[string]any{"type": "string", "enum": alerts.Kinds},
+			"segment":    str(),
+			"year":       integer(false),
+			"month":      integer(false),
+			"district":   str(),
+			"region":     map[string]any{"type": "string", "description": "Region of share_drop and new_entrant alerts"},
+			"brand":      map[string]any{"type": "string", "description": "Watched brand for share_drop, entering brand for new_entrant"},
+			"value":      map[string]any{"type": "number", "description": "Share in the month, %; the entrant's sales; the district's sales"},
+			"baseline":   map[string]any{"type": "number", "description": "Share over the earlier months, %; 0; sales expected from the seasonal profile"},
+			"message":    str(),
+			"created_at": map[string]any{"type": "string", "format": "date-time"},
+		}, "id", "kind", "segment", "year", "month", "district", "value", "baseline", "message", "created_at"),
+		"AlertList": object(map[string]any{
+			"data": map[string]any{"type": "array", "items": ref("Alert")},
+		}, "data"),
+		"StatusResponse": object(map[string]any{
+			"status": str(),
+		}, "status"),
+	}
+
+	loginOp := map[string]any{
+		"tags":        []string{"auth"},
+		"summary":     "Issue a JWT for login and password",
+		"operationId": "login",
+		"requestBody": map[string]any{
+			"required": true,
+			"content":  jsonContent(ref("LoginRequest")),
+		},
+		"responses": map[string]any{
+			"200": jsonResponse("JWT token", ref("TokenResponse")),
+			"400": jsonResponse("Invalid request body", ref("ErrorResponse")),
+			"401": jsonResponse("Wrong login or password", ref("ErrorResponse")),
+			"413": jsonResponse("Request body is too large", ref("ErrorResponse")),
+			"429": jsonResponse("Too many failed login attempts; see Retry-After", ref("ErrorResponse")),
+		},
+	}
+	verifyOp := map[string]any{
+		"tags":        []string{"auth"},
+		"summary":     "Check that a JWT is valid",
+		"operationId": "verifyToken",
+		"parameters":  []any{authorizationHeader()},
+		"responses": map[string]any{
+			"200": jsonResponse("Token is valid", object(map[string]any{"message": str()}, "message")),
+			"401": jsonResponse("Token is missing or invalid", ref("ErrorResponse")),
+		},
+	}
+
+	paths := map[string]any{
+		APIPrefix + "/auth/token":  map[string]any{"post": loginOp},
+		APIPrefix + "/auth/verify": map[string]any{"get": verifyOp},
+		"/auth":                    map[string]any{"post": deprecated(loginOp, APIPrefix+"/auth/token", "legacyLogin")},
+		"/verify-token":            map[string]any{"get": deprecated(verifyOp, APIPrefix+"/auth/verify", "legacyVerifyToken")},
+		GraphQLPath: map[string]any{
+			"post": map[string]any{
+				"tags":        []string{"graphql"},
+				"summary":     "GraphQL query over registrations: typed filters and district, region and city breakdown",
+				"operationId": "graphql",
+				"requestBody": map[string]any{
+					"required": true,
+					"content":  jsonContent(ref("GraphQLRequest")),
+				},
+				"responses": map[string]any{
+					"200": jsonResponse("GraphQL result; query errors are returned in errors", ref("GraphQLResponse")),
+					"400": jsonResponse("Invalid request body", ref("ErrorResponse")),
+					"429": jsonResponse("Rate limit exceeded; see Retry-After", ref("ErrorResponse")),
+					"503": jsonResponse("Data failed quality checks and is not published", ref("ErrorResponse")),
+				},
+			},
+		},
+		PivotPath: map[string]any{
+			"post": map[string]any{
+				"tags":        []string{"pivot"},
+				"summary":     "Pivot table over registrations with chosen row and column dimensions",
+				"operationId": "pivot",
+				"parameters": []any{
+					queryParameter("format", "csv exports the grid followed by the annotations", map[string]any{"type": "string", "enum": []string{"json", "csv"}, "default": "json"}, false),
+				},
+				"requestBody": map[string]any{
+					"required": true,
+					"content":  jsonContent(ref("PivotSpec")),
+				},
+				"responses": map[string]any{
+					"200": map[string]any{
+						"description": "Grid of rows by column keys with annotations; subtotal rows have null keys",
+						"content": map[string]any{
+							"application/json": map[string]any{"schema": ref("Pivot")},
+							"text/csv":         map[string]any{"schema": str()},
+						},
+					},
+					"400": jsonResponse("Invalid pivot spec", ref("ErrorResponse")),
+					"401": jsonResponse("CSV export without a JWT or API key, or invalid credentials", ref("ErrorResponse")),
+					"403": jsonResponse("API key has no read:segments scope, or no export scope for CSV", ref("ErrorResponse")),
+					"500": jsonResponse("Query failed", ref("ErrorResponse")),
+					"429": jsonResponse("Analytics or export rate limit exceeded; see Retry-After", ref("ErrorResponse")),
+					"503": jsonResponse("Data failed quality checks and is not published", ref("ErrorResponse")),
+				},
+			},
+		},
+		TopModelsPath: map[string]any{
+			"get": map[string]any{
+				"tags":        []string{"models"},
+				"summary":     "Best-selling models of a segment in each district or region with their share",
+				"operationId": "topModels",
+				"parameters": []any{
+					queryParameter("year", "Registration year", integer(false), true),
+					queryParameter("segment", "HDT segment key, e.g. tractors4x2; all registrations when omitted", str(), false),
+					queryParameter("level", "Area to rank models in", map[string]any{"type": "string", "enum": []string{"district", "region"}, "default": "district"}, false),
+					queryParameter("limit", "Models per area", map[string]any{"type": "integer", "minimum": 1, "maximum": 50, "default": DefaultTopModels}, false),
+					queryParameter("district", "Federal district in English; repeat for several", str(), false),
+					queryParameter("region", "Region in English; repeat for several", str(), false),
+					queryParameter("month_from", "First month of registration", integer(false), false),
+					queryParameter("month_to", "Last month of registration", integer(false), false),
+				},
+				"responses": map[string]any{
+					"200": jsonResponse("Areas with their top models", ref("TopModels")),
+					"400": jsonResponse("Invalid parameters", ref("ErrorResponse")),
+					"404": jsonResponse("The year's data has no model column", ref("ErrorResponse")),
+					"500": jsonResponse("Query failed", ref("ErrorResponse")),
+					"429": jsonResponse("Rate limit exceeded; see Retry-After", ref("ErrorResponse")),
+					"503": jsonResponse("Data failed quality checks and is not published", ref("ErrorResponse")),
+				},
+			},
+		},
+		ConcentrationPath: map[string]any{
+			"get": map[string]any{
+				"tags":        []string{"rankings"},
+				"summary":     "Market concentration (HHI, CR3, CR5) in each area with the change against the previous year",
+				"operationId": "concentration",
+				"parameters":  marketParameters("district", []string{"country", "district", "region"}),
+				"responses": map[string]any{
+					"200": jsonResponse("Concentration by area", ref("ConcentrationList")),
+					"400": jsonResponse("Invalid parameters", ref("ErrorResponse")),
+					"500": jsonResponse("Query failed", ref("ErrorResponse")),
+					"429": jsonResponse("Rate limit exceeded; see Retry-After", ref("ErrorResponse")),
+					"503": jsonResponse("Data failed quality checks and is not published", ref("ErrorResponse")),
+				},
+			},
+		},
+		RankingsPath + "/regions": map[string]any{
+			"get": rankingOp("rankRegions", "Regions or districts ranked by a brand's volume, growth or share",
+				append([]any{queryParameter("brand", "Canonical brand id, e.g. FOTON", str(), true)},
+					rankingParameters("region", []string{"district", "region"}, true)...),
+				ref("RegionRanking")),
+		},
+		RankingsPath + "/brands": map[string]any{
+			"get": rankingOp("rankBrands", "Brands ranked in each area by volume, growth or share",
+				rankingParameters("district", []string{"country", "district", "region"}, true), ref("BrandRanking")),
+		},
+		RankingsPath + "/share-changes": map[string]any{
+			"get": rankingOp("shareChanges", "Brands with the biggest share gains and losses against the previous year",
+				rankingParameters("country", []string{"country", "district", "region"}, false), ref("ShareChanges")),
+		},
+		WhitespacePath: map[string]any{
+			"get": map[string]any{
+				"tags":        []string{"rankings"},
+				"summary":     "Areas where a brand has no registrations while competitors sell, and competitors that entered or left an area",
+				"operationId": "whitespace",
+				"parameters": append([]any{queryParameter("brand", "Canonical brand id, e.g. FOTON", str(), true)},
+					marketParameters("region", []string{"district", "region"})...),
+				"responses": map[string]any{
+					"200": jsonResponse("Whitespace and competitor moves against the same months of the previous year", ref("Whitespace")),
+					"400": jsonResponse("Invalid parameters", ref("ErrorResponse")),
+					"500": jsonResponse("Query failed", ref("ErrorResponse")),
+					"429": jsonResponse("Rate limit exceeded; see Retry-After", ref("ErrorResponse")),
+					"503": jsonResponse("Data failed quality checks and is not published", ref("ErrorResponse")),
+				},
+			},
+		},
+		ForecastPath: map[string]any{
+			"get": map[string]any{
+				"tags":        []string{"forecast"},
+				"summary":     "Expected full-year sales of a segment and its brands with a 95% interval",
+				"operationId": "forecast",
+				"parameters": []any{
+					queryParameter("segment", "Segment key: tractors4x2, tractors6x4, dumpers6x4, dumpers8x4, ldt or mdt", map[string]any{"type": "string", "enum": reports.MarketKeys()}, true),
+					queryParameter("year", "Year to forecast; the previous year must be loaded", integer(false), true),
+					queryParameter("level", "Area to forecast", map[string]any{"type": "string", "enum": []string{"country", "district"}, "default": "country"}, false),
+					queryParameter("brand", "Canonical brand id; repeat for several; all brands when omitted", str(), false),
+					queryParameter("model", "seasonal_naive repeats last year's months, profile scales last year's profile by the year-to-date ratio, linear_trend extends the deseasonalised trend", map[string]any{"type": "string", "enum": forecast.Models, "default": forecast.Profile}, false),
+					queryParameter("as_of", "Months taken as actual; defaults to all months loaded", integer(false), false),
+				},
+				"responses": map[string]any{
+					"200": jsonResponse("Forecast by area", ref("Forecast")),
+					"400": jsonResponse("Invalid parameters", ref("ErrorResponse")),
+					"500": jsonResponse("Query failed", ref("ErrorResponse")),
+					"429": jsonResponse("Rate limit exceeded; see Retry-After", ref("ErrorResponse")),
+					"503": jsonResponse("Data failed quality checks and is not published", ref("ErrorResponse")),
+				},
+			},
+		},
+		AlertsPath: map[string]any{
+			"get": map[string]any{
+				"tags":        []string{"alerts"},
+				"summary":     "Alerts on monthly loads: share drops, new competitors in a region, seasonal deviations",
+				"operationId": "listAlerts",
+				"parameters": []any{
+					queryParameter("segment", "Segment key", map[string]any{"type": "string", "enum": reports.MarketKeys()}, false),
+					queryParameter("kind", "Alert kind", map[string]any{"type": "string", "enum": alerts.Kinds}, false),
+					queryParameter("district", "Federal district in English", str(), false),
+					queryParameter("brand", "Canonical brand id", str(), false),
+					queryParameter("year", "Year of the loaded month", integer(false), false),
+					queryParameter("month", "Loaded month", integer(false), false),
+					queryParameter("limit", "Alerts to return", map[string]any{"type": "integer", "minimum": 1, "maximum": alerts.MaxListed, "default": DefaultListedAlerts}, false),
+				},
+				"responses": map[string]any{
+					"200": jsonResponse("Alerts, newest first", ref("AlertList")),
+					"400": jsonResponse("Invalid parameters", ref("ErrorResponse")),
+					"500": jsonResponse("Database error", ref("ErrorResponse")),
+				},
+			},
+		},
+		AlertsPath + "/run": map[string]any{
+			"post": map[string]any{
+				"tags":        []string{"alerts"},
+				"summary":     "Check the latest loaded month of every segment now; new alerts are emailed when SMTP is configured",
+				"operationId": "runAlerts",
+				"security":    authSecurity(),
+				"responses": map[string]any{
+					"200": jsonResponse("Alerts created by this run", ref("AlertList")),
+					"401": jsonResponse("Token is missing or invalid", ref("ErrorResponse")),
+					"403": jsonResponse("User is not an administrator or API key has no admin scope", ref("ErrorResponse")),
+					"500": jsonResponse("Query failed", ref("ErrorResponse")),
+				},
+			},
+		},
+		DataQualityPath: map[string]any{
+			"get": map[string]any{
+				"tags":        []string{"quality"},
+				"summary":     "Latest data quality report: unmapped regions, misspelled brands, month gaps, duplicates, outliers",
+				"operationId": "dataQuality",
+				"responses": map[string]any{
+					"200": jsonResponse("Issues by table; errors block publication when blocking is on", ref("DataQualityReport")),
+					"404": jsonResponse("Data has not been checked yet", ref("ErrorResponse")),
+				},
+			},
+		},
+		DataQualityPath + "/run": map[string]any{
+			"post": map[string]any{
+				"tags":        []string{"quality"},
+				"summary":     "Re-run data quality checks for all tables; administrators only",
+				"operationId": "runDataQuality",
+				"security":    authSecurity(),
+				"responses": map[string]any{
+					"200": jsonResponse("Fresh data quality report", ref("DataQualityReport")),
+					"401": jsonResponse("Token is missing or invalid", ref("ErrorResponse")),
+					"403": jsonResponse("User is not an administrator or API key has no admin scope", ref("ErrorResponse")),
+					"429": jsonResponse("Rate limit exceeded; see Retry-After", ref("ErrorResponse")),
+					"503": jsonResponse("Database is not ready", ref("ErrorResponse")),
+				},
+			},
+		},
+		DealersPath: map[string]any{
+			"get": map[string]any{
+				"tags":        []string{"dealers"},
+				"summary":     "Dealers with the brands they sell and their territories",
+				"operationId": "listDealers",
+				"responses": map[string]any{
+					"200": jsonResponse("Dealers ordered by id", ref("DealerList")),
+					"500": jsonResponse("Database error", ref("ErrorResponse")),
+				},
+			},
+			"post": map[string]any{
+				"tags":        []string{"dealers"},
+				"summary":     "Create a dealer",
+				"operationId": "createDealer",
+				"security":    authSecurity(),
+				"requestBody": map[string]any{
+					"required": true,
+					"content":  jsonContent(ref("DealerInput")),
+				},
+				"responses": map[string]any{
+					"201": jsonResponse("Created dealer", ref("Dealer")),
+					"400": jsonResponse("Invalid dealer", ref("ErrorResponse")),
+					"413": jsonResponse("Request body is too large", ref("ErrorResponse")),
+					"401": jsonResponse("Token is missing or invalid", ref("ErrorResponse")),
+					"403": jsonResponse("User is not an administrator or API key has no admin scope", ref("ErrorResponse")),
+					"500": jsonResponse("Database error", ref("ErrorResponse")),
+				},
+			},
+		},
+		DealersPath + "/{id}": map[string]any{
+			"get": map[string]any{
+				"tags":        []string{"dealers"},
+				"summary":     "Dealer by id",
+				"operationId": "getDealer",
+				"parameters":  []any{idParameter("Dealer id")},
+				"responses": map[string]any{
+					"200": jsonResponse("Dealer", ref("Dealer")),
+					"400": jsonResponse("Invalid id", ref("ErrorResponse")),
+					"404": jsonResponse("Dealer not found", ref("ErrorResponse")),
+					"500": jsonResponse("Database error", ref("ErrorResponse")),
+				},
+			},
+			"put": map[string]any{
+				"tags":        []string{"dealers"},
+				"summary":     "Replace a dealer with its territory",
+				"operationId": "putDealer",
+				"security":    authSecurity(),
+				"parameters":  []any{idParameter("Dealer id")},
+				"requestBody": map[string]any{
+					"required": true,
+					"content":  jsonContent(ref("DealerInput")),
+				},
+				"responses": map[string]any{
+					"200": jsonResponse("Saved dealer", ref("Dealer")),
+					"400": jsonResponse("Invalid dealer", ref("ErrorResponse")),
+					"413": jsonResponse("Request body is too large", ref("ErrorResponse")),
+					"401": jsonResponse("Token is missing or invalid", ref("ErrorResponse")),
+					"403": jsonResponse("User is not an administrator or API key has no admin scope", ref("ErrorResponse")),
+					"404": jsonResponse("Dealer not found", ref("ErrorResponse")),
+					"500": jsonResponse("Database error", ref("ErrorResponse")),
+				},
+			},
+			"delete": map[string]any{
+				"tags":        []string{"dealers"},
+				"summary":     "Delete a dealer with its territory",
+				"operationId": "deleteDealer",
+				"security":    authSecurity(),
+				"parameters":  []any{idParameter("Dealer id")},
+				"responses": map[string]any{
+					"204": map[string]any{"description": "Deleted"},
+					"400": jsonResponse("Invalid id", ref("ErrorResponse")),
+					"401": jsonResponse("Token is missing or invalid", ref("ErrorResponse")),
+					"403": jsonResponse("User is not an administrator or API key has no admin scope", ref("ErrorResponse")),
+					"404": jsonResponse("Dealer not found", ref("ErrorResponse")),
+					"500": jsonResponse("Database error", ref("ErrorResponse")),
+				},
+			},
+		},
+		DealerTerritoriesPath: map[string]any{
+			"get": map[string]any{
+				"tags":        []string{"dealers"},
+				"summary":     "Registrations in each dealer territory and a brand's penetration of the territory market",
+				"operationId": "dealerTerritories",
+				"parameters": []any{
+					queryParameter("segment", "Segment key: tractors4x2, tractors6x4, dumpers6x4, dumpers8x4, ldt or mdt", map[string]any{"type": "string", "enum": reports.MarketKeys()}, true),
+					queryParameter("year", "Registration year", integer(false), true),
+					queryParameter("brand", "Canonical brand id", map[string]any{"type": "string", "default": "FOTON"}, false),
+					queryParameter("month_from", "First month of registration", integer(false), false),
+					queryParameter("month_to", "Last month of registration; defaults to the last month loaded", integer(false), false),
+				},
+				"responses": map[string]any{
+					"200": jsonResponse("Territories by dealer", ref("DealerTerritories")),
+					"400": jsonResponse("Invalid parameters", ref("ErrorResponse")),
+					"500": jsonResponse("Query failed", ref("ErrorResponse")),
+					"429": jsonResponse("Rate limit exceeded; see Retry-After", ref("ErrorResponse")),
+					"503": jsonResponse("Data failed quality checks and is not published", ref("ErrorResponse")),
+				},
+			},
+		},
+		TargetsPath: map[string]any{
+			"get": map[string]any{
+				"tags":        []string{"targets"},
+				"summary":     "Monthly sales targets by segment, district and brand",
+				"operationId": "listTargets",
+				"parameters": []any{
+					queryParameter("segment", "Segment key", map[string]any{"type": "string", "enum": reports.MarketKeys()}, false),
+					queryParameter("year", "Plan year", integer(false), false),
+					queryParameter("brand", "Canonical brand id", str(), false),
+				},
+				"responses": map[string]any{
+					"200": jsonResponse("Targets ordered by segment, year, brand, district and month", ref("TargetList")),
+					"400": jsonResponse("Invalid parameters", ref("ErrorResponse")),
+					"500": jsonResponse("Database error", ref("ErrorResponse")),
+				},
+			},
+			"post": map[string]any{
+				"tags":        []string{"targets"},
+				"summary":     "Upload targets as JSON or CSV; a target for the same month, district and brand is replaced",
+				"operationId": "saveTargets",
+				"security":    authSecurity(),
+				"requestBody": map[string]any{
+					"required": true,
+					"content": map[string]any{
+						"application/json": map[string]any{"schema": map[string]any{"type": "array", "items": ref("Target"), "minItems": 1}},
+						"text/csv": map[string]any{"schema": map[string]any{
+							"type":        "string",
+							"description": "Header row segment,year,month,district,brand,volume,share; brand, volume and share may be empty",
+						}},
+					},
+				},
+				"responses": map[string]any{
+					"200": jsonResponse("Saved targets", ref("TargetList")),
+					"400": jsonResponse("Invalid targets", ref("ErrorResponse")),
+					"413": jsonResponse("Request body is too large", ref("ErrorResponse")),
+					"401": jsonResponse("Token is missing or invalid", ref("ErrorResponse")),
+					"403": jsonResponse("User is not an administrator or API key has no admin scope", ref("ErrorResponse")),
+					"500": jsonResponse("Database error", ref("ErrorResponse")),
+				},
+			},
+			"delete": map[string]any{
+				"tags":        []string{"targets"},
+				"summary":     "Delete a brand's targets for a segment and year",
+				"operationId": "deleteTargets",
+				"security":    authSecurity(),
+				"parameters": []any{
+					queryParameter("segment", "Segment key", map[string]any{"type": "string", "enum": reports.MarketKeys()}, true),
+					queryParameter("year", "Plan year", integer(false), true),
+					queryParameter("brand", "Canonical brand id", map[string]any{"type": "string", "default": "FOTON"}, false),
+				},
+				"responses": map[string]any{
+					"204": map[string]any{"description": "Deleted"},
+					"400": jsonResponse("Invalid parameters", ref("ErrorResponse")),
+					"401": jsonResponse("Token is missing or invalid", ref("ErrorResponse")),
+					"403": jsonResponse("User is not an administrator or API key has no admin scope", ref("ErrorResponse")),
+					"404": jsonResponse("No targets to delete", ref("ErrorResponse")),
+					"500": jsonResponse("Database error", ref("ErrorResponse")),
+				},
+			},
+		},
+		TargetProgressPath: map[string]any{
+			"get": map[string]any{
+				"tags":        []string{"targets"},
+				"summary":     "Plan vs actual from January: achievement, gap, run-rate projection and share against plan by district",
+				"operationId": "targetProgress",
+				"parameters": []any{
+					queryParameter("segment", "Segment key: tractors4x2, tractors6x4, dumpers6x4, dumpers8x4, ldt or mdt", map[string]any{"type": "string", "enum": reports.MarketKeys()}, true),
+					queryParameter("year", "Plan year", integer(false), true),
+					queryParameter("brand", "Canonical brand id", map[string]any{"type": "string", "default": "FOTON"}, false),
+					queryParameter("month_to", "Last month compared; defaults to the last month loaded", integer(false), false),
+				},
+				"responses": map[string]any{
+					"200": jsonResponse("Progress by district with a plan and the total", ref("TargetProgress")),
+					"400": jsonResponse("Invalid parameters", ref("ErrorResponse")),
+					"500": jsonResponse("Query failed", ref("ErrorResponse")),
+					"429": jsonResponse("Rate limit exceeded; see Retry-After", ref("ErrorResponse")),
+					"503": jsonResponse("Data failed quality checks and is not published", ref("ErrorResponse")),
+				},
+			},
+		},
+		ViewsPath: map[string]any{
+			"get": map[string]any{
+				"tags":        []string{"views"},
+				"summary":     "The user's saved views, the default one first",
+				"operationId": "listViews",
+				"security":    authSecurity(),
+				"responses": map[string]any{
+					"200": jsonResponse("Saved views", ref("SavedViewList")),
+					"401": jsonResponse("Token is missing or invalid", ref("ErrorResponse")),
+					"500": jsonResponse("Database error", ref("ErrorResponse")),
+				},
+			},
+			"post": map[string]any{
+				"tags":        []string{"views"},
+				"summary":     "Save a report view with a new share token",
+				"operationId": "createView",
+				"security":    authSecurity(),
+				"requestBody": map[string]any{
+					"required": true,
+					"content":  jsonContent(ref("SavedViewInput")),
+				},
+				"responses": map[string]any{
+					"201": jsonResponse("Created view", ref("SavedView")),
+					"400": jsonResponse("Invalid saved view", ref("ErrorResponse")),
+					"401": jsonResponse("Token is missing or invalid", ref("ErrorResponse")),
+					"500": jsonResponse("Database error", ref("ErrorResponse")),
+				},
+			},
+		},
+		ViewsPath + "/{id}": map[string]any{
+			"get": map[string]any{
+				"tags":        []string{"views"},
+				"summary":     "The user's saved view by id",
+				"operationId": "getView",
+				"security":    authSecurity(),
+				"parameters":  []any{idParameter("Saved view id")},
+				"responses": map[string]any{
+					"200": jsonResponse("Saved view", ref("SavedView")),
+					"400": jsonResponse("Invalid id", ref("ErrorResponse")),
+					"401": jsonResponse("Token is missing or invalid", ref("ErrorResponse")),
+					"404": jsonResponse("Saved view not found or owned by another user", ref("ErrorResponse")),
+					"500": jsonResponse("Database error", ref("ErrorResponse")),
+				},
+			},
+			"put": map[string]any{
+				"tags":        []string{"views"},
+				"summary":     "Replace the user's saved view; the share token is kept",
+				"operationId": "putView",
+				"security":    authSecurity(),
+				"parameters":  []any{idParameter("Saved view id")},
+				"requestBody": map[string]any{
+					"required": true,
+					"content":  jsonContent(ref("SavedViewInput")),
+				},
+				"responses": map[string]any{
+					"200": jsonResponse("Saved view", ref("SavedView")),
+					"400": jsonResponse("Invalid saved view", ref("ErrorResponse")),
+					"401": jsonResponse("Token is missing or invalid", ref("ErrorResponse")),
+					"404": jsonResponse("Saved view not found or owned by another user", ref("ErrorResponse")),
+					"500": jsonResponse("Database error", ref("ErrorResponse")),
+				},
+			},
+			"delete": map[string]any{
+				"tags":        []string{"views"},
+				"summary":     "Delete the user's saved view",
+				"operationId": "deleteView",
+				"security":    authSecurity(),
+				"parameters":  []any{idParameter("Saved view id")},
+				"responses": map[string]any{
+					"204": map[string]any{"description": "Deleted"},
+					"400": jsonResponse("Invalid id", ref("ErrorResponse")),
+					"401": jsonResponse("Token is missing or invalid", ref("ErrorResponse")),
+					"404": jsonResponse("Saved view not found or owned by another user", ref("ErrorResponse")),
+					"500": jsonResponse("Database error", ref("ErrorResponse")),
+				},
+			},
+		},
+		DashboardsPath: map[string]any{
+			"get": map[string]any{
+				"tags":        []string{"views"},
+				"summary":     "The user's dashboards, the default one first",
+				"operationId": "listDashboards",
+				"security":    authSecurity(),
+				"responses": map[string]any{
+					"200": jsonResponse("Dashboards", ref("DashboardList")),
+					"401": jsonResponse("Token is missing or invalid", ref("ErrorResponse")),
+					"500": jsonResponse("Database error", ref("ErrorResponse")),
+				},
+			},
+			"post": map[string]any{
+				"tags":        []string{"views"},
+				"summary":     "Save a dashboard with a new share token",
+				"operationId": "createDashboard",
+				"security":    authSecurity(),
+				"requestBody": map[string]any{
+					"required": true,
+					"content":  jsonContent(ref("DashboardInput")),
+				},
+				"responses": map[string]any{
+					"201": jsonResponse("Created dashboard", ref("Dashboard")),
+					"400": jsonResponse("Invalid dashboard", ref("ErrorResponse")),
+					"401": jsonResponse("Token is missing or invalid", ref("ErrorResponse")),
+					"500": jsonResponse("Database error", ref("ErrorResponse")),
+				},
+			},
+		},
+		DashboardsPath + "/{id}": map[string]any{
+			"get": map[string]any{
+				"tags":        []string{"views"},
+				"summary":     "The user's dashboard by id",
+				"operationId": "getDashboard",
+				"security":    authSecurity(),
+				"parameters":  []any{idParameter("Dashboard id")},
+				"responses": map[string]any{
+					"200": jsonResponse("Dashboard", ref("Dashboard")),
+					"400": jsonResponse("Invalid id", ref("ErrorResponse")),
+					"401": jsonResponse("Token is missing or invalid", ref("ErrorResponse")),
+					"404": jsonResponse("Dashboard not found or owned by another user", ref("ErrorResponse")),
+					"500": jsonResponse("Database error", ref("ErrorResponse")),
+				},
+			},
+			"put": map[string]any{
+				"tags":        []string{"views"},
+				"summary":     "Replace the user's dashboard; the share token is kept",
+				"operationId": "putDashboard",
+				"security":    authSecurity(),
+				"parameters":  []any{idParameter("Dashboard id")},
+				"requestBody": map[string]any{
+					"required": true,
+					"content":  jsonContent(ref("DashboardInput")),
+				},
+				"responses": map[string]any{
+					"200": jsonResponse("Saved dashboard", ref("Dashboard")),
+					"400": jsonResponse("Invalid dashboard", ref("ErrorResponse")),
+					"401": jsonResponse("Token is missing or invalid", ref("ErrorResponse")),
+					"404": jsonResponse("Dashboard not found or owned by another user", ref("ErrorResponse")),
+					"500": jsonResponse("Database error", ref("ErrorResponse")),
+				},
+			},
+			"delete": map[string]any{
+				"tags":        []string{"views"},
+				"summary":     "Delete the user's dashboard",
+				"operationId": "deleteDashboard",
+				"security":    authSecurity(),
+				"parameters":  []any{idParameter("Dashboard id")},
+				"responses": map[string]any{
+					"204": map[string]any{"description": "Deleted"},
+					"400": jsonResponse("Invalid id", ref("ErrorResponse")),
+					"401": jsonResponse("Token is missing or invalid", ref("ErrorResponse")),
+					"404": jsonResponse("Dashboard not found or owned by another user", ref("ErrorResponse")),
+					"500": jsonResponse("Database error", ref("ErrorResponse")),
+				},
+			},
+		},
+		SharedPath + "/views/{token}": map[string]any{
+			"get": map[string]any{
+				"tags":        []string{"views"},
+				"summary":     "A saved view opened by its share link, without signing in",
+				"operationId": "sharedView",
+				"parameters": []any{map[string]any{
+					"name":        "token",
+					"in":          "path",
+					"required":    true,
+					"description": "Share token of the saved view",
+					"schema":      str(),
+				}},
+				"responses": map[string]any{
+					"200": jsonResponse("Saved view", ref("SavedView")),
+					"404": jsonResponse("No saved view with this token", ref("ErrorResponse")),
+					"500": jsonResponse("Database error", ref("ErrorResponse")),
+				},
+			},
+		},
+		SharedPath + "/dashboards/{token}": map[string]any{
+			"get": map[string]any{
+				"tags":        []string{"views"},
+				"summary":     "A dashboard opened by its share link, without signing in",
+				"operationId": "sharedDashboard",
+				"parameters": []any{map[string]any{
+					"name":        "token",
+					"in":          "path",
+					"required":    true,
+					"description": "Share token of the dashboard",
+					"schema":      str(),
+				}},
+				"responses": map[string]any{
+					"200": jsonResponse("Dashboard", ref("Dashboard")),
+					"404": jsonResponse("No dashboard with this token", ref("ErrorResponse")),
+					"500": jsonResponse("Database error", ref("ErrorResponse")),
+				},
+			},
+		},
+		AnnotationsPath: map[string]any{
+			"get": map[string]any{
+				"tags":        []string{"annotations"},
+				"summary":     "Analyst notes on data points; a note with an empty key field matches any value of that filter",
+				"operationId": "listAnnotations",
+				"parameters": []any{
+					queryParameter("segment", "Segment key", str(), false),
+					queryParameter("year", "Year", integer(false), false),
+					queryParameter("month_from", "First month", integer(false), false),
+					queryParameter("month_to", "Last month", integer(false), false),
+					queryParameter("district", "District in English; repeat for several", str(), false),
+					queryParameter("region", "Region in English; repeat for several", str(), false),
+					queryParameter("brand", "Canonical brand id; repeat for several", str(), false),
+					queryParameter("author", "Login of the author", str(), false),
+				},
+				"responses": map[string]any{
+					"200": jsonResponse("Annotations ordered by period", ref("AnnotationList")),
+					"400": jsonResponse("Invalid parameters", ref("ErrorResponse")),
+					"500": jsonResponse("Database error", ref("ErrorResponse")),
+				},
+			},
+			"post": map[string]any{
+				"tags":        []string{"annotations"},
+				"summary":     "Add a note; the author is the token's user",
+				"operationId": "createAnnotation",
+				"security":    authSecurity(),
+				"requestBody": map[string]any{
+					"required": true,
+					"content":  jsonContent(ref("AnnotationInput")),
+				},
+				"responses": map[string]any{
+					"201": jsonResponse("Created annotation", ref("Annotation")),
+					"400": jsonResponse("Invalid annotation", ref("ErrorResponse")),
+					"401": jsonResponse("Token is missing or invalid", ref("ErrorResponse")),
+					"500": jsonResponse("Database error", ref("ErrorResponse")),
+				},
+			},
+		},
+		AnnotationsPath + "/{id}": map[string]any{
+			"put": map[string]any{
+				"tags":        []string{"annotations"},
+				"summary":     "Replace a note; only its author can change it",
+				"operationId": "putAnnotation",
+				"security":    authSecurity(),
+				"parameters":  []any{idParameter("Annotation id")},
+				"requestBody": map[string]any{
+					"required": true,
+					"content":  jsonContent(ref("AnnotationInput")),
+				},
+				"responses": map[string]any{
+					"200": jsonResponse("Saved annotation", ref("Annotation")),
+					"400": jsonResponse("Invalid annotation", ref("ErrorResponse")),
+					"401": jsonResponse("Token is missing or invalid", ref("ErrorResponse")),
+					"404": jsonResponse("Annotation not found or written by another user", ref("ErrorResponse")),
+					"500": jsonResponse("Database error", ref("ErrorResponse")),
+				},
+			},
+			"delete": map[string]any{
+				"tags":        []string{"annotations"},
+				"summary":     "Delete a note; only its author can delete it",
+				"operationId": "deleteAnnotation",
+				"security":    authSecurity(),
+				"parameters":  []any{idParameter("Annotation id")},
+				"responses": map[string]any{
+					"204": map[string]any{"description": "Deleted"},
+					"400": jsonResponse("Invalid id", ref("ErrorResponse")),
+					"401": jsonResponse("Token is missing or invalid", ref("ErrorResponse")),
+					"404": jsonResponse("Annotation not found or written by another user", ref("ErrorResponse")),
+					"500": jsonResponse("Database error", ref("ErrorResponse")),
+				},
+			},
+		},
+		APIKeysPath: map[string]any{
+			"get": map[string]any{
+				"tags":        []string{"api-keys"},
+				"summary":     "API keys for BI tools and scripts, without secrets; administrators only",
+				"operationId": "listAPIKeys",
+				"security":    authSecurity(),
+				"responses": map[string]any{
+					"200": jsonResponse("API keys ordered by creation", ref("APIKeyList")),
+					"401": jsonResponse("Token is missing or invalid", ref("ErrorResponse")),
+					"403": jsonResponse("User is not an administrator or API key has no admin scope", ref("ErrorResponse")),
+					"500": jsonResponse("Database error", ref("ErrorResponse")),
+				},
+			},
+			"post": map[string]any{
+				"tags":        []string{"api-keys"},
+				"summary":     "Issue an API key; the key is returned once and stored hashed",
+				"operationId": "createAPIKey",
+				"security":    authSecurity(),
+				"requestBody": map[string]any{
+					"required": true,
+					"content":  jsonContent(ref("APIKeyInput")),
+				},
+				"responses": map[string]any{
+					"201": jsonResponse("Issued key with the secret", ref("CreatedAPIKey")),
+					"400": jsonResponse("Invalid API key", ref("ErrorResponse")),
+					"413": jsonResponse("Request body is too large", ref("ErrorResponse")),
+					"401": jsonResponse("Token is missing or invalid", ref("ErrorResponse")),
+					"403": jsonResponse("User is not an administrator or API key has no admin scope", ref("ErrorResponse")),
+					"409": jsonResponse("Name is already taken", ref("ErrorResponse")),
+					"500": jsonResponse("Database error", ref("ErrorResponse")),
+				},
+			},
+		},
+		APIKeysPath + "/{id}": map[string]any{
+			"delete": map[string]any{
+				"tags":        []string{"api-keys"},
+				"summary":     "Revoke an API key",
+				"operationId": "deleteAPIKey",
+				"security":    authSecurity(),
+				"parameters":  []any{idParameter("API key id")},
+				"responses": map[string]any{
+					"204": map[string]any{"description": "Revoked"},
+					"400": jsonResponse("Invalid id", ref("ErrorResponse")),
+					"401": jsonResponse("Token is missing or invalid", ref("ErrorResponse")),
+					"403": jsonResponse("User is not an administrator or API key has no admin scope", ref("ErrorResponse")),
+					"404": jsonResponse("API key not found", ref("ErrorResponse")),
+					"500": jsonResponse("Database error", ref("ErrorResponse")),
+				},
+			},
+		},
+		AuditPath: map[string]any{
+			"get": map[string]any{
+				"tags":        []string{"audit"},
+				"summary":     "Append-only audit log of logins, exports, reference data changes and data loads, newest first; administrators only",
+				"operationId": "listAuditEvents",
+				"security":    authSecurity(),
+				"parameters": []any{
+					queryParameter("action", "Action, e.g. auth.login", str(), false),
+					queryParameter("actor", "Login of the user", str(), false),
+					queryParameter("from", "Date (2006-01-02) or RFC 3339 time, inclusive", str(), false),
+					queryParameter("to", "Date (2006-01-02) or RFC 3339 time, exclusive", str(), false),
+					queryParameter("limit", "Events to return; CSV exports up to the maximum by default", map[string]any{"type": "integer", "minimum": 1, "maximum": audit.MaxListed, "default": DefaultListedAuditEvents}, false),
+					queryParameter("format", "csv exports the log as a file", map[string]any{"type": "string", "enum": []string{"json", "csv"}, "default": "json"}, false),
+				},
+				"responses": map[string]any{
+					"200": map[string]any{
+						"description": "Audit events",
+						"content": map[string]any{
+							"application/json": map[string]any{"schema": ref("AuditEventList")},
+							"text/csv":         map[string]any{"schema": str()},
+						},
+					},
+					"400": jsonResponse("Invalid parameters", ref("ErrorResponse")),
+					"401": jsonResponse("Token is missing or invalid", ref("ErrorResponse")),
+					"403": jsonResponse("User is not an administrator or API key has no admin scope", ref("ErrorResponse")),
+					"500": jsonResponse("Database error", ref("ErrorResponse")),
+					"429": jsonResponse("Export rate limit exceeded; see Retry-After", ref("ErrorResponse")),
+				},
+			},
+		},
+		BrandsPath: map[string]any{
+			"get": map[string]any{
+				"tags":        []string{"brands"},
+				"summary":     "Brand master: canonical brand ids, manufacturer groups and spelling aliases",
+				"operationId": "listBrands",
+				"responses": map[string]any{
+					"200": jsonResponse("Brands ordered by id", ref("BrandList")),
+					"500": jsonResponse("Database error", ref("ErrorResponse")),
+				},
+			},
+		},
+		BrandsPath + "/{id}": map[string]any{
+			"get": map[string]any{
+				"tags":        []string{"brands"},
+				"summary":     "Brand by canonical id",
+				"operationId": "getBrand",
+				"parameters":  []any{brandIDParameter()},
+				"responses": map[string]any{
+					"200": jsonResponse("Brand", ref("Brand")),
+					"404": jsonResponse("Brand not found", ref("ErrorResponse")),
+					"500": jsonResponse("Database error", ref("ErrorResponse")),
+				},
+			},
+			"put": map[string]any{
+				"tags":        []string{"brands"},
+				"summary":     "Create or replace a brand with its aliases; an alias listed here moves from any other brand",
+				"operationId": "putBrand",
+				"security":    authSecurity(),
+				"parameters":  []any{brandIDParameter()},
+				"requestBody": map[string]any{
+					"required": true,
+					"content":  jsonContent(ref("BrandInput")),
+				},
+				"responses": map[string]any{
+					"200": jsonResponse("Saved brand", ref("Brand")),
+					"400": jsonResponse("Invalid brand", ref("ErrorResponse")),
+					"413": jsonResponse("Request body is too large", ref("ErrorResponse")),
+					"401": jsonResponse("Token is missing or invalid", ref("ErrorResponse")),
+					"403": jsonResponse("User is not an administrator or API key has no admin scope", ref("ErrorResponse")),
+					"500": jsonResponse("Database error", ref("ErrorResponse")),
+				},
+			},
+			"delete": map[string]any{
+				"tags":        []string{"brands"},
+				"summary":     "Delete a brand and its aliases; brands of its group stay without a group",
+				"operationId": "deleteBrand",
+				"security":    authSecurity(),
+				"parameters":  []any{brandIDParameter()},
+				"responses": map[string]any{
+					"204": map[string]any{"description": "Deleted"},
+					"401": jsonResponse("Token is missing or invalid", ref("ErrorResponse")),
+					"403": jsonResponse("User is not an administrator or API key has no admin scope", ref("ErrorResponse")),
+					"404": jsonResponse("Brand not found", ref("ErrorResponse")),
+					"500": jsonResponse("Database error", ref("ErrorResponse")),
+				},
+			},
+		},
+		"/healthz": map[string]any{
+			"get": map[string]any{
+				"tags":        []string{"service"},
+				"summary":     "Liveness probe",
+				"operationId": "healthz",
+				"responses": map[string]any{
+					"200": jsonResponse("Process is up", ref("StatusResponse")),
+				},
+			},
+		},
+		"/readyz": map[string]any{
+			"get": map[string]any{
+				"tags":        []string{"service"},
+				"summary":     "Readiness probe: database reachable, migrations applied and data tables present",
+				"operationId": "readyz",
+				"responses": map[string]any{
+					"200": jsonResponse("Ready to serve traffic", ref("StatusResponse")),
+					"503": jsonResponse("Database is not ready", ref("ErrorResponse")),
+				},
+			},
+		},
+		"/metrics": map[string]any{
+			"get": map[string]any{
+				"tags":        []string{"service"},
+				"summary":     "Prometheus metrics",
+				"operationId": "metrics",
+				"responses": map[string]any{
+					"200": map[string]any{
+						"description": "Metrics in Prometheus text format",
+						"content":     map[string]any{"text/plain": map[string]any{"schema": str()}},
+					},
+				},
+			},
+		},
+		"/openapi.json": map[string]any{
+			"get": map[string]any{
+				"tags":        []string{"service"},
+				"summary":     "This OpenAPI document",
+				"operationId": "openapi",
+				"responses": map[string]any{
+					"200": jsonResponse("OpenAPI 3 document", map[string]any{"type": "object"}),
+				},
+			},
+		},
+		"/docs": map[string]any{
+			"get": map[string]any{
+				"tags":        []string{"service"},
+				"summary":     "Swagger UI",
+				"operationId": "docs",
+				"responses": map[string]any{
+					"200": map[string]any{
+						"description": "HTML page",
+						"content":     map[string]any{"text/html": map[string]any{"schema": str()}},
+					},
+				},
+			},
+		},
+	}
+
+	for _, route := range reportRoutes {
+		schemas[route.Row.Name] = route.Row.schema()
+
+		op := map[string]any{
+			"tags":        []string{"reports"},
+			"summary":     route.Summary,
+			"operationId": operationID(route),
+			"responses": map[string]any{
+				"200": jsonResponse("Rows grouped by federal district", reportResponseSchema(route.Row.Name)),
+				"429": jsonResponse("Rate limit exceeded; see Retry-After", ref("ErrorResponse")),
+				"500": jsonResponse("Database error", ref("ErrorResponse")),
+				"503": jsonResponse("Report data failed quality checks and is not published", ref("ErrorResponse")),
+			},
+		}
+		if route.Total {
+			op["parameters"] = []any{queryParameter(ConcentrationParam, "Add HHI, CR3 and CR5 columns with their change against the previous year", map[string]any{"type": "boolean", "default": false}, false)}
+		}
+		paths[route.Path()] = map[string]any{"get": op}
+		paths[route.LegacyPath()] = map[string]any{
+			"get": deprecated(op, route.Path(), "legacy"+route.LegacyPath()[1:]),
+		}
+	}
+
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":       "Truck Analytics Platform API",
+			"version":     openAPIVersion,
+			"description": "Truck registration analytics by segment, federal district and region.",
+		},
+		"tags": []any{
+			map[string]any{"name": "reports", "description": "Registration reports by segment and period"},
+			map[string]any{"name": "auth", "description": "Authentication"},
+			map[string]any{"name": "service", "description": "Health, metrics and documentation"},
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"schemas": schemas,
+			"securitySchemes": map[string]any{
+				"jwt": map[string]any{
+					"type": "apiKey",
+					"in":   "header",
+					"name": "Authorization",
+				},
+				"apiKey": map[string]any{
+					"type":        "apiKey",
+					"in":          "header",
+					"name":        APIKeyHeader,
+					"description": "API key issued by an administrator. Open data endpoints also accept it and then require the read:segments scope, CSV exports the export scope. Open data stays readable without credentials, so scopes only narrow what a key can do; CSV exports always need a JWT or a key with the export scope",
+				},
+			},
+		},
+	}
+}
+
+// schema строит JSON Schema строки отчёта
+func (r rowSchema) schema() map[string]any {
+	properties := map[string]any{"region_name": str()}
+	required := []string{"region_name"}
+
+	for _, brand := range r.Brands {
+		properties[brand] = integer(r.Nullable)
+		required = append(required, brand)
+	}
+
+	properties["total"] = integer(false)
+	required = append(required, "total")
+
+	if r.TotalMarket {
+		properties["total_market"] = integer(false)
+		required = append(required, "total_market")
+	}
+
+	// Колонки концентрации есть только в ответах с ?concentration=true
+	if r.Concentration {
+		for _, column := range concentrationColumns {
+			properties[column] = map[string]any{"type": "number", "nullable": true}
+		}
+	}
+
+	return object(properties, required...)
+}
+
+func reportResponseSchema(rowName string) map[string]any {
+	return object(map[string]any{
+		"data": map[string]any{
+			"type":        "object",
+			"description": "Rows keyed by federal district (Summary first for total-market reports)",
+			"additionalProperties": map[string]any{
+				"type":  "array",
+				"items": ref(rowName),
+			},
+		},
+	}, "data")
+}
+
+func errorResponseSchema() map[string]any {
+	return object(map[string]any{
+		"error": object(map[string]any{
+			"code":       str(),
+			"message":    str(),
+			"request_id": str(),
+		}, "code", "message"),
+	}, "error")
+}
+
+// authSecurity — маршрут принимает JWT или API-ключ
+func authSecurity() []any {
+	return []any{map[string]any{"jwt": []string{}}, map[string]any{"apiKey": []string{}}}
+}
+
+func authorizationHeader() map[string]any {
+	return map[string]any{
+		"name":     "Authorization",
+		"in":       "header",
+		"required": true,
+		"schema":   str(),
+	}
+}
+
+func brandIDParameter() map[string]any {
+	return map[string]any{
+		"name":        "id",
+		"in":          "path",
+		"required":    true,
+		"description": "Canonical brand id, e.g. SITRAK",
+		"schema":      str(),
+	}
+}
+
+func idParameter(description string) map[string]any {
+	return map[string]any{
+		"name":        "id",
+		"in":          "path",
+		"required":    true,
+		"description": description,
+		"schema":      integer(false),
+	}
+}
+
+func queryParameter(name, description string, schema map[string]any, required bool) map[string]any {
+	return map[string]any{
+		"name":        name,
+		"in":          "query",
+		"required":    required,
+		"description": description,
+		"schema":      schema,
+	}
+}
+
+// marketParameters — рынок, год, уровень территории и месяцы
+func marketParameters(level string, levels []string) []any {
+	return []any{
+		queryParameter("segment", "Segment key: tractors4x2, tractors6x4, dumpers6x4, dumpers8x4, ldt or mdt", map[string]any{"type": "string", "enum": reports.MarketKeys()}, true),
+		queryParameter("year", "Registration year", integer(false), true),
+		queryParameter("level", "Area to rank in", map[string]any{"type": "string", "enum": levels, "default": level}, false),
+		queryParameter("month_from", "First month of registration", integer(false), false),
+		queryParameter("month_to", "Last month of registration; defaults to the last month loaded for both years", integer(false), false),
+	}
+}
+
+// rankingParameters — параметры рейтингов; withSort добавляет sort и order
+func rankingParameters(level string, levels []string, withSort bool) []any {
+	params := append(marketParameters(level, levels),
+		queryParameter("limit", "Items per ranking", map[string]any{"type": "integer", "minimum": 1, "maximum": reports.MaxRankingItems, "default": DefaultRankingItems}, false),
+	)
+	if withSort {
+		params = append(params,
+			queryParameter("sort", "Ranking metric; growth and share_change need the previous year", map[string]any{"type": "string", "enum": reports.RankingSorts, "default": reports.SortVolume}, false),
+			queryParameter("order", "Sort order", map[string]any{"type": "string", "enum": []string{"desc", "asc"}, "default": "desc"}, false),
+		)
+	}
+	return params
+}
+
+func rankingOp(operationID, summary string, params []any, schema map[string]any) map[string]any {
+	return map[string]any{
+		"tags":        []string{"rankings"},
+		"summary":     summary,
+		"operationId": operationID,
+		"parameters":  params,
+		"responses": map[string]any{
+			"200": jsonResponse("Ranking with the compared period", schema),
+			"400": jsonResponse("Invalid parameters", ref("ErrorResponse")),
+			"429": jsonResponse("Rate limit exceeded; see Retry-After", ref("ErrorResponse")),
+			"503": jsonResponse("Data failed quality checks and is not published", ref("ErrorResponse")),
+			"500": jsonResponse("Query failed", ref("ErrorResponse")),
+		},
+	}
+}
+
+// operationID строит идентификатор операции отчёта: report_9m2024_ldt_regions
+func operationID(route reportRoute) string {
+	level := "regions"
+	if route.Total {
+		level = "districts"
+	}
+	return "report_" + route.Period + "_" + route.Segment + "_" + level
+}
+
+// deprecated описывает устаревший алиас: та же операция с пометкой deprecated
+func deprecated(op map[string]any, successor string, operationID string) map[string]any {
+	alias := make(map[string]any, len(op)+2)
+	for key, value := range op {
+		alias[key] = value
+	}
+	alias["operationId"] = operationID
+	alias["deprecated"] = true
+	alias["description"] = "Deprecated alias of " + successor + ". Responses carry a Deprecation header."
+	return alias
+}
+
+func object(properties map[string]any, required ...string) map[string]any {
+	schema := map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func str() map[string]any {
+	return map[string]any{"type": "string"}
+}
+
+// nullable разрешает null вместо значения схемы
+func nullable(schema map[string]any) map[string]any {
+	schema["nullable"] = true
+	return schema
+}
+
+func integer(nullable bool) map[string]any {
+	schema := map[string]any{"type": "integer"}
+	if nullable {
+		schema["nullable"] = true
+	}
+	return schema
+}
+
+func ref(name string) map[string]any {
+	return map[string]any{"$ref": "#/components/schemas/" + name}
+}
+
+func jsonContent(schema map[string]any) map[string]any {
+	return map[string]any{"application/json": map[string]any{"schema": schema}}
+}
+
+func jsonResponse(description string, schema map[string]any) map[string]any {
+	return map[string]any{
+		"description": description,
+		"content":     jsonContent(schema),
+	}
+}
+
+const swaggerUIPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="utf-8">
+    <title>Truck Analytics Platform API</title>
+    <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+    <div id="swagger-ui"></div>
+    <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+    <script>
+        window.ui = SwaggerUIBundle({ url: "/openapi.json", dom_id: "#swagger-ui" });
+    </script>
+</body>
+</html>
+`
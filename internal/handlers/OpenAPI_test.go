@@ -0,0 +1,316 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"truck-analytics-platform/internal/db"
+	"truck-analytics-platform/internal/db/dbtest"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// loadSpec прогоняет документ через JSON, чтобы проверять ровно то, что отдаёт /openapi.json
+func loadSpec(t *testing.T) map[string]any {
+	t.Helper()
+
+	w := httptest.NewRecorder()
+	NewRouter().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /openapi.json: status %d", w.Code)
+	}
+
+	var spec map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &spec); err != nil {
+		t.Fatalf("decode spec: %v", err)
+	}
+	return spec
+}
+
+var ginParam = regexp.MustCompile(`[:*](\w+)`)
+
+func TestOpenAPIDocumentsEveryRoute(t *testing.T) {
+	spec := loadSpec(t)
+	paths := spec["paths"].(map[string]any)
+
+	for _, route := range NewRouter().Routes() {
+		path := ginParam.ReplaceAllString(route.Path, "{$1}")
+		item, ok := paths[path].(map[string]any)
+		if !ok {
+			t.Errorf("%s %s is not documented", route.Method, route.Path)
+			continue
+		}
+		if _, ok := item[strings.ToLower(route.Method)]; !ok {
+			t.Errorf("%s %s: method is not documented", route.Method, route.Path)
+		}
+	}
+}
+
+func TestReportResponsesMatchSchema(t *testing.T) {
+	spec := loadSpec(t)
+	router := NewRouter()
+
+	for _, route := range reportRoutes {
+		t.Run(route.Path(), func(t *testing.T) {
+			rows := dbtest.RegionRows(12)
+			if route.Total {
+				rows = dbtest.DistrictRows(12)
+			}
+			db.SetQuerier(&dbtest.Querier{Rows: rows})
+			t.Cleanup(func() { db.SetQuerier(nil) })
+
+			body := serve(t, router, http.MethodGet, route.Path(), "", http.StatusOK)
+			schema := responseSchema(t, spec, route.Path(), "get", "200")
+			if err := validate(spec, schema, body, "$"); err != nil {
+				t.Fatal(err)
+			}
+
+			data := body.(map[string]any)["data"].(map[string]any)
+			if len(data) == 0 {
+				t.Fatal("response has no districts")
+			}
+		})
+	}
+}
+
+func TestLegacyRoutesAreDeprecatedAliases(t *testing.T) {
+	router := NewRouter()
+	db.SetQuerier(&dbtest.Querier{Rows: dbtest.RegionRows(12)})
+	t.Cleanup(func() { db.SetQuerier(nil) })
+
+	for _, route := range reportRoutes {
+		if route.Total {
+			continue
+		}
+
+		current := httptest.NewRecorder()
+		router.ServeHTTP(current, httptest.NewRequest(http.MethodGet, route.Path(), nil))
+
+		legacy := httptest.NewRecorder()
+		router.ServeHTTP(legacy, httptest.NewRequest(http.MethodGet, route.LegacyPath(), nil))
+
+		if legacy.Header().Get("Deprecation") != "true" {
+			t.Errorf("%s: missing Deprecation header", route.LegacyPath())
+		}
+		if !strings.Contains(legacy.Header().Get("Link"), route.Path()) {
+			t.Errorf("%s: Link header %q does not point to %s", route.LegacyPath(), legacy.Header().Get("Link"), route.Path())
+		}
+		if legacy.Body.String() != current.Body.String() {
+			t.Errorf("%s: body differs from %s", route.LegacyPath(), route.Path())
+		}
+
+		if current.Header().Get("Deprecation") != "" {
+			t.Errorf("%s: versioned route must not be deprecated", route.Path())
+		}
+	}
+}
+
+func TestErrorResponsesMatchSchema(t *testing.T) {
+	spec := loadSpec(t)
+	router := NewRouter()
+
+	cases := []struct {
+		method, path, body, code string
+		status                   int
+	}{
+		{http.MethodPost, "/auth", `{}`, "400", http.StatusBadRequest},
+		{http.MethodPost, "/auth", `{"login":"x","password":"y"}`, "401", http.StatusUnauthorized},
+		{http.MethodGet, "/verify-token", "", "401", http.StatusUnauthorized},
+		{http.MethodPost, APIPrefix + "/auth/token", `{}`, "400", http.StatusBadRequest},
+		{http.MethodGet, APIPrefix + "/auth/verify", "", "401", http.StatusUnauthorized},
+		{http.MethodGet, "/9m2024ldt", "", "500", http.StatusInternalServerError},
+		{http.MethodPut, BrandsPath + "/SITRAK", `{"name":"Sitrak"}`, "401", http.StatusUnauthorized},
+		{http.MethodDelete, BrandsPath + "/SITRAK", "", "401", http.StatusUnauthorized},
+		{http.MethodGet, TopModelsPath + "?year=2019", "", "400", http.StatusBadRequest},
+		{http.MethodGet, RankingsPath + "/share-changes?segment=ldt&year=2023", "", "400", http.StatusBadRequest},
+		{http.MethodGet, WhitespacePath + "?segment=ldt&year=2024", "", "400", http.StatusBadRequest},
+		{http.MethodGet, ForecastPath + "?segment=ldt&year=2023", "", "400", http.StatusBadRequest},
+		{http.MethodGet, DealersPath + "/abc", "", "400", http.StatusBadRequest},
+		{http.MethodPost, DealersPath, `{"name":"X"}`, "401", http.StatusUnauthorized},
+		{http.MethodPost, TargetsPath, `[]`, "401", http.StatusUnauthorized},
+		{http.MethodDelete, TargetsPath + "?segment=ldt&year=2024", "", "401", http.StatusUnauthorized},
+		{http.MethodGet, TargetProgressPath + "?segment=ldt&year=2019", "", "400", http.StatusBadRequest},
+		{http.MethodGet, ViewsPath, "", "401", http.StatusUnauthorized},
+		{http.MethodPost, DashboardsPath, `{"name":"X"}`, "401", http.StatusUnauthorized},
+		{http.MethodPost, AnnotationsPath, `{"year":2024,"text":"x"}`, "401", http.StatusUnauthorized},
+		{http.MethodPost, PivotPath + "?format=xlsx", pivotGoldenSpec, "400", http.StatusBadRequest},
+		{http.MethodGet, AlertsPath + "?kind=spike", "", "400", http.StatusBadRequest},
+		{http.MethodPost, AlertsPath + "/run", "", "401", http.StatusUnauthorized},
+		{http.MethodGet, AuditPath, "", "401", http.StatusUnauthorized},
+		{http.MethodPost, APIKeysPath, `{"name":"powerbi","scopes":["export"]}`, "401", http.StatusUnauthorized},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.method+" "+tc.path+" "+tc.code, func(t *testing.T) {
+			body := serve(t, router, tc.method, tc.path, tc.body, tc.status)
+			schema := responseSchema(t, spec, tc.path, strings.ToLower(tc.method), tc.code)
+			if err := validate(spec, schema, body, "$"); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func serve(t *testing.T, router http.Handler, method, path, body string, wantStatus int) any {
+	t.Helper()
+
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != wantStatus {
+		t.Fatalf("%s %s: status %d, want %d: %s", method, path, w.Code, wantStatus, w.Body.String())
+	}
+
+	var decoded any
+	if err := json.Unmarshal(w.Body.Bytes(), &decoded); err != nil {
+		t.Fatalf("%s %s: decode body: %v", method, path, err)
+	}
+	return decoded
+}
+
+func responseSchema(t *testing.T, spec map[string]any, path, method, status string) map[string]any {
+	t.Helper()
+
+	defer func() {
+		if r := recover(); r != nil {
+			t.Fatalf("%s %s %s: response is not documented", method, path, status)
+		}
+	}()
+
+	op := specPath(spec, path)[method].(map[string]any)
+	resp := op["responses"].(map[string]any)[status].(map[string]any)
+	content := resp["content"].(map[string]any)["application/json"].(map[string]any)
+	return content["schema"].(map[string]any)
+}
+
+// specPath находит описание пути, в том числе шаблонного: /api/v1/brands/SITRAK -> /api/v1/brands/{id}
+func specPath(spec map[string]any, path string) map[string]any {
+	path, _, _ = strings.Cut(path, "?")
+	paths := spec["paths"].(map[string]any)
+	if item, ok := paths[path].(map[string]any); ok {
+		return item
+	}
+
+	parts := strings.Split(path, "/")
+	for template, item := range paths {
+		templateParts := strings.Split(template, "/")
+		if len(templateParts) != len(parts) {
+			continue
+		}
+		match := true
+		for i, part := range templateParts {
+			if part != parts[i] && !strings.HasPrefix(part, "{") {
+				match = false
+				break
+			}
+		}
+		if match {
+			return item.(map[string]any)
+		}
+	}
+	return nil
+}
+
+// validate проверяет значение по подмножеству JSON Schema, которое использует документ
+func validate(spec map[string]any, schema map[string]any, value any, at string) error {
+	if ref, ok := schema["$ref"].(string); ok {
+		name := strings.TrimPrefix(ref, "#/components/schemas/")
+		target, ok := spec["components"].(map[string]any)["schemas"].(map[string]any)[name].(map[string]any)
+		if !ok {
+			return fmt.Errorf("%s: unresolved $ref %s", at, ref)
+		}
+		return validate(spec, target, value, at)
+	}
+
+	if value == nil {
+		if schema["nullable"] == true {
+			return nil
+		}
+		return fmt.Errorf("%s: null is not allowed", at)
+	}
+
+	switch schema["type"] {
+	case "object":
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return fmt.Errorf("%s: expected object, got %T", at, value)
+		}
+
+		if required, ok := schema["required"].([]any); ok {
+			for _, name := range required {
+				if _, ok := obj[name.(string)]; !ok {
+					return fmt.Errorf("%s: missing required property %q", at, name)
+				}
+			}
+		}
+
+		properties, _ := schema["properties"].(map[string]any)
+		keys := make([]string, 0, len(obj))
+		for key := range obj {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			if prop, ok := properties[key].(map[string]any); ok {
+				if err := validate(spec, prop, obj[key], at+"."+key); err != nil {
+					return err
+				}
+				continue
+			}
+			switch extra := schema["additionalProperties"].(type) {
+			case bool:
+				if !extra {
+					return fmt.Errorf("%s: unexpected property %q", at, key)
+				}
+			case map[string]any:
+				if err := validate(spec, extra, obj[key], at+"."+key); err != nil {
+					return err
+				}
+			}
+		}
+	case "array":
+		items, ok := value.([]any)
+		if !ok {
+			return fmt.Errorf("%s: expected array, got %T", at, value)
+		}
+		itemSchema, _ := schema["items"].(map[string]any)
+		for i, item := range items {
+			if err := validate(spec, itemSchema, item, fmt.Sprintf("%s[%d]", at, i)); err != nil {
+				return err
+			}
+		}
+	case "string":
+		if _, ok := value.(string); !ok {
+			return fmt.Errorf("%s: expected string, got %T", at, value)
+		}
+	case "integer":
+		n, ok := value.(float64)
+		if !ok || n != math.Trunc(n) {
+			return fmt.Errorf("%s: expected integer, got %v", at, value)
+		}
+	case "number":
+		if _, ok := value.(float64); !ok {
+			return fmt.Errorf("%s: expected number, got %T", at, value)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("%s: expected boolean, got %T", at, value)
+		}
+	}
+
+	return nil
+}
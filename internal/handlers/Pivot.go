@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"truck-analytics-platform/internal/annotations"
+	"truck-analytics-platform/internal/audit"
+	"truck-analytics-platform/internal/handlers/utils"
+	"truck-analytics-platform/internal/reports"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PivotPath — эндпоинт сводных таблиц по произвольным измерениям
+const PivotPath = APIPrefix + "/pivot"
+
+// PivotHandler строит сводную таблицу по JSON-спецификации. Измерения
+// проверяются по белому списку, значения фильтров уходят в SQL параметрами.
+// К таблице прикладываются заметки аналитиков к её данным; ?format=csv
+// отдаёт таблицу и заметки файлом; выгрузка записывается в журнал аудита
+func PivotHandler(c *gin.Context) {
+	var spec reports.PivotSpec
+	if err := c.ShouldBindJSON(&spec); err != nil {
+		utils.RespondError(c, http.StatusBadRequest, utils.CodeBadRequest, "Invalid pivot spec", nil)
+		return
+	}
+	if err := spec.Validate(); err != nil {
+		utils.RespondError(c, http.StatusBadRequest, utils.CodeBadRequest, err.Error(), nil)
+		return
+	}
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "csv" {
+		utils.RespondError(c, http.StatusBadRequest, utils.CodeBadRequest, "format must be json or csv", nil)
+		return
+	}
+
+	pivot, err := reports.RunPivot(c.Request.Context(), spec)
+	if errors.Is(err, reports.ErrUnavailable) {
+		utils.RespondError(c, http.StatusBadRequest, utils.CodeBadRequest, err.Error(), nil)
+		return
+	}
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to build pivot", err)
+		return
+	}
+
+	// Без заметок таблица всё равно полезна: сбой чтения заметок не роняет ответ
+	notes, err := annotations.List(c.Request.Context(), annotations.ForPivot(spec))
+	if err != nil {
+		slog.WarnContext(c.Request.Context(), "Annotations are not attached to pivot", "error", err)
+		notes = []annotations.Annotation{}
+	}
+
+	if format == "csv" {
+		writePivotCSV(c, pivot, notes)
+		recordAudit(c, audit.ActionExport, map[string]any{"export": "pivot", "spec": spec, "rows": len(pivot.Grid)})
+		return
+	}
+	c.JSON(http.StatusOK, struct {
+		*reports.Pivot
+		Annotations []annotations.Annotation `json:"annotations"`
+	}{pivot, notes})
+}
+
+// writePivotCSV выгружает сетку сводной таблицы, а после пустой строки —
+// заметки к её данным
+func writePivotCSV(c *gin.Context, pivot *reports.Pivot, notes []annotations.Annotation) {
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", `attachment; filename="pivot.csv"`)
+	c.Status(http.StatusOK)
+
+	w := csv.NewWriter(c.Writer)
+	header := append([]string{}, pivot.Rows...)
+	for _, key := range pivot.ColumnKeys {
+		header = append(header, strings.Join(key, " "))
+	}
+	w.Write(append(header, "total"))
+
+	for _, row := range pivot.Grid {
+		record := make([]string, 0, len(header)+1)
+		labelled := false
+		for _, key := range row.Keys {
+			// Итог по уровню помечается в первом свёрнутом измерении
+			switch {
+			case key != nil:
+				record = append(record, *key)
+			case !labelled:
+				record = append(record, "Total")
+				labelled = true
+			default:
+				record = append(record, "")
+			}
+		}
+		for _, value := range row.Values {
+			record = append(record, strconv.Itoa(value))
+		}
+		w.Write(append(record, strconv.Itoa(row.Total)))
+	}
+
+	if len(notes) > 0 {
+		w.Write([]string{})
+		w.Write([]string{"annotation", "segment", "year", "month", "district", "region", "brand", "author", "created_at"})
+		for _, a := range notes {
+			w.Write([]string{csvText(a.Text), csvText(a.Segment), strconv.Itoa(a.Year), strconv.Itoa(a.Month),
+				csvText(a.District), csvText(a.Region), csvText(a.Brand), csvText(a.Author), a.CreatedAt.UTC().Format(time.RFC3339)})
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		slog.WarnContext(c.Request.Context(), "Pivot CSV export failed", "error", err)
+	}
+}
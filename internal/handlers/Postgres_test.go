@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"truck-analytics-platform/internal/db/dbtest"
+	"truck-analytics-platform/internal/reports"
+)
+
+// fixtureSale — продажи бренда в dbtest/fixture.sql: в отчётах за 9 и за 10
+// месяцев. Отчёт по регионам показывает их в строке региона, отчёт по округам —
+// в строке округа: других регионов с этим брендом в округе нет
+type fixtureSale struct {
+	district, region, brand string
+	nineMonths, tenMonths   int
+}
+
+var fixtureSales = map[string][]fixtureSale{
+	"tractors4x2": {
+		{"Central", "Moscow", "foton", 3, 7},
+		{"Central", "Moscow", "sitrak", 2, 2},
+	},
+	"tractors6x4": {{"Ural", "Sverdlovsk Region", "shacman", 5, 5}},
+	"dumpers6x4":  {{"Ural", "Sverdlovsk Region", "howo", 1, 1}},
+	"dumpers8x4":  {{"Central", "Tula Region", "faw", 6, 6}},
+	"ldt":         {{"Central", "Moscow", "foton", 3, 7}},
+	"mdt": {
+		{"Central", "Moscow", "foton", 3, 7},
+		{"Ural", "Sverdlovsk Region", "howo", 1, 1},
+	},
+}
+
+// TestReportsOnPostgres выполняет запрос каждого отчёта на настоящей БД
+// и сверяет продажи с dbtest/fixture.sql
+func TestReportsOnPostgres(t *testing.T) {
+	dbtest.Postgres(t)
+	reports.ResetColumnsCache()
+
+	for _, route := range reportRoutes {
+		t.Run(route.Path(), func(t *testing.T) {
+			var got struct {
+				Data map[string][]map[string]any `json:"data"`
+			}
+			getJSON(t, route.Path(), &got)
+
+			for _, sale := range fixtureSales[route.Segment] {
+				name := sale.region
+				if route.Total {
+					name = sale.district
+				}
+				want := sale.nineMonths
+				if strings.HasPrefix(route.Period, "10m") {
+					want = sale.tenMonths
+				}
+
+				row := findRow(got.Data[sale.district], name)
+				if row == nil {
+					t.Errorf("%s: no row %q", sale.district, name)
+					continue
+				}
+				if value, _ := row[sale.brand].(float64); int(value) != want {
+					t.Errorf("%s / %s: %s = %v, want %d", sale.district, name, sale.brand, row[sale.brand], want)
+				}
+			}
+		})
+	}
+}
+
+// TestRegistrationsOnPostgres проверяет, что GraphQL читает таблицу и условия
+// рынка сегмента и сводит варианты написания к брендам справочника
+func TestRegistrationsOnPostgres(t *testing.T) {
+	dbtest.Postgres(t)
+
+	tests := []struct {
+		filter string
+		total  int
+		brands map[string]int
+	}{
+		{"year: 2024, segment: tractors4x2, monthTo: 9", 5, map[string]int{"FOTON": 3, "SITRAK": 2}},
+		{"year: 2024, segment: dumpers6x4", 1, map[string]int{"HOWO": 1}},
+		{"year: 2024, segment: mdt", 21, map[string]int{"FAW": 6, "FOTON": 7, "SHACMAN": 5, "SITRAK": 2, "HOWO": 1}},
+		{"year: 2023, segment: ldt, monthTo: 9", 17, map[string]int{"FAW": 6, "FOTON": 3, "SHACMAN": 5, "SITRAK": 2, "HOWO": 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			query := fmt.Sprintf(`{"query":"{ registrations(filter: {%s}) { total brands { brand quantity } } }"}`, tt.filter)
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, GraphQLPath, strings.NewReader(query))
+			req.Header.Set("Content-Type", "application/json")
+			NewRouter().ServeHTTP(w, req)
+
+			var got struct {
+				Data struct {
+					Registrations struct {
+						Total  int          `json:"total"`
+						Brands []brandTotal `json:"brands"`
+					} `json:"registrations"`
+				} `json:"data"`
+				Errors []any `json:"errors"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil || w.Code != http.StatusOK || len(got.Errors) > 0 {
+				t.Fatalf("status %d: %s", w.Code, w.Body.String())
+			}
+
+			if got.Data.Registrations.Total != tt.total {
+				t.Errorf("total = %d, want %d", got.Data.Registrations.Total, tt.total)
+			}
+			brands := map[string]int{}
+			for _, brand := range got.Data.Registrations.Brands {
+				brands[brand.Brand] = brand.Quantity
+			}
+			if fmt.Sprint(brands) != fmt.Sprint(tt.brands) {
+				t.Errorf("brands = %v, want %v", brands, tt.brands)
+			}
+		})
+	}
+}
+
+// TestAnalyticsOnPostgres выполняет запросы аналитики из goldenRequests на
+// настоящей БД: фейк принимает любой SQL, а здесь запрос должен выполниться
+func TestAnalyticsOnPostgres(t *testing.T) {
+	dbtest.Postgres(t)
+	reports.ResetColumnsCache()
+
+	requests := goldenRequests(t)
+	for _, key := range []string{
+		"POST " + PivotPath,
+		"GET " + TopModelsPath,
+		"GET " + ConcentrationPath,
+		"GET " + RankingsPath + "/regions",
+		"GET " + RankingsPath + "/brands",
+		"GET " + RankingsPath + "/share-changes",
+		"GET " + WhitespacePath,
+		"GET " + ForecastPath,
+		"GET " + DealerTerritoriesPath,
+		"GET " + TargetProgressPath,
+	} {
+		t.Run(key, func(t *testing.T) {
+			request := requests[key]
+			method, path, _ := strings.Cut(key, " ")
+			if request.path != "" {
+				path = request.path
+			}
+			req := httptest.NewRequest(method, path, strings.NewReader(request.body))
+			req.Header.Set("Content-Type", "application/json")
+
+			w := httptest.NewRecorder()
+			NewRouter().ServeHTTP(w, req)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status %d: %s", w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func getJSON(t *testing.T, path string, v any) {
+	t.Helper()
+
+	w := httptest.NewRecorder()
+	NewRouter().ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body.String())
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+}
+
+func findRow(rows []map[string]any, name string) map[string]any {
+	for _, row := range rows {
+		if row["region_name"] == name {
+			return row
+		}
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"truck-analytics-platform/internal/db"
+	"truck-analytics-platform/internal/db/dbtest"
+	"truck-analytics-platform/internal/reports"
+	sb "truck-analytics-platform/internal/sqlbuilder"
+
+	"github.com/gin-gonic/gin"
+)
+
+// update перезаписывает golden-файлы: go test ./internal/handlers -update
+var update = flag.Bool("update", false, "rewrite golden files in testdata")
+
+// golden сравнивает got с testdata/<name>, а с -update записывает его туда
+func golden(t *testing.T, name string, got []byte) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read golden file (run with -update to create it): %v", err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("%s differs from golden file:\n%s", path, got)
+	}
+}
+
+// queryText — запрос и его параметры в виде, удобном для ревью golden-файлов
+func queryText(call dbtest.Call) string {
+	var b strings.Builder
+	b.WriteString(strings.TrimSpace(call.SQL))
+	b.WriteString("\n\n")
+	for i, arg := range call.Args {
+		fmt.Fprintf(&b, "-- $%d = %#v\n", i+1, arg)
+	}
+	return b.String()
+}
+
+func TestReportQueries(t *testing.T) {
+	for _, route := range reportRoutes {
+		name := strings.TrimPrefix(route.LegacyPath(), "/")
+		t.Run(name, func(t *testing.T) {
+			querier := &dbtest.Querier{}
+			db.SetQuerier(querier)
+			t.Cleanup(func() { db.SetQuerier(nil) })
+
+			// Обработчик вызывается напрямую, в обход middleware роутера
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, route.Path(), nil)
+			route.Handler(c)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status %d: %s", w.Code, w.Body.String())
+			}
+
+			calls := querier.Calls()
+			if len(calls) != 1 {
+				t.Fatalf("handler ran %d queries, want 1", len(calls))
+			}
+			call := calls[0]
+
+			if !strings.Contains(call.SQL, "FROM "+string(route.Table())) {
+				t.Errorf("query does not read %s, the table the quality gate checks", route.Table())
+			}
+
+			// Данные идут только параметрами: строковых литералов в тексте запроса нет
+			if strings.Contains(call.SQL, "'") {
+				t.Errorf("query contains a string literal:\n%s", call.SQL)
+			}
+			for _, arg := range call.Args {
+				if s, ok := arg.(string); ok && strings.Contains(call.SQL, s) {
+					t.Errorf("parameter %q is also spliced into the query", s)
+				}
+			}
+
+			golden(t, filepath.Join("queries", name+".sql"), []byte(queryText(call)))
+		})
+	}
+}
+
+func TestTopModelsQuery(t *testing.T) {
+	querier := &dbtest.Querier{Responses: []dbtest.Response{
+		modelResponses[0],
+		{Contains: "model_rank", Rows: []dbtest.Row{
+			{Strings: []string{"Центральный Федеральный Округ", "Москва", "HOWO", "T5G"}, Ints: []int{7, 20, 1}},
+		}},
+	}}
+	db.SetQuerier(querier)
+	reports.ResetColumnsCache()
+	t.Cleanup(func() { db.SetQuerier(nil) })
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, TopModelsPath+"?segment=dumpers6x4&year=2023&level=region&region=Moscow&month_to=6", nil)
+	TopModelsHandler(c)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body.String())
+	}
+
+	calls := querier.Calls()
+	call := calls[len(calls)-1]
+	if strings.Contains(call.SQL, "'") {
+		t.Errorf("query contains a string literal:\n%s", call.SQL)
+	}
+	if !strings.Contains(w.Body.String(), `"region":"Moscow"`) {
+		t.Errorf("response has no translated region: %s", w.Body.String())
+	}
+	golden(t, filepath.Join("queries", "models_top.sql"), []byte(queryText(call)))
+}
+
+// TestMonthlyQueries сверяет помесячные продажи рынков, из которых строятся
+// прогноз, алерты и выполнение планов
+func TestMonthlyQueries(t *testing.T) {
+	for _, key := range reports.MarketKeys() {
+		t.Run(key, func(t *testing.T) {
+			querier := &dbtest.Querier{}
+			db.SetQuerier(querier)
+			t.Cleanup(func() { db.SetQuerier(nil) })
+
+			years := []int{2024, 2023}
+			if _, err := reports.Markets[key].MonthlySales(context.Background(), years); err != nil {
+				t.Fatal(err)
+			}
+
+			calls := querier.Calls()
+			if len(calls) != 1 {
+				t.Fatalf("MonthlySales ran %d queries, want 1", len(calls))
+			}
+			call := calls[0]
+
+			// Внешний запрос выбирает округ и регион, поэтому каждая таблица должна их отдавать
+			grouping := "GROUP BY " + sb.FederalDistrict.String() + ", " + sb.Region.String() + ","
+			if n := strings.Count(call.SQL, grouping); n != len(years) {
+				t.Errorf("%d of %d sources group by district and region:\n%s", n, len(years), call.SQL)
+			}
+			if strings.Contains(call.SQL, "'") {
+				t.Errorf("query contains a string literal:\n%s", call.SQL)
+			}
+
+			golden(t, filepath.Join("queries", "monthly_"+key+".sql"), []byte(queryText(call)))
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"net/http"
+
+	"truck-analytics-platform/internal/handlers/utils"
+	"truck-analytics-platform/internal/reports"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RankingsPath — рейтинги регионов и брендов по объёму, росту и доле
+const RankingsPath = APIPrefix + "/rankings"
+
+// DefaultRankingItems — сколько позиций отдаётся без параметра limit
+const DefaultRankingItems = 10
+
+// rankingSpec читает общие параметры рейтингов. Без order позиции идут по убыванию метрики
+func rankingSpec(c *gin.Context, level string) (reports.RankingSpec, bool) {
+	spec := reports.RankingSpec{
+		Sort:      c.DefaultQuery("sort", reports.SortVolume),
+		Ascending: c.Query("order") == "asc",
+	}
+	if order := c.DefaultQuery("order", "desc"); order != "asc" && order != "desc" {
+		utils.RespondError(c, http.StatusBadRequest, utils.CodeBadRequest, "order must be asc or desc", nil)
+		return spec, false
+	}
+
+	var ok bool
+	if spec.MarketSpec, ok = marketSpec(c, level); !ok {
+		return spec, false
+	}
+	var err error
+	if spec.Limit, err = queryInt(c, "limit", DefaultRankingItems); err != nil {
+		utils.RespondError(c, http.StatusBadRequest, utils.CodeBadRequest, err.Error(), nil)
+		return spec, false
+	}
+
+	return spec, true
+}
+
+// marketSpec читает рынок, год, уровень территории и месяцы. Без level — уровень level
+func marketSpec(c *gin.Context, level string) (reports.MarketSpec, bool) {
+	spec := reports.MarketSpec{
+		Segment: c.Query("segment"),
+		Level:   c.DefaultQuery("level", level),
+	}
+	for _, param := range []struct {
+		name  string
+		value *int
+	}{
+		{"year", &spec.Year},
+		{"month_from", &spec.MonthFrom},
+		{"month_to", &spec.MonthTo},
+	} {
+		var err error
+		if *param.value, err = queryInt(c, param.name, 0); err != nil {
+			utils.RespondError(c, http.StatusBadRequest, utils.CodeBadRequest, err.Error(), nil)
+			return spec, false
+		}
+	}
+	return spec, true
+}
+
+// RankRegionsHandler ранжирует регионы или округа по продажам бренда:
+// ?brand=FOTON&segment=tractors4x2&year=2024&sort=growth
+func RankRegionsHandler(c *gin.Context) {
+	spec, ok := rankingSpec(c, reports.LevelRegion)
+	if !ok {
+		return
+	}
+	brand := c.Query("brand")
+	if brand == "" {
+		utils.RespondError(c, http.StatusBadRequest, utils.CodeBadRequest, "brand is required", nil)
+		return
+	}
+	if err := spec.Validate(reports.LevelDistrict, reports.LevelRegion); err != nil {
+		utils.RespondError(c, http.StatusBadRequest, utils.CodeBadRequest, err.Error(), nil)
+		return
+	}
+
+	items, period, err := reports.RankRegions(c.Request.Context(), spec, brand)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to rank regions", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"period": period, "data": items})
+}
+
+// RankBrandsHandler ранжирует бренды на каждой территории: по умолчанию в каждом округе
+func RankBrandsHandler(c *gin.Context) {
+	spec, ok := rankingSpec(c, reports.LevelDistrict)
+	if !ok {
+		return
+	}
+	if err := spec.Validate(reports.LevelCountry, reports.LevelDistrict, reports.LevelRegion); err != nil {
+		utils.RespondError(c, http.StatusBadRequest, utils.CodeBadRequest, err.Error(), nil)
+		return
+	}
+
+	areas, period, err := reports.RankBrands(c.Request.Context(), spec)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to rank brands", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"period": period, "data": areas})
+}
+
+// ShareChangesHandler отдаёт бренды с наибольшим ростом и падением доли
+// к прошлому году: по умолчанию по стране целиком
+func ShareChangesHandler(c *gin.Context) {
+	spec, ok := rankingSpec(c, reports.LevelCountry)
+	if !ok {
+		return
+	}
+	spec.Sort = reports.SortShareChange
+	if err := spec.Validate(reports.LevelCountry, reports.LevelDistrict, reports.LevelRegion); err != nil {
+		utils.RespondError(c, http.StatusBadRequest, utils.CodeBadRequest, err.Error(), nil)
+		return
+	}
+
+	areas, period, err := reports.ShareChanges(c.Request.Context(), spec)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to compare shares", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"period": period, "data": areas})
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"truck-analytics-platform/internal/handlers/utils"
+	"truck-analytics-platform/internal/metrics"
+	"truck-analytics-platform/internal/ratelimit"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimit ограничивает запросы к группе эндпоинтов name квотой: отдельно
+// по IP клиента и по пользователю токена. Сверх квоты — 429 с Retry-After
+func RateLimit(name string, quota ratelimit.Quota, store ratelimit.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if quota.Unlimited() {
+			c.Next()
+			return
+		}
+
+		keys := []string{name + ":ip:" + c.ClientIP()}
+		if user := requestUser(c); user != "" {
+			keys = append(keys, name+":user:"+user)
+		}
+		var wait time.Duration
+		for _, key := range keys {
+			if allowed, retryAfter := store.Hit(key, quota); !allowed {
+				wait = max(wait, retryAfter)
+			}
+		}
+		if wait > 0 {
+			metrics.RateLimited.WithLabelValues(name).Inc()
+			tooManyRequests(c, wait, "Rate limit exceeded, retry later")
+			return
+		}
+		c.Next()
+	}
+}
+
+// ExportRateLimit — квота выгрузок файлами: учитываются только запросы с ?format=csv
+func ExportRateLimit(quota ratelimit.Quota, store ratelimit.Store) gin.HandlerFunc {
+	limit := RateLimit("export", quota, store)
+
+	return func(c *gin.Context) {
+		if c.Query("format") != "csv" {
+			c.Next()
+			return
+		}
+		limit(c)
+	}
+}
+
+// LoginGuard защищает вход от подбора пароля: неудачные попытки считаются
+// по IP и по логину, после lockout.Failures неудач подряд вход закрывается
+// с удвоением срока на каждой следующей неудаче. Успешный вход сбрасывает счёт
+func LoginGuard(lockout ratelimit.Lockout, store ratelimit.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		keys := []string{"login:ip:" + c.ClientIP()}
+		var attempt struct {
+			Login string `json:"login"`
+		}
+		body, ok := peekBody(c)
+		if !ok {
+			return
+		}
+		if json.Unmarshal(body, &attempt) == nil && attempt.Login != "" {
+			c.Set(loginAttemptKey, attempt.Login)
+			keys = append(keys, "login:account:"+attempt.Login)
+		}
+
+		var wait time.Duration
+		for _, key := range keys {
+			wait = max(wait, store.Locked(key))
+		}
+		if wait > 0 {
+			metrics.AuthFailures.WithLabelValues("locked_out").Inc()
+			tooManyRequests(c, wait, "Too many failed login attempts, retry later")
+			return
+		}
+
+		c.Next()
+
+		switch c.Writer.Status() {
+		case http.StatusUnauthorized:
+			for _, key := range keys {
+				if locked := store.Fail(key, lockout); locked > 0 {
+					slog.WarnContext(c.Request.Context(), "Login locked after failed attempts", "key", key, "duration", locked)
+				}
+			}
+		case http.StatusOK:
+			for _, key := range keys {
+				store.Succeed(key)
+			}
+		}
+	}
+}
+
+// MaxPeekedBody — сколько байт тела читают middleware до обработчика:
+// журнал аудита и защита входа, которые стоят и перед входом без токена
+const MaxPeekedBody = 1 << 20
+
+// peekBody читает тело запроса, не больше MaxPeekedBody, и возвращает его
+// на место для обработчика. На слишком большое тело отвечает 413 и возвращает false
+func peekBody(c *gin.Context) ([]byte, bool) {
+	if c.Request.Body == nil {
+		return nil, true
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, MaxPeekedBody))
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		utils.RespondError(c, http.StatusRequestEntityTooLarge, utils.CodeBadRequest, "Request body is too large", nil)
+		return nil, false
+	}
+	if err != nil {
+		slog.WarnContext(c.Request.Context(), "Request body is not read", "error", err)
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	return body, true
+}
+
+func tooManyRequests(c *gin.Context, wait time.Duration, message string) {
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	utils.RespondError(c, http.StatusTooManyRequests, utils.CodeTooManyRequests, message, nil)
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"truck-analytics-platform/internal/db"
+	"truck-analytics-platform/internal/db/dbtest"
+)
+
+func TestLoginLockout(t *testing.T) {
+	t.Setenv("LOGIN_MAX_FAILURES", "3")
+	db.SetQuerier(&dbtest.Querier{})
+	t.Cleanup(func() { db.SetQuerier(nil) })
+	spec := loadSpec(t)
+	router := NewRouter()
+
+	for range 3 {
+		serve(t, router, http.MethodPost, APIPrefix+"/auth/token", `{"login":"foton-trucks","password":"guess"}`, http.StatusUnauthorized)
+	}
+	// Верный пароль во время блокировки не помогает
+	body := serve(t, router, http.MethodPost, APIPrefix+"/auth/token", `{"login":"foton-trucks","password":"foton1996"}`, http.StatusTooManyRequests)
+	schema := responseSchema(t, spec, APIPrefix+"/auth/token", "post", "429")
+	if err := validate(spec, schema, body, "$"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAnalyticsQuota(t *testing.T) {
+	t.Setenv("RATE_LIMIT_ANALYTICS", "2/1m")
+	db.SetQuerier(&dbtest.Querier{Rows: pivotRows})
+	t.Cleanup(func() { db.SetQuerier(nil) })
+	router := NewRouter()
+
+	for range 2 {
+		serve(t, router, http.MethodPost, PivotPath, pivotGoldenSpec, http.StatusOK)
+	}
+	serve(t, router, http.MethodPost, PivotPath, pivotGoldenSpec, http.StatusTooManyRequests)
+	// Маршруты вне группы квотой не ограничиваются
+	serve(t, router, http.MethodGet, "/healthz", "", http.StatusOK)
+}
+
+func TestReportQuotaIgnoresForwardedForFromUntrustedClients(t *testing.T) {
+	t.Setenv("RATE_LIMIT_ANALYTICS", "1/1m")
+	db.SetQuerier(&dbtest.Querier{Rows: dbtest.RegionRows(12)})
+	t.Cleanup(func() { db.SetQuerier(nil) })
+	path := reportRoutes[0].Path()
+
+	get := func(router http.Handler, forwardedFor string) int {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// Без TRUSTED_PROXIES подставной X-Forwarded-For не даёт новой квоты
+	router := NewRouter()
+	if code := get(router, "203.0.113.1"); code != http.StatusOK {
+		t.Fatalf("first request: status %d", code)
+	}
+	if code := get(router, "203.0.113.2"); code != http.StatusTooManyRequests {
+		t.Fatalf("forged client IP: status %d, want %d", code, http.StatusTooManyRequests)
+	}
+
+	// За доверенным прокси клиенты различаются по X-Forwarded-For
+	t.Setenv("TRUSTED_PROXIES", "192.0.2.0/24")
+	router = NewRouter()
+	for _, ip := range []string{"203.0.113.1", "203.0.113.2"} {
+		if code := get(router, ip); code != http.StatusOK {
+			t.Fatalf("client %s behind a trusted proxy: status %d", ip, code)
+		}
+	}
+}
+
+func TestLoginRejectsOversizedBody(t *testing.T) {
+	db.SetQuerier(&dbtest.Querier{})
+	t.Cleanup(func() { db.SetQuerier(nil) })
+
+	body := `{"login":"foton-trucks","password":"` + strings.Repeat("x", MaxPeekedBody) + `"}`
+	for _, path := range []string{APIPrefix + "/auth/token", "/auth"} {
+		serve(t, NewRouter(), http.MethodPost, path, body, http.StatusRequestEntityTooLarge)
+	}
+}
@@ -1,141 +1,242 @@
 package handlers
 
 import (
-	"log"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
-	"sync"
+	"os"
+	"strings"
+	"time"
 
-	october2023 "truck-analytics-platform/internal/handlers/2023/october"
-	september2023 "truck-analytics-platform/internal/handlers/2023/september"
-	october2024 "truck-analytics-platform/internal/handlers/2024/october"
-	september2024 "truck-analytics-platform/internal/handlers/2024/september"
+	"truck-analytics-platform/internal/apikeys"
+	"truck-analytics-platform/internal/audit"
 	"truck-analytics-platform/internal/handlers/utils"
+	"truck-analytics-platform/internal/metrics"
+	"truck-analytics-platform/internal/ratelimit"
 
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
-func InitRouter() {
-	var wg sync.WaitGroup
-
-	// API-сервер
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		server := gin.Default()
-		server.Use(CORSMiddleware())
-
-		// 9 MONTH
-
-		// 2023
-		// Tractors
-		server.Handle("GET", "/9m2023tractors4x2", september2023.NineMonth2023Tractors4x2)
-		server.Handle("GET", "/9m2023tractors6x4", september2023.NineMonth2023Tractors6x4)
-
-		// Dumpers
-		server.Handle("GET", "/9m2023dumpers6x4", september2023.NineMonth2023Dumpers6x4)
-		server.Handle("GET", "/9m2023dumpers8x4", september2023.NineMonth2023Dumpers8x4)
-
-		// LDT | MDT
-		server.Handle("GET", "/9m2023ldt", september2023.NineMonth2023Ldt)
-		server.Handle("GET", "/9m2023mdt", september2023.NineMonth2023Mdt)
-
-		// -----------------------
-
-		// 2024
-		// Tractors
-		server.Handle("GET", "/9m2024tractors4x2", september2024.NineMonth2024Tractors4x2)
-		server.Handle("GET", "/9m2024tractors6x4", september2024.NineMonth2024Tractors6x4)
-
-		// Dumpers
-		server.Handle("GET", "/9m2024dumpers6x4", september2024.NineMonth2024Dumpers6x4)
-		server.Handle("GET", "/9m2024dumpers8x4", september2024.NineMonth2024Dumpers8x4)
-
-		// LDT | MDT
-		server.Handle("GET", "/9m2024ldt", september2024.NineMonth2024Ldt)
-		server.Handle("GET", "/9m2024mdt", september2024.NineMonth2024Mdt)
-
-		// -----------------------
-
-		// Total market 9M 2023
-		server.Handle("GET", "/9m2023tractors4x2total", september2023.Tractors4x2WithTotalMarket2023)
-		server.Handle("GET", "/9m2023tractors6x4total", september2023.Tractors6x4WithTotalMarket2023)
-		server.Handle("GET", "/9m2023dumpers6x4total", september2023.Dumpers6x4WithTotalMarket2023)
-		server.Handle("GET", "/9m2023dumpers8x4total", september2023.Dumpers8x4WithTotalMarket2023)
-		server.Handle("GET", "/9m2023ldttotal", september2023.NineMonth2023LDTTotal)
-		server.Handle("GET", "/9m2023mdttotal", september2023.NineMonth2023MDTTotal)
-
-		// -----------------------
-
-		// Total market 9M 2024
-		server.Handle("GET", "/9m2024tractors4x2total", september2024.Tractors4x2WithTotalMarket2024)
-		server.Handle("GET", "/9m2024tractors6x4total", september2024.Tractors6x4WithTotalMarket2024)
-		server.Handle("GET", "/9m2024dumpers6x4total", september2024.Dumpers6x4WithTotalMarket2024)
-		server.Handle("GET", "/9m2024dumpers8x4total", september2024.Dumpers8x4WithTotalMarket2024)
-		server.Handle("GET", "/9m2024ldttotal", september2024.NineMonth2024LDTTotal)
-		server.Handle("GET", "/9m2024mdttotal", september2024.NineMonth2024MDTTotal)
-
-		// -------------------------------------
-
-		// MDT 2023 10M
-		server.Handle("GET", "/10m2023mdt", october2023.TenMonth2023Mdt)
-		server.Handle("GET", "/10m2023mdttotal", october2023.TenMonth2023MDTTotal)
+// Таймауты HTTP-серверов. WriteTimeout с запасом покрывает самые тяжёлые
+// аналитические запросы, ShutdownTimeout — время на завершение активных запросов
+const (
+	ReadHeaderTimeout = 5 * time.Second
+	ReadTimeout       = 15 * time.Second
+	WriteTimeout      = 60 * time.Second
+	IdleTimeout       = 120 * time.Second
+	ShutdownTimeout   = 30 * time.Second
+)
 
-		// LDT 2023 10M
-		server.Handle("GET", "/10m2023ldt", october2023.TenMonth2023Ldt)
-		server.Handle("GET", "/10m2023ldttotal", october2023.TenMonth2023LDTTotal)
+// TrustedProxies возвращает адреса и подсети прокси перед API из TRUSTED_PROXIES
+// через запятую, например 10.0.0.0/8. По умолчанию прокси не доверяют
+// и IP клиента — адрес соединения
+func TrustedProxies() []string {
+	var proxies []string
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
+}
 
-		// MDT 2024 10M
-		server.Handle("GET", "/10m2024mdt", october2024.TenMonth2024Mdt)
-		server.Handle("GET", "/10m2024mdttotal", october2024.TenMonth2024MDTTotal)
+// NewRouter собирает gin-роутер API со всеми маршрутами
+func NewRouter() *gin.Engine {
+	server := gin.New()
+	// IP клиента из X-Forwarded-For учитывается в квотах, защите входа и журнале
+	// аудита, поэтому заголовок принимается только от известных прокси
+	if err := server.SetTrustedProxies(TrustedProxies()); err != nil {
+		slog.Error("Invalid TRUSTED_PROXIES, trusting no proxies", "error", err)
+		server.SetTrustedProxies(nil)
+	}
+	server.Use(RequestLogger(), gin.CustomRecovery(RecoveryHandler), CORSMiddleware(), metrics.Middleware())
+
+	// Служебные эндпоинты для Docker и мониторинга
+	server.GET("/healthz", HealthHandler)
+	server.GET("/readyz", ReadyHandler)
+	server.GET("/metrics", gin.WrapH(promhttp.Handler()))
+
+	// Квоты тяжёлых аналитических запросов и выгрузок, защита входа от подбора
+	// пароля. Счётчики — в памяти процесса
+	limits := ratelimit.ConfigFromEnv()
+	store := ratelimit.NewMemory()
+	analytics := RateLimit("analytics", limits.Analytics, store)
+	export := ExportRateLimit(limits.Export, store)
+	loginGuard := LoginGuard(limits.Login, store)
+
+	// Данные открыты и без входа, но API-ключ, если он передан, должен иметь
+	// право на чтение сегментов, а для выгрузок — на экспорт. Изменения
+	// справочников и планов по ключу требуют права admin
+	read := OptionalAuth(apikeys.ScopeReadSegments)
+	exportScope := ExportScope()
+
+	// Данные таблиц с ошибками качества не публикуются ни в отчётах,
+	// ни в произвольных выборках, если блокировка включена
+	published := MarketQualityGate()
+
+	// Старые пути отчётов вида /9m2024tractors4x2 остаются алиасами новых
+	// и помечаются заголовком Deprecation
+	for _, route := range reportRoutes {
+		chain := []gin.HandlerFunc{read, QualityGate(route.Table()), analytics}
+		if route.Total {
+			chain = append(chain, ConcentrationColumns(route))
+		}
+		chain = append(chain, route.Handler)
 
-		// LDT 2024 10M
-		server.Handle("GET", "/10m2024ldt", october2024.TenMonth2024Ldt)
-		server.Handle("GET", "/10m2024ldttotal", october2024.TenMonth2024LDTTotal)
+		server.GET(route.Path(), chain...)
+		server.GET(route.LegacyPath(), append([]gin.HandlerFunc{DeprecatedAlias(route.Path())}, chain...)...)
+	}
 
-		// HDT 2023 10M 4x2 Tractors
-		server.Handle("GET", "/10m2023tractors4x2", october2023.TenMonth2023Tractors4x2)
-		server.Handle("GET", "/10m2023tractors4x2total", october2023.TenTractors4x2WithTotalMarket2023)
+	// Качество загруженных данных. Изменения справочников и планов,
+	// ручные проверки и выгрузки записываются в журнал аудита
+	// Ручная проверка читает все таблицы, поэтому доступна только администраторам и в пределах квоты
+	server.GET(DataQualityPath, DataQualityHandler)
+	server.POST(DataQualityPath+"/run", Audited(audit.ActionDataCheck), AuthRequired(apikeys.ScopeAdmin), AdminRequired(), analytics, RunDataQualityHandler)
+
+	// Справочник брендов: чтение открыто, изменения — только администраторам
+	server.GET(BrandsPath, ListBrandsHandler)
+	server.GET(BrandsPath+"/:id", GetBrandHandler)
+	server.PUT(BrandsPath+"/:id", Audited(audit.ActionBrandChange), AuthRequired(apikeys.ScopeAdmin), AdminRequired(), PutBrandHandler)
+	server.DELETE(BrandsPath+"/:id", Audited(audit.ActionBrandChange), AuthRequired(apikeys.ScopeAdmin), AdminRequired(), DeleteBrandHandler)
+
+	// Дилеры и их территории: чтение открыто, изменения — только администраторам
+	server.GET(DealersPath, ListDealersHandler)
+	server.POST(DealersPath, Audited(audit.ActionDealerChange), AuthRequired(apikeys.ScopeAdmin), AdminRequired(), CreateDealerHandler)
+	server.GET(DealersPath+"/:id", GetDealerHandler)
+	server.PUT(DealersPath+"/:id", Audited(audit.ActionDealerChange), AuthRequired(apikeys.ScopeAdmin), AdminRequired(), PutDealerHandler)
+	server.DELETE(DealersPath+"/:id", Audited(audit.ActionDealerChange), AuthRequired(apikeys.ScopeAdmin), AdminRequired(), DeleteDealerHandler)
+	server.GET(DealerTerritoriesPath, read, published, analytics, DealerTerritoriesHandler)
+
+	// Планы продаж и их выполнение: чтение открыто, загрузка — только администраторам
+	server.GET(TargetsPath, ListTargetsHandler)
+	server.POST(TargetsPath, Audited(audit.ActionTargetsChange), AuthRequired(apikeys.ScopeAdmin), AdminRequired(), SaveTargetsHandler)
+	server.DELETE(TargetsPath, Audited(audit.ActionTargetsChange), AuthRequired(apikeys.ScopeAdmin), AdminRequired(), DeleteTargetsHandler)
+	server.GET(TargetProgressPath, read, published, analytics, TargetProgressHandler)
+
+	// Сохранённые виды и дашборды пользователя; по ссылке с токеном — без входа
+	server.GET(ViewsPath, AuthRequired(), ListViewsHandler)
+	server.POST(ViewsPath, AuthRequired(), CreateViewHandler)
+	server.GET(ViewsPath+"/:id", AuthRequired(), GetViewHandler)
+	server.PUT(ViewsPath+"/:id", AuthRequired(), PutViewHandler)
+	server.DELETE(ViewsPath+"/:id", AuthRequired(), DeleteViewHandler)
+	server.GET(DashboardsPath, AuthRequired(), ListDashboardsHandler)
+	server.POST(DashboardsPath, AuthRequired(), CreateDashboardHandler)
+	server.GET(DashboardsPath+"/:id", AuthRequired(), GetDashboardHandler)
+	server.PUT(DashboardsPath+"/:id", AuthRequired(), PutDashboardHandler)
+	server.DELETE(DashboardsPath+"/:id", AuthRequired(), DeleteDashboardHandler)
+	server.GET(SharedPath+"/views/:token", SharedViewHandler)
+	server.GET(SharedPath+"/dashboards/:token", SharedDashboardHandler)
+
+	// Заметки к данным: чтение открыто, изменения — по JWT и только автором
+	server.GET(AnnotationsPath, ListAnnotationsHandler)
+	server.POST(AnnotationsPath, AuthRequired(), CreateAnnotationHandler)
+	server.PUT(AnnotationsPath+"/:id", AuthRequired(), PutAnnotationHandler)
+	server.DELETE(AnnotationsPath+"/:id", AuthRequired(), DeleteAnnotationHandler)
+
+	// Произвольные выборки по регистрациям для BI
+	server.POST(GraphQLPath, read, published, analytics, GraphQLHandler)
+	server.POST(PivotPath, read, exportScope, published, analytics, export, PivotHandler)
+	server.GET(TopModelsPath, read, published, analytics, TopModelsHandler)
+
+	server.GET(ConcentrationPath, read, published, analytics, ConcentrationHandler)
+
+	// Рейтинги по всем сегментам вместо ручной сортировки выгруженных отчётов
+	server.GET(RankingsPath+"/regions", read, published, analytics, RankRegionsHandler)
+	server.GET(RankingsPath+"/brands", read, published, analytics, RankBrandsHandler)
+	server.GET(RankingsPath+"/share-changes", read, published, analytics, ShareChangesHandler)
+	server.GET(WhitespacePath, read, published, analytics, WhitespaceHandler)
+
+	// Прогноз полного года для страниц с неполным годом
+	server.GET(ForecastPath, read, published, analytics, ForecastHandler)
+
+	// Алерты по месячным загрузкам: список открыт, ручной запуск — только администраторам
+	server.GET(AlertsPath, ListAlertsHandler)
+	server.POST(AlertsPath+"/run", Audited(audit.ActionAlertsRun), AuthRequired(apikeys.ScopeAdmin), AdminRequired(), RunAlertsHandler)
+
+	// Журнал аудита: входы, выгрузки, изменения справочников и загрузки данных
+	server.GET(AuditPath, AuthRequired(apikeys.ScopeAdmin), AdminRequired(), exportScope, export, ListAuditHandler)
+
+	// API-ключи для BI и скриптов выдают и отзывают администраторы
+	server.GET(APIKeysPath, AuthRequired(apikeys.ScopeAdmin), AdminRequired(), ListAPIKeysHandler)
+	server.POST(APIKeysPath, Audited(audit.ActionAPIKeyChange), AuthRequired(apikeys.ScopeAdmin), AdminRequired(), CreateAPIKeyHandler)
+	server.DELETE(APIKeysPath+"/:id", Audited(audit.ActionAPIKeyChange), AuthRequired(apikeys.ScopeAdmin), AdminRequired(), DeleteAPIKeyHandler)
+
+	// Попытки входа, в том числе заблокированные, записываются в журнал аудита
+	server.POST(APIPrefix+"/auth/token", Audited(audit.ActionLogin), loginGuard, AuthHandler)
+	server.GET(APIPrefix+"/auth/verify", VerifyTokenHandler)
+	server.POST("/auth", DeprecatedAlias(APIPrefix+"/auth/token"), Audited(audit.ActionLogin), loginGuard, AuthHandler)
+	server.GET("/verify-token", DeprecatedAlias(APIPrefix+"/auth/verify"), VerifyTokenHandler)
+
+	// Документация API
+	server.GET("/openapi.json", OpenAPIHandler)
+	server.GET("/docs", SwaggerUIHandler)
+
+	return server
+}
 
-		// HDT 2023 10M 6x4 Tractors
-		server.Handle("GET", "/10m2023tractors6x4", october2023.TenMonth2023Tractors6x4)
-		server.Handle("GET", "/10m2023tractors6x4total", october2023.TenTractors6x4WithTotalMarket2023)
+// InitRouter запускает API-сервер и фронтенд-сервер и блокируется до отмены ctx
+// (SIGINT/SIGTERM) или падения одного из серверов. При отмене серверы перестают
+// принимать новые соединения и дожидаются завершения активных запросов
+func InitRouter(ctx context.Context) error {
+	servers := []*http.Server{
+		newServer(":8080", NewRouter()),
+		newServer(":80", http.FileServer(http.Dir("./frontend"))),
+	}
 
-		// HDT 2023 10M 6x4 Dumpers
-		server.Handle("GET", "/10m2023dumpers6x4", october2023.TenMonth2023Dumpers6x4)
-		server.Handle("GET", "/10m2023dumpers6x4total", october2023.TenDumpers6x4WithTotalMarket2023)
+	errs := make(chan error, len(servers))
+	for _, srv := range servers {
+		go func(srv *http.Server) {
+			slog.Info("Server is running", "addr", srv.Addr)
+			if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				errs <- fmt.Errorf("server %s: %w", srv.Addr, err)
+				return
+			}
+			errs <- nil
+		}(srv)
+	}
 
-		// HDT 2023 10M 8x4 Dumpers
-		server.Handle("GET", "/10m2023dumpers8x4", october2023.TenMonth2023Dumpers8x4)
-		server.Handle("GET", "/10m2023dumpers8x4total", october2023.TenDumpers8x4WithTotalMarket2023)
+	var runErr error
+	select {
+	case <-ctx.Done():
+		slog.Info("Shutdown signal received, draining requests")
+	case runErr = <-errs:
+		slog.Error("Server stopped unexpectedly", "error", runErr)
+	}
 
-		server.POST("/auth", AuthHandler)
-		server.GET("/verify-token", VerifyTokenHandler)
+	// Ожидание завершения активных запросов
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), ShutdownTimeout)
+	defer cancel()
 
-		log.Println("API server is running on port 8080...")
-		if err := http.ListenAndServe(":8080", server); err != nil {
-			log.Fatalf("Failed to start API server: %v", err)
-		}
-	}()
-
-	// Фронтенд-сервер
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		log.Println("Frontend server is running on port 80...")
-		if err := http.ListenAndServe(":80", http.FileServer(http.Dir("./frontend"))); err != nil {
-			log.Fatalf("Failed to start frontend server: %v", err)
+	for _, srv := range servers {
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			slog.Error("Graceful shutdown failed", "addr", srv.Addr, "error", err)
+			runErr = errors.Join(runErr, err)
 		}
-	}()
+	}
 
-	// Ожидание завершения работы серверов
-	wg.Wait()
+	return runErr
+}
+
+func newServer(addr string, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: ReadHeaderTimeout,
+		ReadTimeout:       ReadTimeout,
+		WriteTimeout:      WriteTimeout,
+		IdleTimeout:       IdleTimeout,
+	}
 }
 
 func CORSMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Origin, Content-Type, Authorization")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Origin, Content-Type, Authorization, "+APIKeyHeader+", "+RequestIDHeader)
+		c.Writer.Header().Set("Access-Control-Expose-Headers", RequestIDHeader+", Deprecation, Link")
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204) // завершает запрос на этапе OPTIONS
@@ -146,6 +247,18 @@ func CORSMiddleware() gin.HandlerFunc {
 	}
 }
 
+// DeprecatedAlias помечает устаревший путь заголовками Deprecation и Link
+// на путь-преемник в /api/v1
+func DeprecatedAlias(successor string) gin.HandlerFunc {
+	link := fmt.Sprintf("<%s>; rel=\"successor-version\"", successor)
+
+	return func(c *gin.Context) {
+		c.Header("Deprecation", "true")
+		c.Header("Link", link)
+		c.Next()
+	}
+}
+
 // AuthHandler обрабатывает запросы на авторизацию
 func AuthHandler(c *gin.Context) {
 	var loginData struct {
@@ -154,16 +267,19 @@ func AuthHandler(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&loginData); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data"})
+		utils.RespondError(c, http.StatusBadRequest, utils.CodeBadRequest, "Invalid request data", nil)
 		return
 	}
+	c.Set(loginAttemptKey, loginData.Login)
 
 	token, err := utils.CreateJWT(loginData.Login, loginData.Password)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		metrics.AuthFailures.WithLabelValues("invalid_credentials").Inc()
+		utils.RespondError(c, http.StatusUnauthorized, utils.CodeUnauthorized, "Wrong password or login", nil)
 		return
 	}
 
+	c.Set(UserKey, loginData.Login)
 	c.JSON(http.StatusOK, gin.H{"token": token})
 }
 
@@ -171,15 +287,19 @@ func AuthHandler(c *gin.Context) {
 func VerifyTokenHandler(c *gin.Context) {
 	token := c.GetHeader("Authorization")
 	if token == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Token is required"})
+		metrics.AuthFailures.WithLabelValues("missing_token").Inc()
+		utils.RespondError(c, http.StatusUnauthorized, utils.CodeUnauthorized, "Token is required", nil)
 		return
 	}
 
-	err := utils.VerifyJWT(token)
+	login, err := utils.ParseJWT(token)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		metrics.AuthFailures.WithLabelValues("invalid_token").Inc()
+		utils.RespondError(c, http.StatusUnauthorized, utils.CodeUnauthorized, "Invalid token", nil)
 		return
 	}
 
+	c.Set(UserKey, login)
+
 	c.JSON(http.StatusOK, gin.H{"message": "Token is valid"})
 }
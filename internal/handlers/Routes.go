@@ -0,0 +1,243 @@
+package handlers
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	october2023 "truck-analytics-platform/internal/handlers/2023/october"
+	september2023 "truck-analytics-platform/internal/handlers/2023/september"
+	october2024 "truck-analytics-platform/internal/handlers/2024/october"
+	september2024 "truck-analytics-platform/internal/handlers/2024/september"
+	"truck-analytics-platform/internal/reports"
+	sb "truck-analytics-platform/internal/sqlbuilder"
+
+	"github.com/gin-gonic/gin"
+)
+
+// rowSchema описывает строку отчёта для OpenAPI: колонки брендов
+// и признак дополнительной колонки total_market
+type rowSchema struct {
+	Name        string
+	Brands      []string
+	Nullable    bool
+	TotalMarket bool
+	// Concentration — строки могут содержать колонки концентрации (?concentration=true)
+	Concentration bool
+}
+
+// reportRoute связывает отчёт (период, сегмент, уровень детализации)
+// с обработчиком и формой ответа
+type reportRoute struct {
+	Period  string
+	Segment string
+	Total   bool
+	Handler gin.HandlerFunc
+	Summary string
+	Row     rowSchema
+}
+
+// APIPrefix — префикс версионированного API
+const APIPrefix = "/api/v1"
+
+// Path возвращает ресурсный путь отчёта в /api/v1:
+// /api/v1/segments/tractors-4x2/periods/2024-9m/regions — по регионам,
+// .../districts — итоги рынка по федеральным округам
+func (r reportRoute) Path() string {
+	level := "regions"
+	if r.Total {
+		level = "districts"
+	}
+	return fmt.Sprintf("%s/segments/%s/periods/%s/%s", APIPrefix, segmentSlug(r.Segment), periodSlug(r.Period), level)
+}
+
+// LegacyPath возвращает исторический путь вида /9m2024tractors4x2total,
+// который остаётся алиасом для страниц frontend/september2024 и frontend/october2024
+func (r reportRoute) LegacyPath() string {
+	path := "/" + r.Period + r.Segment
+	if r.Total {
+		path += "total"
+	}
+	return path
+}
+
+// Table возвращает таблицу регистраций, из которой строится отчёт:
+// LDT и MDT — по году, HDT 2024 — по выгрузке за 9 или 10 месяцев
+func (r reportRoute) Table() sb.Table {
+	year := r.Period[len(r.Period)-4:]
+
+	switch r.Segment {
+	case "ldt":
+		if year == "2023" {
+			return sb.LDT2023
+		}
+		return sb.LDT2024
+	case "mdt":
+		if year == "2023" {
+			return sb.MDT2023
+		}
+		return sb.MDT2024
+	}
+
+	switch {
+	case year == "2023":
+		return sb.HDT2023
+	case r.Period == "9m2024":
+		return sb.HDT2024Sep
+	default:
+		return sb.HDT2024Oct
+	}
+}
+
+// MarketSpec возвращает рынок и период отчёта по округам для индексов концентрации
+func (r reportRoute) MarketSpec() reports.MarketSpec {
+	parts := periodParts.FindStringSubmatch(r.Period)
+	year, _ := strconv.Atoi(parts[2])
+	months, _ := strconv.Atoi(strings.TrimSuffix(parts[1], "m"))
+	return reports.MarketSpec{Segment: r.Segment, Year: year, Level: reports.LevelDistrict, MonthTo: months}
+}
+
+var segmentAxle = regexp.MustCompile(`^([a-z]+)(\dx\d)$`)
+
+// segmentSlug: tractors4x2 -> tractors-4x2, ldt -> ldt
+func segmentSlug(segment string) string {
+	return segmentAxle.ReplaceAllString(segment, "$1-$2")
+}
+
+var periodParts = regexp.MustCompile(`^(\d+m)(\d{4})$`)
+
+// periodSlug: 9m2024 -> 2024-9m
+func periodSlug(period string) string {
+	return periodParts.ReplaceAllString(period, "$2-$1")
+}
+
+// Колонки брендов в отчётах по сегментам
+var (
+	tractors4x2Brands    = []string{"dongfeng", "faw", "foton", "jac", "shacman", "sitrak"}
+	tractors6x4Brands    = []string{"dongfeng", "faw", "foton", "howo", "shacman", "sitrak"}
+	dumpers6x4Brands2023 = []string{"faw", "howo", "jac", "sany", "sitrak"}
+	dumpers6x4Brands2024 = []string{"faw", "howo", "jac", "sany", "sitrak", "shacman", "dongfeng"}
+	dumpers8x4Brands     = []string{"faw", "howo", "shacman", "sitrak"}
+	ldtBrands            = []string{"dongfeng", "foton", "gaz", "isuzu", "jac", "kamaz", "other"}
+	mdtBrands            = []string{"dongfeng", "foton", "howo", "jac", "kamaz", "ural", "daewoo", "other"}
+)
+
+// Формы строк ответов. Отчёты по регионам и *total-отчёты по округам
+// исторически отличаются набором колонок, поэтому схемы у них разные
+var (
+	tractors4x2Row       = rowSchema{Name: "Tractors4x2Row", Brands: tractors4x2Brands, Nullable: true, TotalMarket: true}
+	tractors4x2TotalRow  = rowSchema{Name: "Tractors4x2TotalRow", Brands: tractors4x2Brands, Nullable: true, Concentration: true}
+	tractors6x4Row       = rowSchema{Name: "Tractors6x4Row", Brands: tractors6x4Brands, Nullable: true}
+	tractors6x4MarketRow = rowSchema{Name: "Tractors6x4MarketRow", Brands: tractors6x4Brands, Nullable: true, TotalMarket: true}
+	tractors6x4TotalRow  = rowSchema{Name: "Tractors6x4TotalRow", Brands: tractors6x4Brands, Nullable: true, Concentration: true}
+	dumpers6x4Row2023    = rowSchema{Name: "Dumpers6x4Row2023", Brands: dumpers6x4Brands2023, Nullable: true}
+	dumpers6x4TotalRow23 = rowSchema{Name: "Dumpers6x4TotalRow2023", Brands: dumpers6x4Brands2023, Nullable: true, Concentration: true}
+	dumpers6x4Row2024    = rowSchema{Name: "Dumpers6x4Row2024", Brands: dumpers6x4Brands2024, Nullable: true, TotalMarket: true}
+	dumpers6x4TotalRow   = rowSchema{Name: "Dumpers6x4TotalRow2024", Brands: dumpers6x4Brands2024, Nullable: true, Concentration: true}
+	dumpers8x4Row        = rowSchema{Name: "Dumpers8x4Row", Brands: dumpers8x4Brands, Nullable: true}
+	dumpers8x4MarketRow  = rowSchema{Name: "Dumpers8x4MarketRow", Brands: dumpers8x4Brands, Nullable: true, TotalMarket: true}
+	dumpers8x4TotalRow   = rowSchema{Name: "Dumpers8x4TotalRow", Brands: dumpers8x4Brands, Nullable: true, Concentration: true}
+	ldtRow               = rowSchema{Name: "LdtRow", Brands: ldtBrands}
+	ldtTotalRow          = rowSchema{Name: "LdtTotalRow", Brands: ldtBrands, Nullable: true, Concentration: true}
+	mdtRow               = rowSchema{Name: "MdtRow", Brands: mdtBrands}
+	mdtTotalRow          = rowSchema{Name: "MdtTotalRow", Brands: mdtBrands, Nullable: true, Concentration: true}
+)
+
+// reportRoutes — все отчёты API. Таблица используется и для регистрации
+// маршрутов, и для генерации OpenAPI-документа
+var reportRoutes = []reportRoute{
+	// 9 MONTH
+
+	// 2023
+	// Tractors
+	{"9m2023", "tractors4x2", false, september2023.NineMonth2023Tractors4x2, "HDT 4x2 tractors by region, 9M 2023", tractors4x2Row},
+	{"9m2023", "tractors6x4", false, september2023.NineMonth2023Tractors6x4, "HDT 6x4 tractors by region, 9M 2023", tractors6x4Row},
+
+	// Dumpers
+	{"9m2023", "dumpers6x4", false, september2023.NineMonth2023Dumpers6x4, "HDT 6x4 dumpers by region, 9M 2023", dumpers6x4Row2023},
+	{"9m2023", "dumpers8x4", false, september2023.NineMonth2023Dumpers8x4, "HDT 8x4 dumpers by region, 9M 2023", dumpers8x4Row},
+
+	// LDT | MDT
+	{"9m2023", "ldt", false, september2023.NineMonth2023Ldt, "LDT by region, 9M 2023", ldtRow},
+	{"9m2023", "mdt", false, september2023.NineMonth2023Mdt, "MDT by region, 9M 2023", mdtRow},
+
+	// -----------------------
+
+	// 2024
+	// Tractors
+	{"9m2024", "tractors4x2", false, september2024.NineMonth2024Tractors4x2, "HDT 4x2 tractors by region, 9M 2024", tractors4x2Row},
+	{"9m2024", "tractors6x4", false, september2024.NineMonth2024Tractors6x4, "HDT 6x4 tractors by region, 9M 2024", tractors6x4MarketRow},
+
+	// Dumpers
+	{"9m2024", "dumpers6x4", false, september2024.NineMonth2024Dumpers6x4, "HDT 6x4 dumpers by region, 9M 2024", dumpers6x4Row2024},
+	{"9m2024", "dumpers8x4", false, september2024.NineMonth2024Dumpers8x4, "HDT 8x4 dumpers by region, 9M 2024", dumpers8x4MarketRow},
+
+	// LDT | MDT
+	{"9m2024", "ldt", false, september2024.NineMonth2024Ldt, "LDT by region, 9M 2024", ldtRow},
+	{"9m2024", "mdt", false, september2024.NineMonth2024Mdt, "MDT by region, 9M 2024", mdtRow},
+
+	// -----------------------
+
+	// Total market 9M 2023
+	{"9m2023", "tractors4x2", true, september2023.Tractors4x2WithTotalMarket2023, "HDT 4x2 tractors total market by district, 9M 2023", tractors4x2TotalRow},
+	{"9m2023", "tractors6x4", true, september2023.Tractors6x4WithTotalMarket2023, "HDT 6x4 tractors total market by district, 9M 2023", tractors6x4TotalRow},
+	{"9m2023", "dumpers6x4", true, september2023.Dumpers6x4WithTotalMarket2023, "HDT 6x4 dumpers total market by district, 9M 2023", dumpers6x4TotalRow23},
+	{"9m2023", "dumpers8x4", true, september2023.Dumpers8x4WithTotalMarket2023, "HDT 8x4 dumpers total market by district, 9M 2023", dumpers8x4TotalRow},
+	{"9m2023", "ldt", true, september2023.NineMonth2023LDTTotal, "LDT total market by district, 9M 2023", ldtTotalRow},
+	{"9m2023", "mdt", true, september2023.NineMonth2023MDTTotal, "MDT total market by district, 9M 2023", mdtTotalRow},
+
+	// -----------------------
+
+	// Total market 9M 2024
+	{"9m2024", "tractors4x2", true, september2024.Tractors4x2WithTotalMarket2024, "HDT 4x2 tractors total market by district, 9M 2024", tractors4x2TotalRow},
+	{"9m2024", "tractors6x4", true, september2024.Tractors6x4WithTotalMarket2024, "HDT 6x4 tractors total market by district, 9M 2024", tractors6x4TotalRow},
+	{"9m2024", "dumpers6x4", true, september2024.Dumpers6x4WithTotalMarket2024, "HDT 6x4 dumpers total market by district, 9M 2024", dumpers6x4TotalRow},
+	{"9m2024", "dumpers8x4", true, september2024.Dumpers8x4WithTotalMarket2024, "HDT 8x4 dumpers total market by district, 9M 2024", dumpers8x4TotalRow},
+	{"9m2024", "ldt", true, september2024.NineMonth2024LDTTotal, "LDT total market by district, 9M 2024", ldtTotalRow},
+	{"9m2024", "mdt", true, september2024.NineMonth2024MDTTotal, "MDT total market by district, 9M 2024", mdtTotalRow},
+
+	// -------------------------------------
+
+	// MDT 2023 10M
+	{"10m2023", "mdt", false, october2023.TenMonth2023Mdt, "MDT by region, 10M 2023", mdtRow},
+	{"10m2023", "mdt", true, october2023.TenMonth2023MDTTotal, "MDT total market by district, 10M 2023", mdtTotalRow},
+
+	// LDT 2023 10M
+	{"10m2023", "ldt", false, october2023.TenMonth2023Ldt, "LDT by region, 10M 2023", ldtRow},
+	{"10m2023", "ldt", true, october2023.TenMonth2023LDTTotal, "LDT total market by district, 10M 2023", ldtTotalRow},
+
+	// MDT 2024 10M
+	{"10m2024", "mdt", false, october2024.TenMonth2024Mdt, "MDT by region, 10M 2024", mdtRow},
+	{"10m2024", "mdt", true, october2024.TenMonth2024MDTTotal, "MDT total market by district, 10M 2024", mdtTotalRow},
+
+	// LDT 2024 10M
+	{"10m2024", "ldt", false, october2024.TenMonth2024Ldt, "LDT by region, 10M 2024", ldtRow},
+	{"10m2024", "ldt", true, october2024.TenMonth2024LDTTotal, "LDT total market by district, 10M 2024", ldtTotalRow},
+
+	// HDT 2023 10M 4x2 Tractors
+	{"10m2023", "tractors4x2", false, october2023.TenMonth2023Tractors4x2, "HDT 4x2 tractors by region, 10M 2023", tractors4x2Row},
+	{"10m2023", "tractors4x2", true, october2023.TenTractors4x2WithTotalMarket2023, "HDT 4x2 tractors total market by district, 10M 2023", tractors4x2TotalRow},
+
+	// HDT 2023 10M 6x4 Tractors
+	{"10m2023", "tractors6x4", false, october2023.TenMonth2023Tractors6x4, "HDT 6x4 tractors by region, 10M 2023", tractors6x4Row},
+	{"10m2023", "tractors6x4", true, october2023.TenTractors6x4WithTotalMarket2023, "HDT 6x4 tractors total market by district, 10M 2023", tractors6x4TotalRow},
+
+	// HDT 2023 10M 6x4 Dumpers
+	{"10m2023", "dumpers6x4", false, october2023.TenMonth2023Dumpers6x4, "HDT 6x4 dumpers by region, 10M 2023", dumpers6x4Row2023},
+	{"10m2023", "dumpers6x4", true, october2023.TenDumpers6x4WithTotalMarket2023, "HDT 6x4 dumpers total market by district, 10M 2023", dumpers6x4TotalRow23},
+
+	// HDT 2023 10M 8x4 Dumpers
+	{"10m2023", "dumpers8x4", false, october2023.TenMonth2023Dumpers8x4, "HDT 8x4 dumpers by region, 10M 2023", dumpers8x4Row},
+	{"10m2023", "dumpers8x4", true, october2023.TenDumpers8x4WithTotalMarket2023, "HDT 8x4 dumpers total market by district, 10M 2023", dumpers8x4TotalRow},
+
+	// HDT 2024 10M
+	{"10m2024", "tractors4x2", false, october2024.TenMonth2024Tractors4x2, "HDT 4x2 tractors by region, 10M 2024", tractors4x2Row},
+	{"10m2024", "tractors4x2", true, october2024.TenMonth2024Tractors4x2Total, "HDT 4x2 tractors total market by district, 10M 2024", tractors4x2TotalRow},
+	{"10m2024", "tractors6x4", false, october2024.TenMonth2024Tractors6x4, "HDT 6x4 tractors by region, 10M 2024", tractors6x4MarketRow},
+	{"10m2024", "tractors6x4", true, october2024.TenMonth2024Tractors6x4Total, "HDT 6x4 tractors total market by district, 10M 2024", tractors6x4TotalRow},
+	{"10m2024", "dumpers6x4", false, october2024.TenMonth2024Dumpers6x4, "HDT 6x4 dumpers by region, 10M 2024", dumpers6x4Row2024},
+	{"10m2024", "dumpers6x4", true, october2024.TenMonth2024Dumpers6x4Total, "HDT 6x4 dumpers total market by district, 10M 2024", dumpers6x4TotalRow},
+	{"10m2024", "dumpers8x4", false, october2024.TenMonth2024Dumpers8x4, "HDT 8x4 dumpers by region, 10M 2024", dumpers8x4MarketRow},
+	{"10m2024", "dumpers8x4", true, october2024.TenMonth2024Dumpers8x4Total, "HDT 8x4 dumpers total market by district, 10M 2024", dumpers8x4TotalRow},
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"truck-analytics-platform/internal/handlers/utils"
+	"truck-analytics-platform/internal/targets"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TargetsPath — планы продаж по месяцам, округам и сегментам
+const TargetsPath = APIPrefix + "/targets"
+
+// TargetProgressPath — выполнение планов по регистрациям с начала года
+const TargetProgressPath = TargetsPath + "/progress"
+
+// ListTargetsHandler отдаёт планы: ?segment=ldt&year=2025&brand=FOTON
+func ListTargetsHandler(c *gin.Context) {
+	filter := targets.Filter{Segment: c.Query("segment"), Brand: c.Query("brand")}
+	var err error
+	if filter.Year, err = queryInt(c, "year", 0); err != nil {
+		utils.RespondError(c, http.StatusBadRequest, utils.CodeBadRequest, err.Error(), nil)
+		return
+	}
+
+	list, err := targets.List(c.Request.Context(), filter)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to load targets", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": list})
+}
+
+// SaveTargetsHandler записывает планы: JSON-массив или CSV с заголовком
+// segment,year,month,district,brand,volume,share (Content-Type: text/csv).
+// План на тот же месяц, округ и бренд заменяется
+func SaveTargetsHandler(c *gin.Context) {
+	var list []targets.Target
+	if strings.HasPrefix(c.ContentType(), "text/csv") {
+		var err error
+		if list, err = targets.ParseCSV(c.Request.Body); err != nil {
+			utils.RespondError(c, http.StatusBadRequest, utils.CodeBadRequest, "Invalid CSV: "+err.Error(), nil)
+			return
+		}
+	} else if err := c.ShouldBindJSON(&list); err != nil {
+		utils.RespondError(c, http.StatusBadRequest, utils.CodeBadRequest, "Invalid targets", nil)
+		return
+	}
+	for i := range list {
+		if list[i].Brand == "" {
+			list[i].Brand = targets.DefaultBrand
+		}
+	}
+	if err := targets.ValidateAll(list); err != nil {
+		utils.RespondError(c, http.StatusBadRequest, utils.CodeBadRequest, err.Error(), nil)
+		return
+	}
+
+	if err := targets.Save(c.Request.Context(), list); err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to save targets", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": list})
+}
+
+// DeleteTargetsHandler удаляет план бренда на год по сегменту:
+// ?segment=ldt&year=2025&brand=FOTON
+func DeleteTargetsHandler(c *gin.Context) {
+	segment := c.Query("segment")
+	brand := c.DefaultQuery("brand", targets.DefaultBrand)
+	year, err := queryInt(c, "year", 0)
+	if err != nil || segment == "" || year == 0 {
+		utils.RespondError(c, http.StatusBadRequest, utils.CodeBadRequest, "segment and year are required", nil)
+		return
+	}
+
+	deleted, err := targets.Delete(c.Request.Context(), segment, year, brand)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to delete targets", err)
+		return
+	}
+	if deleted == 0 {
+		utils.RespondError(c, http.StatusNotFound, utils.CodeNotFound, "Targets not found", nil)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// TargetProgressHandler сравнивает планы с регистрациями с начала года по округам:
+// ?segment=ldt&year=2024&brand=FOTON&month_to=9
+func TargetProgressHandler(c *gin.Context) {
+	spec := targets.Spec{
+		Segment: c.Query("segment"),
+		Brand:   c.DefaultQuery("brand", targets.DefaultBrand),
+	}
+	for _, param := range []struct {
+		name  string
+		value *int
+	}{
+		{"year", &spec.Year},
+		{"month_to", &spec.MonthTo},
+	} {
+		var err error
+		if *param.value, err = queryInt(c, param.name, 0); err != nil {
+			utils.RespondError(c, http.StatusBadRequest, utils.CodeBadRequest, err.Error(), nil)
+			return
+		}
+	}
+	if err := spec.Validate(); err != nil {
+		utils.RespondError(c, http.StatusBadRequest, utils.CodeBadRequest, err.Error(), nil)
+		return
+	}
+
+	list, period, err := targets.Progress(c.Request.Context(), spec)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to compute plan progress", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"period": period, "brand": spec.Brand, "data": list})
+}
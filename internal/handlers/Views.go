@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"truck-analytics-platform/internal/handlers/utils"
+	"truck-analytics-platform/internal/views"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ViewsPath — сохранённые виды отчётов текущего пользователя
+const ViewsPath = APIPrefix + "/views"
+
+// DashboardsPath — дашборды текущего пользователя
+const DashboardsPath = APIPrefix + "/dashboards"
+
+// SharedPath — виды и дашборды по токену ссылки, без входа
+const SharedPath = APIPrefix + "/shared"
+
+// ListViewsHandler отдаёт виды пользователя
+func ListViewsHandler(c *gin.Context) {
+	list, err := views.List(c.Request.Context(), c.GetString(UserKey))
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to load views", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": list})
+}
+
+// GetViewHandler отдаёт вид пользователя
+func GetViewHandler(c *gin.Context) {
+	id, ok := pathID(c)
+	if !ok {
+		return
+	}
+
+	view, ok, err := views.Get(c.Request.Context(), c.GetString(UserKey), id)
+	respondView(c, view, ok, err)
+}
+
+// CreateViewHandler сохраняет вид пользователя; токен ссылки выдаётся при создании
+func CreateViewHandler(c *gin.Context) {
+	view, ok := bindView(c)
+	if !ok {
+		return
+	}
+
+	created, err := views.Create(c.Request.Context(), c.GetString(UserKey), view)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to save view", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, created)
+}
+
+// PutViewHandler заменяет вид пользователя; ссылка на вид не меняется
+func PutViewHandler(c *gin.Context) {
+	id, ok := pathID(c)
+	if !ok {
+		return
+	}
+	view, ok := bindView(c)
+	if !ok {
+		return
+	}
+	view.ID = id
+
+	saved, ok, err := views.Update(c.Request.Context(), c.GetString(UserKey), view)
+	respondView(c, saved, ok, err)
+}
+
+// DeleteViewHandler удаляет вид пользователя и убирает его с дашбордов
+func DeleteViewHandler(c *gin.Context) {
+	id, ok := pathID(c)
+	if !ok {
+		return
+	}
+
+	deleted, err := views.Delete(c.Request.Context(), c.GetString(UserKey), id)
+	respondDeleted(c, deleted, err, "View not found", "Failed to delete view")
+}
+
+// SharedViewHandler отдаёт вид по токену ссылки
+func SharedViewHandler(c *gin.Context) {
+	view, ok, err := views.Shared(c.Request.Context(), c.Param("token"))
+	respondView(c, view, ok, err)
+}
+
+// ListDashboardsHandler отдаёт дашборды пользователя без видов
+func ListDashboardsHandler(c *gin.Context) {
+	list, err := views.ListDashboards(c.Request.Context(), c.GetString(UserKey))
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to load dashboards", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": list})
+}
+
+// GetDashboardHandler отдаёт дашборд пользователя с определениями видов
+func GetDashboardHandler(c *gin.Context) {
+	id, ok := pathID(c)
+	if !ok {
+		return
+	}
+
+	dashboard, ok, err := views.GetDashboard(c.Request.Context(), c.GetString(UserKey), id)
+	respondDashboard(c, dashboard, ok, err)
+}
+
+// CreateDashboardHandler сохраняет дашборд из видов пользователя
+func CreateDashboardHandler(c *gin.Context) {
+	dashboard, ok := bindDashboard(c)
+	if !ok {
+		return
+	}
+
+	created, err := views.CreateDashboard(c.Request.Context(), c.GetString(UserKey), dashboard)
+	if errors.Is(err, views.ErrForeignView) {
+		utils.RespondError(c, http.StatusBadRequest, utils.CodeBadRequest, err.Error(), nil)
+		return
+	}
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to save dashboard", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, created)
+}
+
+// PutDashboardHandler заменяет название, флаг и виды дашборда
+func PutDashboardHandler(c *gin.Context) {
+	id, ok := pathID(c)
+	if !ok {
+		return
+	}
+	dashboard, ok := bindDashboard(c)
+	if !ok {
+		return
+	}
+	dashboard.ID = id
+
+	saved, ok, err := views.UpdateDashboard(c.Request.Context(), c.GetString(UserKey), dashboard)
+	if errors.Is(err, views.ErrForeignView) {
+		utils.RespondError(c, http.StatusBadRequest, utils.CodeBadRequest, err.Error(), nil)
+		return
+	}
+	respondDashboard(c, saved, ok, err)
+}
+
+// DeleteDashboardHandler удаляет дашборд; виды остаются
+func DeleteDashboardHandler(c *gin.Context) {
+	id, ok := pathID(c)
+	if !ok {
+		return
+	}
+
+	deleted, err := views.DeleteDashboard(c.Request.Context(), c.GetString(UserKey), id)
+	respondDeleted(c, deleted, err, "Dashboard not found", "Failed to delete dashboard")
+}
+
+// SharedDashboardHandler отдаёт дашборд с видами по токену ссылки
+func SharedDashboardHandler(c *gin.Context) {
+	dashboard, ok, err := views.SharedDashboard(c.Request.Context(), c.Param("token"))
+	respondDashboard(c, dashboard, ok, err)
+}
+
+func respondView(c *gin.Context, view views.View, ok bool, err error) {
+	switch {
+	case err != nil:
+		utils.RespondError(c, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to load view", err)
+	case !ok:
+		utils.RespondError(c, http.StatusNotFound, utils.CodeNotFound, "View not found", nil)
+	default:
+		c.JSON(http.StatusOK, view)
+	}
+}
+
+func respondDashboard(c *gin.Context, dashboard views.Dashboard, ok bool, err error) {
+	switch {
+	case err != nil:
+		utils.RespondError(c, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to load dashboard", err)
+	case !ok:
+		utils.RespondError(c, http.StatusNotFound, utils.CodeNotFound, "Dashboard not found", nil)
+	default:
+		c.JSON(http.StatusOK, dashboard)
+	}
+}
+
+// respondDeleted отвечает 204 или 404, если удалять было нечего
+func respondDeleted(c *gin.Context, deleted bool, err error, notFound, failed string) {
+	switch {
+	case err != nil:
+		utils.RespondError(c, http.StatusInternalServerError, utils.CodeQueryFailed, failed, err)
+	case !deleted:
+		utils.RespondError(c, http.StatusNotFound, utils.CodeNotFound, notFound, nil)
+	default:
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// bindView читает и проверяет вид из тела запроса
+func bindView(c *gin.Context) (views.View, bool) {
+	var view views.View
+	if err := c.ShouldBindJSON(&view); err != nil {
+		utils.RespondError(c, http.StatusBadRequest, utils.CodeBadRequest, "Invalid view", nil)
+		return view, false
+	}
+	if err := view.Validate(); err != nil {
+		utils.RespondError(c, http.StatusBadRequest, utils.CodeBadRequest, err.Error(), nil)
+		return view, false
+	}
+	return view, true
+}
+
+// bindDashboard читает и проверяет дашборд из тела запроса
+func bindDashboard(c *gin.Context) (views.Dashboard, bool) {
+	var dashboard views.Dashboard
+	if err := c.ShouldBindJSON(&dashboard); err != nil {
+		utils.RespondError(c, http.StatusBadRequest, utils.CodeBadRequest, "Invalid dashboard", nil)
+		return dashboard, false
+	}
+	if err := dashboard.Validate(); err != nil {
+		utils.RespondError(c, http.StatusBadRequest, utils.CodeBadRequest, err.Error(), nil)
+		return dashboard, false
+	}
+	return dashboard, true
+}
@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"net/http"
+
+	"truck-analytics-platform/internal/handlers/utils"
+	"truck-analytics-platform/internal/reports"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WhitespacePath — белые пятна бренда и появление и уход конкурентов
+const WhitespacePath = APIPrefix + "/whitespace"
+
+// WhitespaceHandler отдаёт регионы без продаж бренда при активных конкурентах
+// и конкурентов, появившихся или ушедших за год:
+// ?brand=FOTON&segment=tractors4x2&year=2024&level=region
+func WhitespaceHandler(c *gin.Context) {
+	spec, ok := marketSpec(c, reports.LevelRegion)
+	if !ok {
+		return
+	}
+	brand := c.Query("brand")
+	if brand == "" {
+		utils.RespondError(c, http.StatusBadRequest, utils.CodeBadRequest, "brand is required", nil)
+		return
+	}
+	if err := spec.Validate(reports.LevelDistrict, reports.LevelRegion); err != nil {
+		utils.RespondError(c, http.StatusBadRequest, utils.CodeBadRequest, err.Error(), nil)
+		return
+	}
+
+	report, period, err := reports.Whitespace(c.Request.Context(), spec, brand)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to find whitespace", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"period": period, "data": report})
+}
@@ -0,0 +1,30 @@
+SELECT
+			"Federal_district",
+			COALESCE(SUM(CASE WHEN COALESCE(brand_aliases.brand_id, "Brand") = $1 THEN "Quantity" END), 0) AS "faw",
+			COALESCE(SUM(CASE WHEN COALESCE(brand_aliases.brand_id, "Brand") = $2 THEN "Quantity" END), 0) AS "howo",
+			COALESCE(SUM(CASE WHEN COALESCE(brand_aliases.brand_id, "Brand") = $3 THEN "Quantity" END), 0) AS "jac",
+			COALESCE(SUM(CASE WHEN COALESCE(brand_aliases.brand_id, "Brand") = $4 THEN "Quantity" END), 0) AS "sany",
+			COALESCE(SUM(CASE WHEN COALESCE(brand_aliases.brand_id, "Brand") = $5 THEN "Quantity" END), 0) AS "sitrak",
+			COALESCE(SUM("Quantity"), 0) AS total
+		FROM truck_analytics_2023_01_12
+				LEFT JOIN brand_aliases ON brand_aliases.alias = "Brand"
+				LEFT JOIN brands ON brands.id = COALESCE(brand_aliases.brand_id, "Brand")
+		WHERE
+			"Wheel_formula" = $6
+				AND "Body_type" = $7
+				AND "Mass_in_segment_1" = $8
+				AND "Month_of_registration" <= $9
+				AND COALESCE(brand_aliases.brand_id, "Brand") = ANY($10)
+		GROUP BY "Federal_district"
+		ORDER BY "Federal_district"
+
+-- $1 = "FAW"
+-- $2 = "HOWO"
+-- $3 = "JAC"
+-- $4 = "SANY"
+-- $5 = "SITRAK"
+-- $6 = "6x4"
+-- $7 = "Самосвал"
+-- $8 = "32001-40000"
+-- $9 = 10
+-- $10 = []string{"FAW", "HOWO", "JAC", "SANY", "SITRAK"}
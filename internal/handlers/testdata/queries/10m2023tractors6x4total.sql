@@ -0,0 +1,32 @@
+SELECT
+			"Federal_district",
+			COALESCE(SUM(CASE WHEN COALESCE(brand_aliases.brand_id, "Brand") = $1 THEN "Quantity" END), 0) AS "dongfeng",
+			COALESCE(SUM(CASE WHEN COALESCE(brand_aliases.brand_id, "Brand") = $2 THEN "Quantity" END), 0) AS "faw",
+			COALESCE(SUM(CASE WHEN COALESCE(brand_aliases.brand_id, "Brand") = $3 THEN "Quantity" END), 0) AS "foton",
+			COALESCE(SUM(CASE WHEN COALESCE(brand_aliases.brand_id, "Brand") = $4 THEN "Quantity" END), 0) AS "howo",
+			COALESCE(SUM(CASE WHEN COALESCE(brand_aliases.brand_id, "Brand") = $5 THEN "Quantity" END), 0) AS "shacman",
+			COALESCE(SUM(CASE WHEN COALESCE(brand_aliases.brand_id, "Brand") = $6 THEN "Quantity" END), 0) AS "sitrak",
+			COALESCE(SUM("Quantity"), 0) AS total
+		FROM truck_analytics_2023_01_12
+				LEFT JOIN brand_aliases ON brand_aliases.alias = "Brand"
+				LEFT JOIN brands ON brands.id = COALESCE(brand_aliases.brand_id, "Brand")
+		WHERE
+			"Wheel_formula" = $7
+				AND "Body_type" = $8
+				AND "Exact_mass" = $9
+				AND "Month_of_registration" <= $10
+				AND COALESCE(brand_aliases.brand_id, "Brand") = ANY($11)
+		GROUP BY "Federal_district"
+		ORDER BY "Federal_district"
+
+-- $1 = "DONGFENG"
+-- $2 = "FAW"
+-- $3 = "FOTON"
+-- $4 = "HOWO"
+-- $5 = "SHACMAN"
+-- $6 = "SITRAK"
+-- $7 = "6x4"
+-- $8 = "Седельный тягач"
+-- $9 = 25000
+-- $10 = 10
+-- $11 = []string{"DONGFENG", "FAW", "FOTON", "HOWO", "SHACMAN", "SITRAK"}
@@ -0,0 +1,34 @@
+SELECT
+			"Federal_district",
+			COALESCE(SUM(CASE WHEN COALESCE(brand_aliases.brand_id, "Brand") = $1 THEN "Quantity" END), 0) AS "faw",
+			COALESCE(SUM(CASE WHEN COALESCE(brand_aliases.brand_id, "Brand") = $2 THEN "Quantity" END), 0) AS "howo",
+			COALESCE(SUM(CASE WHEN COALESCE(brand_aliases.brand_id, "Brand") = $3 THEN "Quantity" END), 0) AS "jac",
+			COALESCE(SUM(CASE WHEN COALESCE(brand_aliases.brand_id, "Brand") = $4 THEN "Quantity" END), 0) AS "sany",
+			COALESCE(SUM(CASE WHEN COALESCE(brand_aliases.brand_id, "Brand") = $5 THEN "Quantity" END), 0) AS "sitrak",
+			COALESCE(SUM(CASE WHEN COALESCE(brand_aliases.brand_id, "Brand") = $6 THEN "Quantity" END), 0) AS "shacman",
+			COALESCE(SUM(CASE WHEN COALESCE(brand_aliases.brand_id, "Brand") = $7 THEN "Quantity" END), 0) AS "dongfeng",
+			COALESCE(SUM("Quantity"), 0) AS total
+		FROM truck_analytics_2024_01_10
+				LEFT JOIN brand_aliases ON brand_aliases.alias = "Brand"
+				LEFT JOIN brands ON brands.id = COALESCE(brand_aliases.brand_id, "Brand")
+		WHERE
+			"Wheel_formula" = $8
+				AND "Body_type" = $9
+				AND "Mass_in_segment_1" = $10
+				AND "Month_of_registration" <= $11
+				AND COALESCE(brand_aliases.brand_id, "Brand") = ANY($12)
+		GROUP BY "Federal_district"
+		ORDER BY "Federal_district"
+
+-- $1 = "FAW"
+-- $2 = "HOWO"
+-- $3 = "JAC"
+-- $4 = "SANY"
+-- $5 = "SITRAK"
+-- $6 = "SHACMAN"
+-- $7 = "DONGFENG"
+-- $8 = "6x4"
+-- $9 = "Самосвал"
+-- $10 = "32001-40000"
+-- $11 = 10
+-- $12 = []string{"FAW", "HOWO", "JAC", "SANY", "SITRAK", "SHACMAN", "DONGFENG"}
@@ -0,0 +1,52 @@
+WITH base_data AS (
+			SELECT
+				"Federal_district",
+				"Region",
+				CASE WHEN COALESCE(brand_aliases.brand_id, "Brand") = ANY($1) THEN COALESCE(brand_aliases.brand_id, "Brand") END AS brand_key,
+				SUM("Quantity") AS total_sales
+			FROM ldt_3_5_12_truck_analytics_10_2024
+				LEFT JOIN brand_aliases ON brand_aliases.alias = "Brand"
+				LEFT JOIN brands ON brands.id = COALESCE(brand_aliases.brand_id, "Brand")
+			WHERE
+				"Brand" IS NOT NULL
+			GROUP BY "Federal_district", "Region", brand_key
+		),
+		federal_totals AS (
+			SELECT
+				"Federal_district",
+				"Federal_district" AS "Region",
+				brand_key,
+				SUM(total_sales) AS total_sales
+			FROM base_data
+			GROUP BY "Federal_district", brand_key
+		),
+		combined_data AS (
+			SELECT * FROM base_data
+			UNION ALL
+			SELECT * FROM federal_totals
+		)
+		SELECT
+			"Federal_district",
+			COALESCE("Region", "Federal_district") AS region_name,
+			COALESCE(MAX(CASE WHEN brand_key = $2 THEN total_sales END), 0) AS "dongfeng",
+			COALESCE(MAX(CASE WHEN brand_key = $3 THEN total_sales END), 0) AS "foton",
+			COALESCE(MAX(CASE WHEN brand_key = $4 THEN total_sales END), 0) AS "gaz",
+			COALESCE(MAX(CASE WHEN brand_key = $5 THEN total_sales END), 0) AS "isuzu",
+			COALESCE(MAX(CASE WHEN brand_key = $6 THEN total_sales END), 0) AS "jac",
+			COALESCE(MAX(CASE WHEN brand_key = $7 THEN total_sales END), 0) AS "kamaz",
+			COALESCE(MAX(CASE WHEN brand_key IS NULL THEN total_sales END), 0) AS "other",
+			COALESCE(SUM(total_sales), 0) AS total
+		FROM combined_data
+		GROUP BY "Federal_district", "Region"
+		ORDER BY
+			"Federal_district",
+			CASE WHEN "Region" = "Federal_district" THEN 1 ELSE 0 END,
+			"Region"
+
+-- $1 = []string{"DONGFENG", "FOTON", "GAZ", "ISUZU", "JAC", "KAMAZ"}
+-- $2 = "DONGFENG"
+-- $3 = "FOTON"
+-- $4 = "GAZ"
+-- $5 = "ISUZU"
+-- $6 = "JAC"
+-- $7 = "KAMAZ"
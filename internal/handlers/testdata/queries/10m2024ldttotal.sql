@@ -0,0 +1,25 @@
+SELECT
+			"Federal_district",
+			COALESCE(SUM(CASE WHEN COALESCE(brand_aliases.brand_id, "Brand") = $1 THEN "Quantity" END), 0) AS "dongfeng",
+			COALESCE(SUM(CASE WHEN COALESCE(brand_aliases.brand_id, "Brand") = $2 THEN "Quantity" END), 0) AS "foton",
+			COALESCE(SUM(CASE WHEN COALESCE(brand_aliases.brand_id, "Brand") = $3 THEN "Quantity" END), 0) AS "gaz",
+			COALESCE(SUM(CASE WHEN COALESCE(brand_aliases.brand_id, "Brand") = $4 THEN "Quantity" END), 0) AS "isuzu",
+			COALESCE(SUM(CASE WHEN COALESCE(brand_aliases.brand_id, "Brand") = $5 THEN "Quantity" END), 0) AS "jac",
+			COALESCE(SUM(CASE WHEN COALESCE(brand_aliases.brand_id, "Brand") = $6 THEN "Quantity" END), 0) AS "kamaz",
+			COALESCE(SUM(CASE WHEN COALESCE(brand_aliases.brand_id, "Brand") <> ALL($7) THEN "Quantity" END), 0) AS "other",
+			COALESCE(SUM("Quantity"), 0) AS total
+		FROM ldt_3_5_12_truck_analytics_10_2024
+				LEFT JOIN brand_aliases ON brand_aliases.alias = "Brand"
+				LEFT JOIN brands ON brands.id = COALESCE(brand_aliases.brand_id, "Brand")
+		WHERE
+			TRUE
+		GROUP BY "Federal_district"
+		ORDER BY "Federal_district"
+
+-- $1 = "DONGFENG"
+-- $2 = "FOTON"
+-- $3 = "GAZ"
+-- $4 = "ISUZU"
+-- $5 = "JAC"
+-- $6 = "KAMAZ"
+-- $7 = []string{"DONGFENG", "FOTON", "GAZ", "ISUZU", "JAC", "KAMAZ"}
@@ -0,0 +1,59 @@
+WITH base_data AS (
+			SELECT
+				"Federal_district",
+				"Region",
+				COALESCE(brand_aliases.brand_id, "Brand") AS brand_key,
+				SUM("Quantity") AS total_sales
+			FROM truck_analytics_2024_01_10
+				LEFT JOIN brand_aliases ON brand_aliases.alias = "Brand"
+				LEFT JOIN brands ON brands.id = COALESCE(brand_aliases.brand_id, "Brand")
+			WHERE
+				"Wheel_formula" = $1
+				AND "Body_type" = $2
+				AND "Exact_mass" = $3
+				AND "Month_of_registration" <= $4
+				AND COALESCE(brand_aliases.brand_id, "Brand") = ANY($5)
+			GROUP BY "Federal_district", "Region", brand_key
+		),
+		federal_totals AS (
+			SELECT
+				"Federal_district",
+				"Federal_district" AS "Region",
+				brand_key,
+				SUM(total_sales) AS total_sales
+			FROM base_data
+			GROUP BY "Federal_district", brand_key
+		),
+		combined_data AS (
+			SELECT * FROM base_data
+			UNION ALL
+			SELECT * FROM federal_totals
+		)
+		SELECT
+			"Federal_district",
+			COALESCE("Region", "Federal_district") AS region_name,
+			MAX(CASE WHEN brand_key = $6 THEN total_sales END) AS "dongfeng",
+			MAX(CASE WHEN brand_key = $7 THEN total_sales END) AS "faw",
+			MAX(CASE WHEN brand_key = $8 THEN total_sales END) AS "foton",
+			MAX(CASE WHEN brand_key = $9 THEN total_sales END) AS "howo",
+			MAX(CASE WHEN brand_key = $10 THEN total_sales END) AS "shacman",
+			MAX(CASE WHEN brand_key = $11 THEN total_sales END) AS "sitrak",
+			COALESCE(SUM(total_sales), 0) AS total
+		FROM combined_data
+		GROUP BY "Federal_district", "Region"
+		ORDER BY
+			"Federal_district",
+			CASE WHEN "Region" = "Federal_district" THEN 1 ELSE 0 END,
+			"Region"
+
+-- $1 = "6x4"
+-- $2 = "Седельный тягач"
+-- $3 = 25000
+-- $4 = 10
+-- $5 = []string{"DONGFENG", "FAW", "FOTON", "HOWO", "SHACMAN", "SITRAK"}
+-- $6 = "DONGFENG"
+-- $7 = "FAW"
+-- $8 = "FOTON"
+-- $9 = "HOWO"
+-- $10 = "SHACMAN"
+-- $11 = "SITRAK"
@@ -0,0 +1,28 @@
+SELECT
+			"Federal_district",
+			COALESCE(SUM(CASE WHEN COALESCE(brand_aliases.brand_id, "Brand") = $1 THEN "Quantity" END), 0) AS "faw",
+			COALESCE(SUM(CASE WHEN COALESCE(brand_aliases.brand_id, "Brand") = $2 THEN "Quantity" END), 0) AS "howo",
+			COALESCE(SUM(CASE WHEN COALESCE(brand_aliases.brand_id, "Brand") = $3 THEN "Quantity" END), 0) AS "sitrak",
+			COALESCE(SUM(CASE WHEN COALESCE(brand_aliases.brand_id, "Brand") = $4 THEN "Quantity" END), 0) AS "shacman",
+			COALESCE(SUM("Quantity"), 0) AS total
+		FROM truck_analytics_2023_01_12
+				LEFT JOIN brand_aliases ON brand_aliases.alias = "Brand"
+				LEFT JOIN brands ON brands.id = COALESCE(brand_aliases.brand_id, "Brand")
+		WHERE
+			"Wheel_formula" = $5
+				AND "Body_type" = $6
+				AND "Weight_in_segment_4" = $7
+				AND "Month_of_registration" <= $8
+				AND COALESCE(brand_aliases.brand_id, "Brand") = ANY($9)
+		GROUP BY "Federal_district"
+		ORDER BY "Federal_district"
+
+-- $1 = "FAW"
+-- $2 = "HOWO"
+-- $3 = "SITRAK"
+-- $4 = "SHACMAN"
+-- $5 = "8x4"
+-- $6 = "Самосвал"
+-- $7 = "35001-45000"
+-- $8 = 9
+-- $9 = []string{"FAW", "HOWO", "SITRAK", "SHACMAN"}
@@ -0,0 +1,53 @@
+WITH base_data AS (
+			SELECT
+				"Federal_district",
+				"Region",
+				COALESCE(brand_aliases.brand_id, "Brand") AS brand_key,
+				SUM("Quantity") AS total_sales
+			FROM truck_analytics_2024_01_09
+				LEFT JOIN brand_aliases ON brand_aliases.alias = "Brand"
+				LEFT JOIN brands ON brands.id = COALESCE(brand_aliases.brand_id, "Brand")
+			WHERE
+				"Wheel_formula" = $1
+				AND "Body_type" = $2
+				AND "Weight_in_segment_4" = $3
+				AND COALESCE(brand_aliases.brand_id, "Brand") = ANY($4)
+			GROUP BY "Federal_district", "Region", brand_key
+		),
+		federal_totals AS (
+			SELECT
+				"Federal_district",
+				"Federal_district" AS "Region",
+				brand_key,
+				SUM(total_sales) AS total_sales
+			FROM base_data
+			GROUP BY "Federal_district", brand_key
+		),
+		combined_data AS (
+			SELECT * FROM base_data
+			UNION ALL
+			SELECT * FROM federal_totals
+		)
+		SELECT
+			"Federal_district",
+			COALESCE("Region", "Federal_district") AS region_name,
+			MAX(CASE WHEN brand_key = $5 THEN total_sales END) AS "faw",
+			MAX(CASE WHEN brand_key = $6 THEN total_sales END) AS "howo",
+			MAX(CASE WHEN brand_key = $7 THEN total_sales END) AS "shacman",
+			MAX(CASE WHEN brand_key = $8 THEN total_sales END) AS "sitrak",
+			COALESCE(SUM(total_sales), 0) AS total
+		FROM combined_data
+		GROUP BY "Federal_district", "Region"
+		ORDER BY
+			"Federal_district",
+			CASE WHEN "Region" = "Federal_district" THEN 1 ELSE 0 END,
+			"Region"
+
+-- $1 = "8x4"
+-- $2 = "Самосвал"
+-- $3 = "35001-45000"
+-- $4 = []string{"FAW", "HOWO", "SHACMAN", "SITRAK"}
+-- $5 = "FAW"
+-- $6 = "HOWO"
+-- $7 = "SHACMAN"
+-- $8 = "SITRAK"
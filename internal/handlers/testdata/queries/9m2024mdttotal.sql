@@ -0,0 +1,28 @@
+SELECT
+			"Federal_district",
+			COALESCE(SUM(CASE WHEN COALESCE(brand_aliases.brand_id, "Brand") = $1 THEN CAST("Quantity" AS INTEGER) END), 0) AS "dongfeng",
+			COALESCE(SUM(CASE WHEN COALESCE(brand_aliases.brand_id, "Brand") = $2 THEN CAST("Quantity" AS INTEGER) END), 0) AS "foton",
+			COALESCE(SUM(CASE WHEN COALESCE(brand_aliases.brand_id, "Brand") = $3 THEN CAST("Quantity" AS INTEGER) END), 0) AS "howo",
+			COALESCE(SUM(CASE WHEN COALESCE(brand_aliases.brand_id, "Brand") = $4 THEN CAST("Quantity" AS INTEGER) END), 0) AS "jac",
+			COALESCE(SUM(CASE WHEN COALESCE(brand_aliases.brand_id, "Brand") = $5 THEN CAST("Quantity" AS INTEGER) END), 0) AS "kamaz",
+			COALESCE(SUM(CASE WHEN COALESCE(brand_aliases.brand_id, "Brand") = $6 THEN CAST("Quantity" AS INTEGER) END), 0) AS "ural",
+			COALESCE(SUM(CASE WHEN COALESCE(brand_aliases.brand_id, "Brand") = $7 THEN CAST("Quantity" AS INTEGER) END), 0) AS "daewoo",
+			COALESCE(SUM(CASE WHEN COALESCE(brand_aliases.brand_id, "Brand") <> ALL($8) THEN CAST("Quantity" AS INTEGER) END), 0) AS "other",
+			COALESCE(SUM(CAST("Quantity" AS INTEGER)), 0) AS total
+		FROM mdt_12_18_truck_analytics_10_2024
+				LEFT JOIN brand_aliases ON brand_aliases.alias = "Brand"
+				LEFT JOIN brands ON brands.id = COALESCE(brand_aliases.brand_id, "Brand")
+		WHERE
+			"Month_of_registration" <= $9
+		GROUP BY "Federal_district"
+		ORDER BY "Federal_district"
+
+-- $1 = "DONGFENG"
+-- $2 = "FOTON"
+-- $3 = "HOWO"
+-- $4 = "JAC"
+-- $5 = "KAMAZ"
+-- $6 = "URAL"
+-- $7 = "DAEWOO"
+-- $8 = []string{"DONGFENG", "FOTON", "HOWO", "JAC", "KAMAZ", "URAL", "DAEWOO"}
+-- $9 = 9
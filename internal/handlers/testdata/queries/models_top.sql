@@ -0,0 +1,38 @@
+WITH sales AS (
+			SELECT
+				"Federal_district", "Region",
+				COALESCE(brand_aliases.brand_id, "Brand") AS brand_key,
+				"Model" AS model_key,
+				COALESCE(SUM("Quantity"), 0) AS total_sales
+			FROM truck_analytics_2023_01_12
+				LEFT JOIN brand_aliases ON brand_aliases.alias = "Brand"
+				LEFT JOIN brands ON brands.id = COALESCE(brand_aliases.brand_id, "Brand")
+			WHERE
+				"Wheel_formula" = $1
+				AND "Body_type" = $2
+				AND "Mass_in_segment_1" = $3
+				AND "Region" = ANY($4)
+				AND "Month_of_registration" <= $5
+			GROUP BY "Federal_district", "Region", COALESCE(brand_aliases.brand_id, "Brand"), "Model"
+		),
+		ranked AS (
+			SELECT
+				*,
+				SUM(total_sales) OVER (PARTITION BY "Federal_district", "Region") AS area_sales,
+				ROW_NUMBER() OVER (
+					PARTITION BY "Federal_district", "Region"
+					ORDER BY model_key IS NULL, total_sales DESC, brand_key, model_key
+				) AS model_rank
+			FROM sales
+		)
+		SELECT "Federal_district", "Region", brand_key, model_key, total_sales, area_sales, model_rank
+		FROM ranked
+		WHERE model_key IS NOT NULL AND model_rank <= $6
+		ORDER BY "Federal_district", "Region", model_rank
+
+-- $1 = "6x4"
+-- $2 = "Самосвал"
+-- $3 = "32001-40000"
+-- $4 = []string{"Москва"}
+-- $5 = 6
+-- $6 = 10
@@ -0,0 +1,46 @@
+WITH sales AS (
+			SELECT
+				"Federal_district",
+				"Region",
+				COALESCE(brand_aliases.brand_id, "Brand") AS brand_key,
+				$1::int AS year,
+				"Month_of_registration" AS month,
+				COALESCE(SUM("Quantity"), 0) AS total_sales
+			FROM truck_analytics_2024_01_10
+				LEFT JOIN brand_aliases ON brand_aliases.alias = "Brand"
+				LEFT JOIN brands ON brands.id = COALESCE(brand_aliases.brand_id, "Brand")
+			WHERE
+				"Wheel_formula" = $2
+				AND "Body_type" = $3
+				AND "Weight_in_segment_4" = $4
+			GROUP BY "Federal_district", "Region", COALESCE(brand_aliases.brand_id, "Brand"), "Month_of_registration"
+			UNION ALL
+			SELECT
+				"Federal_district",
+				"Region",
+				COALESCE(brand_aliases.brand_id, "Brand") AS brand_key,
+				$5::int AS year,
+				"Month_of_registration" AS month,
+				COALESCE(SUM("Quantity"), 0) AS total_sales
+			FROM truck_analytics_2023_01_12
+				LEFT JOIN brand_aliases ON brand_aliases.alias = "Brand"
+				LEFT JOIN brands ON brands.id = COALESCE(brand_aliases.brand_id, "Brand")
+			WHERE
+				"Wheel_formula" = $6
+				AND "Body_type" = $7
+				AND "Weight_in_segment_4" = $8
+			GROUP BY "Federal_district", "Region", COALESCE(brand_aliases.brand_id, "Brand"), "Month_of_registration"
+		)
+		SELECT "Federal_district", "Region", brand_key, year, month, total_sales
+		FROM sales
+		WHERE brand_key IS NOT NULL AND month BETWEEN 1 AND 12
+		ORDER BY year, month, "Federal_district", "Region", brand_key
+
+-- $1 = 2024
+-- $2 = "8x4"
+-- $3 = "Самосвал"
+-- $4 = "35001-45000"
+-- $5 = 2023
+-- $6 = "8x4"
+-- $7 = "Самосвал"
+-- $8 = "35001-45000"
@@ -0,0 +1,36 @@
+WITH sales AS (
+			SELECT
+				"Federal_district",
+				"Region",
+				COALESCE(brand_aliases.brand_id, "Brand") AS brand_key,
+				$1::int AS year,
+				"Month_of_registration" AS month,
+				COALESCE(SUM("Quantity"), 0) AS total_sales
+			FROM ldt_3_5_12_truck_analytics_10_2024
+				LEFT JOIN brand_aliases ON brand_aliases.alias = "Brand"
+				LEFT JOIN brands ON brands.id = COALESCE(brand_aliases.brand_id, "Brand")
+			WHERE
+				TRUE
+			GROUP BY "Federal_district", "Region", COALESCE(brand_aliases.brand_id, "Brand"), "Month_of_registration"
+			UNION ALL
+			SELECT
+				"Federal_district",
+				"Region",
+				COALESCE(brand_aliases.brand_id, "Brand") AS brand_key,
+				$2::int AS year,
+				"Month_of_registration" AS month,
+				COALESCE(SUM("Quantity"), 0) AS total_sales
+			FROM ldt_3_5_12_truck_analytics_10_2023
+				LEFT JOIN brand_aliases ON brand_aliases.alias = "Brand"
+				LEFT JOIN brands ON brands.id = COALESCE(brand_aliases.brand_id, "Brand")
+			WHERE
+				TRUE
+			GROUP BY "Federal_district", "Region", COALESCE(brand_aliases.brand_id, "Brand"), "Month_of_registration"
+		)
+		SELECT "Federal_district", "Region", brand_key, year, month, total_sales
+		FROM sales
+		WHERE brand_key IS NOT NULL AND month BETWEEN 1 AND 12
+		ORDER BY year, month, "Federal_district", "Region", brand_key
+
+-- $1 = 2024
+-- $2 = 2023
@@ -0,0 +1,52 @@
+package utils
+
+import (
+	"log/slog"
+	"truck-analytics-platform/internal/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Коды ошибок API. Клиенты ориентируются на код, а не на текст сообщения
+const (
+	CodeBadRequest          = "bad_request"
+	CodeUnauthorized        = "unauthorized"
+	CodeForbidden           = "forbidden"
+	CodeNotFound            = "not_found"
+	CodeConflict            = "conflict"
+	CodeTooManyRequests     = "too_many_requests"
+	CodeDatabaseUnavailable = "database_unavailable"
+	CodeQueryFailed         = "query_failed"
+	CodeInternal            = "internal_error"
+	CodeDataNotPublished    = "data_not_published"
+)
+
+// ErrorBody — тело ошибки в едином формате ответа
+type ErrorBody struct {
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// ErrorResponse — единый конверт ошибки: {"error": {"code": ..., "message": ...}}
+type ErrorResponse struct {
+	Error ErrorBody `json:"error"`
+}
+
+// RespondError логирует внутреннюю ошибку и отвечает клиенту конвертом без
+// её деталей — тексты ошибок SQL остаются только в логах
+func RespondError(ctx *gin.Context, status int, code string, message string, err error) {
+	reqCtx := ctx.Request.Context()
+
+	if err != nil {
+		slog.ErrorContext(reqCtx, message, "code", code, "status", status, "error", err)
+	}
+
+	ctx.AbortWithStatusJSON(status, ErrorResponse{
+		Error: ErrorBody{
+			Code:      code,
+			Message:   message,
+			RequestID: logging.RequestID(reqCtx),
+		},
+	})
+}
@@ -31,17 +31,29 @@ func CreateJWT(login string, password string) (string, error) {
 }
 
 func VerifyJWT(tokenString string) error {
+	_, err := ParseJWT(tokenString)
+	return err
+}
+
+// ParseJWT проверяет токен и возвращает логин пользователя из него
+func ParseJWT(tokenString string) (string, error) {
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
 		return secretKey, nil
 	})
 
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	if !token.Valid {
-		return fmt.Errorf("invalid token")
+		return "", fmt.Errorf("invalid token")
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return "", fmt.Errorf("invalid token claims")
 	}
 
-	return nil
+	login, _ := claims["login"].(string)
+	return login, nil
 }
@@ -0,0 +1,80 @@
+package metrics
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	// HTTPRequestDuration — латентность запросов к API по маршрутам
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Duration of HTTP requests by route.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	// DBQueryDuration — длительность SQL-запросов в разрезе отчётов
+	DBQueryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "db_query_duration_seconds",
+		Help:    "Duration of database queries by report.",
+		Buckets: []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+	}, []string{"report", "status"})
+
+	// CacheRequests — обращения к кэшам приложения, cache = columns, result = hit | miss
+	CacheRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "cache_requests_total",
+		Help: "Cache lookups by cache and result.",
+	}, []string{"cache", "result"})
+
+	// AuthFailures — неудачные попытки авторизации и проверки токена
+	AuthFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "auth_failures_total",
+		Help: "Failed authentication attempts by reason.",
+	}, []string{"reason"})
+
+	// RateLimited — запросы, отклонённые квотой, по группам эндпоинтов
+	RateLimited = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "rate_limited_requests_total",
+		Help: "Requests rejected by rate limits by endpoint group.",
+	}, []string{"limit"})
+)
+
+type reportKey struct{}
+
+// WithReport помечает контекст именем отчёта, под которым будут учтены SQL-запросы
+func WithReport(ctx context.Context, report string) context.Context {
+	return context.WithValue(ctx, reportKey{}, report)
+}
+
+// ReportFromContext возвращает имя отчёта из контекста или "unknown"
+func ReportFromContext(ctx context.Context) string {
+	if report, ok := ctx.Value(reportKey{}).(string); ok && report != "" {
+		return report
+	}
+	return "unknown"
+}
+
+// Middleware замеряет латентность запросов и пробрасывает маршрут в контекст
+// запроса, чтобы длительность SQL-запросов учитывалась по отчётам
+func Middleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		route := ctx.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		ctx.Request = ctx.Request.WithContext(WithReport(ctx.Request.Context(), route))
+
+		start := time.Now()
+		ctx.Next()
+
+		HTTPRequestDuration.
+			WithLabelValues(ctx.Request.Method, route, strconv.Itoa(ctx.Writer.Status())).
+			Observe(time.Since(start).Seconds())
+	}
+}
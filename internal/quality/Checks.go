@@ -0,0 +1,439 @@
+package quality
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"truck-analytics-platform/internal/db"
+	"truck-analytics-platform/internal/reports"
+	sb "truck-analytics-platform/internal/sqlbuilder"
+	"unicode"
+)
+
+// Пороги проверок количества и выбросов
+const (
+	// MaxRowQuantity — больше регистраций в одной строке выгрузки не бывает
+	MaxRowQuantity = 1000
+	// OutlierRatio — во сколько раз продажи бренда должны измениться
+	// к прошлому месяцу, чтобы считаться выбросом
+	OutlierRatio = 3
+	// OutlierMinVolume — меньшие объёмы колеблются сами по себе и не проверяются
+	OutlierMinVolume = 50
+)
+
+// check — проверка таблицы
+type check struct {
+	name string
+	run  func(ctx context.Context, conn db.Querier, table sb.Table) ([]Issue, error)
+}
+
+var checks = []check{
+	{"geography", checkGeography},
+	{"null_geography", checkNullGeography},
+	{"brands", checkBrands},
+	{"quantity", checkQuantity},
+	{"months", checkMonths},
+	{"duplicates", checkDuplicates},
+	{"outliers", checkOutliers},
+}
+
+// referenceBrands — бренды, которые отчёты по таблице выводят колонками.
+// Бренд вне списка идёт в OTHER или не попадает в отчёт
+var referenceBrands = func() map[sb.Table][]string {
+	var hdt []string
+	seen := map[string]bool{}
+	for _, segment := range []reports.Segment{reports.Tractors4x2, reports.Tractors6x4, reports.Dumpers6x4, reports.Dumpers8x4} {
+		for _, name := range segment.BrandNames() {
+			if !seen[name] {
+				seen[name] = true
+				hdt = append(hdt, name)
+			}
+		}
+	}
+
+	ldt := []string{"DONGFENG", "FOTON", "GAZ", "ISUZU", "JAC", "KAMAZ"}
+	mdt := []string{"DONGFENG", "FOTON", "HOWO", "JAC", "KAMAZ", "URAL", "DAEWOO"}
+
+	return map[sb.Table][]string{
+		sb.HDT2023: hdt, sb.HDT2024Sep: hdt, sb.HDT2024Oct: hdt,
+		sb.LDT2023: ldt, sb.LDT2024: ldt,
+		sb.MDT2023: mdt, sb.MDT2024: mdt,
+	}
+}()
+
+// quantity — "Quantity" как число: в таблицах MDT колонка текстовая
+func quantity() string {
+	return fmt.Sprintf("CAST(%s AS INTEGER)", sb.Quantity)
+}
+
+// checkTable прогоняет все проверки таблицы. Упавшая проверка
+// становится ошибкой отчёта, чтобы таблица не считалась чистой
+func checkTable(ctx context.Context, conn db.Querier, table sb.Table) TableReport {
+	report := TableReport{Table: string(table), CheckedAt: time.Now().UTC(), Issues: []Issue{}}
+
+	fp, err := tableFingerprint(ctx, conn, table)
+	if err != nil {
+		slog.ErrorContext(ctx, "Data quality check failed", "table", string(table), "check", "fingerprint", "error", err)
+		report.Issues = append(report.Issues, failed("fingerprint"))
+	}
+	report.fingerprint = fp
+	report.Rows = fp.rows
+
+	for _, c := range checks {
+		issues, err := c.run(ctx, conn, table)
+		if err != nil {
+			slog.ErrorContext(ctx, "Data quality check failed", "table", string(table), "check", c.name, "error", err)
+			issues = []Issue{failed(c.name)}
+		}
+		report.Issues = append(report.Issues, issues...)
+	}
+
+	for _, issue := range report.Issues {
+		if issue.Severity == SeverityError {
+			report.Errors++
+		} else {
+			report.Warnings++
+		}
+	}
+	return report
+}
+
+func failed(name string) Issue {
+	return Issue{Check: name, Severity: SeverityError, Message: "Check failed, see server logs"}
+}
+
+func tableFingerprint(ctx context.Context, conn db.Querier, table sb.Table) (fingerprint, error) {
+	var fp fingerprint
+	err := queryRows(ctx, conn, fmt.Sprintf(`SELECT COUNT(*), COALESCE(SUM(%s), 0) FROM %s`, quantity(), table), nil,
+		func(scan func(...any) error) error {
+			return scan(&fp.rows, &fp.quantity)
+		})
+	return fp, err
+}
+
+// checkGeography ищет округа и регионы без английского названия:
+// регион уходит в ответ непереведённым, округ выпадает из отчётов
+func checkGeography(ctx context.Context, conn db.Querier, table sb.Table) ([]Issue, error) {
+	sql := fmt.Sprintf(`
+		SELECT %[1]s, %[2]s, COUNT(*)
+		FROM %[3]s
+		WHERE %[1]s IS NOT NULL AND %[2]s IS NOT NULL
+		GROUP BY %[1]s, %[2]s
+		ORDER BY %[1]s, %[2]s
+	`, sb.FederalDistrict, sb.Region, table)
+
+	districts := map[string]int{}
+	regions := map[string]int{}
+	err := queryRows(ctx, conn, sql, nil, func(scan func(...any) error) error {
+		var district, region string
+		var rows int
+		if err := scan(&district, &region, &rows); err != nil {
+			return err
+		}
+		if !reports.KnownDistrict(district) {
+			districts[district] += rows
+		}
+		if !reports.KnownRegion(region) {
+			regions[region] += rows
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []Issue
+	for _, name := range sortedKeys(districts) {
+		issues = append(issues, Issue{Check: "unmapped_district", Severity: SeverityError, Value: name, Rows: districts[name],
+			Message: "Federal district has no translation and is dropped from reports"})
+	}
+	for _, name := range sortedKeys(regions) {
+		issues = append(issues, Issue{Check: "unmapped_region", Severity: SeverityError, Value: name, Rows: regions[name],
+			Message: "Region has no translation and is returned untranslated"})
+	}
+	return issues, nil
+}
+
+// checkNullGeography ищет строки без округа или региона
+func checkNullGeography(ctx context.Context, conn db.Querier, table sb.Table) ([]Issue, error) {
+	sql := fmt.Sprintf(`
+		SELECT
+			COUNT(*) FILTER (WHERE %[1]s IS NULL),
+			COUNT(*) FILTER (WHERE %[2]s IS NULL)
+		FROM %[3]s
+	`, sb.FederalDistrict, sb.Region, table)
+
+	var districts, regions int
+	err := queryRows(ctx, conn, sql, nil, func(scan func(...any) error) error {
+		return scan(&districts, &regions)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []Issue
+	if districts > 0 {
+		issues = append(issues, Issue{Check: "null_district", Severity: SeverityError, Rows: districts,
+			Message: "Rows without a federal district are dropped from reports"})
+	}
+	if regions > 0 {
+		issues = append(issues, Issue{Check: "null_region", Severity: SeverityError, Rows: regions,
+			Message: "Rows without a region are merged into the district total row"})
+	}
+	return issues, nil
+}
+
+// checkBrands ищет написания брендов, похожие на бренды отчётов:
+// "DONG FENG" вместо "DONGFENG" не попадает в колонку бренда, пока
+// не заведён вариантом написания в справочнике брендов
+func checkBrands(ctx context.Context, conn db.Querier, table sb.Table) ([]Issue, error) {
+	sql := fmt.Sprintf(`
+		SELECT COALESCE(%[1]s, ''), COALESCE(%[2]s, ''), COUNT(*)
+		FROM %[3]s
+		GROUP BY %[1]s, %[2]s
+		ORDER BY %[1]s
+	`, sb.Brand, sb.CanonicalBrand, table.From())
+
+	brands := map[string]int{}
+	err := queryRows(ctx, conn, sql, nil, func(scan func(...any) error) error {
+		var brand, canonical string
+		var rows int
+		if err := scan(&brand, &canonical, &rows); err != nil {
+			return err
+		}
+		// Вариант написания из справочника уже сведён к каноническому бренду
+		if brand == canonical {
+			brands[brand] += rows
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return brandIssues(brands, referenceBrands[table]), nil
+}
+
+func brandIssues(brands map[string]int, reference []string) []Issue {
+	var issues []Issue
+	for _, brand := range sortedKeys(brands) {
+		if brand == "" {
+			issues = append(issues, Issue{Check: "null_brand", Severity: SeverityWarning, Rows: brands[brand],
+				Message: "Rows without a brand are counted in no brand column"})
+			continue
+		}
+		if like := similarBrand(brand, reference); like != "" {
+			issues = append(issues, Issue{Check: "unknown_brand", Severity: SeverityWarning, Value: brand, Rows: brands[brand],
+				Message: fmt.Sprintf("Brand looks like %s but is not counted as %s; add it as an alias in the brand master", like, like)})
+		}
+	}
+	return issues
+}
+
+// similarBrand возвращает бренд из списка, вариантом написания которого
+// выглядит brand: совпадает без учёта регистра, пробелов и знаков
+// или содержит его название, как "Shacman (Shaanxi)"
+func similarBrand(brand string, reference []string) string {
+	key := brandKey(brand)
+	for _, known := range reference {
+		if brand == known {
+			return ""
+		}
+	}
+	for _, known := range reference {
+		if k := brandKey(known); key == k || (len(k) >= 3 && strings.Contains(key, k)) {
+			return known
+		}
+	}
+	return ""
+}
+
+func brandKey(brand string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToUpper(r)
+		}
+		return -1
+	}, brand)
+}
+
+// checkQuantity ищет отрицательные и неправдоподобно большие количества
+func checkQuantity(ctx context.Context, conn db.Querier, table sb.Table) ([]Issue, error) {
+	var p sb.Params
+	sql := fmt.Sprintf(`
+		SELECT
+			COUNT(*) FILTER (WHERE %[1]s < 0),
+			COUNT(*) FILTER (WHERE %[1]s > %[2]s)
+		FROM %[3]s
+	`, quantity(), p.Bind(MaxRowQuantity), table)
+
+	var negative, implausible int
+	err := queryRows(ctx, conn, sql, p.Args(), func(scan func(...any) error) error {
+		return scan(&negative, &implausible)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []Issue
+	if negative > 0 {
+		issues = append(issues, Issue{Check: "negative_quantity", Severity: SeverityError, Rows: negative,
+			Message: "Rows with a negative quantity"})
+	}
+	if implausible > 0 {
+		issues = append(issues, Issue{Check: "implausible_quantity", Severity: SeverityWarning, Rows: implausible,
+			Message: fmt.Sprintf("Rows with more than %d registrations", MaxRowQuantity)})
+	}
+	return issues, nil
+}
+
+// checkMonths ищет месяцы вне 1–12 и пропущенные месяцы до последнего загруженного
+func checkMonths(ctx context.Context, conn db.Querier, table sb.Table) ([]Issue, error) {
+	sql := fmt.Sprintf(`
+		SELECT COALESCE(%[1]s, 0), COUNT(*)
+		FROM %[2]s
+		GROUP BY %[1]s
+		ORDER BY %[1]s
+	`, sb.MonthOfRegistration, table)
+
+	months := map[int]int{}
+	err := queryRows(ctx, conn, sql, nil, func(scan func(...any) error) error {
+		var month, rows int
+		if err := scan(&month, &rows); err != nil {
+			return err
+		}
+		months[month] += rows
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return monthIssues(months), nil
+}
+
+func monthIssues(months map[int]int) []Issue {
+	var issues []Issue
+	last := 0
+	for _, month := range sortedInts(months) {
+		if month < 1 || month > 12 {
+			issues = append(issues, Issue{Check: "invalid_month", Severity: SeverityError, Value: strconv.Itoa(month), Rows: months[month],
+				Message: "Month of registration is outside 1-12"})
+			continue
+		}
+		last = month
+	}
+
+	for month := 1; month < last; month++ {
+		if months[month] == 0 {
+			issues = append(issues, Issue{Check: "month_gap", Severity: SeverityError, Value: strconv.Itoa(month),
+				Message: fmt.Sprintf("No registrations for month %d while month %d is loaded", month, last)})
+		}
+	}
+	return issues
+}
+
+// checkDuplicates считает полностью совпадающие строки — обычно след повторной загрузки файла
+func checkDuplicates(ctx context.Context, conn db.Querier, table sb.Table) ([]Issue, error) {
+	sql := fmt.Sprintf(`SELECT COUNT(*) - COUNT(DISTINCT t) FROM %s AS t`, table)
+
+	var duplicates int
+	err := queryRows(ctx, conn, sql, nil, func(scan func(...any) error) error {
+		return scan(&duplicates)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if duplicates == 0 {
+		return nil, nil
+	}
+	return []Issue{{Check: "duplicate_rows", Severity: SeverityWarning, Rows: duplicates,
+		Message: "Rows that exactly repeat another row"}}, nil
+}
+
+// checkOutliers сравнивает продажи бренда с прошлым месяцем
+func checkOutliers(ctx context.Context, conn db.Querier, table sb.Table) ([]Issue, error) {
+	sql := fmt.Sprintf(`
+		SELECT %[1]s, %[2]s, COALESCE(SUM(%[3]s), 0)
+		FROM %[4]s
+		WHERE %[1]s IS NOT NULL AND %[2]s IS NOT NULL
+		GROUP BY %[1]s, %[2]s
+		ORDER BY %[1]s, %[2]s
+	`, sb.CanonicalBrand, sb.MonthOfRegistration, quantity(), table.From())
+
+	sales := map[string]map[int]int{}
+	err := queryRows(ctx, conn, sql, nil, func(scan func(...any) error) error {
+		var brand string
+		var month, total int
+		if err := scan(&brand, &month, &total); err != nil {
+			return err
+		}
+		if sales[brand] == nil {
+			sales[brand] = map[int]int{}
+		}
+		sales[brand][month] += total
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return outlierIssues(sales), nil
+}
+
+func outlierIssues(sales map[string]map[int]int) []Issue {
+	var issues []Issue
+	for _, brand := range sortedKeys(sales) {
+		months := sales[brand]
+		for _, month := range sortedInts(months) {
+			current, previous := months[month], months[month-1]
+			if month == 1 || max(current, previous) < OutlierMinVolume {
+				continue
+			}
+			if current > previous*OutlierRatio || current*OutlierRatio < previous {
+				issues = append(issues, Issue{Check: "outlier", Severity: SeverityWarning, Value: fmt.Sprintf("%s/%d", brand, month), Rows: current,
+					Message: fmt.Sprintf("%s sold %d in month %d against %d in month %d", brand, current, month, previous, month-1)})
+			}
+		}
+	}
+	return issues
+}
+
+// queryRows выполняет запрос и передаёт каждую строку в handle
+func queryRows(ctx context.Context, conn db.Querier, sql string, args []any, handle func(scan func(...any) error) error) error {
+	rows, err := conn.Query(ctx, sql, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := handle(rows.Scan); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedInts[V any](m map[int]V) []int {
+	keys := make([]int, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	return keys
+}
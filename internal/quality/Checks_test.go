@@ -0,0 +1,77 @@
+package quality
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSimilarBrand(t *testing.T) {
+	reference := []string{"DONGFENG", "SHACMAN", "SITRAK", "FAW"}
+
+	cases := map[string]string{
+		"DONGFENG":          "",
+		"DONG FENG":         "DONGFENG",
+		"Dongfeng":          "DONGFENG",
+		"Shacman (Shaanxi)": "SHACMAN",
+		"Sitrak/SINOTRUK":   "SITRAK",
+		"VOLVO":             "",
+		"FA":                "",
+	}
+
+	for brand, want := range cases {
+		if got := similarBrand(brand, reference); got != want {
+			t.Errorf("similarBrand(%q) = %q, want %q", brand, got, want)
+		}
+	}
+}
+
+func TestBrandIssues(t *testing.T) {
+	issues := brandIssues(map[string]int{"FAW": 10, "F.A.W.": 2, "": 3, "MAN": 4}, []string{"FAW"})
+
+	var checks []string
+	for _, issue := range issues {
+		checks = append(checks, issue.Check+":"+issue.Value)
+	}
+	want := []string{"null_brand:", "unknown_brand:F.A.W."}
+	if !reflect.DeepEqual(checks, want) {
+		t.Errorf("issues = %v, want %v", checks, want)
+	}
+}
+
+func TestMonthIssues(t *testing.T) {
+	issues := monthIssues(map[int]int{0: 5, 1: 10, 2: 10, 4: 10, 13: 1})
+
+	var got []string
+	for _, issue := range issues {
+		got = append(got, issue.Check+":"+issue.Value)
+		if issue.Severity != SeverityError {
+			t.Errorf("%s: severity %s, want error", issue.Check, issue.Severity)
+		}
+	}
+	want := []string{"invalid_month:0", "invalid_month:13", "month_gap:3"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("issues = %v, want %v", got, want)
+	}
+}
+
+func TestOutlierIssues(t *testing.T) {
+	issues := outlierIssues(map[string]map[int]int{
+		// Рост в 4 раза — выброс
+		"FOTON": {1: 100, 2: 400},
+		// Падение в 4 раза — выброс
+		"HOWO": {1: 200, 2: 50},
+		// Малые объёмы не проверяются
+		"JAC": {1: 2, 2: 40},
+		// Колебание в пределах порога
+		"FAW": {1: 100, 2: 250},
+	})
+
+	var got []string
+	for _, issue := range issues {
+		got = append(got, issue.Value)
+	}
+	want := []string{"FOTON/2", "HOWO/2"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("outliers = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,206 @@
+// Package quality проверяет данные регистраций после загрузки: непереведённые
+// регионы, неизвестные бренды, пустые округа, пропуски месяцев, дубли
+// и выбросы. Последний отчёт хранится в памяти и может блокировать
+// публикацию отчётов по таблице с ошибками
+package quality
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+	"truck-analytics-platform/internal/audit"
+	"truck-analytics-platform/internal/db"
+	sb "truck-analytics-platform/internal/sqlbuilder"
+)
+
+// WatchInterval — как часто Watch проверяет, не перезагружены ли таблицы
+const WatchInterval = 10 * time.Minute
+
+// Severity — серьёзность проблемы. Ошибки блокируют публикацию, предупреждения нет
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Issue — проблема, найденная проверкой
+type Issue struct {
+	Check    string   `json:"check"`
+	Severity Severity `json:"severity"`
+	// Value — проблемное значение: регион, бренд, месяц
+	Value string `json:"value,omitempty"`
+	// Rows — число затронутых строк таблицы
+	Rows    int    `json:"rows"`
+	Message string `json:"message"`
+}
+
+// TableReport — результат проверок одной таблицы
+type TableReport struct {
+	Table     string    `json:"table"`
+	CheckedAt time.Time `json:"checked_at"`
+	Rows      int       `json:"rows"`
+	Errors    int       `json:"errors"`
+	Warnings  int       `json:"warnings"`
+	Issues    []Issue   `json:"issues"`
+
+	fingerprint fingerprint
+}
+
+// Report — отчёт о качестве данных по всем таблицам
+type Report struct {
+	CheckedAt time.Time     `json:"checked_at"`
+	Blocking  bool          `json:"blocking"`
+	Tables    []TableReport `json:"tables"`
+}
+
+// fingerprint отличает одну загрузку таблицы от другой
+type fingerprint struct {
+	rows     int
+	quantity int
+}
+
+var (
+	mu     sync.RWMutex
+	latest = map[sb.Table]TableReport{}
+)
+
+// BlockingEnabled сообщает, блокируют ли ошибки качества публикацию отчётов.
+// Включается переменной окружения DATA_QUALITY_BLOCKING=true
+func BlockingEnabled() bool {
+	return os.Getenv("DATA_QUALITY_BLOCKING") == "true"
+}
+
+// Blocked сообщает, что отчёты по таблице нельзя публиковать: блокировка
+// включена и в последней проверке таблицы есть ошибки. Непроверенная
+// таблица не блокируется
+func Blocked(table sb.Table) bool {
+	if !BlockingEnabled() {
+		return false
+	}
+
+	mu.RLock()
+	defer mu.RUnlock()
+	return latest[table].Errors > 0
+}
+
+// Latest возвращает последний отчёт; false — проверок ещё не было
+func Latest() (Report, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	if len(latest) == 0 {
+		return Report{}, false
+	}
+
+	report := Report{Blocking: BlockingEnabled(), Tables: []TableReport{}}
+	for _, table := range sb.Tables {
+		t, ok := latest[table]
+		if !ok {
+			continue
+		}
+		report.Tables = append(report.Tables, t)
+		if t.CheckedAt.After(report.CheckedAt) {
+			report.CheckedAt = t.CheckedAt
+		}
+	}
+	return report, true
+}
+
+// Run проверяет все таблицы и возвращает свежий отчёт. Ошибка —
+// только если нет соединения с БД; сбой отдельной проверки попадает в отчёт
+func Run(ctx context.Context) (Report, error) {
+	conn, err := db.Connect(ctx)
+	if err != nil {
+		return Report{}, err
+	}
+
+	for _, table := range sb.Tables {
+		store(ctx, checkTable(ctx, conn, table))
+	}
+
+	report, _ := Latest()
+	return report, nil
+}
+
+// Watch проверяет таблицы при старте и затем каждые interval перепроверяет
+// те, что были перезагружены. Блокируется до отмены ctx
+func Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		conn, err := db.Connect(ctx)
+		if err != nil {
+			slog.Warn("Data quality check skipped", "error", err)
+		} else {
+			for _, table := range sb.Tables {
+				checkIfLoaded(ctx, conn, table)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkIfLoaded перепроверяет таблицу, если её отпечаток изменился
+func checkIfLoaded(ctx context.Context, conn db.Querier, table sb.Table) {
+	current, err := tableFingerprint(ctx, conn, table)
+	if err != nil {
+		slog.ErrorContext(ctx, "Data quality fingerprint failed", "table", string(table), "error", err)
+		return
+	}
+
+	mu.RLock()
+	previous, checked := latest[table]
+	mu.RUnlock()
+	if checked && previous.fingerprint == current {
+		return
+	}
+
+	report := checkTable(ctx, conn, table)
+	store(ctx, report)
+	slog.InfoContext(ctx, "Data quality checked", "table", report.Table, "rows", report.Rows,
+		"errors", report.Errors, "warnings", report.Warnings)
+}
+
+// store сохраняет отчёт таблицы. Отпечаток, изменившийся с прошлой проверки, —
+// новая загрузка таблицы: она записывается в журнал аудита
+func store(ctx context.Context, report TableReport) {
+	table := sb.Table(report.Table)
+
+	mu.Lock()
+	previous, checked := latest[table]
+	latest[table] = report
+	mu.Unlock()
+
+	if checked && previous.fingerprinted() && report.fingerprinted() && previous.fingerprint != report.fingerprint {
+		recordLoad(ctx, report)
+	}
+}
+
+// fingerprinted сообщает, что отпечаток таблицы снят: при сбое он пустой
+func (r TableReport) fingerprinted() bool {
+	for _, issue := range r.Issues {
+		if issue.Check == "fingerprint" {
+			return false
+		}
+	}
+	return true
+}
+
+func recordLoad(ctx context.Context, report TableReport) {
+	params, _ := json.Marshal(map[string]any{
+		"table":    report.Table,
+		"rows":     report.fingerprint.rows,
+		"quantity": report.fingerprint.quantity,
+	})
+	audit.Record(ctx, audit.Event{Action: audit.ActionDataLoad, Actor: audit.SystemActor, Params: params})
+}
@@ -0,0 +1,43 @@
+package quality
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"truck-analytics-platform/internal/db"
+	"truck-analytics-platform/internal/db/dbtest"
+	sb "truck-analytics-platform/internal/sqlbuilder"
+)
+
+func TestStoreRecordsReloadedTable(t *testing.T) {
+	querier := &dbtest.Querier{}
+	db.SetQuerier(querier)
+	t.Cleanup(func() {
+		db.SetQuerier(nil)
+		mu.Lock()
+		delete(latest, sb.LDT2024)
+		mu.Unlock()
+	})
+
+	ctx := context.Background()
+	report := func(rows, quantity int, issues ...Issue) TableReport {
+		return TableReport{Table: string(sb.LDT2024), Issues: issues, fingerprint: fingerprint{rows: rows, quantity: quantity}}
+	}
+	store(ctx, report(10, 120))
+	store(ctx, report(10, 120))
+	store(ctx, report(0, 0, failed("fingerprint")))
+	store(ctx, report(10, 120))
+	if calls := querier.Calls(); len(calls) != 0 {
+		t.Fatalf("unchanged table recorded as loaded: %v", calls)
+	}
+
+	store(ctx, report(12, 150))
+	calls := querier.Calls()
+	if len(calls) != 1 || !strings.Contains(calls[0].SQL, "INSERT INTO audit_log") {
+		t.Fatalf("reload is not recorded once: %v", calls)
+	}
+	if params := calls[0].Args[6].(string); !strings.Contains(params, `"table":"`+string(sb.LDT2024)+`"`) {
+		t.Errorf("params = %s, want the reloaded table", params)
+	}
+}
@@ -0,0 +1,140 @@
+// Package ratelimit — квоты запросов и блокировка входа после неудачных
+// попыток. Счётчики живут в Store; по умолчанию — в памяти процесса
+package ratelimit
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Quota — не больше Requests запросов за окно Window. Нулевая квота не ограничивает
+type Quota struct {
+	Requests int
+	Window   time.Duration
+}
+
+// Unlimited сообщает, что квота выключена
+func (q Quota) Unlimited() bool {
+	return q.Requests <= 0 || q.Window <= 0
+}
+
+func (q Quota) String() string {
+	if q.Unlimited() {
+		return "off"
+	}
+	return fmt.Sprintf("%d/%s", q.Requests, q.Window)
+}
+
+// ParseQuota разбирает квоту вида 120/1m; off или 0 выключают квоту
+func ParseQuota(raw string) (Quota, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "off" || raw == "0" {
+		return Quota{}, nil
+	}
+	count, window, ok := strings.Cut(raw, "/")
+	if !ok {
+		return Quota{}, fmt.Errorf("quota %q must look like 120/1m", raw)
+	}
+	requests, err := strconv.Atoi(count)
+	if err != nil || requests < 0 {
+		return Quota{}, fmt.Errorf("quota %q: requests must be a non-negative integer", raw)
+	}
+	duration, err := time.ParseDuration(window)
+	if err != nil || duration <= 0 {
+		return Quota{}, fmt.Errorf("quota %q: window must be a positive duration", raw)
+	}
+	return Quota{Requests: requests, Window: duration}, nil
+}
+
+// Lockout — блокировка после неудачных входов: после Failures неудач подряд
+// вход закрывается на Base, и каждая следующая неудача удваивает срок до Max.
+// Счёт неудач забывается, если их не было дольше Max
+type Lockout struct {
+	Failures int
+	Base     time.Duration
+	Max      time.Duration
+}
+
+// Duration — срок блокировки после failures неудач подряд; 0 — без блокировки
+func (l Lockout) Duration(failures int) time.Duration {
+	if l.Failures <= 0 || failures < l.Failures {
+		return 0
+	}
+	duration := l.Base
+	for i := l.Failures; i < failures && duration < l.Max; i++ {
+		duration *= 2
+	}
+	return min(duration, l.Max)
+}
+
+// Config — квоты тяжёлых эндпоинтов и политика блокировки входа
+type Config struct {
+	// Analytics — квота аналитических запросов: сводные таблицы, GraphQL, рейтинги
+	Analytics Quota
+	// Export — квота выгрузок файлами
+	Export Quota
+	Login  Lockout
+}
+
+// DefaultConfig — квоты и блокировка по умолчанию
+func DefaultConfig() Config {
+	return Config{
+		Analytics: Quota{Requests: 120, Window: time.Minute},
+		Export:    Quota{Requests: 20, Window: time.Minute},
+		Login:     Lockout{Failures: 5, Base: time.Minute, Max: time.Hour},
+	}
+}
+
+// ConfigFromEnv читает RATE_LIMIT_ANALYTICS и RATE_LIMIT_EXPORT (120/1m, off),
+// LOGIN_MAX_FAILURES, LOGIN_LOCKOUT и LOGIN_LOCKOUT_MAX. Некорректные
+// значения заменяются значениями по умолчанию
+func ConfigFromEnv() Config {
+	cfg := DefaultConfig()
+	for _, v := range []struct {
+		name  string
+		value *Quota
+	}{
+		{"RATE_LIMIT_ANALYTICS", &cfg.Analytics},
+		{"RATE_LIMIT_EXPORT", &cfg.Export},
+	} {
+		raw := os.Getenv(v.name)
+		if raw == "" {
+			continue
+		}
+		if parsed, err := ParseQuota(raw); err == nil {
+			*v.value = parsed
+		} else {
+			slog.Warn("Invalid rate limit, using default", "variable", v.name, "value", raw, "default", v.value.String())
+		}
+	}
+	if raw := os.Getenv("LOGIN_MAX_FAILURES"); raw != "" {
+		if parsed, err := strconv.Atoi(raw); err == nil && parsed >= 0 {
+			cfg.Login.Failures = parsed
+		} else {
+			slog.Warn("Invalid login lockout, using default", "variable", "LOGIN_MAX_FAILURES", "value", raw)
+		}
+	}
+	for _, v := range []struct {
+		name  string
+		value *time.Duration
+	}{
+		{"LOGIN_LOCKOUT", &cfg.Login.Base},
+		{"LOGIN_LOCKOUT_MAX", &cfg.Login.Max},
+	} {
+		raw := os.Getenv(v.name)
+		if raw == "" {
+			continue
+		}
+		if parsed, err := time.ParseDuration(raw); err == nil && parsed > 0 {
+			*v.value = parsed
+		} else {
+			slog.Warn("Invalid login lockout, using default", "variable", v.name, "value", raw)
+		}
+	}
+	cfg.Login.Max = max(cfg.Login.Max, cfg.Login.Base)
+	return cfg
+}
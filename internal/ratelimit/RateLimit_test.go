@@ -0,0 +1,114 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseQuota(t *testing.T) {
+	cases := []struct {
+		raw  string
+		want Quota
+		err  bool
+	}{
+		{"120/1m", Quota{Requests: 120, Window: time.Minute}, false},
+		{"5/30s", Quota{Requests: 5, Window: 30 * time.Second}, false},
+		{"off", Quota{}, false},
+		{"0", Quota{}, false},
+		{"120", Quota{}, true},
+		{"x/1m", Quota{}, true},
+		{"10/0s", Quota{}, true},
+	}
+	for _, tc := range cases {
+		got, err := ParseQuota(tc.raw)
+		if (err != nil) != tc.err || got != tc.want {
+			t.Errorf("ParseQuota(%q) = %v, %v; want %v, error %v", tc.raw, got, err, tc.want, tc.err)
+		}
+	}
+}
+
+func TestLockoutDoubles(t *testing.T) {
+	lockout := Lockout{Failures: 3, Base: time.Minute, Max: 5 * time.Minute}
+	want := []time.Duration{0, 0, 0, time.Minute, 2 * time.Minute, 4 * time.Minute, 5 * time.Minute, 5 * time.Minute}
+	for failures, duration := range want {
+		if got := lockout.Duration(failures); got != duration {
+			t.Errorf("Duration(%d) = %s, want %s", failures, got, duration)
+		}
+	}
+}
+
+// clock — управляемое время для Memory
+type clock struct{ now time.Time }
+
+func (c *clock) Now() time.Time { return c.now }
+
+func newTestMemory() (*Memory, *clock) {
+	c := &clock{now: time.Date(2024, 11, 5, 9, 0, 0, 0, time.UTC)}
+	m := NewMemory()
+	m.now = c.Now
+	return m, c
+}
+
+func TestMemoryHitResetsWithWindow(t *testing.T) {
+	m, c := newTestMemory()
+	quota := Quota{Requests: 2, Window: time.Minute}
+
+	for i := range 2 {
+		if ok, _ := m.Hit("ip:10.0.0.7", quota); !ok {
+			t.Fatalf("request %d rejected within quota", i+1)
+		}
+	}
+	c.now = c.now.Add(20 * time.Second)
+	ok, retryAfter := m.Hit("ip:10.0.0.7", quota)
+	if ok || retryAfter != 40*time.Second {
+		t.Fatalf("over quota: allowed %v, retry after %s; want rejection for 40s", ok, retryAfter)
+	}
+	if ok, _ := m.Hit("ip:10.0.0.8", quota); !ok {
+		t.Fatal("another key shares the window")
+	}
+
+	c.now = c.now.Add(40 * time.Second)
+	if ok, _ := m.Hit("ip:10.0.0.7", quota); !ok {
+		t.Fatal("request rejected in a new window")
+	}
+}
+
+func TestMemoryLocksAfterFailures(t *testing.T) {
+	m, c := newTestMemory()
+	lockout := Lockout{Failures: 2, Base: time.Minute, Max: time.Hour}
+
+	if locked := m.Fail("login:account:analyst", lockout); locked != 0 {
+		t.Fatalf("locked for %s after the first failure", locked)
+	}
+	if locked := m.Fail("login:account:analyst", lockout); locked != time.Minute {
+		t.Fatalf("locked for %s after the second failure, want 1m", locked)
+	}
+	c.now = c.now.Add(15 * time.Second)
+	if locked := m.Locked("login:account:analyst"); locked != 45*time.Second {
+		t.Fatalf("Locked = %s, want 45s", locked)
+	}
+
+	c.now = c.now.Add(time.Minute)
+	if locked := m.Locked("login:account:analyst"); locked != 0 {
+		t.Fatalf("still locked for %s after the lockout", locked)
+	}
+	if locked := m.Fail("login:account:analyst", lockout); locked != 2*time.Minute {
+		t.Fatalf("locked for %s after the third failure, want 2m", locked)
+	}
+
+	m.Succeed("login:account:analyst")
+	if locked := m.Locked("login:account:analyst"); locked != 0 {
+		t.Fatalf("locked for %s after a successful login", locked)
+	}
+}
+
+func TestMemoryForgetsOldFailures(t *testing.T) {
+	m, c := newTestMemory()
+	lockout := Lockout{Failures: 2, Base: time.Minute, Max: time.Hour}
+
+	m.Fail("login:ip:10.0.0.7", lockout)
+	c.now = c.now.Add(2 * time.Hour)
+	if locked := m.Fail("login:ip:10.0.0.7", lockout); locked != 0 {
+		t.Fatalf("failure from two hours ago still counts: locked for %s", locked)
+	}
+}
@@ -0,0 +1,126 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Store хранит счётчики квот и неудачных входов по ключам вида ip:10.0.0.7
+// или login:analyst. Общий Store нескольких экземпляров API делает лимиты общими
+type Store interface {
+	// Hit учитывает запрос в текущем окне квоты. false — квота исчерпана,
+	// retryAfter — сколько осталось до нового окна
+	Hit(key string, quota Quota) (allowed bool, retryAfter time.Duration)
+	// Locked — сколько ещё закрыт вход по ключу; 0 — вход открыт
+	Locked(key string) time.Duration
+	// Fail учитывает неудачный вход и возвращает срок блокировки; 0 — без блокировки
+	Fail(key string, lockout Lockout) time.Duration
+	// Succeed сбрасывает счёт неудачных входов
+	Succeed(key string)
+}
+
+// sweepInterval — как часто Memory выбрасывает устаревшие счётчики
+const sweepInterval = time.Minute
+
+type window struct {
+	count int
+	ends  time.Time
+}
+
+type failures struct {
+	count       int
+	lockedUntil time.Time
+	forgetAt    time.Time
+}
+
+// Memory — Store в памяти процесса с фиксированными окнами квот
+type Memory struct {
+	mu        sync.Mutex
+	now       func() time.Time
+	windows   map[string]window
+	failures  map[string]failures
+	nextSweep time.Time
+}
+
+// NewMemory создаёт пустой Store в памяти
+func NewMemory() *Memory {
+	return &Memory{now: time.Now, windows: map[string]window{}, failures: map[string]failures{}}
+}
+
+func (m *Memory) Hit(key string, quota Quota) (bool, time.Duration) {
+	if quota.Unlimited() {
+		return true, 0
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.sweep()
+
+	w := m.windows[key]
+	if !now.Before(w.ends) {
+		w = window{ends: now.Add(quota.Window)}
+	}
+	w.count++
+	m.windows[key] = w
+	if w.count > quota.Requests {
+		return false, w.ends.Sub(now)
+	}
+	return true, 0
+}
+
+func (m *Memory) Locked(key string) time.Duration {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.sweep()
+
+	if until := m.failures[key].lockedUntil; now.Before(until) {
+		return until.Sub(now)
+	}
+	return 0
+}
+
+func (m *Memory) Fail(key string, lockout Lockout) time.Duration {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.sweep()
+
+	f := m.failures[key]
+	if !now.Before(f.forgetAt) {
+		f = failures{}
+	}
+	f.count++
+	duration := lockout.Duration(f.count)
+	if duration > 0 {
+		f.lockedUntil = now.Add(duration)
+	}
+	f.forgetAt = now.Add(max(lockout.Max, duration))
+	m.failures[key] = f
+	return duration
+}
+
+func (m *Memory) Succeed(key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.failures, key)
+}
+
+// sweep раз в sweepInterval выбрасывает закончившиеся окна и забытые
+// неудачи, чтобы счётчики не росли бесконечно. Вызывается под m.mu
+func (m *Memory) sweep() time.Time {
+	now := m.now()
+	if now.Before(m.nextSweep) {
+		return now
+	}
+	m.nextSweep = now.Add(sweepInterval)
+
+	for key, w := range m.windows {
+		if !now.Before(w.ends) {
+			delete(m.windows, key)
+		}
+	}
+	for key, f := range m.failures {
+		if !now.Before(f.forgetAt) {
+			delete(m.failures, key)
+		}
+	}
+	return now
+}
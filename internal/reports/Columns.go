@@ -0,0 +1,94 @@
+package reports
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+	"truck-analytics-platform/internal/db"
+	"truck-analytics-platform/internal/metrics"
+	sb "truck-analytics-platform/internal/sqlbuilder"
+)
+
+// ColumnsCacheTTL — как долго хранится набор колонок таблицы. Выгрузку
+// могут перезалить с новыми колонками, поэтому набор перечитывается
+const ColumnsCacheTTL = 10 * time.Minute
+
+// ErrUnavailable — в выгрузке периода нет колонки, нужной запросу
+var ErrUnavailable = errors.New("not available")
+
+type tableColumns struct {
+	names     map[string]bool
+	fetchedAt time.Time
+}
+
+var (
+	columnsMu    sync.Mutex
+	columnsCache = map[sb.Table]tableColumns{}
+)
+
+// RequireColumns возвращает ErrUnavailable, если какой-то из колонок нет в таблице
+func RequireColumns(ctx context.Context, table sb.Table, columns ...sb.Column) error {
+	names, err := tableColumnNames(ctx, table)
+	if err != nil {
+		return err
+	}
+	for _, column := range columns {
+		if !names[string(column)] {
+			return fmt.Errorf("column %s of %s: %w", string(column), table, ErrUnavailable)
+		}
+	}
+	return nil
+}
+
+func tableColumnNames(ctx context.Context, table sb.Table) (map[string]bool, error) {
+	columnsMu.Lock()
+	cached, ok := columnsCache[table]
+	columnsMu.Unlock()
+	if ok && time.Since(cached.fetchedAt) < ColumnsCacheTTL {
+		metrics.CacheRequests.WithLabelValues("columns", "hit").Inc()
+		return cached.names, nil
+	}
+	metrics.CacheRequests.WithLabelValues("columns", "miss").Inc()
+
+	conn, err := db.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := conn.Query(ctx, `
+		SELECT column_name
+		FROM information_schema.columns
+		WHERE table_schema = current_schema() AND table_name = $1
+	`, table.String())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := map[string]bool{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names[name] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	columnsMu.Lock()
+	columnsCache[table] = tableColumns{names: names, fetchedAt: time.Now()}
+	columnsMu.Unlock()
+
+	return names, nil
+}
+
+// ResetColumnsCache забывает прочитанные наборы колонок, например после смены источника данных
+func ResetColumnsCache() {
+	columnsMu.Lock()
+	columnsCache = map[sb.Table]tableColumns{}
+	columnsMu.Unlock()
+}
@@ -0,0 +1,123 @@
+package reports
+
+import (
+	"context"
+	"sort"
+)
+
+// Concentration — концентрация рынка на территории: индекс
+// Херфиндаля-Хиршмана (сумма квадратов долей в процентах, 0–10000) и доли
+// трёх и пяти крупнейших брендов, %. Поля прошлого года и изменения — nil,
+// если выгрузки прошлого года нет
+type Concentration struct {
+	Area string `json:"area"`
+	// District — округ региона на уровне регионов
+	District  string   `json:"district,omitempty"`
+	Volume    int      `json:"volume"`
+	Brands    int      `json:"brands"`
+	HHI       float64  `json:"hhi"`
+	CR3       float64  `json:"cr3"`
+	CR5       float64  `json:"cr5"`
+	PrevHHI   *float64 `json:"prev_hhi"`
+	PrevCR3   *float64 `json:"prev_cr3"`
+	PrevCR5   *float64 `json:"prev_cr5"`
+	HHIChange *float64 `json:"hhi_change"`
+	CR3Change *float64 `json:"cr3_change"`
+	CR5Change *float64 `json:"cr5_change"`
+}
+
+// MarketConcentration считает концентрацию на каждой территории уровня спецификации
+func MarketConcentration(ctx context.Context, s MarketSpec) ([]Concentration, Period, error) {
+	if err := s.Validate(LevelCountry, LevelDistrict, LevelRegion); err != nil {
+		return nil, s.period(), err
+	}
+	st, period, err := load(ctx, s)
+	if err != nil {
+		return nil, period, err
+	}
+
+	result := make([]Concentration, 0, len(st.areas))
+	for _, a := range st.areas {
+		c := st.concentration(a)
+		if s.Level == LevelRegion {
+			c.District = a.district
+		}
+		result = append(result, c)
+	}
+	return result, period, nil
+}
+
+// DistrictConcentration считает концентрацию по стране и по каждому округу
+// одним запросом продаж. Ключ — название территории, страна — CountryArea
+func DistrictConcentration(ctx context.Context, s MarketSpec) (map[string]Concentration, error) {
+	s.Level = LevelDistrict
+	if err := s.Validate(LevelDistrict); err != nil {
+		return nil, err
+	}
+	sales, period, err := loadSales(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[string]Concentration{}
+	for _, level := range []string{LevelCountry, LevelDistrict} {
+		st := collect(sales, s.Year, level, period.ComparedWith != nil)
+		for _, a := range st.areas {
+			result[a.name()] = st.concentration(a)
+		}
+	}
+	return result, nil
+}
+
+// concentration считает индексы территории за оба года
+func (st stats) concentration(a area) Concentration {
+	var cur, prev []int
+	for _, v := range st.brands[a] {
+		if v.cur > 0 {
+			cur = append(cur, v.cur)
+		}
+		if v.prev > 0 {
+			prev = append(prev, v.prev)
+		}
+	}
+
+	c := Concentration{Area: a.name(), Volume: st.totals[a].cur, Brands: len(cur)}
+	c.HHI, c.CR3, c.CR5 = indices(cur)
+	if !st.hasPrev {
+		return c
+	}
+
+	hhi, cr3, cr5 := indices(prev)
+	c.PrevHHI, c.PrevCR3, c.PrevCR5 = &hhi, &cr3, &cr5
+	hhiChange, cr3Change, cr5Change := round1(c.HHI-hhi), round1(c.CR3-cr3), round1(c.CR5-cr5)
+	c.HHIChange, c.CR3Change, c.CR5Change = &hhiChange, &cr3Change, &cr5Change
+	return c
+}
+
+// indices возвращает HHI, CR3 и CR5 по продажам брендов. На рынке без
+// продаж все индексы нулевые
+func indices(volumes []int) (hhi, cr3, cr5 float64) {
+	sorted := append([]int(nil), volumes...)
+	sort.Sort(sort.Reverse(sort.IntSlice(sorted)))
+
+	total := 0
+	for _, v := range sorted {
+		total += v
+	}
+	if total == 0 {
+		return 0, 0, 0
+	}
+
+	var top3, top5 int
+	for i, v := range sorted {
+		share := exactPercent(v, total)
+		hhi += share * share
+		if i < 3 {
+			top3 += v
+		}
+		if i < 5 {
+			top5 += v
+		}
+	}
+	return round1(hhi), percent(top3, total), percent(top5, total)
+}
@@ -0,0 +1,147 @@
+package reports
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"truck-analytics-platform/internal/db"
+	sb "truck-analytics-platform/internal/sqlbuilder"
+)
+
+// Filter — отбор регистраций: таблица периода, сегмент, бренды, география
+// и диапазон месяцев. Пустые поля не ограничивают выборку. Значения
+// передаются в запрос параметрами, поэтому фильтр можно собирать из ввода клиента
+type Filter struct {
+	Table   sb.Table
+	Segment Segment
+	// Brands — канонические идентификаторы брендов из справочника
+	Brands []string
+	// Districts и Regions — английские названия, как в ответах API
+	Districts []string
+	Regions   []string
+	MonthFrom int
+	MonthTo   int
+	// GroupByParent сводит бренды в группы производителей в результате выборки
+	GroupByParent bool
+	// CastQuantity приводит "Quantity" к INTEGER, как у рынка MDT
+	CastQuantity bool
+}
+
+// Conditions возвращает условия фильтра для построителя запросов
+func (f Filter) Conditions() []sb.Condition {
+	conditions := append([]sb.Condition(nil), f.Segment.Conditions...)
+
+	if len(f.Brands) > 0 {
+		conditions = append(conditions, sb.In(sb.CanonicalBrand, f.Brands))
+	}
+	if len(f.Districts) > 0 {
+		conditions = append(conditions, sb.In(sb.FederalDistrict, untranslate(districtTranslations, f.Districts)))
+	}
+	if len(f.Regions) > 0 {
+		conditions = append(conditions, sb.In(sb.Region, untranslate(regionTranslations, f.Regions)))
+	}
+	if f.MonthFrom > 0 {
+		conditions = append(conditions, sb.Gte(sb.MonthOfRegistration, f.MonthFrom))
+	}
+	if f.MonthTo > 0 {
+		conditions = append(conditions, sb.Lte(sb.MonthOfRegistration, f.MonthTo))
+	}
+
+	return conditions
+}
+
+// ErrMonthRange — месяц отбора вне года
+var ErrMonthRange = errors.New("month must be between 1 and 12, or 0 for the whole year")
+
+// CheckMonths проверяет месяцы отбора: 0 — месяц не задан, отбор за весь год
+func CheckMonths(months ...int) error {
+	for _, month := range months {
+		if month < 0 || month > 12 {
+			return ErrMonthRange
+		}
+	}
+	return nil
+}
+
+// Registration — продажи бренда (или группы производителя) в городе за период фильтра
+type Registration struct {
+	District string
+	Region   string
+	City     string
+	Brand    string
+	Quantity int
+}
+
+// RegistrationsQuery — продажи с разбивкой округ → регион → город → бренд.
+// Пустые округ, регион и город приходят пустыми строками
+func (f Filter) RegistrationsQuery() sb.Query {
+	var p sb.Params
+	where := sb.Where(&p, f.Conditions()...)
+
+	brand := sb.CanonicalBrand
+	if f.GroupByParent {
+		brand = sb.ParentBrand
+	}
+	quantity := Market{CastQuantity: f.CastQuantity}.quantity()
+
+	sql := fmt.Sprintf(`
+		SELECT
+			COALESCE(%[1]s, ''),
+			COALESCE(%[2]s, ''),
+			COALESCE(%[3]s, ''),
+			%[4]s,
+			COALESCE(SUM(%[5]s), 0) AS total_sales
+		FROM %[6]s
+		WHERE
+			%[7]s
+		GROUP BY %[1]s, %[2]s, %[3]s, %[4]s
+		ORDER BY %[1]s, %[2]s, %[3]s, %[4]s
+	`, sb.FederalDistrict, sb.Region, sb.City, brand, quantity, f.Table.From(), where)
+
+	return sb.Query{SQL: sql, Args: p.Args()}
+}
+
+// Registrations выполняет запрос фильтра. Округа и регионы в результате
+// переведены на английский, как в REST-отчётах
+func Registrations(ctx context.Context, f Filter) ([]Registration, error) {
+	conn, err := db.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	q := f.RegistrationsQuery()
+	rows, err := conn.Query(ctx, q.SQL, q.Args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []Registration
+	for rows.Next() {
+		var r Registration
+		if err := rows.Scan(&r.District, &r.Region, &r.City, &r.Brand, &r.Quantity); err != nil {
+			return nil, err
+		}
+
+		r.District = translate(districtTranslations, r.District)
+		r.Region = translate(regionTranslations, r.Region)
+		result = append(result, r)
+	}
+
+	return result, rows.Err()
+}
+
+// untranslate переводит английские названия обратно в значения БД.
+// Неизвестные названия передаются как есть
+func untranslate(translations map[string]string, names []string) []string {
+	reverse := make(map[string]string, len(translations))
+	for ru, en := range translations {
+		reverse[en] = ru
+	}
+
+	result := make([]string, len(names))
+	for i, name := range names {
+		result[i] = translate(reverse, name)
+	}
+	return result
+}
@@ -0,0 +1,244 @@
+package reports
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"truck-analytics-platform/internal/db"
+	sb "truck-analytics-platform/internal/sqlbuilder"
+)
+
+// Market — рынок для сравнений по годам: сегмент HDT или весь рынок LDT
+// или MDT. У рынка свои таблицы по годам, поэтому сравнение годов идёт
+// по таблицам рынка, а не по Tables
+type Market struct {
+	Key        string
+	Conditions []sb.Condition
+	Tables     map[int]sb.Table
+	// Months — сколько месяцев года есть в выгрузке
+	Months map[int]int
+	// CastQuantity приводит "Quantity" к INTEGER: в таблицах MDT колонка текстовая
+	CastQuantity bool
+}
+
+var hdtMonths = map[int]int{2023: 12, 2024: 10}
+
+func hdtMarket(s Segment) Market {
+	return Market{Key: s.Key, Conditions: s.Conditions, Tables: Tables, Months: hdtMonths}
+}
+
+// Markets — все рынки по ключу: сегменты HDT, ldt и mdt, как в путях отчётов
+var Markets = map[string]Market{
+	Tractors4x2.Key: hdtMarket(Tractors4x2),
+	Tractors6x4.Key: hdtMarket(Tractors6x4),
+	Dumpers6x4.Key:  hdtMarket(Dumpers6x4),
+	Dumpers8x4.Key:  hdtMarket(Dumpers8x4),
+	"ldt": {
+		Key:    "ldt",
+		Tables: map[int]sb.Table{2023: sb.LDT2023, 2024: sb.LDT2024},
+		Months: map[int]int{2023: 10, 2024: 10},
+	},
+	"mdt": {
+		Key:          "mdt",
+		Tables:       map[int]sb.Table{2023: sb.MDT2023, 2024: sb.MDT2024},
+		Months:       map[int]int{2023: 10, 2024: 10},
+		CastQuantity: true,
+	},
+}
+
+// MarketKeys возвращает ключи рынков по алфавиту
+func MarketKeys() []string {
+	keys := make([]string, 0, len(Markets))
+	for key := range Markets {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// CommonMonths — последний месяц, который есть в выгрузках всех годов:
+// годы сравниваются за одинаковое число месяцев
+func (m Market) CommonMonths(years ...int) int {
+	months := 12
+	for _, year := range years {
+		if n, ok := m.Months[year]; ok && n < months {
+			months = n
+		}
+	}
+	return months
+}
+
+// Filter — отбор регистраций рынка за год и месяцы
+func (m Market) Filter(year, monthFrom, monthTo int) Filter {
+	return Filter{
+		Table:        m.Tables[year],
+		Segment:      Segment{Key: m.Key, Conditions: m.Conditions},
+		MonthFrom:    monthFrom,
+		MonthTo:      monthTo,
+		CastQuantity: m.CastQuantity,
+	}
+}
+
+func (m Market) quantity() string {
+	if m.CastQuantity {
+		return fmt.Sprintf("CAST(%s AS INTEGER)", sb.Quantity)
+	}
+	return sb.Quantity.String()
+}
+
+// Sale — продажи бренда в регионе за год
+type Sale struct {
+	Year     int
+	District string
+	Region   string
+	Brand    string
+	Quantity int
+}
+
+// SalesQuery — продажи брендов по регионам за несколько лет одним запросом.
+// Колонки результата: округ, регион, бренд, год, продажи. Годы должны
+// быть в Tables рынка
+func (m Market) SalesQuery(years []int, monthFrom, monthTo int) sb.Query {
+	var p sb.Params
+
+	conditions := append([]sb.Condition(nil), m.Conditions...)
+	if monthFrom > 0 {
+		conditions = append(conditions, sb.Gte(sb.MonthOfRegistration, monthFrom))
+	}
+	if monthTo > 0 {
+		conditions = append(conditions, sb.Lte(sb.MonthOfRegistration, monthTo))
+	}
+
+	sources := make([]string, len(years))
+	for i, year := range years {
+		sources[i] = fmt.Sprintf(`SELECT
+				%[1]s,
+				%[2]s,
+				%[3]s AS brand_key,
+				%[4]s::int AS year,
+				COALESCE(SUM(%[5]s), 0) AS total_sales
+			FROM %[6]s
+			WHERE
+				%[7]s
+			GROUP BY %[1]s, %[2]s, %[3]s`,
+			sb.FederalDistrict, sb.Region, sb.CanonicalBrand, p.Bind(year), m.quantity(), m.Tables[year].From(), sb.Where(&p, conditions...))
+	}
+
+	sql := fmt.Sprintf(`
+		WITH sales AS (
+			%s
+		)
+		SELECT %s, %s, brand_key, year, total_sales
+		FROM sales
+		WHERE brand_key IS NOT NULL
+		ORDER BY year, %s, %s, brand_key
+	`, strings.Join(sources, "\n\t\t\tUNION ALL\n\t\t\t"), sb.FederalDistrict, sb.Region, sb.FederalDistrict, sb.Region)
+
+	return sb.Query{SQL: sql, Args: p.Args()}
+}
+
+// Sales выполняет SalesQuery. Округа и регионы переведены на английский
+func (m Market) Sales(ctx context.Context, years []int, monthFrom, monthTo int) ([]Sale, error) {
+	conn, err := db.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	q := m.SalesQuery(years, monthFrom, monthTo)
+	rows, err := conn.Query(ctx, q.SQL, q.Args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []Sale
+	for rows.Next() {
+		var s Sale
+		if err := rows.Scan(&s.District, &s.Region, &s.Brand, &s.Year, &s.Quantity); err != nil {
+			return nil, err
+		}
+		s.District = translate(districtTranslations, s.District)
+		s.Region = translate(regionTranslations, s.Region)
+		result = append(result, s)
+	}
+
+	return result, rows.Err()
+}
+
+// MonthlySale — продажи бренда в регионе за месяц
+type MonthlySale struct {
+	Year     int
+	Month    int
+	District string
+	Region   string
+	Brand    string
+	Quantity int
+}
+
+// MonthlyQuery — продажи брендов по регионам и месяцам за несколько лет,
+// все загруженные месяцы. Колонки результата: округ, регион, бренд, год, месяц, продажи
+func (m Market) MonthlyQuery(years []int) sb.Query {
+	var p sb.Params
+
+	sources := make([]string, len(years))
+	for i, year := range years {
+		sources[i] = fmt.Sprintf(`SELECT
+				%[1]s,
+				%[2]s,
+				%[3]s AS brand_key,
+				%[4]s::int AS year,
+				%[5]s AS month,
+				COALESCE(SUM(%[6]s), 0) AS total_sales
+			FROM %[7]s
+			WHERE
+				%[8]s
+			GROUP BY %[1]s, %[2]s, %[3]s, %[5]s`,
+			sb.FederalDistrict, sb.Region, sb.CanonicalBrand, p.Bind(year), sb.MonthOfRegistration, m.quantity(), m.Tables[year].From(), sb.Where(&p, m.Conditions...))
+	}
+
+	sql := fmt.Sprintf(`
+		WITH sales AS (
+			%s
+		)
+		SELECT %s, %s, brand_key, year, month, total_sales
+		FROM sales
+		WHERE brand_key IS NOT NULL AND month BETWEEN 1 AND 12
+		ORDER BY year, month, %s, %s, brand_key
+	`, strings.Join(sources, "\n\t\t\tUNION ALL\n\t\t\t"), sb.FederalDistrict, sb.Region, sb.FederalDistrict, sb.Region)
+
+	return sb.Query{SQL: sql, Args: p.Args()}
+}
+
+// MonthlySales выполняет MonthlyQuery. Округа и регионы переведены на английский
+func (m Market) MonthlySales(ctx context.Context, years []int) ([]MonthlySale, error) {
+	conn, err := db.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	q := m.MonthlyQuery(years)
+	rows, err := conn.Query(ctx, q.SQL, q.Args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []MonthlySale
+	for rows.Next() {
+		var s MonthlySale
+		if err := rows.Scan(&s.District, &s.Region, &s.Brand, &s.Year, &s.Month, &s.Quantity); err != nil {
+			return nil, err
+		}
+		s.District = translate(districtTranslations, s.District)
+		s.Region = translate(regionTranslations, s.Region)
+		result = append(result, s)
+	}
+
+	return result, rows.Err()
+}
+
+// DistrictOrder возвращает английские названия округов в порядке отчётов
+func DistrictOrder() []string {
+	return append([]string(nil), districtOrder...)
+}
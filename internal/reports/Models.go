@@ -0,0 +1,186 @@
+package reports
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"truck-analytics-platform/internal/db"
+	sb "truck-analytics-platform/internal/sqlbuilder"
+)
+
+// MaxTopModels — сколько моделей можно запросить на одну территорию
+const MaxTopModels = 50
+
+// Уровни территории рейтингов
+const (
+	LevelDistrict = "district"
+	LevelRegion   = "region"
+)
+
+// TopModelsSpec — запрос рейтинга моделей: сегмент и год, уровень территории,
+// число моделей на территорию и фильтр по географии и месяцам
+type TopModelsSpec struct {
+	// Segment — ключ сегмента HDT, пусто — все регистрации
+	Segment   string
+	Year      int
+	Level     string
+	Limit     int
+	Districts []string
+	Regions   []string
+	MonthFrom int
+	MonthTo   int
+}
+
+// Validate проверяет спецификацию по белым спискам годов, сегментов и уровней
+func (s TopModelsSpec) Validate() error {
+	if _, ok := Tables[s.Year]; !ok {
+		return fmt.Errorf("no data for year %d", s.Year)
+	}
+	if _, ok := Segments[s.Segment]; s.Segment != "" && !ok {
+		return fmt.Errorf("unknown segment %q", s.Segment)
+	}
+	if s.Level != LevelDistrict && s.Level != LevelRegion {
+		return fmt.Errorf("level must be %s or %s", LevelDistrict, LevelRegion)
+	}
+	if s.Limit < 1 || s.Limit > MaxTopModels {
+		return fmt.Errorf("limit must be between 1 and %d", MaxTopModels)
+	}
+	if err := CheckMonths(s.MonthFrom, s.MonthTo); err != nil {
+		return err
+	}
+	if s.MonthFrom > 0 && s.MonthTo > 0 && s.MonthFrom > s.MonthTo {
+		return errors.New("month_from must not be after month_to")
+	}
+	return nil
+}
+
+func (s TopModelsSpec) filter() Filter {
+	return Filter{
+		Table:     Tables[s.Year],
+		Segment:   Segments[s.Segment],
+		Districts: s.Districts,
+		Regions:   s.Regions,
+		MonthFrom: s.MonthFrom,
+		MonthTo:   s.MonthTo,
+	}
+}
+
+// Query строит запрос рейтинга. Итог территории считается по всем
+// регистрациям сегмента, включая строки без модели, поэтому доля модели —
+// это доля в сегменте территории. Строки без модели в рейтинг не попадают
+func (s TopModelsSpec) Query() sb.Query {
+	var p sb.Params
+	where := sb.Where(&p, s.filter().Conditions()...)
+
+	area := []string{sb.FederalDistrict.String()}
+	if s.Level == LevelRegion {
+		area = append(area, sb.Region.String())
+	}
+	areaColumns := strings.Join(area, ", ")
+
+	sql := fmt.Sprintf(`
+		WITH sales AS (
+			SELECT
+				%[1]s,
+				%[2]s AS brand_key,
+				%[3]s AS model_key,
+				COALESCE(SUM(%[4]s), 0) AS total_sales
+			FROM %[5]s
+			WHERE
+				%[6]s
+			GROUP BY %[1]s, %[2]s, %[3]s
+		),
+		ranked AS (
+			SELECT
+				*,
+				SUM(total_sales) OVER (PARTITION BY %[1]s) AS area_sales,
+				ROW_NUMBER() OVER (
+					PARTITION BY %[1]s
+					ORDER BY model_key IS NULL, total_sales DESC, brand_key, model_key
+				) AS model_rank
+			FROM sales
+		)
+		SELECT %[1]s, brand_key, model_key, total_sales, area_sales, model_rank
+		FROM ranked
+		WHERE model_key IS NOT NULL AND model_rank <= %[7]s
+		ORDER BY %[1]s, model_rank
+	`, areaColumns, sb.CanonicalBrand, sb.Model, sb.Quantity, s.filter().Table.From(), where, p.Bind(s.Limit))
+
+	return sb.Query{SQL: sql, Args: p.Args()}
+}
+
+// ModelShare — модель в рейтинге территории
+type ModelShare struct {
+	Rank     int    `json:"rank"`
+	Brand    string `json:"brand"`
+	Model    string `json:"model"`
+	Quantity int    `json:"quantity"`
+	// Share — доля в продажах сегмента на территории, %
+	Share float64 `json:"share"`
+}
+
+// ModelArea — территория рейтинга: округ или регион, продажи сегмента
+// на ней и самые продаваемые модели
+type ModelArea struct {
+	District string       `json:"district"`
+	Region   string       `json:"region,omitempty"`
+	Total    int          `json:"total"`
+	Models   []ModelShare `json:"models"`
+}
+
+// TopModels выполняет запрос рейтинга. Если в выгрузке года нет колонки
+// модели, возвращает ErrUnavailable
+func TopModels(ctx context.Context, s TopModelsSpec) ([]ModelArea, error) {
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	if err := RequireColumns(ctx, Tables[s.Year], sb.Model); err != nil {
+		if errors.Is(err, ErrUnavailable) {
+			return nil, fmt.Errorf("model data is %w for year %d", ErrUnavailable, s.Year)
+		}
+		return nil, err
+	}
+
+	conn, err := db.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	q := s.Query()
+	rows, err := conn.Query(ctx, q.SQL, q.Args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []ModelArea{}
+	for rows.Next() {
+		var (
+			area  ModelArea
+			model ModelShare
+		)
+		dest := []any{&area.District}
+		if s.Level == LevelRegion {
+			dest = append(dest, &area.Region)
+		}
+		dest = append(dest, &model.Brand, &model.Model, &model.Quantity, &area.Total, &model.Rank)
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+
+		area.District = translate(districtTranslations, area.District)
+		area.Region = translate(regionTranslations, area.Region)
+		model.Share = percent(model.Quantity, area.Total)
+
+		last := len(result) - 1
+		if last < 0 || result[last].District != area.District || result[last].Region != area.Region {
+			area.Models = []ModelShare{}
+			result = append(result, area)
+			last++
+		}
+		result[last].Models = append(result[last].Models, model)
+	}
+
+	return result, rows.Err()
+}
@@ -0,0 +1,376 @@
+package reports
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"truck-analytics-platform/internal/db"
+	sb "truck-analytics-platform/internal/sqlbuilder"
+)
+
+// Ограничения сводной таблицы, чтобы один запрос не разворачивал всю базу
+const (
+	MaxPivotRows    = 3
+	MaxPivotColumns = 2
+)
+
+// dimension — измерение сводной таблицы: колонка таблиц регистраций
+// или, для года, колонка year, которую добавляет сам запрос
+type dimension struct {
+	column       sb.Column
+	translations map[string]string
+	// optional — колонка есть не во всех выгрузках
+	optional bool
+}
+
+// yearColumn — номер года, добавляемый к строкам каждой таблицы в UNION ALL
+const yearColumn = "year"
+
+// rowDimensions и columnDimensions — белые списки измерений. В SQL попадают
+// только выражения отсюда, имена из запроса клиента в текст запроса не подставляются
+var (
+	rowDimensions = map[string]dimension{
+		"district":      {column: sb.FederalDistrict, translations: districtTranslations},
+		"region":        {column: sb.Region, translations: regionTranslations},
+		"city":          {column: sb.City},
+		"body_type":     {column: sb.BodyType},
+		"wheel_formula": {column: sb.WheelFormula},
+		"mass_segment":  {column: sb.MassInSegment1},
+		// gvw — полная масса автомобиля, кг
+		"gvw":    {column: sb.ExactMass},
+		"model":  {column: sb.Model, optional: true},
+		"engine": {column: sb.Engine, optional: true},
+	}
+	columnDimensions = map[string]dimension{
+		"brand": {column: sb.CanonicalBrand},
+		// manufacturer — группа производителя из справочника брендов
+		"manufacturer": {column: sb.ParentBrand},
+		"month":        {column: sb.MonthOfRegistration},
+		"year":         {},
+	}
+)
+
+// PivotSpec — запрос сводной таблицы: измерения строк и колонок,
+// годы и фильтр регистраций
+type PivotSpec struct {
+	Rows    []string `json:"rows"`
+	Columns []string `json:"columns"`
+	Years   []int    `json:"years"`
+	// Segment — ключ сегмента HDT, пусто — все регистрации
+	Segment   string   `json:"segment"`
+	Brands    []string `json:"brands"`
+	Districts []string `json:"districts"`
+	Regions   []string `json:"regions"`
+	MonthFrom int      `json:"month_from"`
+	MonthTo   int      `json:"month_to"`
+	// Subtotals добавляет строки итогов по каждому уровню измерений строк
+	Subtotals bool `json:"subtotals"`
+}
+
+// Validate проверяет спецификацию по белым спискам измерений, годов и сегментов
+func (p PivotSpec) Validate() error {
+	if len(p.Rows) > MaxPivotRows {
+		return fmt.Errorf("at most %d row dimensions are allowed", MaxPivotRows)
+	}
+	if len(p.Columns) > MaxPivotColumns {
+		return fmt.Errorf("at most %d column dimensions are allowed", MaxPivotColumns)
+	}
+
+	seen := map[string]bool{}
+	for _, name := range p.Rows {
+		if _, ok := rowDimensions[name]; !ok {
+			return fmt.Errorf("unknown row dimension %q", name)
+		}
+		if seen[name] {
+			return fmt.Errorf("dimension %q is used twice", name)
+		}
+		seen[name] = true
+	}
+	for _, name := range p.Columns {
+		if _, ok := columnDimensions[name]; !ok {
+			return fmt.Errorf("unknown column dimension %q", name)
+		}
+		if seen[name] {
+			return fmt.Errorf("dimension %q is used twice", name)
+		}
+		seen[name] = true
+	}
+
+	if len(p.Years) == 0 {
+		return errors.New("at least one year is required")
+	}
+	for _, year := range p.Years {
+		if _, ok := Tables[year]; !ok {
+			return fmt.Errorf("no data for year %d", year)
+		}
+	}
+
+	if _, ok := Segments[p.Segment]; p.Segment != "" && !ok {
+		return fmt.Errorf("unknown segment %q", p.Segment)
+	}
+
+	if err := CheckMonths(p.MonthFrom, p.MonthTo); err != nil {
+		return err
+	}
+	if p.MonthFrom > 0 && p.MonthTo > 0 && p.MonthFrom > p.MonthTo {
+		return errors.New("month_from must not be after month_to")
+	}
+
+	return nil
+}
+
+func dimensionByName(name string) dimension {
+	if d, ok := rowDimensions[name]; ok {
+		return d
+	}
+	return columnDimensions[name]
+}
+
+// requireColumns проверяет, что колонки необязательных измерений есть
+// в таблицах всех запрошенных лет
+func (p PivotSpec) requireColumns(ctx context.Context) error {
+	for _, name := range p.Rows {
+		d := rowDimensions[name]
+		if !d.optional {
+			continue
+		}
+		for _, year := range p.Years {
+			if err := RequireColumns(ctx, Tables[year], d.column); err != nil {
+				if errors.Is(err, ErrUnavailable) {
+					return fmt.Errorf("dimension %q is %w for year %d", name, ErrUnavailable, year)
+				}
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// filter — фильтр регистраций для таблицы года
+func (p PivotSpec) filter(year int) Filter {
+	return Filter{
+		Table:     Tables[year],
+		Segment:   Segments[p.Segment],
+		Brands:    p.Brands,
+		Districts: p.Districts,
+		Regions:   p.Regions,
+		MonthFrom: p.MonthFrom,
+		MonthTo:   p.MonthTo,
+	}
+}
+
+// Query строит запрос сводной таблицы. Годы объединяются через UNION ALL,
+// итоги по уровням строк считает GROUP BY ROLLUP — как CTE federal_totals
+// в отчётах, но для любого набора измерений. Все измерения приводятся к text,
+// а GROUPING отличает строку итога от пустого значения. Имена измерений
+// попадают в запрос как псевдонимы колонок, поэтому спецификация должна
+// пройти Validate
+func (p PivotSpec) Query() sb.Query {
+	var params sb.Params
+
+	// Из таблиц берутся только нужные колонки: набор колонок у таблиц разных лет может отличаться.
+	// Измерения получают в source имена из белого списка, по ним и группирует внешний запрос
+	dimensions := append(append([]string(nil), p.Rows...), p.Columns...)
+	needed := []string{sb.Quantity.String() + " AS quantity"}
+	for _, name := range dimensions {
+		if d := dimensionByName(name); d.column != "" {
+			needed = append(needed, d.column.String()+" AS "+name)
+		}
+	}
+
+	sources := make([]string, len(p.Years))
+	for i, year := range p.Years {
+		sources[i] = fmt.Sprintf(`SELECT %s, %s::int AS %s FROM %s WHERE %s`,
+			strings.Join(needed, ", "), params.Bind(year), yearColumn, Tables[year].From(), sb.Where(&params, p.filter(year).Conditions()...))
+	}
+
+	rows := p.Rows
+	columns := p.Columns
+
+	var selects []string
+	for _, name := range dimensions {
+		selects = append(selects, fmt.Sprintf("COALESCE(%s::text, '')", name))
+	}
+
+	level := "0"
+	var groupBy []string
+	if len(rows) > 0 {
+		level = fmt.Sprintf("GROUPING(%s)", strings.Join(rows, ", "))
+		if p.Subtotals {
+			groupBy = append(groupBy, fmt.Sprintf("ROLLUP(%s)", strings.Join(rows, ", ")))
+		} else {
+			groupBy = append(groupBy, rows...)
+		}
+	}
+	groupBy = append(groupBy, columns...)
+	selects = append(selects, level+" AS subtotal_mask", "COALESCE(SUM(quantity), 0) AS total_sales")
+
+	var orderBy []string
+	for _, name := range dimensions {
+		orderBy = append(orderBy, name+" NULLS LAST")
+	}
+
+	sql := fmt.Sprintf(`
+		WITH source AS (
+			%s
+		)
+		SELECT
+			%s
+		FROM source`,
+		strings.Join(sources, "\n\t\t\tUNION ALL\n\t\t\t"),
+		strings.Join(selects, ",\n\t\t\t"),
+	)
+	if len(groupBy) > 0 {
+		sql += "\n\t\tGROUP BY " + strings.Join(groupBy, ", ")
+	}
+	if len(orderBy) > 0 {
+		sql += "\n\t\tORDER BY " + strings.Join(orderBy, ", ")
+	}
+
+	return sb.Query{SQL: sql, Args: params.Args()}
+}
+
+// PivotRow — строка сводной таблицы. Keys — значения измерений строк,
+// nil на месте измерений, по которым строка является итогом
+type PivotRow struct {
+	Keys     []*string `json:"keys"`
+	Subtotal bool      `json:"subtotal"`
+	Values   []int     `json:"values"`
+	Total    int       `json:"total"`
+}
+
+// Pivot — результат в виде сетки: строки × колонки
+type Pivot struct {
+	Rows       []string   `json:"rows"`
+	Columns    []string   `json:"columns"`
+	ColumnKeys [][]string `json:"column_keys"`
+	Grid       []PivotRow `json:"grid"`
+}
+
+// RunPivot выполняет запрос и раскладывает ответ в сетку
+func RunPivot(ctx context.Context, p PivotSpec) (*Pivot, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	if err := p.requireColumns(ctx); err != nil {
+		return nil, err
+	}
+
+	conn, err := db.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	q := p.Query()
+	rows, err := conn.Query(ctx, q.SQL, q.Args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	type cell struct {
+		row, column string
+		quantity    int
+	}
+
+	var (
+		cells      []cell
+		rowOrder   []string
+		rowByKey   = map[string]PivotRow{}
+		columnSeen = map[string][]string{}
+	)
+
+	for rows.Next() {
+		values := make([]string, len(p.Rows)+len(p.Columns))
+		var mask, quantity int
+
+		dest := make([]any, 0, len(values)+2)
+		for i := range values {
+			dest = append(dest, &values[i])
+		}
+		dest = append(dest, &mask, &quantity)
+
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+
+		keys := make([]*string, len(p.Rows))
+		for i, name := range p.Rows {
+			// Старший бит GROUPING соответствует первому измерению
+			if mask&(1<<(len(p.Rows)-1-i)) != 0 {
+				continue
+			}
+			value := translate(rowDimensions[name].translations, values[i])
+			keys[i] = &value
+		}
+
+		rowKey := fmt.Sprint(mask, values[:len(p.Rows)])
+		columnKey := strings.Join(values[len(p.Rows):], "\x00")
+
+		if _, ok := rowByKey[rowKey]; !ok {
+			rowOrder = append(rowOrder, rowKey)
+			rowByKey[rowKey] = PivotRow{Keys: keys, Subtotal: mask != 0}
+		}
+		columnSeen[columnKey] = values[len(p.Rows):]
+		cells = append(cells, cell{row: rowKey, column: columnKey, quantity: quantity})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	columnOrder := make([]string, 0, len(columnSeen))
+	for key := range columnSeen {
+		columnOrder = append(columnOrder, key)
+	}
+	sort.Slice(columnOrder, func(i, j int) bool {
+		return lessKeys(columnSeen[columnOrder[i]], columnSeen[columnOrder[j]])
+	})
+	columnIndex := make(map[string]int, len(columnOrder))
+	for i, key := range columnOrder {
+		columnIndex[key] = i
+	}
+
+	result := &Pivot{
+		Rows:       append([]string{}, p.Rows...),
+		Columns:    append([]string{}, p.Columns...),
+		ColumnKeys: make([][]string, len(columnOrder)),
+		Grid:       make([]PivotRow, 0, len(rowOrder)),
+	}
+	for i, key := range columnOrder {
+		result.ColumnKeys[i] = columnSeen[key]
+	}
+
+	for _, c := range cells {
+		row := rowByKey[c.row]
+		if row.Values == nil {
+			row.Values = make([]int, len(columnOrder))
+		}
+		row.Values[columnIndex[c.column]] += c.quantity
+		row.Total += c.quantity
+		rowByKey[c.row] = row
+	}
+	for _, key := range rowOrder {
+		result.Grid = append(result.Grid, rowByKey[key])
+	}
+
+	return result, nil
+}
+
+// lessKeys сравнивает ключи колонок, числа (месяцы, годы) — как числа
+func lessKeys(a, b []string) bool {
+	for i := range a {
+		if a[i] == b[i] {
+			continue
+		}
+		x, errX := strconv.Atoi(a[i])
+		y, errY := strconv.Atoi(b[i])
+		if errX == nil && errY == nil {
+			return x < y
+		}
+		return a[i] < b[i]
+	}
+	return false
+}
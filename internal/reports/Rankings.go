@@ -0,0 +1,418 @@
+package reports
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+)
+
+// LevelCountry — рейтинг по стране целиком
+const LevelCountry = "country"
+
+// CountryArea — название территории «вся страна» в ответах
+const CountryArea = "Russia"
+
+// MaxRankingItems — сколько позиций можно запросить в одном рейтинге
+const MaxRankingItems = 100
+
+// Метрики сортировки рейтингов
+const (
+	SortVolume      = "volume"
+	SortGrowth      = "growth"
+	SortShare       = "share"
+	SortShareChange = "share_change"
+)
+
+// RankingSorts — все метрики сортировки
+var RankingSorts = []string{SortVolume, SortGrowth, SortShare, SortShareChange}
+
+// MarketSpec — рынок и год, уровень территории и месяцы. Прошлый год,
+// если его выгрузка есть, берётся за те же месяцы
+type MarketSpec struct {
+	Segment   string
+	Year      int
+	Level     string
+	MonthFrom int
+	MonthTo   int
+}
+
+// Validate проверяет рынок, год и месяцы; levels — допустимые уровни территории
+func (s MarketSpec) Validate(levels ...string) error {
+	market, ok := Markets[s.Segment]
+	if !ok {
+		return fmt.Errorf("unknown segment %q", s.Segment)
+	}
+	if _, ok := market.Tables[s.Year]; !ok {
+		return fmt.Errorf("no data for year %d", s.Year)
+	}
+	if !slices.Contains(levels, s.Level) {
+		return fmt.Errorf("level must be one of %v", levels)
+	}
+	if err := CheckMonths(s.MonthFrom, s.MonthTo); err != nil {
+		return err
+	}
+	if s.MonthFrom > 0 && s.MonthTo > 0 && s.MonthFrom > s.MonthTo {
+		return errors.New("month_from must not be after month_to")
+	}
+	return nil
+}
+
+// RankingSpec — запрос рейтинга: рынок и период, метрика сортировки и число позиций
+type RankingSpec struct {
+	MarketSpec
+	Sort      string
+	Ascending bool
+	Limit     int
+}
+
+// Validate проверяет спецификацию; levels — уровни территории, допустимые для рейтинга
+func (s RankingSpec) Validate(levels ...string) error {
+	if err := s.MarketSpec.Validate(levels...); err != nil {
+		return err
+	}
+	if !slices.Contains(RankingSorts, s.Sort) {
+		return fmt.Errorf("sort must be one of %v", RankingSorts)
+	}
+	if _, ok := Markets[s.Segment].Tables[s.Year-1]; !ok && (s.Sort == SortGrowth || s.Sort == SortShareChange) {
+		return fmt.Errorf("sorting by %s needs data for year %d", s.Sort, s.Year-1)
+	}
+	if s.Limit < 1 || s.Limit > MaxRankingItems {
+		return fmt.Errorf("limit must be between 1 and %d", MaxRankingItems)
+	}
+	return nil
+}
+
+// Period — период рейтинга и год, с которым он сравнивается
+type Period struct {
+	Year      int `json:"year"`
+	MonthFrom int `json:"month_from"`
+	MonthTo   int `json:"month_to"`
+	// ComparedWith — прошлый год; nil, если его выгрузки нет
+	ComparedWith *int `json:"compared_with"`
+}
+
+// period дополняет месяцы спецификации: без month_to годы сравниваются
+// по последний месяц, который есть в выгрузках обоих лет
+func (s MarketSpec) period() Period {
+	market := Markets[s.Segment]
+	p := Period{Year: s.Year, MonthFrom: max(s.MonthFrom, 1), MonthTo: s.MonthTo}
+
+	years := []int{s.Year}
+	if _, ok := market.Tables[s.Year-1]; ok {
+		prev := s.Year - 1
+		p.ComparedWith = &prev
+		years = append(years, prev)
+	}
+	if p.MonthTo == 0 {
+		p.MonthTo = market.CommonMonths(years...)
+	}
+	return p
+}
+
+// Ranked — позиция рейтинга: регион, округ или бренд. Доли — в процентах,
+// изменение доли — в процентных пунктах. Поля прошлого года — nil, если
+// его выгрузки нет
+type Ranked struct {
+	Rank int    `json:"rank"`
+	Name string `json:"name"`
+	// District — округ региона в рейтинге регионов
+	District    string   `json:"district,omitempty"`
+	Volume      int      `json:"volume"`
+	PrevVolume  *int     `json:"prev_volume"`
+	Growth      *float64 `json:"growth"`
+	Share       float64  `json:"share"`
+	PrevShare   *float64 `json:"prev_share"`
+	ShareChange *float64 `json:"share_change"`
+}
+
+// AreaRanking — рейтинг брендов на территории
+type AreaRanking struct {
+	Area  string   `json:"area"`
+	Items []Ranked `json:"items"`
+}
+
+// AreaMovers — бренды с наибольшим ростом и падением доли на территории
+type AreaMovers struct {
+	Area   string   `json:"area"`
+	Gains  []Ranked `json:"gains"`
+	Losses []Ranked `json:"losses"`
+}
+
+// RankRegions ранжирует территории уровня спецификации по продажам бренда
+func RankRegions(ctx context.Context, s RankingSpec, brand string) ([]Ranked, Period, error) {
+	if err := s.Validate(LevelDistrict, LevelRegion); err != nil {
+		return nil, s.period(), err
+	}
+	st, period, err := load(ctx, s.MarketSpec)
+	if err != nil {
+		return nil, period, err
+	}
+
+	var items []Ranked
+	for _, a := range st.areas {
+		v := st.brands[a][brand]
+		if v.cur == 0 && v.prev == 0 {
+			continue
+		}
+		item := st.item(a, v)
+		item.Name = a.name()
+		if s.Level == LevelRegion {
+			item.District = a.district
+		}
+		items = append(items, item)
+	}
+
+	return rankItems(items, s.Sort, s.Ascending, s.Limit), period, nil
+}
+
+// RankBrands ранжирует бренды на каждой территории уровня спецификации
+func RankBrands(ctx context.Context, s RankingSpec) ([]AreaRanking, Period, error) {
+	if err := s.Validate(LevelCountry, LevelDistrict, LevelRegion); err != nil {
+		return nil, s.period(), err
+	}
+	st, period, err := load(ctx, s.MarketSpec)
+	if err != nil {
+		return nil, period, err
+	}
+
+	result := make([]AreaRanking, 0, len(st.areas))
+	for _, a := range st.areas {
+		result = append(result, AreaRanking{Area: a.name(), Items: rankItems(st.brandItems(a), s.Sort, s.Ascending, s.Limit)})
+	}
+	return result, period, nil
+}
+
+// ShareChanges находит на каждой территории бренды с наибольшим ростом
+// и наибольшим падением доли к прошлому году
+func ShareChanges(ctx context.Context, s RankingSpec) ([]AreaMovers, Period, error) {
+	s.Sort = SortShareChange
+	if err := s.Validate(LevelCountry, LevelDistrict, LevelRegion); err != nil {
+		return nil, s.period(), err
+	}
+	st, period, err := load(ctx, s.MarketSpec)
+	if err != nil {
+		return nil, period, err
+	}
+
+	result := make([]AreaMovers, 0, len(st.areas))
+	for _, a := range st.areas {
+		var gains, losses []Ranked
+		for _, item := range st.brandItems(a) {
+			switch {
+			case *item.ShareChange > 0:
+				gains = append(gains, item)
+			case *item.ShareChange < 0:
+				losses = append(losses, item)
+			}
+		}
+		result = append(result, AreaMovers{
+			Area:   a.name(),
+			Gains:  rankItems(gains, SortShareChange, false, s.Limit),
+			Losses: rankItems(losses, SortShareChange, true, s.Limit),
+		})
+	}
+	return result, period, nil
+}
+
+// area — территория рейтинга; у уровня страны оба поля пустые
+type area struct {
+	district, region string
+}
+
+func (a area) name() string {
+	switch {
+	case a.region != "":
+		return a.region
+	case a.district != "":
+		return a.district
+	}
+	return CountryArea
+}
+
+// volumes — продажи за год рейтинга и за прошлый год
+type volumes struct {
+	cur, prev int
+}
+
+// stats — продажи брендов и рынка по территориям одного уровня
+type stats struct {
+	hasPrev bool
+	areas   []area
+	totals  map[area]volumes
+	brands  map[area]map[string]volumes
+}
+
+// load читает продажи периода и прошлого года и сводит их к территориям уровня спецификации
+func load(ctx context.Context, s MarketSpec) (stats, Period, error) {
+	sales, period, err := loadSales(ctx, s)
+	if err != nil {
+		return stats{}, period, err
+	}
+	return collect(sales, s.Year, s.Level, period.ComparedWith != nil), period, nil
+}
+
+func loadSales(ctx context.Context, s MarketSpec) ([]Sale, Period, error) {
+	period := s.period()
+
+	years := []int{s.Year}
+	if period.ComparedWith != nil {
+		years = append(years, *period.ComparedWith)
+	}
+
+	sales, err := Markets[s.Segment].Sales(ctx, years, period.MonthFrom, period.MonthTo)
+	return sales, period, err
+}
+
+// collect сводит продажи по регионам к территориям уровня level.
+// Территории идут в порядке округов отчётов, регионы — по алфавиту
+func collect(sales []Sale, year int, level string, hasPrev bool) stats {
+	st := stats{hasPrev: hasPrev, totals: map[area]volumes{}, brands: map[area]map[string]volumes{}}
+
+	for _, sale := range sales {
+		var a area
+		switch level {
+		case LevelDistrict:
+			a = area{district: sale.District}
+		case LevelRegion:
+			a = area{district: sale.District, region: sale.Region}
+		}
+
+		if _, ok := st.brands[a]; !ok {
+			st.areas = append(st.areas, a)
+			st.brands[a] = map[string]volumes{}
+		}
+
+		total, brand := st.totals[a], st.brands[a][sale.Brand]
+		if sale.Year == year {
+			total.cur += sale.Quantity
+			brand.cur += sale.Quantity
+		} else {
+			total.prev += sale.Quantity
+			brand.prev += sale.Quantity
+		}
+		st.totals[a], st.brands[a][sale.Brand] = total, brand
+	}
+
+	order := make(map[string]int, len(districtOrder))
+	for i, district := range districtOrder {
+		order[district] = i
+	}
+	sort.SliceStable(st.areas, func(i, j int) bool {
+		a, b := st.areas[i], st.areas[j]
+		if a.district != b.district {
+			x, okX := order[a.district]
+			y, okY := order[b.district]
+			if okX != okY {
+				return okX
+			}
+			if x != y {
+				return x < y
+			}
+			return a.district < b.district
+		}
+		return a.region < b.region
+	})
+
+	return st
+}
+
+// brandItems — позиции всех брендов, продававшихся на территории в любом из годов
+func (st stats) brandItems(a area) []Ranked {
+	items := make([]Ranked, 0, len(st.brands[a]))
+	for brand, v := range st.brands[a] {
+		if v.cur == 0 && v.prev == 0 {
+			continue
+		}
+		item := st.item(a, v)
+		item.Name = brand
+		items = append(items, item)
+	}
+	return items
+}
+
+// item считает метрики продаж v на территории a
+func (st stats) item(a area, v volumes) Ranked {
+	total := st.totals[a]
+	item := Ranked{Volume: v.cur, Share: percent(v.cur, total.cur)}
+	if !st.hasPrev {
+		return item
+	}
+
+	prev := v.prev
+	prevShare := percent(v.prev, total.prev)
+	change := round1(exactPercent(v.cur, total.cur) - exactPercent(v.prev, total.prev))
+	item.PrevVolume, item.PrevShare, item.ShareChange = &prev, &prevShare, &change
+	if v.prev > 0 {
+		growth := round1(float64(v.cur-v.prev) * 100 / float64(v.prev))
+		item.Growth = &growth
+	}
+	return item
+}
+
+// rankItems сортирует позиции по метрике, обрезает до limit и нумерует.
+// Позиции без значения метрики (рост при нулевых продажах прошлого года)
+// идут в конце, равные значения — по объёму продаж и названию
+func rankItems(items []Ranked, metric string, ascending bool, limit int) []Ranked {
+	sort.SliceStable(items, func(i, j int) bool {
+		x, okX := metricValue(items[i], metric)
+		y, okY := metricValue(items[j], metric)
+		switch {
+		case okX != okY:
+			return okX
+		case x != y:
+			if ascending {
+				return x < y
+			}
+			return x > y
+		case items[i].Volume != items[j].Volume:
+			return items[i].Volume > items[j].Volume
+		}
+		return items[i].Name < items[j].Name
+	})
+
+	if len(items) > limit {
+		items = items[:limit]
+	}
+	result := make([]Ranked, len(items))
+	for i, item := range items {
+		item.Rank = i + 1
+		result[i] = item
+	}
+	return result
+}
+
+func metricValue(item Ranked, metric string) (float64, bool) {
+	switch metric {
+	case SortGrowth:
+		if item.Growth == nil {
+			return 0, false
+		}
+		return *item.Growth, true
+	case SortShare:
+		return item.Share, true
+	case SortShareChange:
+		if item.ShareChange == nil {
+			return 0, false
+		}
+		return *item.ShareChange, true
+	}
+	return float64(item.Volume), true
+}
+
+func exactPercent(part, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(part) * 100 / float64(total)
+}
+
+func percent(part, total int) float64 {
+	return round1(exactPercent(part, total))
+}
+
+func round1(x float64) float64 {
+	return math.Round(x*10) / 10
+}
@@ -0,0 +1,185 @@
+package reports
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCollectOrdersAreasAndSumsYears(t *testing.T) {
+	sales := []Sale{
+		{Year: 2024, District: "Ural", Region: "Sverdlovsk Region", Brand: "FOTON", Quantity: 5},
+		{Year: 2024, District: "Central", Region: "Tula Region", Brand: "FOTON", Quantity: 3},
+		{Year: 2024, District: "Central", Region: "Moscow", Brand: "FOTON", Quantity: 7},
+		{Year: 2023, District: "Central", Region: "Moscow", Brand: "SITRAK", Quantity: 10},
+	}
+
+	st := collect(sales, 2024, LevelDistrict, true)
+
+	var names []string
+	for _, a := range st.areas {
+		names = append(names, a.name())
+	}
+	if want := []string{"Central", "Ural"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("areas = %v, want %v", names, want)
+	}
+
+	central := area{district: "Central"}
+	if got := st.totals[central]; got != (volumes{cur: 10, prev: 10}) {
+		t.Errorf("Central totals = %+v", got)
+	}
+
+	country := collect(sales, 2024, LevelCountry, true)
+	if len(country.areas) != 1 || country.areas[0].name() != CountryArea {
+		t.Errorf("country areas = %v", country.areas)
+	}
+}
+
+func TestItemMetrics(t *testing.T) {
+	a := area{}
+	st := stats{hasPrev: true, totals: map[area]volumes{a: {cur: 200, prev: 100}}}
+
+	item := st.item(a, volumes{cur: 50, prev: 40})
+	if item.Share != 25 || *item.PrevShare != 40 || *item.ShareChange != -15 || *item.Growth != 25 {
+		t.Errorf("item = share %v, prev %v, change %v, growth %v", item.Share, *item.PrevShare, *item.ShareChange, *item.Growth)
+	}
+
+	if item := st.item(a, volumes{cur: 10}); item.Growth != nil {
+		t.Errorf("growth from zero = %v, want nil", *item.Growth)
+	}
+
+	st.hasPrev = false
+	if item := st.item(a, volumes{cur: 10}); item.PrevVolume != nil || item.ShareChange != nil {
+		t.Error("previous year metrics without previous year data")
+	}
+}
+
+func TestRankItems(t *testing.T) {
+	growth := func(v float64) *float64 { return &v }
+	items := []Ranked{
+		{Name: "A", Volume: 10, Growth: growth(5)},
+		{Name: "B", Volume: 30, Growth: nil},
+		{Name: "C", Volume: 20, Growth: growth(50)},
+		{Name: "D", Volume: 40, Growth: growth(5)},
+	}
+
+	names := func(ranked []Ranked) string {
+		var b strings.Builder
+		for _, r := range ranked {
+			b.WriteString(r.Name)
+		}
+		return b.String()
+	}
+
+	cases := []struct {
+		metric    string
+		ascending bool
+		limit     int
+		want      string
+	}{
+		{SortVolume, false, 10, "DBCA"},
+		{SortVolume, true, 2, "AC"},
+		// Без значения — в конце, равный рост — по объёму
+		{SortGrowth, false, 10, "CDAB"},
+		{SortGrowth, true, 3, "DAC"},
+	}
+	for _, c := range cases {
+		ranked := rankItems(append([]Ranked(nil), items...), c.metric, c.ascending, c.limit)
+		if got := names(ranked); got != c.want {
+			t.Errorf("%s ascending=%v: %s, want %s", c.metric, c.ascending, got, c.want)
+		}
+		if ranked[0].Rank != 1 || ranked[len(ranked)-1].Rank != len(ranked) {
+			t.Errorf("%s: ranks are not numbered", c.metric)
+		}
+	}
+}
+
+func TestSalesQueryCastsMDTQuantity(t *testing.T) {
+	q := Markets["mdt"].SalesQuery([]int{2024, 2023}, 1, 10)
+
+	for _, part := range []string{
+		"FROM mdt_12_18_truck_analytics_10_2024",
+		"FROM mdt_12_18_truck_analytics_10_2023",
+		`SUM(CAST("Quantity" AS INTEGER))`,
+		"UNION ALL",
+	} {
+		if !strings.Contains(q.SQL, part) {
+			t.Errorf("query has no %q:\n%s", part, q.SQL)
+		}
+	}
+
+	wantArgs := []any{2024, 1, 10, 2023, 1, 10}
+	if !reflect.DeepEqual(q.Args, wantArgs) {
+		t.Errorf("Args = %v, want %v", q.Args, wantArgs)
+	}
+}
+
+func TestRankingSpecNeedsPreviousYearForGrowth(t *testing.T) {
+	spec := RankingSpec{MarketSpec: MarketSpec{Segment: "ldt", Year: 2023, Level: LevelDistrict}, Sort: SortGrowth, Limit: 5}
+	if err := spec.Validate(LevelDistrict); err == nil {
+		t.Error("growth in the first loaded year must be rejected")
+	}
+
+	spec.Sort = SortVolume
+	if err := spec.Validate(LevelDistrict); err != nil {
+		t.Errorf("Validate: %v", err)
+	}
+	if p := spec.period(); p.ComparedWith != nil || p.MonthTo != 10 {
+		t.Errorf("period = %+v", p)
+	}
+}
+
+func TestIndices(t *testing.T) {
+	cases := []struct {
+		volumes       []int
+		hhi, cr3, cr5 float64
+	}{
+		{[]int{100}, 10000, 100, 100},
+		{[]int{50, 50}, 5000, 100, 100},
+		{[]int{10, 10, 10, 10, 10, 10, 10, 10, 10, 10}, 1000, 30, 50},
+		{[]int{5, 60, 20, 15}, 4250, 95, 100},
+		{nil, 0, 0, 0},
+	}
+
+	for _, c := range cases {
+		hhi, cr3, cr5 := indices(c.volumes)
+		if hhi != c.hhi || cr3 != c.cr3 || cr5 != c.cr5 {
+			t.Errorf("indices(%v) = %v, %v, %v, want %v, %v, %v", c.volumes, hhi, cr3, cr5, c.hhi, c.cr3, c.cr5)
+		}
+	}
+}
+
+func TestWhitespace(t *testing.T) {
+	sales := []Sale{
+		{Year: 2024, District: "Central", Region: "Moscow", Brand: "FOTON", Quantity: 5},
+		{Year: 2024, District: "Ural", Region: "Sverdlovsk Region", Brand: "SITRAK", Quantity: 8},
+		{Year: 2024, District: "Ural", Region: "Sverdlovsk Region", Brand: "HOWO", Quantity: 3},
+		{Year: 2023, District: "Ural", Region: "Sverdlovsk Region", Brand: "FOTON", Quantity: 2},
+		{Year: 2023, District: "Central", Region: "Moscow", Brand: "HOWO", Quantity: 4},
+	}
+
+	report := collect(sales, 2024, LevelDistrict, true).whitespace("FOTON")
+	if len(report.Whitespace) != 1 {
+		t.Fatalf("whitespace = %+v", report.Whitespace)
+	}
+	gap := report.Whitespace[0]
+	if gap.Area != "Ural" || gap.District != "" || gap.MarketVolume != 11 || gap.Competitors != 2 ||
+		gap.Leaders[0].Brand != "SITRAK" || gap.PrevBrandVolume == nil || *gap.PrevBrandVolume != 2 {
+		t.Errorf("gap = %+v", gap)
+	}
+	if len(report.Entries) != 2 || report.Entries[0].Brand != "SITRAK" || report.Entries[1].Brand != "HOWO" {
+		t.Errorf("entries = %+v", report.Entries)
+	}
+	if len(report.Exits) != 1 || report.Exits[0].Area != "Central" || report.Exits[0].Brand != "HOWO" {
+		t.Errorf("exits = %+v", report.Exits)
+	}
+
+	// Без прошлого года входы и уходы не определяются
+	report = collect(sales[:3], 2024, LevelRegion, false).whitespace("FOTON")
+	if len(report.Entries) != 0 || len(report.Exits) != 0 || report.Whitespace[0].PrevBrandVolume != nil {
+		t.Errorf("report without the previous year = %+v", report)
+	}
+	if report.Whitespace[0].District != "Ural" {
+		t.Errorf("region gap district = %q", report.Whitespace[0].District)
+	}
+}
@@ -0,0 +1,178 @@
+// Package reports строит отчёты по сегментам HDT из описания сегмента,
+// а не из отдельной копии SQL и обработчика на каждый период
+package reports
+
+import (
+	"net/http"
+	"truck-analytics-platform/internal/db"
+	"truck-analytics-platform/internal/handlers/utils"
+	sb "truck-analytics-platform/internal/sqlbuilder"
+
+	"github.com/gin-gonic/gin"
+	orderedmap "github.com/wk8/go-ordered-map/v2"
+)
+
+// Brand — колонка отчёта: канонический идентификатор бренда из справочника и ключ в JSON
+type Brand struct {
+	Name string
+	Key  string
+}
+
+// Spec описывает отчёт: фильтр регистраций и форму строк.
+// Колонками выводятся бренды сегмента фильтра
+type Spec struct {
+	Filter
+	// TotalMarket добавляет в отчёт по регионам колонку total_market
+	TotalMarket bool
+}
+
+// Row — строка ответа. Порядок ключей сохраняется: region_name, бренды, total
+type Row = *orderedmap.OrderedMap[string, any]
+
+// Response — ответ отчёта, строки сгруппированы по федеральным округам
+type Response struct {
+	Data *orderedmap.OrderedMap[string, []Row] `json:"data"`
+}
+
+// pivot — запрос отчёта: бренды сегмента колонками, условия фильтра без
+// собственного списка брендов
+func (s Spec) pivot() sb.BrandPivot {
+	f := s.Filter
+	f.Brands = nil
+	return sb.BrandPivot{Table: s.Table, Where: f.Conditions(), Brands: s.Segment.BrandNames()}
+}
+
+// RegionHandler отдаёт отчёт по регионам: в каждом округе строки регионов
+// и последней строкой итог округа
+func RegionHandler(spec Spec) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		data := orderedmap.New[string, []Row]()
+		for _, district := range districtOrder {
+			data.Set(district, []Row{})
+		}
+
+		err := query(ctx, spec.pivot().RegionQuery(), 2, len(spec.Segment.Brands), func(names []string, brands []*int, total int) {
+			district, region := translate(districtTranslations, names[0]), names[1]
+			if region == names[0] {
+				region = district
+			} else {
+				region = translate(regionTranslations, region)
+			}
+
+			row := spec.row(region, brands, total)
+			if spec.TotalMarket {
+				row.Set("total_market", total)
+			}
+
+			if existing, ok := data.Get(district); ok {
+				data.Set(district, append(existing, row))
+			}
+		})
+		if err != nil {
+			return
+		}
+
+		ctx.JSON(http.StatusOK, Response{Data: data})
+	}
+}
+
+// DistrictHandler отдаёт отчёт total market: первой идёт сводка Summary
+// по всей стране, затем по одной строке на федеральный округ
+func DistrictHandler(spec Spec) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		data := orderedmap.New[string, []Row]()
+		data.Set("Summary", []Row{})
+		for _, district := range districtOrder {
+			data.Set(district, []Row{})
+		}
+
+		summary := make([]*int, len(spec.Segment.Brands))
+		for i := range summary {
+			summary[i] = new(int)
+		}
+		summaryTotal := 0
+
+		err := query(ctx, spec.pivot().DistrictQuery(), 1, len(spec.Segment.Brands), func(names []string, brands []*int, total int) {
+			for i, value := range brands {
+				if value != nil {
+					*summary[i] += *value
+				}
+			}
+			summaryTotal += total
+
+			district := translate(districtTranslations, names[0])
+			if existing, ok := data.Get(district); ok {
+				data.Set(district, append(existing, spec.row(district, brands, total)))
+			}
+		})
+		if err != nil {
+			return
+		}
+
+		data.Set("Summary", []Row{spec.row("Summary", summary, summaryTotal)})
+		ctx.JSON(http.StatusOK, Response{Data: data})
+	}
+}
+
+func (s Spec) row(name string, brands []*int, total int) Row {
+	row := orderedmap.New[string, any]()
+	row.Set("region_name", name)
+	for i, brand := range s.Segment.Brands {
+		row.Set(brand.Key, brands[i])
+	}
+	row.Set("total", total)
+	return row
+}
+
+// query выполняет запрос отчёта и передаёт в handle строковые колонки,
+// колонки брендов и итог. Ошибку он уже отдал клиенту, вызывающему остаётся выйти
+func query(ctx *gin.Context, q sb.Query, nameColumns, brandColumns int, handle func(names []string, brands []*int, total int)) error {
+	conn, err := db.Connect(ctx.Request.Context())
+	if err != nil {
+		utils.RespondError(ctx, http.StatusInternalServerError, utils.CodeDatabaseUnavailable, "Can't connect to database", err)
+		return err
+	}
+
+	rows, err := conn.Query(ctx.Request.Context(), q.SQL, q.Args...)
+	if err != nil {
+		utils.RespondError(ctx, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to execute query", err)
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		names := make([]string, nameColumns)
+		brands := make([]*int, brandColumns)
+		var total int
+
+		dest := make([]any, 0, nameColumns+brandColumns+1)
+		for i := range names {
+			dest = append(dest, &names[i])
+		}
+		for i := range brands {
+			dest = append(dest, &brands[i])
+		}
+		dest = append(dest, &total)
+
+		if err := rows.Scan(dest...); err != nil {
+			utils.RespondError(ctx, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to scan row", err)
+			return err
+		}
+
+		handle(names, brands, total)
+	}
+
+	if err := rows.Err(); err != nil {
+		utils.RespondError(ctx, http.StatusInternalServerError, utils.CodeQueryFailed, "Failed to iterate over rows", err)
+		return err
+	}
+
+	return nil
+}
+
+func translate(translations map[string]string, name string) string {
+	if translated, ok := translations[name]; ok {
+		return translated
+	}
+	return name
+}
@@ -0,0 +1,108 @@
+package reports
+
+import sb "truck-analytics-platform/internal/sqlbuilder"
+
+// Segment — сегмент рынка HDT: условия отбора регистраций
+// и бренды, которые выводятся колонками отчётов
+type Segment struct {
+	Key        string
+	Conditions []sb.Condition
+	Brands     []Brand
+}
+
+// BrandNames возвращает канонические идентификаторы брендов сегмента
+func (s Segment) BrandNames() []string {
+	names := make([]string, len(s.Brands))
+	for i, brand := range s.Brands {
+		names[i] = brand.Name
+	}
+	return names
+}
+
+var (
+	Tractors4x2 = Segment{
+		Key: "tractors4x2",
+		Conditions: []sb.Condition{
+			sb.Eq(sb.WheelFormula, "4x2"),
+			sb.Eq(sb.BodyType, "Седельный тягач"),
+			sb.Eq(sb.ExactMass, 18000),
+		},
+		Brands: []Brand{
+			{Name: "DONGFENG", Key: "dongfeng"},
+			{Name: "FAW", Key: "faw"},
+			{Name: "FOTON", Key: "foton"},
+			{Name: "JAC", Key: "jac"},
+			{Name: "SHACMAN", Key: "shacman"},
+			{Name: "SITRAK", Key: "sitrak"},
+		},
+	}
+
+	Tractors6x4 = Segment{
+		Key: "tractors6x4",
+		Conditions: []sb.Condition{
+			sb.Eq(sb.WheelFormula, "6x4"),
+			sb.Eq(sb.BodyType, "Седельный тягач"),
+			sb.Eq(sb.ExactMass, 25000),
+		},
+		Brands: []Brand{
+			{Name: "DONGFENG", Key: "dongfeng"},
+			{Name: "FAW", Key: "faw"},
+			{Name: "FOTON", Key: "foton"},
+			{Name: "HOWO", Key: "howo"},
+			{Name: "SHACMAN", Key: "shacman"},
+			{Name: "SITRAK", Key: "sitrak"},
+		},
+	}
+
+	Dumpers6x4 = Segment{
+		Key: "dumpers6x4",
+		Conditions: []sb.Condition{
+			sb.Eq(sb.WheelFormula, "6x4"),
+			sb.Eq(sb.BodyType, "Самосвал"),
+			sb.Eq(sb.MassInSegment1, "32001-40000"),
+		},
+		Brands: []Brand{
+			{Name: "FAW", Key: "faw"},
+			{Name: "HOWO", Key: "howo"},
+			{Name: "JAC", Key: "jac"},
+			{Name: "SANY", Key: "sany"},
+			{Name: "SITRAK", Key: "sitrak"},
+			{Name: "SHACMAN", Key: "shacman"},
+			{Name: "DONGFENG", Key: "dongfeng"},
+		},
+	}
+
+	Dumpers8x4 = Segment{
+		Key: "dumpers8x4",
+		Conditions: []sb.Condition{
+			sb.Eq(sb.WheelFormula, "8x4"),
+			sb.Eq(sb.BodyType, "Самосвал"),
+			sb.Eq(sb.WeightInSegment4, "35001-45000"),
+		},
+		Brands: []Brand{
+			{Name: "FAW", Key: "faw"},
+			{Name: "HOWO", Key: "howo"},
+			{Name: "SHACMAN", Key: "shacman"},
+			{Name: "SITRAK", Key: "sitrak"},
+		},
+	}
+)
+
+// Segments — сегменты HDT по ключу, как в путях отчётов
+var Segments = map[string]Segment{
+	Tractors4x2.Key: Tractors4x2,
+	Tractors6x4.Key: Tractors6x4,
+	Dumpers6x4.Key:  Dumpers6x4,
+	Dumpers8x4.Key:  Dumpers8x4,
+}
+
+// Tables — самые свежие таблицы регистраций HDT по годам
+var Tables = map[int]sb.Table{
+	2023: sb.HDT2023,
+	2024: sb.HDT2024Oct,
+}
+
+// Where возвращает условия сегмента и дополнительные условия отчёта
+func (s Segment) Where(extra ...sb.Condition) []sb.Condition {
+	return append(append([]sb.Condition(nil), s.Conditions...), extra...)
+}
@@ -0,0 +1,150 @@
+package reports
+
+// regionTranslations — английские названия регионов для ответов API
+var regionTranslations = map[string]string{
+	"Новгородская область":                "Novgorod Region",
+	"Владимирская область":                "Vladimir Region",
+	"Мурманская область":                  "Murmansk Region",
+	"Свердловская область":                "Sverdlovsk Region",
+	"Калининградская область":             "Kaliningrad Region",
+	"Тульская область":                    "Tula Region",
+	"Рязанская область":                   "Ryazan Region",
+	"Ярославская область":                 "Yaroslavl Region",
+	"Воронежская область":                 "Voronezh Region",
+	"Приморский край":                     "Primorsky Krai",
+	"Чувашия Республика":                  "Chuvashia Republic",
+	"Москва":                              "Moscow",
+	"Сахалинская область":                 "Sakhalin Region",
+	"Кировская область":                   "Kirov Region",
+	"Белгородская область":                "Belgorod Region",
+	"Красноярский край":                   "Krasnoyarsk Krai",
+	"Новосибирская область":               "Novosibirsk Region",
+	"Башкортостан Республика":             "Bashkortostan Republic",
+	"Ненецкий автономный округ":           "Nenets Autonomous Okrug",
+	"Чукотский автономный округ":          "Chukotka Autonomous Okrug",
+	"Тамбовская область":                  "Tambov Region",
+	"Чеченская Республика":                "Chechen Republic",
+	"Коми Республика":                     "Komi Republic",
+	"Алтайский край":                      "Altai Krai",
+	"Татарстан Республика":                "Tatarstan Republic",
+	"Иркутская область":                   "Irkutsk Region",
+	"Северная Осетия Республика":          "North Ossetia Republic",
+	"Ингушетия Республика":                "Ingushetia Republic",
+	"Крым Республика":                     "Crimea Republic",
+	"Магаданская область":                 "Magadan Region",
+	"Саха (Якутия) Республика":            "Sakha (Yakutia) Republic",
+	"Липецкая область":                    "Lipetsk Region",
+	"Смоленская область":                  "Smolensk Region",
+	"Орловская область":                   "Oryol Region",
+	"Санкт-Петербург":                     "Saint Petersburg",
+	"Луганская Народная Республика":       "Luhansk People's Republic",
+	"Хакасия Республика":                  "Khakassia Republic",
+	"Саратовская область":                 "Saratov Region",
+	"Донецкая Народная Республика":        "Donetsk People's Republic",
+	"Архангельская область":               "Arkhangelsk Region",
+	"Нижегородская область":               "Nizhny Novgorod Region",
+	"Волгоградская область":               "Volgograd Region",
+	"Курская область":                     "Kursk Region",
+	"Пензенская область":                  "Penza Region",
+	"Тверская область":                    "Tver Region",
+	"Челябинская область":                 "Chelyabinsk Region",
+	"Московская область":                  "Moscow Region",
+	"Забайкальский край":                  "Zabaykalsky Krai",
+	"Ямало-Ненецкий автономный округ":     "Yamalo-Nenets Autonomous Okrug",
+	"Брянская область":                    "Bryansk Region",
+	"Курганская область":                  "Kurgan Region",
+	"Удмуртия Республика":                 "Udmurtia Republic",
+	"Самарская область":                   "Samara Region",
+	"Калмыкия Республика":                 "Kalmykia Republic",
+	"Ханты-Мансийский автономный округ":   "Khanty-Mansi Autonomous Okrug",
+	"Адыгея Республика":                   "Adygea Republic",
+	"Амурская область":                    "Amur Region",
+	"Томская область":                     "Tomsk Region",
+	"Тыва Республика":                     "Tuva Republic",
+	"Кабардино-Балкария Республика":       "Kabardino-Balkaria Republic",
+	"Астраханская область":                "Astrakhan Region",
+	"Ивановская область":                  "Ivanovo Region",
+	"Псковская область":                   "Pskov Region",
+	"Карелия Республика":                  "Karelia Republic",
+	"Севастополь":                         "Sevastopol",
+	"Вологодская область":                 "Vologda Region",
+	"Тюменская область":                   "Tyumen Region",
+	"Оренбургская область":                "Orenburg Region",
+	"Марий-Эл Республика":                 "Mari El Republic",
+	"Ростовская область":                  "Rostov Region",
+	"Краснодарский край":                  "Krasnodar Krai",
+	"Алтай Республика":                    "Altai Republic",
+	"Херсонская область":                  "Kherson Region",
+	"Костромская область":                 "Kostroma Region",
+	"Камчатский край":                     "Kamchatka Krai",
+	"Омская область":                      "Omsk Region",
+	"Запорожская область":                 "Zaporizhzhia Region",
+	"Ленинградская область":               "Leningrad Region",
+	"Ульяновская область":                 "Ulyanovsk Region",
+	"Дагестан Республика":                 "Dagestan Republic",
+	"Калужская область":                   "Kaluga Region",
+	"Кемеровская область":                 "Kemerovo Region",
+	"Пермский край":                       "Perm Krai",
+	"Мордовия Республика":                 "Mordovia Republic",
+	"Хабаровский край":                    "Khabarovsk Krai",
+	"Еврейский автономный округ":          "Jewish Autonomous Okrug",
+	"Карачаево-Черкессия Республика":      "Karachay-Cherkessia Republic",
+	"Ставропольский край":                 "Stavropol Krai",
+	"Бурятия Республика":                  "Buryatia Republic",
+	"Центральный Федеральный Округ":       "Central",
+	"Северо-Западный Федеральный Округ":   "North West",
+	"Южный Федеральный Округ":             "South",
+	"Северо-Кавказский Федеральный Округ": "North Caucasian",
+	"Приволжский Федеральный Округ":       "Volga",
+	"Уральский Федеральный Округ":         "Ural",
+	"Сибирский Федеральный Округ":         "Siberia",
+	"Дальневосточный Федеральный Округ":   "Far East",
+}
+
+// districtTranslations — английские названия федеральных округов
+var districtTranslations = map[string]string{
+	"Центральный Федеральный Округ":       "Central",
+	"Северо-Западный Федеральный Округ":   "North West",
+	"Южный Федеральный Округ":             "South",
+	"Северо-Кавказский Федеральный Округ": "North Caucasian",
+	"Приволжский Федеральный Округ":       "Volga",
+	"Уральский Федеральный Округ":         "Ural",
+	"Сибирский Федеральный Округ":         "Siberia",
+	"Дальневосточный Федеральный Округ":   "Far East",
+}
+
+// districtOrder — порядок федеральных округов в ответах, как на страницах фронтенда
+var districtOrder = []string{
+	"Central",
+	"North West",
+	"Volga",
+	"South",
+	"North Caucasian",
+	"Ural",
+	"Siberia",
+	"Far East",
+}
+
+// KnownRegion сообщает, есть ли у региона из БД английское название.
+// Регион без перевода уходит в ответы как есть
+func KnownRegion(name string) bool {
+	_, ok := regionTranslations[name]
+	return ok
+}
+
+// EnglishRegion сообщает, что name — английское название региона, как в ответах API
+func EnglishRegion(name string) bool {
+	for _, en := range regionTranslations {
+		if en == name {
+			return true
+		}
+	}
+	return false
+}
+
+// KnownDistrict сообщает, есть ли у федерального округа из БД английское
+// название. Округа без перевода выпадают из отчётов по регионам
+func KnownDistrict(name string) bool {
+	_, ok := districtTranslations[name]
+	return ok
+}
@@ -0,0 +1,137 @@
+package reports
+
+import (
+	"context"
+	"sort"
+)
+
+// MaxGapLeaders — сколько крупнейших конкурентов показывается в регионе без бренда
+const MaxGapLeaders = 3
+
+// BrandVolume — продажи бренда на территории
+type BrandVolume struct {
+	Brand  string `json:"brand"`
+	Volume int    `json:"volume"`
+}
+
+// Gap — территория, где у бренда нет регистраций, а конкуренты продают
+type Gap struct {
+	Area string `json:"area"`
+	// District — округ региона на уровне регионов
+	District     string `json:"district,omitempty"`
+	MarketVolume int    `json:"market_volume"`
+	Competitors  int    `json:"competitors"`
+	// Leaders — крупнейшие конкуренты территории
+	Leaders []BrandVolume `json:"leaders"`
+	// PrevBrandVolume — продажи бренда в прошлом году: больше нуля, если
+	// территория потеряна, а не не освоена. nil без выгрузки прошлого года
+	PrevBrandVolume *int `json:"prev_brand_volume"`
+}
+
+// Presence — появление или уход конкурента с территории относительно прошлого года
+type Presence struct {
+	Area       string `json:"area"`
+	District   string `json:"district,omitempty"`
+	Brand      string `json:"brand"`
+	Volume     int    `json:"volume"`
+	PrevVolume int    `json:"prev_volume"`
+}
+
+// WhitespaceReport — белые пятна бренда и движение конкурентов. Entries
+// и Exits пусты, если выгрузки прошлого года нет
+type WhitespaceReport struct {
+	Brand      string     `json:"brand"`
+	Whitespace []Gap      `json:"whitespace"`
+	Entries    []Presence `json:"entries"`
+	Exits      []Presence `json:"exits"`
+}
+
+// Whitespace находит территории уровня спецификации, где у бренда нет
+// регистраций при продажах конкурентов, и конкурентов, которые появились
+// на территории или ушли с неё по сравнению с теми же месяцами прошлого года
+func Whitespace(ctx context.Context, s MarketSpec, brand string) (WhitespaceReport, Period, error) {
+	if err := s.Validate(LevelDistrict, LevelRegion); err != nil {
+		return WhitespaceReport{}, s.period(), err
+	}
+	st, period, err := load(ctx, s)
+	if err != nil {
+		return WhitespaceReport{}, period, err
+	}
+
+	return st.whitespace(brand), period, nil
+}
+
+func (st stats) whitespace(brand string) WhitespaceReport {
+	report := WhitespaceReport{Brand: brand, Whitespace: []Gap{}, Entries: []Presence{}, Exits: []Presence{}}
+
+	for _, a := range st.areas {
+		// Округ указывается только у регионов
+		district := ""
+		if a.region != "" {
+			district = a.district
+		}
+
+		own := st.brands[a][brand]
+		var competitors []BrandVolume
+		for name, v := range st.brands[a] {
+			if name == brand {
+				continue
+			}
+			if v.cur > 0 {
+				competitors = append(competitors, BrandVolume{Brand: name, Volume: v.cur})
+			}
+			if !st.hasPrev {
+				continue
+			}
+			presence := Presence{Area: a.name(), District: district, Brand: name, Volume: v.cur, PrevVolume: v.prev}
+			switch {
+			case v.cur > 0 && v.prev == 0:
+				report.Entries = append(report.Entries, presence)
+			case v.cur == 0 && v.prev > 0:
+				report.Exits = append(report.Exits, presence)
+			}
+		}
+
+		if own.cur > 0 || len(competitors) == 0 {
+			continue
+		}
+		sort.Slice(competitors, func(i, j int) bool {
+			if competitors[i].Volume != competitors[j].Volume {
+				return competitors[i].Volume > competitors[j].Volume
+			}
+			return competitors[i].Brand < competitors[j].Brand
+		})
+		gap := Gap{
+			Area:         a.name(),
+			District:     district,
+			MarketVolume: st.totals[a].cur,
+			Competitors:  len(competitors),
+			Leaders:      competitors[:min(len(competitors), MaxGapLeaders)],
+		}
+		if st.hasPrev {
+			prev := own.prev
+			gap.PrevBrandVolume = &prev
+		}
+		report.Whitespace = append(report.Whitespace, gap)
+	}
+
+	// Крупнейшие рынки без бренда и самые заметные входы и уходы — первыми
+	sort.SliceStable(report.Whitespace, func(i, j int) bool {
+		return report.Whitespace[i].MarketVolume > report.Whitespace[j].MarketVolume
+	})
+	sortPresence(report.Entries, func(p Presence) int { return p.Volume })
+	sortPresence(report.Exits, func(p Presence) int { return p.PrevVolume })
+	return report
+}
+
+func sortPresence(list []Presence, volume func(Presence) int) {
+	sort.SliceStable(list, func(i, j int) bool {
+		if volume(list[i]) != volume(list[j]) {
+			return volume(list[i]) > volume(list[j])
+		}
+		if list[i].Area != list[j].Area {
+			return list[i].Area < list[j].Area
+		}
+		return list[i].Brand < list[j].Brand
+	})
+}
@@ -0,0 +1,195 @@
+// Package sqlbuilder собирает SQL-запросы отчётов. Таблицы и колонки берутся
+// только из белого списка, а значения фильтров и названия брендов передаются
+// параметрами $n — в текст запроса данные не подставляются
+package sqlbuilder
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Table — таблица регистраций из белого списка
+type Table string
+
+const (
+	HDT2023    Table = "truck_analytics_2023_01_12"
+	HDT2024Sep Table = "truck_analytics_2024_01_09"
+	HDT2024Oct Table = "truck_analytics_2024_01_10"
+	LDT2023    Table = "ldt_3_5_12_truck_analytics_10_2023"
+	LDT2024    Table = "ldt_3_5_12_truck_analytics_10_2024"
+	MDT2023    Table = "mdt_12_18_truck_analytics_10_2023"
+	MDT2024    Table = "mdt_12_18_truck_analytics_10_2024"
+)
+
+// Tables — все таблицы белого списка
+var Tables = []Table{HDT2023, HDT2024Sep, HDT2024Oct, LDT2023, LDT2024, MDT2023, MDT2024}
+
+var tables = func() map[Table]bool {
+	m := make(map[Table]bool, len(Tables))
+	for _, t := range Tables {
+		m[t] = true
+	}
+	return m
+}()
+
+// String возвращает имя таблицы. Таблица вне белого списка — ошибка
+// программиста, поэтому здесь паника, а не ошибка запроса
+func (t Table) String() string {
+	if !tables[t] {
+		panic(fmt.Sprintf("sqlbuilder: unknown table %q", string(t)))
+	}
+	return string(t)
+}
+
+// Column — колонка таблиц регистраций из белого списка
+type Column string
+
+const (
+	FederalDistrict     Column = "Federal_district"
+	Region              Column = "Region"
+	City                Column = "City"
+	Brand               Column = "Brand"
+	Quantity            Column = "Quantity"
+	WheelFormula        Column = "Wheel_formula"
+	BodyType            Column = "Body_type"
+	ExactMass           Column = "Exact_mass"
+	MassInSegment1      Column = "Mass_in_segment_1"
+	WeightInSegment4    Column = "Weight_in_segment_4"
+	MonthOfRegistration Column = "Month_of_registration"
+	// Model и Engine есть не во всех выгрузках: перед запросом их наличие
+	// в таблице проверяется по information_schema
+	Model  Column = "Model"
+	Engine Column = "Engine"
+
+	// CanonicalBrand — бренд из справочника: вариант написания из brand_aliases
+	// сводится к каноническому идентификатору, бренд без варианта остаётся как есть.
+	// Доступен только в запросах FROM Table.From()
+	CanonicalBrand Column = "canonical_brand"
+	// ParentBrand — группа производителя канонического бренда, например
+	// SINOTRUK для HOWO и SITRAK; бренд без группы — сам себе группа
+	ParentBrand Column = "parent_brand"
+)
+
+var columns = map[Column]bool{
+	FederalDistrict: true, Region: true, City: true, Brand: true, Quantity: true,
+	WheelFormula: true, BodyType: true, ExactMass: true, MassInSegment1: true,
+	WeightInSegment4: true, MonthOfRegistration: true, Model: true, Engine: true,
+}
+
+// brandColumns — выражения колонок справочника брендов
+var brandColumns = map[Column]string{
+	CanonicalBrand: `COALESCE(brand_aliases.brand_id, "Brand")`,
+	ParentBrand:    `COALESCE(brands.parent_id, brand_aliases.brand_id, "Brand")`,
+}
+
+// String возвращает имя колонки в кавычках или выражение колонки справочника брендов
+func (c Column) String() string {
+	if expr, ok := brandColumns[c]; ok {
+		return expr
+	}
+	if !columns[c] {
+		panic(fmt.Sprintf("sqlbuilder: unknown column %q", string(c)))
+	}
+	return `"` + string(c) + `"`
+}
+
+// From возвращает таблицу, соединённую со справочником брендов,
+// для FROM запросов с CanonicalBrand и ParentBrand
+func (t Table) From() string {
+	return fmt.Sprintf(`%s
+				LEFT JOIN brand_aliases ON brand_aliases.alias = %s
+				LEFT JOIN brands ON brands.id = %s`, t, Brand, CanonicalBrand)
+}
+
+// Params нумерует параметры запроса. Один Params на запрос, чтобы
+// плейсхолдеры в нескольких WHERE шли сквозной нумерацией
+type Params struct {
+	args []any
+}
+
+// Bind добавляет значение и возвращает его плейсхолдер
+func (p *Params) Bind(value any) string {
+	p.args = append(p.args, value)
+	return fmt.Sprintf("$%d", len(p.args))
+}
+
+// Args возвращает значения параметров в порядке плейсхолдеров
+func (p *Params) Args() []any {
+	return p.args
+}
+
+// Condition — условие WHERE над колонкой из белого списка
+type Condition struct {
+	column Column
+	op     string
+	value  any
+}
+
+// Eq — колонка равна значению
+func Eq(column Column, value any) Condition {
+	return Condition{column: column, op: "= %s", value: value}
+}
+
+// In — колонка входит в список
+func In(column Column, values []string) Condition {
+	return Condition{column: column, op: "= ANY(%s)", value: values}
+}
+
+// NotIn — колонка не входит в список. Как и NOT IN, не пропускает NULL
+func NotIn(column Column, values []string) Condition {
+	return Condition{column: column, op: "<> ALL(%s)", value: values}
+}
+
+// Gte — колонка не меньше значения
+func Gte(column Column, value int) Condition {
+	return Condition{column: column, op: ">= %s", value: value}
+}
+
+// Lte — колонка не больше значения
+func Lte(column Column, value int) Condition {
+	return Condition{column: column, op: "<= %s", value: value}
+}
+
+// NotNull — колонка заполнена
+func NotNull(column Column) Condition {
+	return Condition{column: column, op: "IS NOT NULL"}
+}
+
+func (c Condition) render(p *Params) string {
+	if c.value == nil {
+		return c.column.String() + " " + c.op
+	}
+	return c.column.String() + " " + fmt.Sprintf(c.op, p.Bind(c.value))
+}
+
+// Where соединяет условия через AND; без условий — TRUE
+func Where(p *Params, conditions ...Condition) string {
+	if len(conditions) == 0 {
+		return "TRUE"
+	}
+
+	parts := make([]string, len(conditions))
+	for i, c := range conditions {
+		parts[i] = c.render(p)
+	}
+	return strings.Join(parts, "\n\t\t\t\tAND ")
+}
+
+// Query — текст запроса и его параметры
+type Query struct {
+	SQL  string
+	Args []any
+}
+
+var identifier = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// alias — имя колонки результата для бренда. Берётся из названия бренда,
+// если оно годится в идентификатор, иначе по номеру
+func alias(brand string, i int) string {
+	name := strings.ToLower(brand)
+	if !identifier.MatchString(name) {
+		name = fmt.Sprintf("brand_%d", i+1)
+	}
+	return `"` + name + `"`
+}
@@ -0,0 +1,218 @@
+package sqlbuilder
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWhereBindsValues(t *testing.T) {
+	var p Params
+	where := Where(&p,
+		Eq(WheelFormula, "4x2"),
+		In(Brand, []string{"FAW", "JAC"}),
+		NotIn(Region, []string{"Москва"}),
+		Gte(MonthOfRegistration, 3),
+		Lte(MonthOfRegistration, 9),
+		NotNull(City),
+	)
+
+	want := strings.Join([]string{
+		`"Wheel_formula" = $1`,
+		`"Brand" = ANY($2)`,
+		`"Region" <> ALL($3)`,
+		`"Month_of_registration" >= $4`,
+		`"Month_of_registration" <= $5`,
+		`"City" IS NOT NULL`,
+	}, "\n\t\t\t\tAND ")
+	if where != want {
+		t.Errorf("Where:\n%s\nwant:\n%s", where, want)
+	}
+
+	wantArgs := []any{"4x2", []string{"FAW", "JAC"}, []string{"Москва"}, 3, 9}
+	if !reflect.DeepEqual(p.Args(), wantArgs) {
+		t.Errorf("Args = %v, want %v", p.Args(), wantArgs)
+	}
+}
+
+func TestWhereWithoutConditions(t *testing.T) {
+	var p Params
+	if where := Where(&p); where != "TRUE" {
+		t.Errorf("Where() = %q, want TRUE", where)
+	}
+	if len(p.Args()) != 0 {
+		t.Errorf("Args = %v, want none", p.Args())
+	}
+}
+
+func TestParamsNumberAcrossClauses(t *testing.T) {
+	var p Params
+	first := Where(&p, Eq(Brand, "FAW"))
+	second := Where(&p, Eq(Brand, "JAC"))
+
+	if first != `"Brand" = $1` || second != `"Brand" = $2` {
+		t.Errorf("placeholders = %q, %q", first, second)
+	}
+}
+
+func TestUnknownIdentifiersPanic(t *testing.T) {
+	cases := map[string]func(){
+		"table":  func() { _ = Table("users; DROP TABLE users").String() },
+		"column": func() { _ = Column(`Brand" OR 1=1 --`).String() },
+	}
+
+	for name, f := range cases {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected panic")
+				}
+			}()
+			f()
+		})
+	}
+}
+
+func TestAlias(t *testing.T) {
+	cases := []struct {
+		brand string
+		i     int
+		want  string
+	}{
+		{"FOTON", 0, `"foton"`},
+		{"GAZ", 3, `"gaz"`},
+		{"MERCEDES-BENZ", 2, `"brand_3"`},
+		{`X"; DROP`, 0, `"brand_1"`},
+	}
+
+	for _, c := range cases {
+		if got := alias(c.brand, c.i); got != c.want {
+			t.Errorf("alias(%q, %d) = %s, want %s", c.brand, c.i, got, c.want)
+		}
+	}
+}
+
+// canonical — выражение канонического бренда в тексте запросов
+const canonical = `COALESCE(brand_aliases.brand_id, "Brand")`
+
+func TestBrandColumnsNeedJoin(t *testing.T) {
+	from := HDT2023.From()
+	for _, part := range []string{
+		"truck_analytics_2023_01_12",
+		`LEFT JOIN brand_aliases ON brand_aliases.alias = "Brand"`,
+		"LEFT JOIN brands ON brands.id = " + canonical,
+	} {
+		if !strings.Contains(from, part) {
+			t.Errorf("From() has no %q:\n%s", part, from)
+		}
+	}
+
+	if got := ParentBrand.String(); got != `COALESCE(brands.parent_id, brand_aliases.brand_id, "Brand")` {
+		t.Errorf("ParentBrand = %s", got)
+	}
+}
+
+func TestRegionQuery(t *testing.T) {
+	q := BrandPivot{
+		Table:  HDT2024Sep,
+		Where:  []Condition{Eq(WheelFormula, "4x2"), Lte(MonthOfRegistration, 9)},
+		Brands: []string{"FAW", "JAC"},
+	}.RegionQuery()
+
+	for _, part := range []string{
+		"FROM truck_analytics_2024_01_09",
+		`"Wheel_formula" = $1`,
+		`"Month_of_registration" <= $2`,
+		canonical + ` = ANY($3)`,
+		`MAX(CASE WHEN brand_key = $4 THEN total_sales END) AS "faw"`,
+		`MAX(CASE WHEN brand_key = $5 THEN total_sales END) AS "jac"`,
+		"federal_totals AS",
+		"combined_data AS",
+	} {
+		if !strings.Contains(q.SQL, part) {
+			t.Errorf("query has no %q:\n%s", part, q.SQL)
+		}
+	}
+	if strings.Contains(q.SQL, `"other"`) || strings.Contains(q.SQL, "COALESCE(MAX") {
+		t.Errorf("unexpected other/zero fill:\n%s", q.SQL)
+	}
+
+	wantArgs := []any{"4x2", 9, []string{"FAW", "JAC"}, "FAW", "JAC"}
+	if !reflect.DeepEqual(q.Args, wantArgs) {
+		t.Errorf("Args = %v, want %v", q.Args, wantArgs)
+	}
+}
+
+func TestRegionQueryWithOther(t *testing.T) {
+	q := BrandPivot{
+		Table:        MDT2024,
+		Brands:       []string{"KAMAZ"},
+		Other:        true,
+		ZeroFill:     true,
+		CastQuantity: true,
+	}.RegionQuery()
+
+	for _, part := range []string{
+		`CASE WHEN ` + canonical + ` = ANY($1) THEN ` + canonical + ` END AS brand_key`,
+		`SUM(CAST("Quantity" AS INTEGER))`,
+		`"Brand" IS NOT NULL`,
+		`COALESCE(MAX(CASE WHEN brand_key = $2 THEN total_sales END), 0) AS "kamaz"`,
+		`COALESCE(MAX(CASE WHEN brand_key IS NULL THEN total_sales END), 0) AS "other"`,
+	} {
+		if !strings.Contains(q.SQL, part) {
+			t.Errorf("query has no %q:\n%s", part, q.SQL)
+		}
+	}
+
+	wantArgs := []any{[]string{"KAMAZ"}, "KAMAZ"}
+	if !reflect.DeepEqual(q.Args, wantArgs) {
+		t.Errorf("Args = %v, want %v", q.Args, wantArgs)
+	}
+}
+
+func TestDistrictQuery(t *testing.T) {
+	q := BrandPivot{
+		Table:  HDT2023,
+		Where:  []Condition{Eq(BodyType, "Самосвал")},
+		Brands: []string{"HOWO"},
+	}.DistrictQuery()
+
+	for _, part := range []string{
+		`COALESCE(SUM(CASE WHEN ` + canonical + ` = $1 THEN "Quantity" END), 0) AS "howo"`,
+		"FROM truck_analytics_2023_01_12",
+		`"Body_type" = $2`,
+		canonical + ` = ANY($3)`,
+		`COALESCE(SUM("Quantity"), 0) AS total`,
+		`GROUP BY "Federal_district"`,
+	} {
+		if !strings.Contains(q.SQL, part) {
+			t.Errorf("query has no %q:\n%s", part, q.SQL)
+		}
+	}
+
+	wantArgs := []any{"HOWO", "Самосвал", []string{"HOWO"}}
+	if !reflect.DeepEqual(q.Args, wantArgs) {
+		t.Errorf("Args = %v, want %v", q.Args, wantArgs)
+	}
+}
+
+func TestDistrictQueryWithOther(t *testing.T) {
+	q := BrandPivot{
+		Table:  LDT2024,
+		Brands: []string{"GAZ"},
+		Other:  true,
+	}.DistrictQuery()
+
+	if !strings.Contains(q.SQL, `COALESCE(SUM(CASE WHEN `+canonical+` <> ALL($2) THEN "Quantity" END), 0) AS "other"`) {
+		t.Errorf("query has no other column:\n%s", q.SQL)
+	}
+	// Итог по всем брендам: фильтра по брендам нет
+	if !strings.Contains(q.SQL, "WHERE\n\t\t\tTRUE") {
+		t.Errorf("query must not filter brands:\n%s", q.SQL)
+	}
+
+	wantArgs := []any{"GAZ", []string{"GAZ"}}
+	if !reflect.DeepEqual(q.Args, wantArgs) {
+		t.Errorf("Args = %v, want %v", q.Args, wantArgs)
+	}
+}
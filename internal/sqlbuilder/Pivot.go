@@ -0,0 +1,138 @@
+package sqlbuilder
+
+import (
+	"fmt"
+	"strings"
+)
+
+// BrandPivot описывает отчёт «бренды колонками»: продажи брендов
+// из списка по регионам или федеральным округам. Бренды — канонические
+// идентификаторы справочника, варианты написания в данных сводятся к ним
+type BrandPivot struct {
+	Table  Table
+	Where  []Condition
+	Brands []string
+	// Other добавляет колонку other — продажи брендов вне списка
+	Other bool
+	// ZeroFill заменяет NULL в колонках брендов отчёта по регионам на 0
+	ZeroFill bool
+	// CastQuantity приводит "Quantity" к INTEGER: в таблицах MDT колонка текстовая
+	CastQuantity bool
+}
+
+func (b BrandPivot) quantity() string {
+	if b.CastQuantity {
+		return fmt.Sprintf("CAST(%s AS INTEGER)", Quantity)
+	}
+	return Quantity.String()
+}
+
+// RegionQuery строит запрос по регионам в виде base_data / federal_totals /
+// combined_data: base_data — продажи по регионам, federal_totals — итоги округов
+// (Region = Federal_district). Колонки результата: округ, регион, бренды
+// в порядке Brands, other (если Other), total. Итог округа идёт после его регионов
+func (b BrandPivot) RegionQuery() Query {
+	var p Params
+
+	// При Other бренды вне списка попадают в base_data с brand_key = NULL
+	brandKey := CanonicalBrand.String()
+	conditions := append([]Condition(nil), b.Where...)
+	if b.Other {
+		brandKey = fmt.Sprintf("CASE WHEN %s = ANY(%s) THEN %s END", CanonicalBrand, p.Bind(b.Brands), CanonicalBrand)
+		conditions = append(conditions, NotNull(Brand))
+	} else {
+		conditions = append(conditions, In(CanonicalBrand, b.Brands))
+	}
+	where := Where(&p, conditions...)
+
+	var selects []string
+	for i, brand := range b.Brands {
+		selects = append(selects, b.fill(fmt.Sprintf("MAX(CASE WHEN brand_key = %s THEN total_sales END)", p.Bind(brand)))+" AS "+alias(brand, i))
+	}
+	if b.Other {
+		selects = append(selects, b.fill("MAX(CASE WHEN brand_key IS NULL THEN total_sales END)")+` AS "other"`)
+	}
+
+	sql := fmt.Sprintf(`
+		WITH base_data AS (
+			SELECT
+				%[1]s,
+				%[2]s,
+				%[3]s AS brand_key,
+				SUM(%[4]s) AS total_sales
+			FROM %[5]s
+			WHERE
+				%[6]s
+			GROUP BY %[1]s, %[2]s, brand_key
+		),
+		federal_totals AS (
+			SELECT
+				%[1]s,
+				%[1]s AS %[2]s,
+				brand_key,
+				SUM(total_sales) AS total_sales
+			FROM base_data
+			GROUP BY %[1]s, brand_key
+		),
+		combined_data AS (
+			SELECT * FROM base_data
+			UNION ALL
+			SELECT * FROM federal_totals
+		)
+		SELECT
+			%[1]s,
+			COALESCE(%[2]s, %[1]s) AS region_name,
+			%[7]s,
+			COALESCE(SUM(total_sales), 0) AS total
+		FROM combined_data
+		GROUP BY %[1]s, %[2]s
+		ORDER BY
+			%[1]s,
+			CASE WHEN %[2]s = %[1]s THEN 1 ELSE 0 END,
+			%[2]s
+	`, FederalDistrict, Region, brandKey, b.quantity(), b.Table.From(), where, strings.Join(selects, ",\n\t\t\t"))
+
+	return Query{SQL: sql, Args: p.Args()}
+}
+
+// DistrictQuery строит запрос итогов по федеральным округам. Колонки
+// результата: округ, бренды в порядке Brands, other (если Other), total.
+// Пустые значения брендов всегда 0
+func (b BrandPivot) DistrictQuery() Query {
+	var p Params
+
+	quantity := b.quantity()
+	var selects []string
+	for i, brand := range b.Brands {
+		selects = append(selects, fmt.Sprintf("COALESCE(SUM(CASE WHEN %s = %s THEN %s END), 0) AS %s", CanonicalBrand, p.Bind(brand), quantity, alias(brand, i)))
+	}
+
+	// С Other в total входят все бренды, иначе только бренды из списка
+	conditions := append([]Condition(nil), b.Where...)
+	if b.Other {
+		selects = append(selects, fmt.Sprintf(`COALESCE(SUM(CASE WHEN %s <> ALL(%s) THEN %s END), 0) AS "other"`, CanonicalBrand, p.Bind(b.Brands), quantity))
+	} else {
+		conditions = append(conditions, In(CanonicalBrand, b.Brands))
+	}
+
+	sql := fmt.Sprintf(`
+		SELECT
+			%[1]s,
+			%[2]s,
+			COALESCE(SUM(%[3]s), 0) AS total
+		FROM %[4]s
+		WHERE
+			%[5]s
+		GROUP BY %[1]s
+		ORDER BY %[1]s
+	`, FederalDistrict, strings.Join(selects, ",\n\t\t\t"), quantity, b.Table.From(), Where(&p, conditions...))
+
+	return Query{SQL: sql, Args: p.Args()}
+}
+
+func (b BrandPivot) fill(expr string) string {
+	if b.ZeroFill {
+		return "COALESCE(" + expr + ", 0)"
+	}
+	return expr
+}
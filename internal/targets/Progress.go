@@ -0,0 +1,212 @@
+package targets
+
+import (
+	"context"
+	"math"
+	"truck-analytics-platform/internal/reports"
+)
+
+// TotalArea — строка итога по всем округам с планом
+const TotalArea = "Total"
+
+// Spec — запрос выполнения плана: рынок, год, бренд и последний месяц
+// периода; MonthTo = 0 — последний загруженный месяц года
+type Spec struct {
+	Segment string
+	Year    int
+	Brand   string
+	MonthTo int
+}
+
+// Validate проверяет рынок, год и месяц так же, как отчёты по рынку
+func (s Spec) Validate() error {
+	return reports.MarketSpec{Segment: s.Segment, Year: s.Year, Level: reports.LevelDistrict, MonthTo: s.MonthTo}.
+		Validate(reports.LevelDistrict)
+}
+
+// VolumeProgress — выполнение плана по объёму с начала года. Achievement
+// и ProjectedAchievement — %, nil при нулевом плане
+type VolumeProgress struct {
+	Plan        int      `json:"plan"`
+	Actual      int      `json:"actual"`
+	Gap         int      `json:"gap"`
+	Achievement *float64 `json:"achievement"`
+	// YearPlan — план на год; RunRate — продажи года при темпе с начала года
+	YearPlan             int      `json:"year_plan"`
+	RunRate              int      `json:"run_rate"`
+	ProjectedAchievement *float64 `json:"projected_achievement"`
+}
+
+// ShareProgress — плановая и фактическая доля рынка, %, за месяцы с планом
+// доли; плановая доля взвешена продажами рынка месяцев. Gap — п.п.
+type ShareProgress struct {
+	Plan   float64 `json:"plan"`
+	Actual float64 `json:"actual"`
+	Gap    float64 `json:"gap"`
+}
+
+// AreaProgress — выполнение плана округа. Volume и Share — nil, если плана
+// такого вида в округе нет
+type AreaProgress struct {
+	Area         string          `json:"area"`
+	MarketVolume int             `json:"market_volume"`
+	BrandVolume  int             `json:"brand_volume"`
+	Volume       *VolumeProgress `json:"volume"`
+	Share        *ShareProgress  `json:"share"`
+}
+
+// Progress сравнивает планы бренда на год с регистрациями из тех же таблиц
+// рынков, что и отчёты по рынку: по округам с планом и итогом по ним
+func Progress(ctx context.Context, s Spec) ([]AreaProgress, reports.Period, error) {
+	market := reports.Markets[s.Segment]
+	period := reports.Period{Year: s.Year, MonthFrom: 1, MonthTo: s.MonthTo}
+	if period.MonthTo == 0 {
+		period.MonthTo = market.Months[s.Year]
+	}
+
+	plans, err := List(ctx, Filter{Segment: s.Segment, Year: s.Year, Brand: s.Brand})
+	if err != nil {
+		return nil, period, err
+	}
+	sales, err := market.MonthlySales(ctx, []int{s.Year})
+	if err != nil {
+		return nil, period, err
+	}
+
+	return progress(plans, sales, s.Brand, s.Year, period.MonthTo), period, nil
+}
+
+// monthly — продажи рынка и бренда и планы округа по месяцам; индекс — месяц
+type monthly struct {
+	market, brand [13]int
+	volume        [13]*int
+	share         [13]*float64
+}
+
+// tally — суммы округа или итога за период, из которых считается выполнение
+type tally struct {
+	market, brand int
+	// actual — продажи бренда в округах с планом объёма
+	plan, yearPlan, actual           int
+	hasVolume, hasShare              bool
+	planned, shareMarket, shareBrand float64
+}
+
+func progress(plans []Target, sales []reports.MonthlySale, brand string, year, months int) []AreaProgress {
+	districts := map[string]*monthly{}
+	for _, t := range plans {
+		m := districts[t.District]
+		if m == nil {
+			m = &monthly{}
+			districts[t.District] = m
+		}
+		m.volume[t.Month], m.share[t.Month] = t.Volume, t.Share
+	}
+	if len(districts) == 0 {
+		return []AreaProgress{}
+	}
+
+	for _, s := range sales {
+		m := districts[s.District]
+		if m == nil || s.Year != year || s.Month < 1 || s.Month > 12 {
+			continue
+		}
+		m.market[s.Month] += s.Quantity
+		if s.Brand == brand {
+			m.brand[s.Month] += s.Quantity
+		}
+	}
+
+	var result []AreaProgress
+	var total tally
+	for _, district := range reports.DistrictOrder() {
+		m := districts[district]
+		if m == nil {
+			continue
+		}
+		t := m.tally(months)
+		total.add(t)
+		result = append(result, t.progress(district, months))
+	}
+	return append(result, total.progress(TotalArea, months))
+}
+
+func (m *monthly) tally(months int) tally {
+	var t tally
+	for month := 1; month <= 12; month++ {
+		if m.volume[month] != nil {
+			t.hasVolume = true
+			t.yearPlan += *m.volume[month]
+			if month <= months {
+				t.plan += *m.volume[month]
+			}
+		}
+		if month > months {
+			continue
+		}
+		t.market += m.market[month]
+		t.brand += m.brand[month]
+		if m.share[month] != nil {
+			// Плановая доля переводится в объём, чтобы взвесить её продажами рынка месяца
+			t.hasShare = true
+			t.planned += *m.share[month] / 100 * float64(m.market[month])
+			t.shareMarket += float64(m.market[month])
+			t.shareBrand += float64(m.brand[month])
+		}
+	}
+	if t.hasVolume {
+		t.actual = t.brand
+	}
+	return t
+}
+
+func (t *tally) add(o tally) {
+	t.market += o.market
+	t.brand += o.brand
+	t.plan += o.plan
+	t.yearPlan += o.yearPlan
+	t.actual += o.actual
+	t.hasVolume = t.hasVolume || o.hasVolume
+	t.hasShare = t.hasShare || o.hasShare
+	t.planned += o.planned
+	t.shareMarket += o.shareMarket
+	t.shareBrand += o.shareBrand
+}
+
+func (t tally) progress(area string, months int) AreaProgress {
+	p := AreaProgress{Area: area, MarketVolume: t.market, BrandVolume: t.brand}
+	if t.hasVolume {
+		v := VolumeProgress{Plan: t.plan, Actual: t.actual, Gap: t.actual - t.plan, YearPlan: t.yearPlan}
+		v.Achievement = percent(v.Actual, v.Plan)
+		if months > 0 {
+			v.RunRate = int(math.Round(float64(v.Actual) / float64(months) * 12))
+		}
+		v.ProjectedAchievement = percent(v.RunRate, v.YearPlan)
+		p.Volume = &v
+	}
+	if t.hasShare {
+		s := ShareProgress{Plan: round(ratio(t.planned, t.shareMarket) * 100), Actual: round(ratio(t.shareBrand, t.shareMarket) * 100)}
+		s.Gap = round(s.Actual - s.Plan)
+		p.Share = &s
+	}
+	return p
+}
+
+func ratio(a, b float64) float64 {
+	if b == 0 {
+		return 0
+	}
+	return a / b
+}
+
+func percent(actual, plan int) *float64 {
+	if plan == 0 {
+		return nil
+	}
+	v := round(float64(actual) / float64(plan) * 100)
+	return &v
+}
+
+func round(v float64) float64 {
+	return math.Round(v*10) / 10
+}
@@ -0,0 +1,248 @@
+// Package targets — планы продаж бренда по месяцам, округам и сегментам
+// и сравнение плана с фактом регистраций
+package targets
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"truck-analytics-platform/internal/db"
+	"truck-analytics-platform/internal/reports"
+)
+
+// DefaultBrand — бренд, для которого задаются планы, если бренд не указан
+const DefaultBrand = "FOTON"
+
+// Target — план на месяц: объём регистраций, доля рынка округа в процентах или оба
+type Target struct {
+	Segment  string   `json:"segment"`
+	Year     int      `json:"year"`
+	Month    int      `json:"month"`
+	District string   `json:"district"`
+	Brand    string   `json:"brand"`
+	Volume   *int     `json:"volume"`
+	Share    *float64 `json:"share"`
+}
+
+var validBrand = regexp.MustCompile(`^[A-Z0-9][A-Z0-9 .-]{0,49}$`)
+
+// Validate проверяет план: рынок, месяц и округ существуют, задан объём или доля
+func (t Target) Validate() error {
+	if _, ok := reports.Markets[t.Segment]; !ok {
+		return fmt.Errorf("unknown segment %q", t.Segment)
+	}
+	if t.Year < 2000 || t.Year > 2100 {
+		return fmt.Errorf("year %d is out of range", t.Year)
+	}
+	if t.Month < 1 || t.Month > 12 {
+		return errors.New("month must be between 1 and 12")
+	}
+	if !slices.Contains(reports.DistrictOrder(), t.District) {
+		return fmt.Errorf("unknown district %q", t.District)
+	}
+	if !validBrand.MatchString(t.Brand) {
+		return fmt.Errorf("brand %q must be a canonical brand id", t.Brand)
+	}
+	if t.Volume == nil && t.Share == nil {
+		return errors.New("volume or share is required")
+	}
+	if t.Volume != nil && *t.Volume < 0 {
+		return errors.New("volume must not be negative")
+	}
+	if t.Share != nil && (*t.Share < 0 || *t.Share > 100) {
+		return errors.New("share must be between 0 and 100")
+	}
+	return nil
+}
+
+// ValidateAll проверяет загружаемые планы и дубли: ошибка указывает номер плана
+func ValidateAll(list []Target) error {
+	if len(list) == 0 {
+		return errors.New("no targets")
+	}
+	type key struct {
+		segment, district, brand string
+		year, month              int
+	}
+	seen := map[key]bool{}
+	for i, t := range list {
+		if err := t.Validate(); err != nil {
+			return fmt.Errorf("target %d: %w", i+1, err)
+		}
+		k := key{t.Segment, t.District, t.Brand, t.Year, t.Month}
+		if seen[k] {
+			return fmt.Errorf("target %d: %s %d/%d %s is listed twice", i+1, t.District, t.Month, t.Year, t.Brand)
+		}
+		seen[k] = true
+	}
+	return nil
+}
+
+// csvColumns — колонки CSV-выгрузки планов; volume и share могут быть пустыми
+var csvColumns = []string{"segment", "year", "month", "district", "brand", "volume", "share"}
+
+// ParseCSV читает планы из CSV с заголовком. Колонки — csvColumns в любом
+// порядке; без колонки brand план ставится DefaultBrand
+func ParseCSV(r io.Reader) ([]Target, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+	index := map[string]int{}
+	for i, name := range header {
+		index[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"segment", "year", "month", "district"} {
+		if _, ok := index[name]; !ok {
+			return nil, fmt.Errorf("column %q is required; columns are %s", name, strings.Join(csvColumns, ","))
+		}
+	}
+
+	var result []Target
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return result, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		value := func(name string) string {
+			if i, ok := index[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		t := Target{Segment: value("segment"), District: value("district"), Brand: value("brand")}
+		if t.Brand == "" {
+			t.Brand = DefaultBrand
+		}
+		if t.Year, err = strconv.Atoi(value("year")); err != nil {
+			return nil, fmt.Errorf("line %d: year must be an integer", line)
+		}
+		if t.Month, err = strconv.Atoi(value("month")); err != nil {
+			return nil, fmt.Errorf("line %d: month must be an integer", line)
+		}
+		if raw := value("volume"); raw != "" {
+			volume, err := strconv.Atoi(raw)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: volume must be an integer", line)
+			}
+			t.Volume = &volume
+		}
+		if raw := value("share"); raw != "" {
+			share, err := strconv.ParseFloat(raw, 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: share must be a number", line)
+			}
+			t.Share = &share
+		}
+		result = append(result, t)
+	}
+}
+
+// Filter — отбор планов; пустые поля не фильтруют
+type Filter struct {
+	Segment string
+	Year    int
+	Brand   string
+}
+
+// List возвращает планы по сегменту, году, бренду, округу и месяцу
+func List(ctx context.Context, f Filter) ([]Target, error) {
+	conn, err := db.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := conn.Query(ctx, `
+		SELECT segment, district, brand, year, month, volume, share
+		FROM targets
+		WHERE ($1 = '' OR segment = $1) AND ($2 = 0 OR year = $2) AND ($3 = '' OR brand = $3)
+		ORDER BY segment, year, brand, district, month
+	`, f.Segment, f.Year, f.Brand)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []Target{}
+	for rows.Next() {
+		var t Target
+		if err := rows.Scan(&t.Segment, &t.District, &t.Brand, &t.Year, &t.Month, &t.Volume, &t.Share); err != nil {
+			return nil, err
+		}
+		result = append(result, t)
+	}
+	return result, rows.Err()
+}
+
+// Save записывает планы одним запросом; план на тот же месяц заменяется
+func Save(ctx context.Context, list []Target) error {
+	conn, err := db.Connect(ctx)
+	if err != nil {
+		return err
+	}
+
+	var (
+		segments, districts, brands []string
+		years, months               []int
+		volumes                     []*int
+		shares                      []*float64
+	)
+	for _, t := range list {
+		segments = append(segments, t.Segment)
+		districts = append(districts, t.District)
+		brands = append(brands, t.Brand)
+		years = append(years, t.Year)
+		months = append(months, t.Month)
+		volumes = append(volumes, t.Volume)
+		shares = append(shares, t.Share)
+	}
+
+	rows, err := conn.Query(ctx, `
+		INSERT INTO targets (segment, district, brand, year, month, volume, share)
+		SELECT * FROM unnest($1::text[], $2::text[], $3::text[], $4::int[], $5::int[], $6::int[], $7::float8[])
+		ON CONFLICT (segment, year, brand, district, month)
+		DO UPDATE SET volume = EXCLUDED.volume, share = EXCLUDED.share
+	`, segments, districts, brands, years, months, volumes, shares)
+	if err != nil {
+		return err
+	}
+	rows.Close()
+	return rows.Err()
+}
+
+// Delete удаляет план бренда на год по сегменту и возвращает число удалённых месяцев
+func Delete(ctx context.Context, segment string, year int, brand string) (int, error) {
+	conn, err := db.Connect(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	rows, err := conn.Query(ctx, `
+		DELETE FROM targets WHERE segment = $1 AND year = $2 AND brand = $3
+		RETURNING month
+	`, segment, year, brand)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	deleted := 0
+	for rows.Next() {
+		deleted++
+	}
+	return deleted, rows.Err()
+}
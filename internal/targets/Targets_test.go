@@ -0,0 +1,128 @@
+package targets
+
+import (
+	"strings"
+	"testing"
+
+	"truck-analytics-platform/internal/reports"
+)
+
+func TestParseCSV(t *testing.T) {
+	list, err := ParseCSV(strings.NewReader("district,segment,year,month,volume,share\n" +
+		"Central,ldt,2025,1,45,\n" +
+		"Ural, ldt, 2025, 2, , 12.5\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("parsed %d targets, want 2", len(list))
+	}
+
+	first, second := list[0], list[1]
+	if first.District != "Central" || first.Brand != DefaultBrand || first.Volume == nil || *first.Volume != 45 || first.Share != nil {
+		t.Fatalf("first target %+v", first)
+	}
+	if second.Month != 2 || second.Volume != nil || second.Share == nil || *second.Share != 12.5 {
+		t.Fatalf("second target %+v", second)
+	}
+	if err := ValidateAll(list); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParseCSVErrors(t *testing.T) {
+	cases := map[string]string{
+		"segment,year,month\nldt,2025,1\n":                      `column "district" is required`,
+		"segment,year,month,district\nldt,next,1,Central\n":     "line 2: year must be an integer",
+		"segment,year,month,district,volume\nldt,2025,1,Ural,x": "line 2: volume must be an integer",
+	}
+	for input, want := range cases {
+		if _, err := ParseCSV(strings.NewReader(input)); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%q: error %v, want %q", input, err, want)
+		}
+	}
+}
+
+func TestValidateAll(t *testing.T) {
+	volume, share := 10, 101.0
+	valid := Target{Segment: "ldt", Year: 2025, Month: 1, District: "Central", Brand: "FOTON", Volume: &volume}
+
+	cases := []struct {
+		name   string
+		change func(list []Target) []Target
+		err    string
+	}{
+		{"valid", func(list []Target) []Target { return list }, ""},
+		{"empty", func([]Target) []Target { return nil }, "no targets"},
+		{"unknown segment", func(list []Target) []Target { list[0].Segment = "buses"; return list }, "target 1: unknown segment"},
+		{"month", func(list []Target) []Target { list[0].Month = 13; return list }, "month must be"},
+		{"russian district", func(list []Target) []Target { list[0].District = "Центральный"; return list }, "unknown district"},
+		{"no values", func(list []Target) []Target { list[0].Volume = nil; return list }, "volume or share"},
+		{"share", func(list []Target) []Target { list[0].Share = &share; return list }, "share must be"},
+		{"twice", func(list []Target) []Target { return append(list, list[0]) }, "target 2: Central 1/2025 FOTON is listed twice"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateAll(tc.change([]Target{valid}))
+			switch {
+			case tc.err == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case tc.err != "" && (err == nil || !strings.Contains(err.Error(), tc.err)):
+				t.Fatalf("error %v, want %q", err, tc.err)
+			}
+		})
+	}
+}
+
+func TestProgressWeightsShareAndProjectsRunRate(t *testing.T) {
+	volume, share := 10, 50.0
+	var plans []Target
+	for month := 1; month <= 12; month++ {
+		plans = append(plans, Target{District: "Ural", Month: month, Brand: "FOTON", Volume: &volume})
+	}
+	// План доли только на первые два месяца: в январе рынок втрое меньше февраля
+	for month := 1; month <= 2; month++ {
+		plans = append(plans, Target{District: "Central", Month: month, Brand: "FOTON", Share: &share})
+	}
+
+	sales := []reports.MonthlySale{
+		{Year: 2024, Month: 1, District: "Ural", Brand: "FOTON", Quantity: 12},
+		{Year: 2024, Month: 2, District: "Ural", Brand: "FOTON", Quantity: 6},
+		{Year: 2024, Month: 3, District: "Ural", Brand: "FOTON", Quantity: 100},
+		{Year: 2023, Month: 1, District: "Ural", Brand: "FOTON", Quantity: 100},
+		{Year: 2024, Month: 1, District: "Central", Brand: "FOTON", Quantity: 10},
+		{Year: 2024, Month: 1, District: "Central", Brand: "SITRAK", Quantity: 10},
+		{Year: 2024, Month: 2, District: "Central", Brand: "SITRAK", Quantity: 60},
+		{Year: 2024, Month: 1, District: "Volga", Brand: "FOTON", Quantity: 50},
+	}
+
+	list := progress(plans, sales, "FOTON", 2024, 2)
+	if len(list) != 3 || list[0].Area != "Central" || list[1].Area != "Ural" || list[2].Area != TotalArea {
+		t.Fatalf("areas %+v", list)
+	}
+
+	central, ural, total := list[0], list[1], list[2]
+	if central.Volume != nil || central.Share == nil {
+		t.Fatalf("central %+v", central)
+	}
+	if central.Share.Plan != 50 || central.Share.Actual != 12.5 || central.Share.Gap != -37.5 {
+		t.Fatalf("central share %+v", *central.Share)
+	}
+
+	v := ural.Volume
+	if v == nil || v.Plan != 20 || v.Actual != 18 || v.Gap != -2 || *v.Achievement != 90 || v.YearPlan != 120 ||
+		v.RunRate != 108 || *v.ProjectedAchievement != 90 {
+		t.Fatalf("ural volume %+v", v)
+	}
+
+	if total.MarketVolume != 98 || total.BrandVolume != 28 || total.Volume.Plan != 20 || total.Volume.Actual != 18 || total.Share.Plan != 50 {
+		t.Fatalf("total %+v", total)
+	}
+}
+
+func TestProgressWithoutPlans(t *testing.T) {
+	if list := progress(nil, nil, "FOTON", 2024, 9); list == nil || len(list) != 0 {
+		t.Fatalf("progress without plans = %v, want empty list", list)
+	}
+}
@@ -0,0 +1,250 @@
+package views
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+	"truck-analytics-platform/internal/db"
+)
+
+// MaxDashboardViews — сколько видов помещается на дашборд
+const MaxDashboardViews = 50
+
+// ErrForeignView — дашборд ссылается на вид, которого нет у владельца
+var ErrForeignView = errors.New("dashboard can only include the owner's saved views")
+
+// Dashboard — дашборд из видов в порядке ViewIDs. Views заполняется при
+// чтении одного дашборда: фронтенд строит страницу по ним
+type Dashboard struct {
+	ID        int64     `json:"id"`
+	Owner     string    `json:"owner"`
+	Name      string    `json:"name"`
+	Default   bool      `json:"default"`
+	Token     string    `json:"token"`
+	ViewIDs   []int64   `json:"view_ids"`
+	Views     []View    `json:"views,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Validate проверяет название и список видов
+func (d Dashboard) Validate() error {
+	if err := validName(d.Name); err != nil {
+		return err
+	}
+	if len(d.ViewIDs) > MaxDashboardViews {
+		return fmt.Errorf("dashboard can hold at most %d views", MaxDashboardViews)
+	}
+	seen := map[int64]bool{}
+	for _, id := range d.ViewIDs {
+		if id < 1 {
+			return errors.New("view ids must be positive")
+		}
+		if seen[id] {
+			return fmt.Errorf("view %d is listed twice", id)
+		}
+		seen[id] = true
+	}
+	return nil
+}
+
+const dashboardColumns = `d.owner, d.token, d.name,
+	COALESCE((SELECT string_agg(view_id::text, ',' ORDER BY position) FROM dashboard_views WHERE dashboard_id = d.id), ''),
+	d.updated_at, d.id, d.is_default`
+
+// ListDashboards возвращает дашборды владельца без видов: сначала дашборд по умолчанию
+func ListDashboards(ctx context.Context, owner string) ([]Dashboard, error) {
+	return queryDashboards(ctx, `
+		SELECT `+dashboardColumns+` FROM dashboards d
+		WHERE d.owner = $1
+		ORDER BY d.is_default DESC, d.name, d.id
+	`, owner)
+}
+
+// GetDashboard возвращает дашборд владельца с видами; false — дашборда нет или он чужой
+func GetDashboard(ctx context.Context, owner string, id int64) (Dashboard, bool, error) {
+	return withViews(ctx, `SELECT `+dashboardColumns+` FROM dashboards d WHERE d.owner = $1 AND d.id = $2`, owner, id)
+}
+
+// SharedDashboard возвращает дашборд с видами по токену ссылки
+func SharedDashboard(ctx context.Context, token string) (Dashboard, bool, error) {
+	return withViews(ctx, `SELECT `+dashboardColumns+` FROM dashboards d WHERE d.token = $1`, token)
+}
+
+// CreateDashboard сохраняет дашборд владельца с новым токеном ссылки
+func CreateDashboard(ctx context.Context, owner string, d Dashboard) (Dashboard, error) {
+	if err := checkOwned(ctx, owner, d.ViewIDs); err != nil {
+		return Dashboard{}, err
+	}
+	token, err := newToken()
+	if err != nil {
+		return Dashboard{}, err
+	}
+
+	id, err := save(ctx, `
+		WITH cleared AS (
+			UPDATE dashboards SET is_default = false WHERE $4 AND owner = $1 AND is_default
+		),
+		dashboard AS (
+			INSERT INTO dashboards (owner, token, name, is_default) VALUES ($1, $2, $3, $4)
+			RETURNING id
+		),
+		items AS (
+			INSERT INTO dashboard_views (dashboard_id, position, view_id)
+			SELECT dashboard.id, v.position, v.id FROM dashboard, unnest($5::bigint[]) WITH ORDINALITY AS v (id, position)
+		)
+		SELECT id FROM dashboard
+	`, owner, token, d.Name, d.Default, ids(d.ViewIDs))
+	if err != nil {
+		return Dashboard{}, err
+	}
+	created, _, err := GetDashboard(ctx, owner, id)
+	return created, err
+}
+
+// UpdateDashboard заменяет название, флаг и виды дашборда владельца; false — дашборда нет или он чужой
+func UpdateDashboard(ctx context.Context, owner string, d Dashboard) (Dashboard, bool, error) {
+	if err := checkOwned(ctx, owner, d.ViewIDs); err != nil {
+		return Dashboard{}, false, err
+	}
+
+	id, err := save(ctx, `
+		WITH cleared AS (
+			UPDATE dashboards SET is_default = false WHERE $4 AND owner = $1 AND is_default AND id <> $2
+		),
+		dashboard AS (
+			UPDATE dashboards SET name = $3, is_default = $4, updated_at = now()
+			WHERE owner = $1 AND id = $2
+			RETURNING id
+		),
+		removed AS (
+			DELETE FROM dashboard_views WHERE dashboard_id IN (SELECT id FROM dashboard)
+		),
+		items AS (
+			INSERT INTO dashboard_views (dashboard_id, position, view_id)
+			SELECT dashboard.id, v.position, v.id FROM dashboard, unnest($5::bigint[]) WITH ORDINALITY AS v (id, position)
+		)
+		SELECT id FROM dashboard
+	`, owner, d.ID, d.Name, d.Default, ids(d.ViewIDs))
+	if err != nil || id == 0 {
+		return Dashboard{}, false, err
+	}
+	return GetDashboard(ctx, owner, id)
+}
+
+// DeleteDashboard удаляет дашборд владельца, виды остаются; false — дашборда нет или он чужой
+func DeleteDashboard(ctx context.Context, owner string, id int64) (bool, error) {
+	return deleteOwned(ctx, `DELETE FROM dashboards WHERE owner = $1 AND id = $2 RETURNING id`, owner, id)
+}
+
+// checkOwned проверяет, что все виды принадлежат владельцу
+func checkOwned(ctx context.Context, owner string, viewIDs []int64) error {
+	if len(viewIDs) == 0 {
+		return nil
+	}
+	conn, err := db.Connect(ctx)
+	if err != nil {
+		return err
+	}
+
+	rows, err := conn.Query(ctx, `SELECT count(*) FROM saved_views WHERE owner = $1 AND id = ANY($2)`, owner, viewIDs)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var owned int
+	if rows.Next() {
+		if err := rows.Scan(&owned); err != nil {
+			return err
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if owned != len(viewIDs) {
+		return ErrForeignView
+	}
+	return nil
+}
+
+func ids(values []int64) []int64 {
+	if values == nil {
+		return []int64{}
+	}
+	return values
+}
+
+// withViews читает один дашборд и его виды в порядке дашборда
+func withViews(ctx context.Context, sql string, args ...any) (Dashboard, bool, error) {
+	d, ok, err := one(queryDashboards(ctx, sql, args...))
+	if err != nil || !ok {
+		return d, ok, err
+	}
+
+	d.Views, err = queryViews(ctx, `
+		SELECT `+viewColumns+`
+		FROM dashboard_views dv JOIN saved_views v ON v.id = dv.view_id
+		WHERE dv.dashboard_id = $1
+		ORDER BY dv.position
+	`, d.ID)
+	return d, true, err
+}
+
+func queryDashboards(ctx context.Context, sql string, args ...any) ([]Dashboard, error) {
+	conn, err := db.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := conn.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []Dashboard{}
+	for rows.Next() {
+		var d Dashboard
+		var viewIDs string
+		if err := rows.Scan(&d.Owner, &d.Token, &d.Name, &viewIDs, &d.UpdatedAt, &d.ID, &d.Default); err != nil {
+			return nil, err
+		}
+		d.ViewIDs = []int64{}
+		for _, raw := range split(viewIDs) {
+			id, err := strconv.ParseInt(raw, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("dashboard %d: view id %q: %w", d.ID, raw, err)
+			}
+			d.ViewIDs = append(d.ViewIDs, id)
+		}
+		result = append(result, d)
+	}
+	return result, rows.Err()
+}
+
+// save выполняет запрос записи и возвращает идентификатор записанной строки;
+// 0 — запрос ничего не записал
+func save(ctx context.Context, sql string, args ...any) (int64, error) {
+	conn, err := db.Connect(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	rows, err := conn.Query(ctx, sql, args...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var id int64
+	if rows.Next() {
+		if err := rows.Scan(&id); err != nil {
+			return 0, err
+		}
+	}
+	rows.Close()
+	return id, rows.Err()
+}
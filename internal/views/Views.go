@@ -0,0 +1,227 @@
+// Package views — сохранённые виды отчётов и дашборды пользователей.
+// Вид запоминает сегмент, период, уровень, округа и бренды; дашборд —
+// упорядоченный набор видов. Виды и дашборды открываются по ссылке с токеном
+package views
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"truck-analytics-platform/internal/db"
+	"truck-analytics-platform/internal/reports"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// View — сохранённый вид отчёта. MonthFrom и MonthTo = 0 — весь загруженный
+// период, пустой Level — уровень отчёта по умолчанию
+type View struct {
+	ID        int64     `json:"id"`
+	Owner     string    `json:"owner"`
+	Name      string    `json:"name"`
+	Segment   string    `json:"segment"`
+	Year      int       `json:"year"`
+	MonthFrom int       `json:"month_from"`
+	MonthTo   int       `json:"month_to"`
+	Level     string    `json:"level"`
+	Districts []string  `json:"districts"`
+	Brands    []string  `json:"brands"`
+	Default   bool      `json:"default"`
+	Token     string    `json:"token"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+var validBrand = regexp.MustCompile(`^[A-Z0-9][A-Z0-9 .-]{0,49}$`)
+
+// Validate проверяет вид: рынок, уровень и округа должны быть из отчётов
+func (v View) Validate() error {
+	if err := validName(v.Name); err != nil {
+		return err
+	}
+	if _, ok := reports.Markets[v.Segment]; !ok {
+		return fmt.Errorf("unknown segment %q", v.Segment)
+	}
+	if v.Year < 2000 || v.Year > 2100 {
+		return fmt.Errorf("year %d is out of range", v.Year)
+	}
+	if err := reports.CheckMonths(v.MonthFrom, v.MonthTo); err != nil {
+		return err
+	}
+	if v.MonthFrom > 0 && v.MonthTo > 0 && v.MonthFrom > v.MonthTo {
+		return errors.New("month_from must not be after month_to")
+	}
+	levels := []string{reports.LevelCountry, reports.LevelDistrict, reports.LevelRegion}
+	if v.Level != "" && !slices.Contains(levels, v.Level) {
+		return fmt.Errorf("level must be one of %v", levels)
+	}
+	if err := unique(v.Districts, "district", func(d string) bool { return slices.Contains(reports.DistrictOrder(), d) }); err != nil {
+		return err
+	}
+	// Списки читаются одной строкой через запятую: запятой в идентификаторе нет
+	return unique(v.Brands, "brand", validBrand.MatchString)
+}
+
+func validName(name string) error {
+	if strings.TrimSpace(name) == "" || len(name) > 100 {
+		return errors.New("name must be 1-100 characters")
+	}
+	return nil
+}
+
+// unique проверяет значения списка и отсутствие повторов
+func unique(values []string, kind string, valid func(string) bool) error {
+	seen := map[string]bool{}
+	for _, value := range values {
+		if !valid(value) {
+			return fmt.Errorf("unknown %s %q", kind, value)
+		}
+		if seen[value] {
+			return fmt.Errorf("%s %q is listed twice", kind, value)
+		}
+		seen[value] = true
+	}
+	return nil
+}
+
+// newToken — токен ссылки на вид или дашборд
+func newToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+const viewColumns = `v.owner, v.token, v.name, v.segment, v.level, array_to_string(v.districts, ','),
+	array_to_string(v.brands, ','), v.updated_at, v.id, v.year, v.month_from, v.month_to, v.is_default`
+
+// List возвращает виды владельца: сначала вид по умолчанию, затем по названию
+func List(ctx context.Context, owner string) ([]View, error) {
+	return queryViews(ctx, `
+		SELECT `+viewColumns+` FROM saved_views v
+		WHERE v.owner = $1
+		ORDER BY v.is_default DESC, v.name, v.id
+	`, owner)
+}
+
+// Get возвращает вид владельца; false — вида нет или он чужой
+func Get(ctx context.Context, owner string, id int64) (View, bool, error) {
+	return one(queryViews(ctx, `SELECT `+viewColumns+` FROM saved_views v WHERE v.owner = $1 AND v.id = $2`, owner, id))
+}
+
+// Shared возвращает вид по токену ссылки
+func Shared(ctx context.Context, token string) (View, bool, error) {
+	return one(queryViews(ctx, `SELECT `+viewColumns+` FROM saved_views v WHERE v.token = $1`, token))
+}
+
+// Create сохраняет новый вид владельца с новым токеном ссылки
+func Create(ctx context.Context, owner string, v View) (View, error) {
+	token, err := newToken()
+	if err != nil {
+		return View{}, err
+	}
+	created, _, err := one(queryViews(ctx, `
+		WITH cleared AS (
+			UPDATE saved_views SET is_default = false WHERE $11 AND owner = $1 AND is_default
+		)
+		INSERT INTO saved_views AS v (owner, token, name, segment, year, month_from, month_to, level, districts, brands, is_default)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING `+viewColumns,
+		owner, token, v.Name, v.Segment, v.Year, v.MonthFrom, v.MonthTo, v.Level, list(v.Districts), list(v.Brands), v.Default))
+	return created, err
+}
+
+// Update заменяет вид владельца, токен ссылки сохраняется; false — вида нет или он чужой
+func Update(ctx context.Context, owner string, v View) (View, bool, error) {
+	return one(queryViews(ctx, `
+		WITH cleared AS (
+			UPDATE saved_views SET is_default = false WHERE $11 AND owner = $1 AND is_default AND id <> $2
+		)
+		UPDATE saved_views AS v SET name = $3, segment = $4, year = $5, month_from = $6, month_to = $7,
+			level = $8, districts = $9, brands = $10, is_default = $11, updated_at = now()
+		WHERE v.owner = $1 AND v.id = $2
+		RETURNING `+viewColumns,
+		owner, v.ID, v.Name, v.Segment, v.Year, v.MonthFrom, v.MonthTo, v.Level, list(v.Districts), list(v.Brands), v.Default))
+}
+
+// Delete удаляет вид владельца и убирает его из дашбордов; false — вида нет или он чужой
+func Delete(ctx context.Context, owner string, id int64) (bool, error) {
+	return deleteOwned(ctx, `DELETE FROM saved_views WHERE owner = $1 AND id = $2 RETURNING id`, owner, id)
+}
+
+func list(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
+func queryViews(ctx context.Context, sql string, args ...any) ([]View, error) {
+	conn, err := db.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := conn.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	return scanViews(rows)
+}
+
+func scanViews(rows pgx.Rows) ([]View, error) {
+	defer rows.Close()
+
+	result := []View{}
+	for rows.Next() {
+		var v View
+		var districts, brands string
+		if err := rows.Scan(&v.Owner, &v.Token, &v.Name, &v.Segment, &v.Level, &districts, &brands, &v.UpdatedAt,
+			&v.ID, &v.Year, &v.MonthFrom, &v.MonthTo, &v.Default); err != nil {
+			return nil, err
+		}
+		v.Districts, v.Brands = split(districts), split(brands)
+		result = append(result, v)
+	}
+	return result, rows.Err()
+}
+
+func split(joined string) []string {
+	if joined == "" {
+		return []string{}
+	}
+	return strings.Split(joined, ",")
+}
+
+// one возвращает первый элемент выборки; false — выборка пуста
+func one[T any](list []T, err error) (T, bool, error) {
+	var zero T
+	if err != nil || len(list) == 0 {
+		return zero, false, err
+	}
+	return list[0], true, nil
+}
+
+// deleteOwned выполняет удаление с RETURNING; false — ничего не удалено
+func deleteOwned(ctx context.Context, sql string, owner string, id int64) (bool, error) {
+	conn, err := db.Connect(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := conn.Query(ctx, sql, owner, id)
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	deleted := rows.Next()
+	rows.Close()
+	return deleted, rows.Err()
+}
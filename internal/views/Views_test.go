@@ -0,0 +1,70 @@
+package views
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"truck-analytics-platform/internal/db"
+	"truck-analytics-platform/internal/db/dbtest"
+)
+
+func TestValidateView(t *testing.T) {
+	valid := View{Name: "LDT Central", Segment: "ldt", Year: 2024, MonthTo: 9, Level: "district", Districts: []string{"Central"}, Brands: []string{"FOTON"}}
+
+	cases := []struct {
+		name   string
+		change func(v *View)
+		err    string
+	}{
+		{"valid", func(v *View) {}, ""},
+		{"report default level", func(v *View) { v.Level = "" }, ""},
+		{"blank name", func(v *View) { v.Name = "  " }, "name must be"},
+		{"unknown segment", func(v *View) { v.Segment = "buses" }, "unknown segment"},
+		{"month out of range", func(v *View) { v.MonthTo = 13 }, "month must be between 1 and 12, or 0 for the whole year"},
+		{"months reversed", func(v *View) { v.MonthFrom = 10 }, "month_from must not be after month_to"},
+		{"unknown level", func(v *View) { v.Level = "city" }, "level must be"},
+		{"russian district", func(v *View) { v.Districts = []string{"Уральский"} }, `unknown district "Уральский"`},
+		{"brand with comma", func(v *View) { v.Brands = []string{"FOTON,SITRAK"} }, "unknown brand"},
+		{"brand twice", func(v *View) { v.Brands = []string{"FOTON", "FOTON"} }, "listed twice"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			v := valid
+			tc.change(&v)
+
+			err := v.Validate()
+			switch {
+			case tc.err == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case tc.err != "" && (err == nil || !strings.Contains(err.Error(), tc.err)):
+				t.Fatalf("error %v, want %q", err, tc.err)
+			}
+		})
+	}
+}
+
+func TestValidateDashboard(t *testing.T) {
+	if err := (Dashboard{Name: "Review", ViewIDs: []int64{2, 1}}).Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := (Dashboard{Name: "Review", ViewIDs: []int64{1, 1}}).Validate(); err == nil || !strings.Contains(err.Error(), "listed twice") {
+		t.Fatalf("error %v, want view listed twice", err)
+	}
+	if err := (Dashboard{Name: "Review", ViewIDs: make([]int64, MaxDashboardViews+1)}).Validate(); err == nil {
+		t.Fatal("dashboard over the view limit passed validation")
+	}
+}
+
+func TestCreateDashboardRejectsForeignViews(t *testing.T) {
+	// Из двух видов владельцу принадлежит один
+	db.SetQuerier(&dbtest.Querier{Rows: []dbtest.Row{{Ints: []int{1}}}})
+	t.Cleanup(func() { db.SetQuerier(nil) })
+
+	_, err := CreateDashboard(context.Background(), "analyst", Dashboard{Name: "Review", ViewIDs: []int64{1, 7}})
+	if !errors.Is(err, ErrForeignView) {
+		t.Fatalf("error %v, want ErrForeignView", err)
+	}
+}